@@ -46,6 +46,7 @@ import (
 	"github.com/neilotoole/sq/cli/buildinfo"
 	"github.com/neilotoole/sq/cli/config"
 	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/cli/output/arroww"
 	"github.com/neilotoole/sq/cli/output/csvw"
 	"github.com/neilotoole/sq/cli/output/htmlw"
 	"github.com/neilotoole/sq/cli/output/jsonw"
@@ -54,15 +55,25 @@ import (
 	"github.com/neilotoole/sq/cli/output/tablew"
 	"github.com/neilotoole/sq/cli/output/xlsxw"
 	"github.com/neilotoole/sq/cli/output/xmlw"
+	"github.com/neilotoole/sq/cli/output/yamlw"
 	"github.com/neilotoole/sq/drivers/csv"
+	"github.com/neilotoole/sq/drivers/dir"
+	"github.com/neilotoole/sq/drivers/fwf"
+	"github.com/neilotoole/sq/drivers/html"
 	"github.com/neilotoole/sq/drivers/json"
 	"github.com/neilotoole/sq/drivers/mysql"
+	"github.com/neilotoole/sq/drivers/parquet"
 	"github.com/neilotoole/sq/drivers/postgres"
 	"github.com/neilotoole/sq/drivers/sqlite3"
 	"github.com/neilotoole/sq/drivers/sqlserver"
 	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/userdriver/csvud"
+	"github.com/neilotoole/sq/drivers/userdriver/jsonud"
+	"github.com/neilotoole/sq/drivers/userdriver/regexud"
 	"github.com/neilotoole/sq/drivers/userdriver/xmlud"
 	"github.com/neilotoole/sq/drivers/xlsx"
+	"github.com/neilotoole/sq/drivers/xml"
+	"github.com/neilotoole/sq/drivers/yaml"
 	"github.com/neilotoole/sq/libsq/core/cleanup"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/driver"
@@ -218,13 +229,30 @@ func newCommandTree(rc *RunContext) (rootCmd *cobra.Command) {
 	addCmd(rc, rootCmd, newSrcRemoveCmd())
 	addCmd(rc, rootCmd, newScratchCmd())
 
+	queryCmd := addCmd(rc, rootCmd, newQueryCmd())
+	addCmd(rc, queryCmd, newQuerySaveCmd())
+	addCmd(rc, queryCmd, newQueryListCmd())
+	addCmd(rc, queryCmd, newQueryRemoveCmd())
+	addCmd(rc, rootCmd, newRunCmd())
+
 	addCmd(rc, rootCmd, newInspectCmd())
+	addCmd(rc, rootCmd, newDiffCmd())
+	addCmd(rc, rootCmd, newCopyCmd())
+	addCmd(rc, rootCmd, newExportCmd())
+
+	cacheCmd := addCmd(rc, rootCmd, newCacheCmd())
+	addCmd(rc, cacheCmd, newCacheListCmd())
+	addCmd(rc, cacheCmd, newCacheClearCmd())
 	addCmd(rc, rootCmd, newPingCmd())
 
 	addCmd(rc, rootCmd, newVersionCmd())
 
 	driverCmd := addCmd(rc, rootCmd, newDriverCmd())
 	addCmd(rc, driverCmd, newDriverListCmd())
+	addCmd(rc, driverCmd, newDriverGenDefCmd())
+	addCmd(rc, driverCmd, newDriverValidateCmd())
+	addCmd(rc, driverCmd, newDriverAddCmd())
+	addCmd(rc, driverCmd, newDriverRemoveCmd())
 
 	tblCmd := addCmd(rc, rootCmd, newTblCmd())
 	addCmd(rc, tblCmd, newTblCopyCmd())
@@ -334,6 +362,10 @@ type RunContext struct {
 	// Log is the run's logger.
 	Log lg.Log
 
+	// CacheDir is the import cache dir. If empty, imported
+	// data (e.g. from CSV sources) is not cached.
+	CacheDir string
+
 	initOnce sync.Once
 	initErr  error
 
@@ -341,10 +373,24 @@ type RunContext struct {
 	// the CLI uses to print output.
 	writers *writers
 
-	registry  *driver.Registry
-	files     *source.Files
-	databases *driver.Databases
-	clnup     *cleanup.Cleanup
+	// baseOut and baseErrOut are the output destinations before
+	// the writers wrapped them (e.g. for color). If the writers
+	// are rebuilt, they must be built from these.
+	baseOut, baseErrOut io.Writer
+
+	// outTerminal is true if the output destination, before any
+	// compression, is a terminal. See checkBinaryOutput.
+	outTerminal bool
+
+	// queryArgs holds the values of a saved SQL query's ${ARG}
+	// placeholders, which are bound as SQL args. See "sq run".
+	queryArgs map[string]string
+
+	registry    *driver.Registry
+	files       *source.Files
+	databases   *driver.Databases
+	importCache *driver.ImportCache
+	clnup       *cleanup.Cleanup
 }
 
 // newDefaultRunContext returns a RunContext configured
@@ -371,6 +417,8 @@ func newDefaultRunContext(stdin *os.File, stdout, stderr io.Writer) (*RunContext
 	rc.ConfigStore = cfgStore
 	rc.Config = cfg
 
+	rc.CacheDir = defaultCacheDir(rc.Log)
+
 	switch {
 	case rc.Log == nil:
 		rc.Log = lg.Discard()
@@ -435,6 +483,37 @@ func (rc *RunContext) doInit() error {
 		rc.Out = f
 	}
 
+	// If the output is to be compressed (e.g. --output=data.json.gz),
+	// we wrap rc.Out in a compressor.
+	outputPath, _ := rc.Cmd.Flags().GetString(flagOutput)
+	compression, err := outputCompression(rc.Cmd, outputPath)
+	if err != nil {
+		return err
+	}
+
+	rc.outTerminal = isTerminal(rc.Out)
+
+	if compression != "" {
+		// This applies to each command that has flag --compress.
+		err = checkBinaryOutput(rc)
+		if err != nil {
+			return err
+		}
+
+		cw, err := newCompressWriter(rc.Out, compression)
+		if err != nil {
+			return err
+		}
+
+		// The cleanup funcs run in reverse order, so the compressor
+		// is closed (finalizing the compressed data) before the
+		// output file is closed. This is also the case if the command
+		// fails or is canceled.
+		rc.clnup.AddC(cw)
+		rc.Out = cw
+	}
+
+	rc.baseOut, rc.baseErrOut = rc.Out, rc.ErrOut
 	rc.writers, rc.Out, rc.ErrOut = newWriters(rc.Log, rc.Cmd, rc.Config.Defaults, rc.Out, rc.ErrOut)
 
 	var scratchSrcFunc driver.ScratchSrcFunc
@@ -449,7 +528,6 @@ func (rc *RunContext) doInit() error {
 		}
 	}
 
-	var err error
 	rc.files, err = source.NewFiles(log)
 	if err != nil {
 		log.WarnIfFuncError(rc.clnup.Run)
@@ -467,6 +545,21 @@ func (rc *RunContext) doInit() error {
 	rc.databases = driver.NewDatabases(log, rc.registry, scratchSrcFunc)
 	rc.clnup.AddC(rc.databases)
 
+	if rc.CacheDir != "" {
+		rc.importCache = driver.NewImportCache(log, rc.CacheDir, sqlite3.NewCacheSource, rc.files.Fingerprint)
+
+		// The import cache is only used with the default (SQLite)
+		// scratch source.
+		var noCache bool
+		if cmdFlagChanged(rc.Cmd, flagNoCache) {
+			noCache, _ = rc.Cmd.Flags().GetBool(flagNoCache)
+		}
+
+		if scratchSrc == nil && !noCache {
+			rc.databases.SetImportCache(rc.importCache)
+		}
+	}
+
 	rc.registry.AddProvider(sqlite3.Type, &sqlite3.Provider{Log: log})
 	rc.registry.AddProvider(postgres.Type, &postgres.Provider{Log: log})
 	rc.registry.AddProvider(sqlserver.Type, &sqlserver.Provider{Log: log})
@@ -484,9 +577,21 @@ func (rc *RunContext) doInit() error {
 
 	rc.registry.AddProvider(xlsx.Type, &xlsx.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
 	rc.files.AddTypeDetectors(xlsx.DetectXLSX)
-	// One day we may have more supported user driver genres.
+	rc.registry.AddProvider(html.Type, &html.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
+	rc.files.AddTypeDetectors(html.DetectHTML)
+	rc.registry.AddProvider(parquet.Type, &parquet.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
+	rc.files.AddTypeDetectors(parquet.DetectParquet)
+	rc.registry.AddProvider(fwf.Type, &fwf.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
+	rc.registry.AddProvider(yaml.Type, &yaml.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
+	rc.files.AddTypeDetectors(yaml.DetectYAML)
+	rc.registry.AddProvider(xml.Type, &xml.Provider{Log: log, Scratcher: rc.databases, Files: rc.files})
+	rc.files.AddTypeDetectors(xml.DetectXML)
+	rc.registry.AddProvider(dir.Type, &dir.Provider{Log: log, Scratcher: rc.databases, Files: rc.files, Drivers: rc.registry})
 	userDriverImporters := map[string]userdriver.ImportFunc{
-		xmlud.Genre: xmlud.Import,
+		xmlud.Genre:   xmlud.Import,
+		jsonud.Genre:  jsonud.Import,
+		csvud.Genre:   csvud.Import,
+		regexud.Genre: regexud.Import,
 	}
 
 	for i, userDriverDef := range cfg.Ext.UserDrivers {
@@ -544,9 +649,20 @@ func (rc *RunContext) Close() error {
 type writers struct {
 	fmt *output.Formatting
 
+	// format is the output format, per flag or config.
+	format config.Format
+
 	recordw output.RecordWriter
+
+	// multiw is non-nil if the format supports writing multiple
+	// result sets to a single destination.
+	multiw output.MultiRecordWriter
+
 	metaw   output.MetadataWriter
 	srcw    output.SourceWriter
+	queryw  output.QueryWriter
+	cachew  output.CacheWriter
+	diffw   output.DiffWriter
 	notifyw output.NotificationWriter
 	errw    output.ErrorWriter
 	pingw   output.PingWriter
@@ -580,6 +696,9 @@ func newWriters(log lg.Log, cmd *cobra.Command, defaults config.Defaults, out, e
 		recordw: tablew.NewRecordWriter(out2, fm, printHeader),
 		metaw:   tablew.NewMetadataWriter(out2, fm),
 		srcw:    tablew.NewSourceWriter(out2, fm, printHeader, verbose),
+		queryw:  tablew.NewQueryWriter(out2, fm, printHeader),
+		cachew:  tablew.NewCacheWriter(out2, fm, printHeader),
+		diffw:   tablew.NewDiffWriter(out2, fm, printHeader),
 		pingw:   tablew.NewPingWriter(out2, fm),
 		notifyw: tablew.NewNotifyWriter(out2, fm, printHeader),
 		errw:    tablew.NewErrorWriter(errOut2, fm),
@@ -588,12 +707,16 @@ func newWriters(log lg.Log, cmd *cobra.Command, defaults config.Defaults, out, e
 	// Invoke getFormat to see if the format was specified
 	// via config or flag.
 	format := getFormat(cmd, defaults)
+	w.format = format
 
 	switch format {
 	default:
 		// No format specified, use JSON
 		w.recordw = jsonw.NewStdRecordWriter(out2, fm)
 		w.metaw = jsonw.NewMetadataWriter(out2, fm)
+		w.queryw = jsonw.NewQueryWriter(out2, fm)
+		w.cachew = jsonw.NewCacheWriter(out2, fm)
+		w.diffw = jsonw.NewDiffWriter(out2, fm)
 		w.errw = jsonw.NewErrorWriter(log, errOut2, fm)
 
 	case config.FormatTable:
@@ -611,7 +734,9 @@ func newWriters(log lg.Log, cmd *cobra.Command, defaults config.Defaults, out, e
 		w.recordw = xmlw.NewRecordWriter(out2, fm)
 
 	case config.FormatXLSX:
-		w.recordw = xlsxw.NewRecordWriter(out2, printHeader)
+		formats := getXLSXFormats(defaults)
+		w.recordw = xlsxw.NewRecordWriter(out2, printHeader, formats)
+		w.multiw = xlsxw.NewWorkbook(out2, printHeader, formats)
 
 	case config.FormatRaw:
 		w.recordw = raww.NewRecordWriter(out2)
@@ -627,11 +752,46 @@ func newWriters(log lg.Log, cmd *cobra.Command, defaults config.Defaults, out, e
 
 	case config.FormatJSONL:
 		w.recordw = jsonw.NewObjectRecordWriter(out2, fm)
+
+	case config.FormatParquet:
+		w.recordw = arroww.NewParquetRecordWriter(out2)
+
+	case config.FormatArrow:
+		w.recordw = arroww.NewIPCRecordWriter(out2)
+
+	case config.FormatYAML:
+		w.recordw = yamlw.NewRecordWriter(out2)
+		w.metaw = yamlw.NewMetadataWriter(out2)
 	}
 
 	return w, out2, errOut2
 }
 
+// getXLSXFormats returns the XLSX number formats, per
+// xlsxw.DefaultFormats overridden by any formats in defaults.
+func getXLSXFormats(defaults config.Defaults) xlsxw.Formats {
+	formats := xlsxw.DefaultFormats()
+	cfg := defaults.XLSX
+
+	for _, f := range []struct {
+		dest *string
+		val  string
+	}{
+		{&formats.Int, cfg.IntFormat},
+		{&formats.Float, cfg.FloatFormat},
+		{&formats.Decimal, cfg.DecimalFormat},
+		{&formats.Datetime, cfg.DatetimeFormat},
+		{&formats.Date, cfg.DateFormat},
+		{&formats.Time, cfg.TimeFormat},
+	} {
+		if f.val != "" {
+			*f.dest = f.val
+		}
+	}
+
+	return formats
+}
+
 // getWriterFormatting returns a Formatting instance and
 // colorable or non-colorable writers. It is permissible
 // for the cmd arg to be nil.
@@ -645,8 +805,8 @@ func getWriterFormatting(cmd *cobra.Command, out, errOut io.Writer) (fm *output.
 	// TODO: Should get this default value from config
 	colorize := true
 
-	if cmdFlagChanged(cmd, flagOutput) {
-		// We're outputting to a file, thus no color.
+	if cmdFlagChanged(cmd, flagOutput) || cmdFlagChanged(cmd, flagCompress) {
+		// We're outputting to a file (or compressing), thus no color.
 		colorize = false
 	} else if cmdFlagChanged(cmd, flagMonochrome) {
 		if mono, _ := cmd.Flags().GetBool(flagMonochrome); mono {
@@ -707,6 +867,12 @@ func getFormat(cmd *cobra.Command, defaults config.Defaults) config.Format {
 		format = config.FormatHTML
 	case cmdFlagChanged(cmd, flagMarkdown):
 		format = config.FormatMarkdown
+	case cmdFlagChanged(cmd, flagParquet):
+		format = config.FormatParquet
+	case cmdFlagChanged(cmd, flagArrow):
+		format = config.FormatArrow
+	case cmdFlagChanged(cmd, flagYAML):
+		format = config.FormatYAML
 	case cmdFlagChanged(cmd, flagTable):
 		format = config.FormatTable
 	case cmdFlagChanged(cmd, flagJSONL):
@@ -757,6 +923,24 @@ func defaultLogging() (lg.Log, *cleanup.Cleanup, error) {
 	return log, clnup, nil
 }
 
+// defaultCacheDir returns the import cache dir: the value of
+// envar SQ_CACHEDIR if set, or "sq/import" in the user cache dir.
+// An empty string is returned if the user cache dir can't be
+// determined, thus disabling the cache.
+func defaultCacheDir(log lg.Log) string {
+	if cacheDir, ok := os.LookupEnv(envarCacheDir); ok {
+		return cacheDir
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		log.Warnf("Import cache disabled: %v", err)
+		return ""
+	}
+
+	return filepath.Join(cacheDir, "sq", "import")
+}
+
 // defaultConfig loads sq config from the default location
 // (~/.config/sq/sq.yml) or the location specified in envars.
 func defaultConfig() (*config.Config, config.Store, error) {
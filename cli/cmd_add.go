@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/neilotoole/sq/drivers/dir"
 	"github.com/neilotoole/sq/drivers/sqlite3"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/options"
@@ -33,12 +34,40 @@ func newSrcAddCmd() *cobra.Command {
 
   # add an Excel spreadsheet, with options
   $ sq add ./testdata/test1.xlsx --opts=header=true
+
+  # add an Excel spreadsheet whose header is at row 3 (below a title)
+  $ sq add ./testdata/report.xlsx --opts=header_row=3
   
   # add a CSV source, with options
   $ sq add ./testdata/person.csv --opts=header=true
 
+  # add a JSON source, importing each nested array into a child table
+  $ sq add ./testdata/orders.json --opts=normalize=true
+
+  # add a JSON source, importing only the records at "data.results"
+  $ sq add ./testdata/response.json --opts='root=$.data.results'
+
+  # add a fixed-width source, with explicit column boundaries
+  $ sq add ./testdata/payments.txt --driver=fwf --opts='header=true&cols=name:1-20,amount:21-30'
+
   # add a CSV source from a server (will be downloaded)
   $ sq add https://sq.io/testdata/actor.csv
+
+  # add a directory of files: each file becomes a table
+  $ sq add ./exports/
+
+  # add the CSV files matching a glob as a single "data" table,
+  # with column "_file" holding each row's file name
+  $ sq add './exports/*.csv' --opts='header=true&union=true'
+
+  # add a gzipped CSV file (decompressed transparently)
+  $ sq add ./exports/actor.csv.gz --opts=header=true
+
+  # add a zip archive: each member file becomes a table
+  $ sq add ./exports.zip
+
+  # add only the CSV members of a tar archive
+  $ sq add ./exports.tar.gz --opts='member=*.csv'
 `,
 		Long: `Add data source specified by LOCATION and optionally identified by @HANDLE.
 The format of LOCATION varies, but is generally a DB connection string, a
@@ -53,7 +82,9 @@ on LOCATION and the source driver type.
 
 If flag --driver is omitted, sq will attempt to determine the
 type from LOCATION via file suffix, content type, etc.. If the result
-is ambiguous, specify the driver type via flag --driver.
+is ambiguous, specify the driver type via flag --driver. Note that
+fixed-width text (driver fwf) is never detected: flag --driver=fwf is
+required.
 
 Flag --opts sets source-specific options. Generally opts are relevant
 to document source types (such as a CSV file). The most common
@@ -61,6 +92,17 @@ use is to specify that the document has a header row:
 
   $ sq add actor.csv --opts=header=true
 
+If LOCATION is a directory, or a glob pattern such as "./exports/*.csv",
+the source is a directory source: each matched file becomes a table named
+after the file (e.g. table "day1" for "day1.csv"). With option union=true,
+the data of all the files is instead combined into a single "data" table,
+with an additional "_file" column holding each row's file name.
+
+Files compressed with gzip, bzip2, xz or zstd are decompressed
+transparently. If LOCATION is a zip or tar archive (e.g. "data.zip" or
+"data.tar.gz"), it is a directory source of the archive's member files.
+Option member selects files via a glob pattern, e.g. member=data/*.csv.
+
 Available source driver types can be listed via "sq driver ls".
 
 At a minimum, the following drivers are bundled:
@@ -75,6 +117,12 @@ At a minimum, the following drivers are bundled:
   jsona      JSON Array: LF-delimited JSON arrays 
   jsonl      JSON Lines: LF-delimited JSON objects
   xlsx       Microsoft Excel XLSX                  
+  html       HTML tables
+  parquet    Apache Parquet
+  fwf        Fixed-Width Fields
+  yaml       YAML
+  xml        XML
+  dir        Directory, glob or archive of files
 `,
 		Short: "Add data source",
 	}
@@ -100,6 +148,8 @@ func execSrcAdd(cmd *cobra.Command, args []string) error {
 	if cmd.Flags().Changed(flagDriver) {
 		val, _ := cmd.Flags().GetString(flagDriver)
 		typ = source.Type(strings.TrimSpace(val))
+	} else if source.IsDirLocation(loc) || source.IsArchiveLocation(loc) {
+		typ = dir.Type
 	} else {
 		typ, err = rc.files.Type(cmd.Context(), loc)
 		if err != nil {
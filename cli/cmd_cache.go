@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+func newCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the import cache (ls, clear)",
+		Long: `Manage the import cache. When a file-based source such as CSV, XLSX
+or JSON is queried, sq imports the source data into a scratch database.
+The imported database is cached on disk, so that later queries against an
+unchanged source don't need to import the data again. A cache entry is
+invalidated when the source's location, options, or file size or
+modification time (or for remote files, HTTP ETag, Last-Modified or
+checksum) change. The cached database is read-only.
+
+The cache dir defaults to "sq/import" in the user cache dir, and can be
+changed via envar SQ_CACHEDIR. Use flag --no-cache on any command to
+bypass the cache.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+		Example: `  # List cached imports
+  $ sq cache ls
+
+  # Remove all cached imports
+  $ sq cache clear
+
+  # Remove cached imports for @actor_csv
+  $ sq cache clear @actor_csv
+
+  # Query without using the cache
+  $ sq --no-cache '@actor_csv.data | .[0:5]'`,
+	}
+
+	return cmd
+}
+
+func newCacheListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List cached imports",
+		Args:  cobra.ExactArgs(0),
+		RunE:  execCacheList,
+	}
+
+	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
+	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
+
+	return cmd
+}
+
+func execCacheList(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	importCache, err := rc.getImportCache()
+	if err != nil {
+		return err
+	}
+
+	entries, err := importCache.Entries()
+	if err != nil {
+		return err
+	}
+
+	return rc.writers.cachew.CacheEntries(entries)
+}
+
+func newCacheClearCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear [@HANDLE...]",
+		Short: "Remove cached imports",
+		Long: `Remove the cached imports of the specified sources, or of all
+sources if no source is specified.`,
+		Example: `  # Remove all cached imports
+  $ sq cache clear
+
+  # Remove cached imports for @actor_csv and @film_xlsx
+  $ sq cache clear @actor_csv @film_xlsx`,
+		RunE:              execCacheClear,
+		ValidArgsFunction: completeHandle(0),
+	}
+
+	return cmd
+}
+
+func execCacheClear(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	importCache, err := rc.getImportCache()
+	if err != nil {
+		return err
+	}
+
+	removed, err := importCache.Clear(args...)
+	if err != nil {
+		return err
+	}
+
+	noun := "entries"
+	if len(removed) == 1 {
+		noun = "entry"
+	}
+
+	fmt.Fprintf(rc.Out, "Removed %d import cache %s\n", len(removed), noun)
+	return nil
+}
+
+// getImportCache returns rc's import cache, or an error
+// if the import cache is disabled.
+func (rc *RunContext) getImportCache() (*driver.ImportCache, error) {
+	if rc.importCache == nil {
+		return nil, errz.Errorf("import cache is disabled: set envar %s to enable", envarCacheDir)
+	}
+
+	return rc.importCache, nil
+}
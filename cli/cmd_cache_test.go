@@ -0,0 +1,141 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/sakila"
+	"github.com/neilotoole/sq/testh/testsrc"
+)
+
+func TestCmdCache(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+
+	// We use a copy of the CSV file, because we modify it below.
+	src := testh.New(t).Source(sakila.CSVActor)
+	data, err := ioutil.ReadFile(proj.Abs(sakila.PathCSVActor))
+	require.NoError(t, err)
+	src.Location = filepath.Join(t.TempDir(), "actor.csv")
+	require.NoError(t, ioutil.WriteFile(src.Location, data, 0600))
+
+	newCacheRun := func() *run {
+		ru := newRun(t).add(*src)
+		ru.rc.CacheDir = cacheDir
+		return ru
+	}
+
+	cacheEntries := func() []*driver.ImportCacheEntry {
+		ru := newCacheRun()
+		require.NoError(t, ru.exec("cache", "ls", "--json"))
+		var entries []*driver.ImportCacheEntry
+		require.NoError(t, json.Unmarshal(ru.out.Bytes(), &entries))
+		return entries
+	}
+
+	require.Empty(t, cacheEntries())
+
+	ru := newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".data"))
+	require.Equal(t, sakila.TblActorCount, len(ru.mustReadCSV()))
+	entries := cacheEntries()
+	require.Equal(t, 1, len(entries))
+	require.Equal(t, src.Handle, entries[0].Handle)
+	require.FileExists(t, entries[0].Path)
+
+	// The second query uses the cached import.
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".data"))
+	require.Equal(t, sakila.TblActorCount, len(ru.mustReadCSV()))
+	require.Equal(t, entries, cacheEntries())
+
+	// The cached import is read-only: a query can't modify it.
+	ru = newCacheRun()
+	require.Error(t, ru.exec("sql", "DELETE FROM data"))
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".data"))
+	require.Equal(t, sakila.TblActorCount, len(ru.mustReadCSV()))
+
+	// Modify the CSV file: the cached import is now stale, and
+	// is replaced by a new cache entry.
+	data = append(data, []byte("999,FIRST,LAST,2020-06-11T02:50:54Z\n")...)
+	require.NoError(t, ioutil.WriteFile(src.Location, data, 0600))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(src.Location, modTime, modTime))
+
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".data"))
+	require.Equal(t, sakila.TblActorCount+1, len(ru.mustReadCSV()))
+	entries2 := cacheEntries()
+	require.Equal(t, 1, len(entries2))
+	require.NotEqual(t, entries[0].Path, entries2[0].Path)
+	require.NoFileExists(t, entries[0].Path)
+
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("cache", "clear"))
+	require.Equal(t, "Removed 1 import cache entry\n", ru.out.String())
+	require.Empty(t, cacheEntries())
+
+	// With --no-cache, the cache is not populated.
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--no-cache", "--csv", ".data"))
+	require.Empty(t, cacheEntries())
+
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--no-cache=false", "--csv", ".data"))
+	require.Equal(t, 1, len(cacheEntries()))
+}
+
+// TestCmdCache_UserDriver verifies that a change to a user
+// driver definition invalidates the cached import.
+func TestCmdCache_UserDriver(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	src := testh.New(t).Source(testsrc.PplUD)
+	udDef := testh.DriverDefsFrom(t, testsrc.PathDriverDefPpl)[0]
+
+	newCacheRun := func() *run {
+		ru := newRun(t).add(*src)
+		ru.rc.CacheDir = cacheDir
+		ru.rc.Config.Ext.UserDrivers = append(ru.rc.Config.Ext.UserDrivers, udDef)
+		return ru
+	}
+
+	cacheEntries := func() []*driver.ImportCacheEntry {
+		ru := newCacheRun()
+		require.NoError(t, ru.exec("cache", "ls", "--json"))
+		var entries []*driver.ImportCacheEntry
+		require.NoError(t, json.Unmarshal(ru.out.Bytes(), &entries))
+		return entries
+	}
+
+	ru := newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".person"))
+	require.Equal(t, 3, len(ru.mustReadCSV()))
+	entries := cacheEntries()
+	require.Equal(t, 1, len(entries))
+
+	// The unchanged def uses the cached import.
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".person"))
+	require.Equal(t, entries, cacheEntries())
+
+	udDef.Title += " (changed)"
+	ru = newCacheRun()
+	require.NoError(t, ru.exec("--csv", "--header=false", ".person"))
+	require.Equal(t, 3, len(ru.mustReadCSV()))
+	entries2 := cacheEntries()
+	require.Equal(t, 1, len(entries2))
+	require.NotEqual(t, entries[0].Path, entries2[0].Path)
+}
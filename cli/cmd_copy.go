@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/neilotoole/errgroup"
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/drivers/sqlite3"
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+func newCopyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy @FROM_HANDLE @TO_HANDLE",
+		Short: "Copy the tables of a source to another source",
+		Long: `Copy the tables of a source to another source, which may be of a
+different type, e.g. from Postgres to SQLite. Each table is created in the
+destination source, with column types translated to the destination's
+native types, and then the table data is copied. Use --tables to copy only
+specific tables, and --schema-only to create the tables without copying
+data.
+
+Views are not copied, unless explicitly specified via --tables, in which
+case the view is copied as a table. A single-column primary key, column
+nullability, and foreign keys that reference other copied tables are
+preserved, but other constraints are not recreated. The tables are created
+and copied in foreign key order. The tables are copied concurrently, unless
+there are foreign keys, or the destination is SQLite, which permits only a
+single writer.
+
+It is an error if any of the tables already exist in the destination source.
+If the copy fails, the tables that were created are dropped.`,
+		Args:              cobra.ExactArgs(2),
+		RunE:              execCopy,
+		ValidArgsFunction: completeHandle(2),
+		Example: `  # Copy all tables of @sakila_pg to @sakila_sl3
+  $ sq copy @sakila_pg @sakila_sl3
+
+  # Copy only tables "actor" and "film"
+  $ sq copy @sakila_pg @sakila_sl3 --tables=actor,film
+
+  # Create the tables, but don't copy data
+  $ sq copy @sakila_pg @sakila_sl3 --schema-only`,
+	}
+
+	cmd.Flags().String(flagCopyTables, "", flagCopyTablesUsage)
+	cmd.Flags().Bool(flagCopySchemaOnly, false, flagCopySchemaOnlyUsage)
+
+	return cmd
+}
+
+func execCopy(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rc := RunContextFrom(ctx)
+
+	fromSrc, err := rc.Config.Sources.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	destSrc, err := rc.Config.Sources.Get(args[1])
+	if err != nil {
+		return err
+	}
+
+	if fromSrc.Handle == destSrc.Handle {
+		return errz.Errorf("cannot copy %s to itself", fromSrc.Handle)
+	}
+
+	destDrvr, err := rc.registry.DriverFor(destSrc.Type)
+	if err != nil {
+		return err
+	}
+
+	if !destDrvr.DriverMetadata().IsSQL {
+		return errz.Errorf("cannot copy to %s: source type %q is not a SQL database", destSrc.Handle, destSrc.Type)
+	}
+
+	fromDB, err := rc.databases.Open(ctx, fromSrc)
+	if err != nil {
+		return err
+	}
+
+	destDB, err := rc.databases.Open(ctx, destSrc)
+	if err != nil {
+		return err
+	}
+
+	srcMeta, err := fromDB.SourceMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	tblMetas, err := getFlagTables(cmd, flagCopyTables, srcMeta)
+	if err != nil {
+		return err
+	}
+
+	// Verify that none of the tables exist before we start
+	// creating any of them.
+	destSQLDrvr := destDB.SQLDriver()
+	for _, tblMeta := range tblMetas {
+		var exists bool
+		exists, err = destSQLDrvr.TableExists(ctx, destDB.DB(), tblMeta.Name)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return errz.Errorf("table %s.%s already exists", destSrc.Handle, tblMeta.Name)
+		}
+	}
+
+	// The tables are created (and copied, if not concurrently)
+	// such that a table follows the tables that it references.
+	tblMetas, err = libsq.SortTablesByFK(tblMetas)
+	if err != nil {
+		return err
+	}
+
+	// If the copy fails, we drop the tables that we created, so that
+	// the destination isn't left with partially copied tables.
+	var created []string
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for i := len(created) - 1; i >= 0; i-- {
+			rc.Log.WarnIfError(destSQLDrvr.DropTable(context.Background(), destDB.DB(), created[i], true))
+		}
+	}()
+
+	var hasFKs bool
+	for _, tblMeta := range tblMetas {
+		tblDef := newCopyTableDef(tblMeta, tblMetas)
+		for _, colDef := range tblDef.Cols {
+			hasFKs = hasFKs || colDef.ForeignKey != nil
+		}
+
+		err = destSQLDrvr.CreateTable(ctx, destDB.DB(), tblDef)
+		if err != nil {
+			return errz.Wrapf(err, "failed to create table %s.%s", destSrc.Handle, tblMeta.Name)
+		}
+		created = append(created, tblMeta.Name)
+	}
+
+	schemaOnly, _ := cmd.Flags().GetBool(flagCopySchemaOnly)
+	if schemaOnly {
+		for _, tblMeta := range tblMetas {
+			fmt.Fprintf(rc.Out, "Created table: %s.%s\n", destSrc.Handle, tblMeta.Name)
+		}
+		return nil
+	}
+
+	// The data for each table is copied concurrently, unless
+	// foreign keys were recreated, in which case a table's
+	// referenced tables must be copied first, or the destination
+	// permits only a single writer (SQLite), in which case
+	// concurrent writes fail with "database is locked".
+	numG := driver.Tuning.ErrgroupNumG
+	if hasFKs || destSrc.Type == sqlite3.Type {
+		numG = 1
+	}
+
+	fromDialect := fromDB.SQLDriver().Dialect()
+	copied := make([]int64, len(tblMetas))
+	g, gCtx := errgroup.WithContextN(ctx, numG, driver.Tuning.ErrgroupQSize)
+	for i := range tblMetas {
+		i := i
+		g.Go(func() error {
+			var gErr error
+			query := "SELECT * FROM " + fromDialect.Enquote(tblMetas[i].Name)
+			copied[i], gErr = libsq.CopyTableData(gCtx, rc.Log, fromDB, query, destDB, tblMetas[i].Name)
+			return gErr
+		})
+	}
+
+	err = g.Wait()
+	if err != nil {
+		return err
+	}
+
+	for i, tblMeta := range tblMetas {
+		fmt.Fprintf(rc.Out, stringz.Plu("Copied table: %s.%s --> %s.%s (%d row(s) copied)\n", int(copied[i])),
+			fromSrc.Handle, tblMeta.Name, destSrc.Handle, tblMeta.Name, copied[i])
+	}
+
+	return nil
+}
+
+// newCopyTableDef returns the definition of the destination table
+// for tblMeta, as per libsq.NewTableDefFromMetadata, but with the
+// foreign keys that reference tables of tblMetas recreated.
+func newCopyTableDef(tblMeta *source.TableMetadata, tblMetas []*source.TableMetadata) *sqlmodel.TableDef {
+	tblDef := libsq.NewTableDefFromMetadata(tblMeta, tblMeta.Name)
+	for i, col := range tblMeta.Columns {
+		if col.ForeignKey == nil {
+			continue
+		}
+
+		for _, refMeta := range tblMetas {
+			if refMeta.Name == col.ForeignKey.RefTable {
+				tblDef.Cols[i].ForeignKey = &sqlmodel.FKConstraint{
+					RefTable: col.ForeignKey.RefTable,
+					RefCol:   col.ForeignKey.RefCol,
+				}
+				break
+			}
+		}
+	}
+
+	return tblDef
+}
+
+// getFlagTables returns the metadata for the tables specified by
+// the comma-separated value of flag flagName (e.g. --tables), or
+// if that flag is not set, all of the tables (but not views)
+// in srcMeta.
+func getFlagTables(cmd *cobra.Command, flagName string, srcMeta *source.Metadata) ([]*source.TableMetadata, error) {
+	if !cmdFlagChanged(cmd, flagName) {
+		var tblMetas []*source.TableMetadata
+		for _, tblMeta := range srcMeta.Tables {
+			if tblMeta.TableType != sqlz.TableTypeView {
+				tblMetas = append(tblMetas, tblMeta)
+			}
+		}
+		return tblMetas, nil
+	}
+
+	val, _ := cmd.Flags().GetString(flagName)
+	var tblMetas []*source.TableMetadata
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errz.Errorf("invalid --%s value %q", flagName, val)
+		}
+
+		var found bool
+		for _, tblMeta := range srcMeta.Tables {
+			if tblMeta.Name == name {
+				tblMetas = append(tblMetas, tblMeta)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errz.Errorf("table %s.%s not found", srcMeta.Handle, name)
+		}
+	}
+
+	return tblMetas, nil
+}
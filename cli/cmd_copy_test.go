@@ -0,0 +1,89 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/sqlite3"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+func TestCmdCopy(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	dir := t.TempDir()
+	destSrc := source.Source{
+		Handle:   "@dest",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(dir, "dest.db"),
+	}
+	dest2Src := source.Source{
+		Handle:   "@dest2",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(dir, "dest2.db"),
+	}
+
+	ru := newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("copy", src.Handle, destSrc.Handle))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(&destSrc, "data"))
+
+	// Should fail because the table already exists
+	ru = newRun(t).add(*src, destSrc)
+	require.Error(t, ru.exec("copy", src.Handle, destSrc.Handle))
+
+	// Should fail because the table doesn't exist in the source
+	ru = newRun(t).add(*src, dest2Src)
+	require.Error(t, ru.exec("copy", "--tables=not_exist", src.Handle, dest2Src.Handle))
+
+	// Should fail because the destination isn't a SQL source
+	ru = newRun(t).add(destSrc, *src)
+	require.Error(t, ru.exec("copy", destSrc.Handle, src.Handle))
+
+	ru = newRun(t).add(destSrc, dest2Src)
+	require.NoError(t, ru.exec("copy", "--schema-only", "--tables=data", destSrc.Handle, dest2Src.Handle))
+	require.Equal(t, int64(0), th.RowCount(&dest2Src, "data"))
+
+	dest2DB := th.Open(&dest2Src)
+	tblMeta, err := dest2DB.TableMetadata(th.Context, "data")
+	require.NoError(t, err)
+	require.Equal(t, []string{"actor_id", "first_name", "last_name", "last_update"}, colNames(tblMeta))
+}
+
+func colNames(tblMeta *source.TableMetadata) []string {
+	names := make([]string, len(tblMeta.Columns))
+	for i, col := range tblMeta.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// TestCmdCopy_MultiTable verifies that a source with many tables
+// can be copied to SQLite, which permits only a single writer.
+func TestCmdCopy_MultiTable(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.XLSX)
+	destSrc := source.Source{
+		Handle:   "@dest",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(t.TempDir(), "dest.db"),
+	}
+
+	ru := newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("copy", src.Handle, destSrc.Handle))
+
+	srcMeta, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.True(t, len(srcMeta.Tables) > 1)
+	for _, tblMeta := range srcMeta.Tables {
+		require.Equal(t, tblMeta.RowCount, th.RowCount(&destSrc, tblMeta.Name), tblMeta.Name)
+	}
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/diff"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+func newDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff @HANDLE1.TABLE @HANDLE2.TABLE",
+		Short: "Compare table data or source schemas",
+		Long: `Compare the data of two tables, which may be in different sources.
+Rows are matched by the key columns, specified via --key. If --key is
+not set, the primary key of the first table is used. The added, removed
+and changed rows are reported, followed by a summary.
+
+Rows present only in the second table are "added", rows present only in
+the first table are "removed". Only the columns present in both tables
+are compared. Values are compared by kind, thus an int in one database
+may equal a decimal in another.
+
+With flag --schema, the schemas of two sources are compared instead.
+Either arg may be a source @HANDLE, or the path to a snapshot file
+produced by "sq inspect --snapshot". Added and removed tables and columns
+are reported, as are changes to a column's type, kind, nullability,
+primary key and default value.
+
+Like diff(1), sq diff exits with status 1 if differences are found.`,
+		Args:              cobra.ExactArgs(2),
+		RunE:              execDiff,
+		ValidArgsFunction: completeDiff,
+		Example: `  # Compare table "customer" in @old and @new
+  $ sq diff @old.customer @new.customer
+
+  # Compare tables using the "email" column as the key
+  $ sq diff @old.customer @new.customer --key=email
+
+  # Use a composite key
+  $ sq diff @old.film_actor @new.film_actor --key=film_id,actor_id
+
+  # Output as text table
+  $ sq diff -t @old.customer @new.customer
+
+  # Compare the schemas of @prod and @staging
+  $ sq diff --schema @prod @staging
+
+  # Compare the schema of @staging against a snapshot
+  $ sq inspect --snapshot @prod > schema.json
+  $ sq diff --schema schema.json @staging`,
+	}
+
+	cmd.Flags().String(flagDiffKey, "", flagDiffKeyUsage)
+	cmd.Flags().Bool(flagDiffSchema, false, flagDiffSchemaUsage)
+	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
+	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
+
+	return cmd
+}
+
+func execDiff(cmd *cobra.Command, args []string) error {
+	if cmdFlagChanged(cmd, flagDiffSchema) {
+		if cmdFlagChanged(cmd, flagDiffKey) {
+			return errz.Errorf("flag --%s cannot be used with --%s", flagDiffKey, flagDiffSchema)
+		}
+
+		return execDiffSchema(cmd, args)
+	}
+
+	rc := RunContextFrom(cmd.Context())
+
+	tblHandles, err := parseTableHandleArgs(rc.registry, rc.Config.Sources, args)
+	if err != nil {
+		return err
+	}
+
+	var keyCols []string
+	if cmdFlagChanged(cmd, flagDiffKey) {
+		val, _ := cmd.Flags().GetString(flagDiffKey)
+		for _, col := range strings.Split(val, ",") {
+			col = strings.TrimSpace(col)
+			if col == "" {
+				return errz.Errorf("invalid --%s value %q", flagDiffKey, val)
+			}
+			keyCols = append(keyCols, col)
+		}
+	}
+
+	tbls := make([]diff.Table, len(tblHandles))
+	for i := range tblHandles {
+		tbls[i].Name = tblHandles[i].tbl
+		tbls[i].DB, err = rc.databases.Open(cmd.Context(), tblHandles[i].src)
+		if err != nil {
+			return err
+		}
+	}
+
+	h := &summaryHandler{DataHandler: rc.writers.diffw}
+	err = diff.Data(cmd.Context(), rc.Log, tbls[0], tbls[1], keyCols, h)
+	if err != nil {
+		return err
+	}
+
+	if h.summary.Differs() {
+		// The differences have already been output, so we
+		// return errNoMsg to exit non-zero without printing.
+		return errNoMsg
+	}
+
+	return nil
+}
+
+func execDiffSchema(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+
+	mds := make([]*source.Metadata, len(args))
+	for i := range args {
+		var err error
+		mds[i], err = getSchemaMetadata(cmd.Context(), rc, args[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	sd := diff.Schema(mds[0], mds[1])
+	sd.Left, sd.Right = args[0], args[1]
+
+	err := rc.writers.diffw.SchemaDiff(sd)
+	if err != nil {
+		return err
+	}
+
+	if sd.Differs() {
+		return errNoMsg
+	}
+
+	return nil
+}
+
+// getSchemaMetadata returns the metadata for arg, which is either
+// a source @HANDLE, or the path to a snapshot file produced
+// by "sq inspect --snapshot".
+func getSchemaMetadata(ctx context.Context, rc *RunContext, arg string) (*source.Metadata, error) {
+	if strings.HasPrefix(arg, "@") {
+		src, err := rc.Config.Sources.Get(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		dbase, err := rc.databases.Open(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+
+		return dbase.SourceMetadata(ctx)
+	}
+
+	data, err := ioutil.ReadFile(arg)
+	if err != nil {
+		return nil, errz.Wrap(err, "read schema snapshot")
+	}
+
+	md := &source.Metadata{}
+	err = json.Unmarshal(data, md)
+	if err != nil {
+		return nil, errz.Wrapf(err, "invalid schema snapshot: %s", arg)
+	}
+
+	return md, nil
+}
+
+// summaryHandler is a diff.DataHandler that delegates to the
+// embedded handler, and retains the summary passed to Close.
+type summaryHandler struct {
+	diff.DataHandler
+	summary *diff.DataSummary
+}
+
+// Close implements diff.DataHandler.
+func (h *summaryHandler) Close(summary *diff.DataSummary) error {
+	h.summary = summary
+	return h.DataHandler.Close(summary)
+}
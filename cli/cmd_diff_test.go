@@ -0,0 +1,224 @@
+package cli_test
+
+import (
+	stdj "encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/json"
+	"github.com/neilotoole/sq/drivers/sqlite3"
+	"github.com/neilotoole/sq/libsq/diff"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+func TestCmdDiff(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	// Create a copy of the actor CSV with: actor 5 changed,
+	// actor 12 removed, and actor 201 added.
+	lines := strings.Split(strings.TrimSpace(string(proj.ReadFile(sakila.PathCSVActor))), "\n")
+	var changedLines []string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "5,"):
+			line = strings.Replace(line, "JOHNNY", "JOHN", 1)
+		case strings.HasPrefix(line, "12,"):
+			continue
+		}
+		changedLines = append(changedLines, line)
+	}
+	changedLines = append(changedLines, "201,ALICE,SMITH,2020-02-15T06:59:28Z")
+
+	changedPath := filepath.Join(t.TempDir(), "actor.csv")
+	require.NoError(t, ioutil.WriteFile(changedPath, []byte(strings.Join(changedLines, "\n")), 0600))
+
+	changedSrc := *src
+	changedSrc.Handle = "@actor_changed"
+	changedSrc.Location = changedPath
+
+	// The CSV source has no primary key, so --key is required.
+	ru := newRun(t).add(*src, changedSrc)
+	require.Error(t, ru.exec("diff", src.Handle+".data", changedSrc.Handle+".data"))
+
+	// There are differences, so the command should fail (exit non-zero).
+	ru = newRun(t).add(*src, changedSrc)
+	require.Error(t, ru.exec("diff", "--json", "--key=actor_id", src.Handle+".data", changedSrc.Handle+".data"))
+
+	var result struct {
+		Diffs []struct {
+			Status string        `json:"status"`
+			Key    []interface{} `json:"key"`
+			Cols   []struct {
+				Name  string      `json:"name"`
+				Left  interface{} `json:"left"`
+				Right interface{} `json:"right"`
+			} `json:"cols"`
+		} `json:"diffs"`
+		Summary struct {
+			LeftRows  int64 `json:"left_rows"`
+			RightRows int64 `json:"right_rows"`
+			Same      int64 `json:"same"`
+			Changed   int64 `json:"changed"`
+			Added     int64 `json:"added"`
+			Removed   int64 `json:"removed"`
+		} `json:"summary"`
+	}
+	require.NoError(t, stdj.Unmarshal(ru.out.Bytes(), &result))
+
+	require.Equal(t, int64(sakila.TblActorCount), result.Summary.LeftRows)
+	require.Equal(t, int64(sakila.TblActorCount), result.Summary.RightRows)
+	require.Equal(t, int64(sakila.TblActorCount-2), result.Summary.Same)
+	require.Equal(t, int64(1), result.Summary.Changed)
+	require.Equal(t, int64(1), result.Summary.Added)
+	require.Equal(t, int64(1), result.Summary.Removed)
+
+	require.Equal(t, 3, len(result.Diffs))
+	require.Equal(t, "changed", result.Diffs[0].Status)
+	require.Equal(t, []interface{}{float64(5)}, result.Diffs[0].Key)
+	require.Equal(t, 1, len(result.Diffs[0].Cols))
+	require.Equal(t, "first_name", result.Diffs[0].Cols[0].Name)
+	require.Equal(t, "JOHNNY", result.Diffs[0].Cols[0].Left)
+	require.Equal(t, "JOHN", result.Diffs[0].Cols[0].Right)
+	require.Equal(t, "removed", result.Diffs[1].Status)
+	require.Equal(t, []interface{}{float64(12)}, result.Diffs[1].Key)
+	require.Equal(t, "added", result.Diffs[2].Status)
+	require.Equal(t, []interface{}{float64(201)}, result.Diffs[2].Key)
+
+	// Same table: no differences.
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.exec("diff", "--table", "--key=actor_id", src.Handle+".data", src.Handle+".data"))
+	require.Contains(t, ru.out.String(), src.Handle+".data")
+}
+
+func TestCmdDiff_Schema(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	ru := newRun(t).add(*src)
+	require.NoError(t, ru.exec("inspect", "--snapshot", src.Handle))
+	snapshot := ru.out.Bytes()
+
+	md := &source.Metadata{}
+	require.NoError(t, stdj.Unmarshal(snapshot, md))
+	require.Equal(t, src.Handle, md.Handle)
+	require.Empty(t, md.DBVars)
+
+	snapshotPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, ioutil.WriteFile(snapshotPath, snapshot, 0600))
+
+	// No differences between the snapshot and the source.
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.exec("diff", "--schema", snapshotPath, src.Handle))
+
+	// Modify the snapshot: remove column "last_update", toggle
+	// nullability of column "first_name", and add table "film".
+	tbl := md.Tables[0]
+	require.Equal(t, "last_update", tbl.Columns[3].Name)
+	tbl.Columns = tbl.Columns[0:3]
+	tbl.Columns[1].Nullable = !tbl.Columns[1].Nullable
+	md.Tables = append(md.Tables, &source.TableMetadata{Name: "film"})
+
+	snapshot, err := stdj.Marshal(md)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(snapshotPath, snapshot, 0600))
+
+	ru = newRun(t).add(*src)
+	require.Error(t, ru.exec("diff", "--schema", "--json", snapshotPath, src.Handle))
+
+	sd := &diff.SchemaDiff{}
+	require.NoError(t, stdj.Unmarshal(ru.out.Bytes(), sd))
+	require.Equal(t, snapshotPath, sd.Left)
+	require.Equal(t, src.Handle, sd.Right)
+	require.Equal(t, 2, len(sd.Tables))
+
+	require.Equal(t, "data", sd.Tables[0].Name)
+	require.Equal(t, diff.StatusChanged, sd.Tables[0].Status)
+	require.Equal(t, 2, len(sd.Tables[0].Cols))
+	require.Equal(t, "first_name", sd.Tables[0].Cols[0].Name)
+	require.Equal(t, diff.StatusChanged, sd.Tables[0].Cols[0].Status)
+	require.Equal(t, "nullable", sd.Tables[0].Cols[0].Changes[0].Field)
+	require.Equal(t, "last_update", sd.Tables[0].Cols[1].Name)
+	require.Equal(t, diff.StatusAdded, sd.Tables[0].Cols[1].Status)
+
+	require.Equal(t, "film", sd.Tables[1].Name)
+	require.Equal(t, diff.StatusRemoved, sd.Tables[1].Status)
+
+	// Text output
+	ru = newRun(t).add(*src)
+	require.Error(t, ru.exec("diff", "--schema", "--table", snapshotPath, src.Handle))
+	require.Contains(t, ru.out.String(), "table film")
+}
+
+// TestCmdDiff_NullKey verifies that a NULL key value doesn't
+// break the key ordering that the diff relies upon.
+func TestCmdDiff_NullKey(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	leftPath, rightPath := filepath.Join(dir, "left.json"), filepath.Join(dir, "right.json")
+	require.NoError(t, ioutil.WriteFile(leftPath,
+		[]byte(`[{"id":1,"name":"a"},{"id":null,"name":"b"},{"id":2,"name":"c"}]`), 0600))
+	require.NoError(t, ioutil.WriteFile(rightPath,
+		[]byte(`[{"id":2,"name":"c"},{"id":null,"name":"b"},{"id":1,"name":"z"}]`), 0600))
+
+	leftSrc := source.Source{Handle: "@left", Type: json.TypeJSON, Location: leftPath}
+	rightSrc := source.Source{Handle: "@right", Type: json.TypeJSON, Location: rightPath}
+
+	ru := newRun(t).add(leftSrc, rightSrc)
+	require.Error(t, ru.exec("diff", "--json", "--key=id", "@left.data", "@right.data"), "rows differ")
+
+	var result struct {
+		Summary struct {
+			Same    int64 `json:"same"`
+			Changed int64 `json:"changed"`
+		} `json:"summary"`
+	}
+	require.NoError(t, stdj.Unmarshal(ru.out.Bytes(), &result))
+	require.Equal(t, int64(2), result.Summary.Same)
+	require.Equal(t, int64(1), result.Summary.Changed)
+}
+
+// TestCmdDiff_Collation verifies that text keys are ordered
+// consistently, regardless of the key column's collation.
+func TestCmdDiff_Collation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	leftSrc := source.Source{Handle: "@left", Type: sqlite3.Type, Location: "sqlite3://" + filepath.Join(dir, "left.db")}
+	rightSrc := source.Source{Handle: "@right", Type: sqlite3.Type, Location: "sqlite3://" + filepath.Join(dir, "right.db")}
+
+	// With COLLATE NOCASE, the database orders "B" after "a",
+	// but the diff compares keys by code point.
+	for _, src := range []source.Source{leftSrc, rightSrc} {
+		for _, stmt := range []string{
+			"CREATE TABLE person (name TEXT COLLATE NOCASE PRIMARY KEY, age INTEGER)",
+			"INSERT INTO person VALUES ('a', 1), ('B', 2), ('c', 3)",
+		} {
+			ru := newRun(t).add(src)
+			require.NoError(t, ru.exec("sql", "--src="+src.Handle, stmt))
+		}
+	}
+
+	ru := newRun(t).add(leftSrc, rightSrc)
+	require.NoError(t, ru.exec("diff", "--json", "@left.person", "@right.person"))
+
+	var result struct {
+		Summary struct {
+			Same int64 `json:"same"`
+		} `json:"summary"`
+	}
+	require.NoError(t, stdj.Unmarshal(ru.out.Bytes(), &result))
+	require.Equal(t, int64(3), result.Summary.Same)
+}
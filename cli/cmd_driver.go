@@ -1,7 +1,21 @@
 package cli
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/neilotoole/sq/cli/config"
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/xml"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
 )
 
 func newDriverCmd() *cobra.Command {
@@ -15,8 +29,17 @@ func newDriverCmd() *cobra.Command {
 		Example: `  # List drivers
   $ sq driver ls
 
-  # Install User Driver [TBD]
-  $ sq driver install ./rss.sq.yml
+  # Generate a user driver definition from an XML document
+  $ sq driver gen-def ./feed.xml > ~/.config/sq/ext/feed.sq.yml
+
+  # Validate a user driver definition file
+  $ sq driver validate ./rss.sq.yml
+
+  # Install a user driver definition file
+  $ sq driver add ./rss.sq.yml
+
+  # Remove a user driver
+  $ sq driver rm rss
 `,
 	}
 
@@ -33,6 +56,7 @@ func newDriverListCmd() *cobra.Command {
 
 	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
 	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagYAML, flagYAMLShort, false, flagYAMLUsage)
 	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
 	cmd.Flags().BoolP(flagMonochrome, flagMonochromeShort, false, flagMonochromeUsage)
 
@@ -44,3 +68,277 @@ func execDriverList(cmd *cobra.Command, args []string) error {
 	drvrs := rc.registry.DriversMetadata()
 	return rc.writers.metaw.DriverMetadata(drvrs)
 }
+
+func newDriverGenDefCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen-def FILE",
+		Short: "Generate a user driver definition from an XML document",
+		Long: `Generate a user driver definition from an XML document. The tables
+and columns of the definition are those that the xml driver infers
+from the document. The definition is printed as YAML, suitable for
+a file in the sq ext config dir (e.g. ~/.config/sq/ext/feed.sq.yml),
+and can be edited to rename tables and columns, or to drop them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: execDriverGenDef,
+		Example: `  # Print the generated definition
+  $ sq driver gen-def ./feed.xml
+
+  # Name the driver "feed", and install it
+  $ sq driver gen-def --name feed ./feed.xml > ~/.config/sq/ext/feed.sq.yml`,
+	}
+
+	cmd.Flags().String(flagDriverGenDefName, "", flagDriverGenDefNameUsage)
+	return cmd
+}
+
+func execDriverGenDef(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer rc.Log.WarnIfCloseError(f)
+
+	name, _ := cmd.Flags().GetString(flagDriverGenDefName)
+	def, err := xml.GenerateDriverDef(cmd.Context(), f, name)
+	if err != nil {
+		return err
+	}
+
+	ext := config.Ext{UserDrivers: []*userdriver.DriverDef{def}}
+	data, err := yaml.Marshal(ext)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	_, err = rc.Out.Write(data)
+	return errz.Err(err)
+}
+
+func newDriverValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate FILE",
+		Short: "Validate a user driver definition file",
+		Long: `Validate a user driver definition file, such as one generated by
+"sq driver gen-def". Each error is printed with the line of the file
+at which it occurs.`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    execDriverValidate,
+		Example: `  $ sq driver validate ./rss.sq.yml`,
+	}
+
+	return cmd
+}
+
+func execDriverValidate(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+
+	ext, err := validateExtFile(rc, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rc.Out, "Valid: ")
+	for i, def := range ext.UserDrivers {
+		if i > 0 {
+			fmt.Fprintf(rc.Out, ", ")
+		}
+		_, _ = rc.writers.fmt.Hilite.Fprintf(rc.Out, "%s", def.Name)
+	}
+	fmt.Fprintln(rc.Out)
+
+	return nil
+}
+
+// validateExtFile reads and validates the user driver defs of the
+// ext config file at fpath. If the file is not valid, each error is
+// printed to rc.ErrOut, and a summary error is returned.
+func validateExtFile(rc *RunContext, fpath string) (*config.Ext, error) {
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	ext, errs := config.ValidateExt(data)
+	if len(errs) == 0 {
+		return ext, nil
+	}
+
+	for _, err := range errs {
+		fmt.Fprintf(rc.ErrOut, "%s: %v\n", fpath, err)
+	}
+
+	return nil, errz.Errorf("%s: %d error(s) in driver definition", fpath, len(errs))
+}
+
+func newDriverAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add FILE",
+		Short: "Install a user driver definition file",
+		Long: `Install a user driver definition file, by copying it into the sq ext
+config dir (e.g. ~/.config/sq/ext). The file is validated first, and
+its drivers must not have the same name as an existing driver.`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    execDriverAdd,
+		Example: `  $ sq driver add ./rss.sq.yml`,
+	}
+
+	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
+	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagYAML, flagYAMLShort, false, flagYAMLUsage)
+	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
+	cmd.Flags().BoolP(flagMonochrome, flagMonochromeShort, false, flagMonochromeUsage)
+
+	return cmd
+}
+
+func execDriverAdd(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	fpath := args[0]
+
+	ext, err := validateExtFile(rc, fpath)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range ext.UserDrivers {
+		if rc.registry.ProviderFor(source.Type(def.Name)) != nil {
+			return errz.Errorf("driver %q already exists", def.Name)
+		}
+	}
+
+	extDir, err := extDirFrom(rc)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(fpath)
+	if !strings.HasSuffix(name, config.ExtSuffix) {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + config.ExtSuffix
+	}
+
+	dest := filepath.Join(extDir, name)
+	if _, err = os.Stat(dest); err == nil {
+		return errz.Errorf("ext config file %q already exists", dest)
+	}
+
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	err = os.MkdirAll(extDir, 0750)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	err = ioutil.WriteFile(dest, data, 0600)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	mds := make([]driver.Metadata, len(ext.UserDrivers))
+	for i, def := range ext.UserDrivers {
+		mds[i] = driver.Metadata{
+			Type:        source.Type(def.Name),
+			Description: def.Title,
+			Doc:         def.Doc,
+			UserDefined: true,
+			File:        dest,
+		}
+	}
+
+	return rc.writers.metaw.DriverMetadata(mds)
+}
+
+func newDriverRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm NAME",
+		Short: "Remove a user driver",
+		Long: `Remove a user driver. If the driver's definition file contains only
+that driver, the file is deleted. Otherwise the file is rewritten
+without the driver's definition (note that comments in the file
+are not preserved).`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    execDriverRemove,
+		Example: `  $ sq driver rm rss`,
+	}
+
+	return cmd
+}
+
+func execDriverRemove(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	name := args[0]
+
+	var def *userdriver.DriverDef
+	for _, d := range rc.Config.Ext.UserDrivers {
+		if d.Name == name {
+			def = d
+			break
+		}
+	}
+
+	if def == nil {
+		if rc.registry.ProviderFor(source.Type(name)) != nil {
+			return errz.Errorf("driver %q is not a user driver", name)
+		}
+		return errz.Errorf("user driver %q not found", name)
+	}
+
+	if def.File == "" {
+		return errz.Errorf("user driver %q was not loaded from a file", name)
+	}
+
+	data, err := ioutil.ReadFile(def.File)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	ext, err := config.ParseExt(def.File, data)
+	if err != nil {
+		return err
+	}
+
+	var remaining []*userdriver.DriverDef
+	for _, d := range ext.UserDrivers {
+		if d.Name != name {
+			remaining = append(remaining, d)
+		}
+	}
+
+	if len(remaining) == 0 {
+		err = os.Remove(def.File)
+		if err != nil {
+			return errz.Err(err)
+		}
+	} else {
+		ext.UserDrivers = remaining
+		data, err = yaml.Marshal(ext)
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		err = ioutil.WriteFile(def.File, data, 0600)
+		if err != nil {
+			return errz.Err(err)
+		}
+	}
+
+	fmt.Fprintf(rc.Out, "Removed driver ")
+	_, _ = rc.writers.fmt.Hilite.Fprintf(rc.Out, "%s", name)
+	fmt.Fprintln(rc.Out)
+
+	return nil
+}
+
+// extDirFrom returns the ext config dir of rc's config store.
+func extDirFrom(rc *RunContext) (string, error) {
+	fs, ok := rc.ConfigStore.(*config.YAMLFileStore)
+	if !ok || len(fs.ExtPaths) == 0 {
+		return "", errz.Errorf("config store %T does not support ext config files", rc.ConfigStore)
+	}
+
+	return fs.ExtPaths[0], nil
+}
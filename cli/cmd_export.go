@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export @HANDLE",
+		Short: "Export the tables of a source to a single file",
+		Long: `Export the tables of a source to a single file, with each table
+written as a separate result set. Currently only the XLSX format supports
+multiple result sets: each table is written to a sheet named for the table.
+Use --tables to export only specific tables. Views are not exported, unless
+explicitly specified via --tables. As XLSX is a binary format, it is
+not written to the terminal: use --output, or redirect stdout.
+
+Likewise, for "sq sql" with --xlsx, each query of a multi-statement
+input (e.g. "SELECT * FROM actor; SELECT * FROM film") is written to
+a separate sheet.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              execExport,
+		ValidArgsFunction: completeHandle(1),
+		Example: `  # Export all tables of @sakila_pg to sakila.xlsx
+  $ sq export @sakila_pg --xlsx --output sakila.xlsx
+
+  # Export only tables "actor" and "film"
+  $ sq export @sakila_pg --tables=actor,film --xlsx -o sakila.xlsx`,
+	}
+
+	cmd.Flags().String(flagExportTables, "", flagExportTablesUsage)
+	cmd.Flags().StringP(flagOutput, flagOutputShort, "", flagOutputUsage)
+	cmd.Flags().String(flagCompress, "", flagCompressUsage)
+	cmd.Flags().BoolP(flagXLSX, flagXLSXShort, false, flagXLSXUsage)
+	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
+
+	return cmd
+}
+
+func execExport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rc := RunContextFrom(ctx)
+
+	multiw := rc.writers.multiw
+	if multiw == nil {
+		return errz.Errorf("%s output does not support multiple result sets: use --%s",
+			rc.writers.format, flagXLSX)
+	}
+
+	err := checkBinaryOutput(rc)
+	if err != nil {
+		return err
+	}
+
+	src, err := rc.Config.Sources.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	dbase, err := rc.databases.Open(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	srcMeta, err := dbase.SourceMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	tblMetas, err := getFlagTables(cmd, flagExportTables, srcMeta)
+	if err != nil {
+		return err
+	}
+
+	dialect := dbase.SQLDriver().Dialect()
+	for _, tblMeta := range tblMetas {
+		var recw output.RecordWriter
+		recw, err = multiw.NewRecordWriter(tblMeta.Name)
+		if err != nil {
+			return err
+		}
+
+		// The adapter closes recw when the query completes.
+		adapter := output.NewRecordWriterAdapter(recw)
+		err = libsq.QuerySQL(ctx, rc.Log, dbase, adapter, "SELECT * FROM "+dialect.Enquote(tblMeta.Name))
+		if err != nil {
+			return errz.Wrapf(err, "failed to export table %s.%s", src.Handle, tblMeta.Name)
+		}
+
+		_, err = adapter.Wait()
+		if err != nil {
+			return errz.Wrapf(err, "failed to export table %s.%s", src.Handle, tblMeta.Name)
+		}
+	}
+
+	return multiw.Close()
+}
@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tealeg/xlsx/v2"
+
+	"github.com/neilotoole/sq/drivers/sqlite3"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+func TestCmdExport(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	dir := t.TempDir()
+	destSrc := source.Source{
+		Handle:   "@dest_export",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(dir, "dest.db"),
+	}
+
+	ru := newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("copy", src.Handle, destSrc.Handle))
+
+	destDB := th.Open(&destSrc)
+	_, err := destDB.DB().ExecContext(th.Context, "CREATE TABLE actor2 AS SELECT * FROM data LIMIT 3")
+	require.NoError(t, err)
+
+	// Should fail because the output format doesn't support
+	// multiple result sets.
+	ru = newRun(t).add(destSrc)
+	require.Error(t, ru.exec("export", destSrc.Handle))
+
+	fpath := filepath.Join(dir, "export.xlsx")
+	ru = newRun(t).add(destSrc)
+	require.NoError(t, ru.exec("export", "--xlsx", "--header", "--output", fpath, destSrc.Handle))
+
+	xfile, err := xlsx.OpenFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(xfile.Sheets))
+	require.Equal(t, "actor2", xfile.Sheets[0].Name)
+	require.Equal(t, 4, len(xfile.Sheets[0].Rows))
+	require.Equal(t, "data", xfile.Sheets[1].Name)
+	require.Equal(t, sakila.TblActorCount+1, len(xfile.Sheets[1].Rows))
+	require.Equal(t, "actor_id", xfile.Sheets[1].Rows[0].Cells[0].String())
+
+	fpath = filepath.Join(dir, "export_data.xlsx")
+	ru = newRun(t).add(destSrc)
+	require.NoError(t, ru.exec("export", "--xlsx", "--tables=data", "--output", fpath, destSrc.Handle))
+
+	xfile, err = xlsx.OpenFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(xfile.Sheets))
+	require.Equal(t, "data", xfile.Sheets[0].Name)
+}
+
+func TestCmdSQL_MultiStmtXLSX(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	fpath := filepath.Join(t.TempDir(), "multi.xlsx")
+	ru := newRun(t).add(*src)
+	require.NoError(t, ru.exec("sql", "--xlsx", "--header", "--output", fpath,
+		"SELECT * FROM data; SELECT first_name FROM data WHERE actor_id < 3;"))
+
+	xfile, err := xlsx.OpenFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(xfile.Sheets))
+	require.Equal(t, "result1", xfile.Sheets[0].Name)
+	require.Equal(t, sakila.TblActorCount+1, len(xfile.Sheets[0].Rows))
+	require.Equal(t, "result2", xfile.Sheets[1].Name)
+	require.Equal(t, 3, len(xfile.Sheets[1].Rows))
+	require.Equal(t, "first_name", xfile.Sheets[1].Rows[0].Cells[0].String())
+
+	// A query is detected from its result, not its text: here the
+	// queries start with comments, and the DELETE has no result set.
+	fpath = filepath.Join(t.TempDir(), "multi2.xlsx")
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.exec("sql", "--xlsx", "--header", "--output", fpath,
+		"/* all; */ SELECT * FROM data; DELETE FROM data WHERE actor_id > 10; -- count;\nSELECT count(*) AS n FROM data"))
+
+	xfile, err = xlsx.OpenFile(fpath)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(xfile.Sheets))
+	require.Equal(t, sakila.TblActorCount+1, len(xfile.Sheets[0].Rows))
+	require.Equal(t, "n", xfile.Sheets[1].Rows[0].Cells[0].String())
+	require.Equal(t, "10", xfile.Sheets[1].Rows[1].Cells[0].String())
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/neilotoole/sq/cli/output/jsonw"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/source"
 )
@@ -32,12 +33,20 @@ If @HANDLE is not provided, the active data source is assumed.`,
   $ sq inspect .actor
   
   # inspect piped data
-  $ cat data.xlsx | sq inspect`,
+  $ cat data.xlsx | sq inspect
+
+  # output the inspection of @pg1 as YAML
+  $ sq inspect @pg1 --yaml
+
+  # save a schema snapshot of @pg1, to later compare via "sq diff --schema"
+  $ sq inspect --snapshot @pg1 > schema.json`,
 	}
 
 	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
 	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagYAML, flagYAMLShort, false, flagYAMLUsage)
 	cmd.Flags().Bool(flagInspectFull, false, flagInspectFullUsage)
+	cmd.Flags().Bool(flagInspectSnapshot, false, flagInspectSnapshotUsage)
 
 	return cmd
 }
@@ -116,6 +125,11 @@ func execInspect(cmd *cobra.Command, args []string) error {
 		return errz.Wrapf(err, "failed to inspect %s", src.Handle)
 	}
 
+	snapshot := cmdFlagChanged(cmd, flagInspectSnapshot)
+	if snapshot && table != "" {
+		return errz.Errorf("flag --%s cannot be used with a table: %s", flagInspectSnapshot, args[0])
+	}
+
 	if table != "" {
 		var tblMeta *source.TableMetadata
 		tblMeta, err = dbase.TableMetadata(ctx, table)
@@ -131,6 +145,15 @@ func execInspect(cmd *cobra.Command, args []string) error {
 		return errz.Wrapf(err, "failed to read %s source metadata", src.Handle)
 	}
 
+	if snapshot {
+		// The snapshot is always JSON, regardless of format flags. The
+		// DB vars are omitted as they're not part of the schema, and
+		// the location is redacted as the snapshot may be shared.
+		meta.DBVars = nil
+		meta.Location = source.RedactLocation(meta.Location)
+		return jsonw.NewMetadataWriter(rc.Out, rc.writers.fmt).SourceMetadata(meta)
+	}
+
 	// This is a bit hacky, but it works... if not "--full", then just zap
 	// the DBVars, as we usually don't want to see those
 	if !cmd.Flags().Changed(flagInspectFull) {
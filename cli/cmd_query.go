@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/cli/config"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+func newQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Manage saved queries (save, ls, rm)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+		Example: `  # Save a SLQ query named "actors"
+  $ sq query save actors '@sakila_pg.actor | .first_name, .last_name'
+
+  # List saved queries
+  $ sq query ls
+
+  # Execute saved query "actors"
+  $ sq run actors
+
+  # Remove saved query "actors"
+  $ sq query rm actors`,
+	}
+
+	return cmd
+}
+
+func newQuerySaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "save NAME QUERY",
+		Short: "Save a named query",
+		Long: `Save a SLQ (or SQL, with flag --sql) query as NAME, to be executed
+later via "sq run NAME".
+
+The query's default source is specified via flag --src. If that flag is
+not set, and the query doesn't itself specify a source (e.g. "@pg1.actor"),
+the active source is recorded as the default source. If an output format
+flag (e.g. --json) is set, that format becomes the query's default format.
+
+The query may contain ${ARG} placeholders, which are substituted with
+values supplied via "sq run NAME --arg ARG=VALUE". In a SLQ query, a
+value is substituted as a number or a string literal. In a SQL query,
+a value is bound as a SQL arg. Either way, don't quote the placeholder.`,
+		Args: cobra.ExactArgs(2),
+		RunE: execQuerySave,
+		Example: `  # Save a SLQ query
+  $ sq query save actors '@sakila_pg.actor | .first_name, .last_name'
+
+  # Save a SQL query against @sakila_pg, with default output CSV
+  $ sq query save --sql --src=@sakila_pg --csv actors 'SELECT * FROM actor'
+
+  # Save a query with an argument
+  $ sq query save actor_by_id '.actor | .actor_id == ${id}'
+  $ sq run actor_by_id --arg id=7`,
+	}
+
+	cmd.Flags().Bool(flagQuerySQL, false, flagQuerySQLUsage)
+	cmd.Flags().StringP(flagActiveSrc, "", "", "Default source for the query")
+	_ = cmd.RegisterFlagCompletionFunc(flagActiveSrc, completeHandle(0))
+
+	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
+	cmd.Flags().BoolP(flagJSONA, flagJSONAShort, false, flagJSONAUsage)
+	cmd.Flags().BoolP(flagJSONL, flagJSONLShort, false, flagJSONLUsage)
+	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagXML, flagXMLShort, false, flagXMLUsage)
+	cmd.Flags().BoolP(flagXLSX, flagXLSXShort, false, flagXLSXUsage)
+	cmd.Flags().BoolP(flagCSV, flagCSVShort, false, flagCSVUsage)
+	cmd.Flags().BoolP(flagTSV, flagTSVShort, false, flagTSVUsage)
+	cmd.Flags().BoolP(flagRaw, flagRawShort, false, flagRawUsage)
+	cmd.Flags().Bool(flagHTML, false, flagHTMLUsage)
+	cmd.Flags().Bool(flagMarkdown, false, flagMarkdownUsage)
+	cmd.Flags().Bool(flagParquet, false, flagParquetUsage)
+	cmd.Flags().Bool(flagArrow, false, flagArrowUsage)
+	cmd.Flags().BoolP(flagYAML, flagYAMLShort, false, flagYAMLUsage)
+
+	return cmd
+}
+
+func execQuerySave(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	cfg := rc.Config
+
+	query := &config.Query{
+		Name:  strings.TrimSpace(args[0]),
+		Query: strings.TrimSpace(args[1]),
+	}
+
+	if query.Query == "" {
+		return errz.New(msgEmptyQueryString)
+	}
+
+	query.SQL, _ = cmd.Flags().GetBool(flagQuerySQL)
+
+	// By passing empty defaults, the format is only set
+	// if a format flag was explicitly provided.
+	query.Format = getFormat(cmd, config.Defaults{})
+
+	switch {
+	case cmdFlagChanged(cmd, flagActiveSrc):
+		handle, _ := cmd.Flags().GetString(flagActiveSrc)
+		src, err := cfg.Sources.Get(handle)
+		if err != nil {
+			return errz.Wrapf(err, "flag --%s", flagActiveSrc)
+		}
+		query.Handle = src.Handle
+	case !query.SQL && strings.HasPrefix(query.Query, "@"):
+		// The SLQ query specifies its own source.
+	default:
+		if activeSrc := cfg.Sources.Active(); activeSrc != nil {
+			query.Handle = activeSrc.Handle
+		}
+	}
+
+	err := cfg.Queries.Add(query)
+	if err != nil {
+		return err
+	}
+
+	err = rc.ConfigStore.Save(cfg)
+	if err != nil {
+		return err
+	}
+
+	return rc.writers.queryw.Queries(config.Queries{query})
+}
+
+func newQueryListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List saved queries",
+		Args:  cobra.ExactArgs(0),
+		RunE:  execQueryList,
+	}
+
+	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
+	cmd.Flags().BoolP(flagTable, flagTableShort, false, flagTableUsage)
+	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
+
+	return cmd
+}
+
+func execQueryList(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	return rc.writers.queryw.Queries(rc.Config.Queries)
+}
+
+func newQueryRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "rm NAME",
+		Short:             "Remove saved query",
+		Example:           `  $ sq query rm actors`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              execQueryRemove,
+		ValidArgsFunction: completeQueryName(1),
+	}
+
+	return cmd
+}
+
+func execQueryRemove(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	cfg := rc.Config
+
+	err := cfg.Queries.Remove(args[0])
+	if err != nil {
+		return err
+	}
+
+	err = rc.ConfigStore.Save(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(rc.Out, "Removed saved query ")
+	_, _ = rc.writers.fmt.Bold.Fprintf(rc.Out, "%s", args[0])
+	fmt.Fprintln(rc.Out)
+
+	return nil
+}
+
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run NAME",
+		Short: "Execute saved query",
+		Long: `Execute the saved query NAME. Use flag --arg (which may be repeated)
+to supply values for the query's ${ARG} placeholders.
+
+The query executes against its default source, unless overridden
+via flag --src. Similarly, the query's default output format can be
+overridden via the usual format flags.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              execRun,
+		ValidArgsFunction: completeQueryName(1),
+		Example: `  # Execute saved query "actors"
+  $ sq run actors
+
+  # Execute saved query "actor_by_id", supplying an arg
+  $ sq run actor_by_id --arg id=7
+
+  # Execute saved query "actors" against a different source, outputting JSON
+  $ sq run actors --src=@sakila_my --json
+
+  # Insert saved query results into a table
+  $ sq run actors --insert=@sakila_sl3.actor2`,
+	}
+
+	addQueryCmdFlags(cmd)
+	cmd.Flags().StringArray(flagQueryArg, nil, flagQueryArgUsage)
+
+	return cmd
+}
+
+func execRun(cmd *cobra.Command, args []string) error {
+	rc := RunContextFrom(cmd.Context())
+	srcs := rc.Config.Sources
+
+	query, err := rc.Config.Queries.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	queryArgs, err := getQueryArgs(cmd)
+	if err != nil {
+		return err
+	}
+
+	// A SLQ query's args are expanded into the query text as literals,
+	// whereas a SQL query's args are bound as SQL args when the query
+	// executes (see bindQueryArgs). In both cases, we check the args now.
+	replaceFn := slqLiteral
+	if query.SQL {
+		replaceFn = func(val string) (string, error) { return val, nil }
+	}
+
+	text, err := expandQueryArgs(query.Query, queryArgs, replaceFn)
+	if err != nil {
+		return errz.Wrapf(err, "saved query %s", query.Name)
+	}
+
+	if query.SQL {
+		text = query.Query
+		rc.queryArgs = queryArgs
+	}
+
+	if query.Format != "" {
+		// The saved query has a default format, which is used
+		// unless a format flag was explicitly set.
+		defaults := rc.Config.Defaults
+		defaults.Format = query.Format
+		rc.writers, rc.Out, rc.ErrOut = newWriters(rc.Log, cmd, defaults, rc.baseOut, rc.baseErrOut)
+	}
+
+	if query.Handle != "" && !cmdFlagChanged(cmd, flagActiveSrc) {
+		// Note that the active source is only changed for
+		// this run: the config is not saved.
+		_, err = srcs.SetActive(query.Handle)
+		if err != nil {
+			return errz.Wrapf(err, "saved query %s", query.Name)
+		}
+	} else {
+		_, err = activeSrcFromFlagsOrConfig(cmd, srcs)
+		if err != nil {
+			return err
+		}
+	}
+
+	rc.Log.Debugf("Run saved query %s: %s", query.Name, text)
+	rc.Args = []string{text}
+
+	if query.SQL {
+		return execSQL(cmd, rc.Args)
+	}
+
+	return execSLQ(cmd, rc.Args)
+}
+
+// getQueryArgs returns the values of the repeatable --arg flag
+// as a map of NAME to VALUE.
+func getQueryArgs(cmd *cobra.Command) (map[string]string, error) {
+	queryArgs := map[string]string{}
+	if !cmdFlagChanged(cmd, flagQueryArg) {
+		return queryArgs, nil
+	}
+
+	vals, err := cmd.Flags().GetStringArray(flagQueryArg)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	for _, val := range vals {
+		parts := strings.SplitN(val, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, errz.Errorf("invalid --%s value %q: must be NAME=VALUE", flagQueryArg, val)
+		}
+
+		queryArgs[name] = parts[1]
+	}
+
+	return queryArgs, nil
+}
+
+var queryArgPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// expandQueryArgs returns query with each ${NAME} placeholder
+// replaced by replaceFn for the corresponding value from queryArgs.
+// It is an error if a placeholder has no value in queryArgs, if
+// queryArgs has a value that does not correspond to a placeholder,
+// or if replaceFn returns an error.
+func expandQueryArgs(query string, queryArgs map[string]string,
+	replaceFn func(val string) (string, error)) (string, error) {
+	var missing []string
+	var replaceErr error
+	used := map[string]bool{}
+
+	expanded := queryArgPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		name := queryArgPattern.FindStringSubmatch(placeholder)[1]
+		val, ok := queryArgs[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+
+		used[name] = true
+		replacement, err := replaceFn(val)
+		if err != nil {
+			if replaceErr == nil {
+				replaceErr = errz.Wrapf(err, "query arg %s", name)
+			}
+			return placeholder
+		}
+		return replacement
+	})
+
+	if replaceErr != nil {
+		return "", replaceErr
+	}
+
+	if len(missing) > 0 {
+		return "", errz.Errorf("no value for query arg(s) %s: use flag --%s", strings.Join(missing, ", "), flagQueryArg)
+	}
+
+	var unused []string
+	for name := range queryArgs {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", errz.Errorf("unknown query arg(s): %s", strings.Join(unused, ", "))
+	}
+
+	return expanded, nil
+}
+
+var numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// slqLiteral returns val as a SLQ literal: a number is returned
+// as is, and anything else is returned as a quoted string, so
+// that val can't alter the structure of the query. Because a SLQ
+// string literal is rendered into the SQL as is, val must not
+// contain a double quote, backslash or control character.
+func slqLiteral(val string) (string, error) {
+	if numberPattern.MatchString(val) {
+		return val, nil
+	}
+
+	for _, r := range val {
+		if r == '"' || r == '\\' || unicode.IsControl(r) {
+			return "", errz.Errorf("value %q: double quote, backslash or control character is not allowed", val)
+		}
+	}
+
+	return `"` + val + `"`, nil
+}
+
+// bindQueryArgs returns query with each ${NAME} placeholder of
+// a saved SQL query replaced by a placeholder of dbase's dialect
+// (e.g. "?" or "$1"), and the corresponding values of rc.queryArgs
+// as args for the query. If rc.queryArgs is nil, query is returned
+// unchanged.
+func bindQueryArgs(rc *RunContext, dbase driver.Database, query string) (string, []interface{}, error) {
+	if rc.queryArgs == nil {
+		return query, nil, nil
+	}
+
+	dialect := dbase.SQLDriver().Dialect()
+	var args []interface{}
+	query, err := expandQueryArgs(query, rc.queryArgs, func(val string) (string, error) {
+		args = append(args, val)
+
+		// The placeholders for n args are like "(?, ?)" or "($1, $2)",
+		// and we want the last one.
+		phs := strings.Split(strings.Trim(dialect.Placeholders(len(args), 1), "()"), ",")
+		return strings.TrimSpace(phs[len(phs)-1]), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query, args, nil
+}
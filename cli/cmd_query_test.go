@@ -0,0 +1,125 @@
+package cli_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/cli/config"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+func TestCmdQuery(t *testing.T) {
+	t.Parallel()
+
+	src := testh.New(t).Source(sakila.CSVActor)
+
+	ru := newRun(t).add(*src)
+	err := ru.exec("query", "save", "--csv", "actors", ".data")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ru.rc.Config.Queries))
+	query := ru.rc.Config.Queries[0]
+	require.Equal(t, "actors", query.Name)
+	require.Equal(t, ".data", query.Query)
+	require.Equal(t, src.Handle, query.Handle, "active src should be the default src")
+	require.Equal(t, config.FormatCSV, query.Format)
+
+	// Should fail because the name already exists
+	queries := ru.rc.Config.Queries
+	ru = newRun(t).add(*src)
+	ru.rc.Config.Queries = queries
+	require.Error(t, ru.exec("query", "save", "actors", ".data"))
+
+	// Should fail because of illegal name
+	ru = newRun(t).add(*src)
+	require.Error(t, ru.exec("query", "save", "1actors", ".data"))
+
+	ru = newRun(t).add(*src)
+	ru.rc.Config.Queries = queries
+	require.NoError(t, ru.exec("run", "--header=false", "actors"))
+	recs := ru.mustReadCSV()
+	require.Equal(t, sakila.TblActorCount, len(recs), "saved query format should be CSV")
+
+	ru = newRun(t).add(*src)
+	ru.rc.Config.Queries = queries
+	require.NoError(t, ru.exec("query", "rm", "actors"))
+	require.Empty(t, ru.rc.Config.Queries)
+
+	ru = newRun(t).add(*src)
+	require.Error(t, ru.exec("query", "rm", "actors"))
+}
+
+func TestCmdRun_Args(t *testing.T) {
+	t.Parallel()
+
+	src := testh.New(t).Source(sakila.CSVActor)
+	query := &config.Query{
+		Name:   "actor_by_id",
+		Query:  fmt.Sprintf("%s.data | .actor_id == ${id}", src.Handle),
+		Format: config.FormatCSV,
+	}
+
+	ru := newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.NoError(t, ru.exec("run", "--header=false", "actor_by_id", "--arg", "id=7"))
+	recs := ru.mustReadCSV()
+	require.Equal(t, 1, len(recs))
+	require.Equal(t, "7", recs[0][0])
+
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.Error(t, ru.exec("run", "actor_by_id"), "missing arg")
+
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.Error(t, ru.exec("run", "actor_by_id", "--arg", "id=7", "--arg", "nope=1"), "unknown arg")
+}
+
+func TestCmdRun_ArgsLiteral(t *testing.T) {
+	t.Parallel()
+
+	src := testh.New(t).Source(sakila.CSVActor)
+	query := &config.Query{
+		Name:   "actor_by_name",
+		Query:  fmt.Sprintf("%s.data | .first_name == ${name}", src.Handle),
+		Format: config.FormatCSV,
+	}
+
+	ru := newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.NoError(t, ru.exec("run", "--header=false", "actor_by_name", "--arg", "name=PENELOPE"))
+	recs := ru.mustReadCSV()
+	require.Equal(t, 4, len(recs))
+
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.Error(t, ru.exec("run", "actor_by_name", "--arg", `name=X" OR 1=1 OR "`), "quote in SLQ arg")
+}
+
+func TestCmdRun_ArgsSQL(t *testing.T) {
+	t.Parallel()
+
+	src := testh.New(t).Source(sakila.CSVActor)
+	query := &config.Query{
+		Name:   "actor_by_name",
+		Query:  "SELECT actor_id FROM data WHERE first_name = ${name}",
+		Handle: src.Handle,
+		SQL:    true,
+		Format: config.FormatCSV,
+	}
+
+	ru := newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.NoError(t, ru.exec("run", "--header=false", "actor_by_name", "--arg", "name=PENELOPE"))
+	recs := ru.mustReadCSV()
+	require.Equal(t, 4, len(recs))
+
+	// The value is bound as a SQL arg, so it can't alter the query.
+	ru = newRun(t).add(*src)
+	require.NoError(t, ru.rc.Config.Queries.Add(query))
+	require.NoError(t, ru.exec("run", "--header=false", "actor_by_name", "--arg", "name=X' OR 'a'='a"))
+	recs = ru.mustReadCSV()
+	require.Empty(t, recs)
+}
@@ -68,6 +68,9 @@ More at https://sq.io
   # insert query results into a table in another data source
   $ sq --insert=@pg1.person '@my1.person | .username, .email'
 
+  # insert query results, updating existing rows with the same uid
+  $ sq --insert=@pg1.person --insert-mode=upsert --key=uid '@my1.person'
+
   # execute a database-native SQL query, specifying the source
   $ sq sql --src=@pg1 'SELECT uid, username, email FROM person LIMIT 2'
 
@@ -85,6 +88,7 @@ More at https://sq.io
 	addQueryCmdFlags(cmd)
 	cmd.Flags().Bool(flagVersion, false, flagVersionUsage)
 	cmd.PersistentFlags().BoolP(flagMonochrome, flagMonochromeShort, false, flagMonochromeUsage)
+	cmd.PersistentFlags().Bool(flagNoCache, false, flagNoCacheUsage)
 
 	return cmd
 }
@@ -7,11 +7,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/neilotoole/sq/cli/config"
 	"github.com/neilotoole/sq/cli/output"
 	"github.com/neilotoole/sq/libsq"
 	"github.com/neilotoole/sq/libsq/core/errz"
-	"github.com/neilotoole/sq/libsq/core/stringz"
-	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/source"
 )
 
@@ -66,10 +65,21 @@ func execSLQ(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if !cmdFlagChanged(cmd, flagInsert) {
+	insertOpts, err := getInsertOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, rejectsDone, err := withRejects(cmd.Context(), cmd, rc)
+	if err != nil {
+		return err
+	}
+	defer func() { rc.Log.WarnIfError(rejectsDone()) }()
+
+	if insertOpts == nil {
 		// The user didn't specify the --insert=@src.tbl flag,
 		// so we just want to print the records.
-		return execSLQPrint(cmd.Context(), rc)
+		return execSLQPrint(ctx, rc)
 	}
 
 	// Instead of printing the records, they will be
@@ -89,12 +99,12 @@ func execSLQ(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return execSLQInsert(cmd.Context(), rc, destSrc, destTbl)
+	return execSLQInsert(ctx, rc, destSrc, destTbl, insertOpts)
 }
 
 // execSQLInsert executes the SLQ and inserts resulting records
-// into destTbl in destSrc.
-func execSLQInsert(ctx context.Context, rc *RunContext, destSrc *source.Source, destTbl string) error {
+// into destTbl in destSrc, per insertOpts.
+func execSLQInsert(ctx context.Context, rc *RunContext, destSrc *source.Source, destTbl string, insertOpts *insertOptions) error {
 	args, srcs, dbases := rc.Args, rc.Config.Sources, rc.databases
 	slq, err := preprocessUserSLQ(ctx, rc, args)
 	if err != nil {
@@ -114,29 +124,27 @@ func execSLQInsert(ctx context.Context, rc *RunContext, destSrc *source.Source,
 	// is invoked by rc.Close, and rc is closed further up the
 	// stack.
 
-	inserter := libsq.NewDBWriter(
-		rc.Log,
-		destDB,
-		destTbl,
-		driver.Tuning.RecordChSize,
-		libsq.DBWriterCreateTableIfNotExistsHook(destTbl),
-	)
+	inserter, err := newInsertWriter(ctx, rc, destDB, destTbl, insertOpts)
+	if err != nil {
+		return err
+	}
+
 	execErr := libsq.ExecuteSLQ(ctx, rc.Log, rc.databases, rc.databases, srcs, slq, inserter)
-	affected, waitErr := inserter.Wait() // Wait for the writer to finish processing
 	if execErr != nil {
+		_, _ = inserter.Wait() // Wait for the writer to finish processing
 		return errz.Wrapf(execErr, "insert %s.%s failed", destSrc.Handle, destTbl)
 	}
 
-	if waitErr != nil {
-		return errz.Wrapf(waitErr, "insert %s.%s failed", destSrc.Handle, destTbl)
-	}
-
-	fmt.Fprintf(rc.Out, stringz.Plu("Inserted %d row(s) into %s.%s\n", int(affected)), affected, destSrc.Handle, destTbl)
-	return nil
+	return waitInsert(rc, inserter, destSrc.Handle, destTbl)
 }
 
 // execSLQPrint executes the SLQ query, and prints output to writer.
 func execSLQPrint(ctx context.Context, rc *RunContext) error {
+	err := checkBinaryOutput(rc)
+	if err != nil {
+		return err
+	}
+
 	slq, err := preprocessUserSLQ(ctx, rc, rc.Args)
 	if err != nil {
 		return err
@@ -152,6 +160,43 @@ func execSLQPrint(ctx context.Context, rc *RunContext) error {
 	return waitErr
 }
 
+// checkBinaryOutput returns an error if the output format is
+// binary (e.g. Parquet), but flag --output is not set: binary
+// output is not written to the terminal. Likewise, compressed
+// output (flag --compress) and XLSX output are refused if the
+// output is a terminal, but may be redirected. Note that rc.writers
+// is nil if checkBinaryOutput is invoked before the writers are built.
+func checkBinaryOutput(rc *RunContext) error {
+	if cmdFlagChanged(rc.Cmd, flagOutput) {
+		return nil
+	}
+
+	if rc.writers != nil && rc.writers.format.IsBinary() {
+		return errz.Errorf("%s output requires flag --%s", rc.writers.format, flagOutput)
+	}
+
+	if !rc.outTerminal {
+		return nil
+	}
+
+	compression, err := outputCompression(rc.Cmd, "")
+	if err != nil {
+		return err
+	}
+
+	if compression != "" {
+		return errz.Errorf("compressed output is not written to the terminal: use flag --%s, or redirect stdout",
+			flagOutput)
+	}
+
+	if rc.writers != nil && rc.writers.format == config.FormatXLSX {
+		return errz.Errorf("%s output is not written to the terminal: use flag --%s, or redirect stdout",
+			rc.writers.format, flagOutput)
+	}
+
+	return nil
+}
+
 // preprocessUserSLQ does a bit of validation and munging on the
 // SLQ input (provided in args), returning the SLQ query. This
 // function is something of a hangover from the early days of
@@ -286,6 +331,7 @@ func preprocessUserSLQ(ctx context.Context, rc *RunContext, args []string) (stri
 // addQueryCmdFlags sets the common flags for the slq/sql commands.
 func addQueryCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(flagOutput, flagOutputShort, "", flagOutputUsage)
+	cmd.Flags().String(flagCompress, "", flagCompressUsage)
 
 	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
 	cmd.Flags().BoolP(flagJSONA, flagJSONAShort, false, flagJSONAUsage)
@@ -298,12 +344,15 @@ func addQueryCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolP(flagRaw, flagRawShort, false, flagRawUsage)
 	cmd.Flags().Bool(flagHTML, false, flagHTMLUsage)
 	cmd.Flags().Bool(flagMarkdown, false, flagMarkdownUsage)
+	cmd.Flags().Bool(flagParquet, false, flagParquetUsage)
+	cmd.Flags().Bool(flagArrow, false, flagArrowUsage)
+	cmd.Flags().BoolP(flagYAML, flagYAMLShort, false, flagYAMLUsage)
 
 	cmd.Flags().BoolP(flagHeader, flagHeaderShort, false, flagHeaderUsage)
 	cmd.Flags().BoolP(flagPretty, "", true, flagPrettyUsage)
 
-	cmd.Flags().StringP(flagInsert, "", "", flagInsertUsage)
-	_ = cmd.RegisterFlagCompletionFunc(flagInsert, (&handleTableCompleter{onlySQL: true, handleRequired: true}).complete)
+	addInsertFlags(cmd)
+	addRejectsFlags(cmd)
 
 	cmd.Flags().StringP(flagActiveSrc, "", "", flagActiveSrcUsage)
 	_ = cmd.RegisterFlagCompletionFunc(flagActiveSrc, completeHandle(0))
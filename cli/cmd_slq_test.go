@@ -1,17 +1,24 @@
 package cli_test
 
 import (
+	"compress/gzip"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/neilotoole/sq/drivers/parquet"
 	"github.com/neilotoole/sq/libsq/core/stringz"
 	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh"
@@ -126,6 +133,103 @@ func TestCmdSLQ_OutputFlag(t *testing.T) {
 	require.Equal(t, sakila.TblActorCount, len(recs))
 }
 
+// TestCmdSLQ_OutputCompress verifies that output is compressed
+// per flag --compress, or per the extension of flag --output.
+func TestCmdSLQ_OutputCompress(t *testing.T) {
+	t.Parallel()
+
+	newGzipReader := func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	newZstdReader := func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }
+	newBzip2Reader := func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r, nil) }
+
+	testCases := []struct {
+		fname     string
+		compress  string
+		newReader func(r io.Reader) (io.Reader, error)
+	}{
+		{fname: "actor.csv.gz", newReader: newGzipReader},
+		{fname: "actor.csv.zst", newReader: newZstdReader},
+		{fname: "actor.csv.bz2", newReader: newBzip2Reader},
+		{fname: "actor.csv", compress: "gzip", newReader: newGzipReader},
+		{fname: "actor.csv.gz", compress: "zstd", newReader: newZstdReader},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.fname+"_"+tc.compress, func(t *testing.T) {
+			t.Parallel()
+
+			src := testh.New(t).Source(sakila.CSVActor)
+			outputPath := filepath.Join(t.TempDir(), tc.fname)
+
+			args := []string{"slq", "--header=false", "--csv", src.Handle + ".data", "--output", outputPath}
+			if tc.compress != "" {
+				args = append(args, "--compress="+tc.compress)
+			}
+
+			ru := newRun(t).add(*src)
+			require.NoError(t, ru.exec(args...))
+
+			f, err := os.Open(outputPath)
+			require.NoError(t, err)
+			t.Cleanup(func() { assert.NoError(t, f.Close()) })
+
+			r, err := tc.newReader(f)
+			require.NoError(t, err)
+
+			recs, err := csv.NewReader(r).ReadAll()
+			require.NoError(t, err)
+			require.Equal(t, sakila.TblActorCount, len(recs))
+		})
+	}
+
+	src := testh.New(t).Source(sakila.CSVActor)
+	ru := newRun(t).add(*src)
+	require.Error(t, ru.exec("slq", "--compress=not_a_format", src.Handle+".data"))
+}
+
+// TestCmdSLQ_OutputBinary tests the binary output
+// formats, --parquet and --arrow.
+func TestCmdSLQ_OutputBinary(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+
+	// Binary output is not written to the terminal.
+	require.Error(t, newRun(t).add(*src).exec("slq", "--parquet", src.Handle+".data"))
+	require.Error(t, newRun(t).add(*src).exec("slq", "--arrow", src.Handle+".data"))
+
+	fpath := filepath.Join(t.TempDir(), "actor.parquet")
+	require.NoError(t, newRun(t).add(*src).exec("slq", "--parquet", src.Handle+".data", "--output", fpath))
+
+	sink, err := th.QuerySQL(&source.Source{Handle: "@actor_parquet", Type: parquet.Type, Location: fpath}, "SELECT * FROM data")
+	require.NoError(t, err)
+	require.Equal(t, sakila.TblActorCols(), sink.RecMeta.Names())
+	require.Equal(t, sakila.TblActorCount, len(sink.Recs))
+
+	fpath = filepath.Join(t.TempDir(), "actor.arrow")
+	require.NoError(t, newRun(t).add(*src).exec("slq", "--arrow", src.Handle+".data", "--output", fpath))
+
+	f, err := os.Open(fpath)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, f.Close()) })
+
+	r, err := ipc.NewFileReader(f)
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, sakila.TblActorCols()[0], r.Schema().Field(0).Name)
+	var count int64
+	for i := 0; i < r.NumRecords(); i++ {
+		rec, err := r.Record(i)
+		require.NoError(t, err)
+		count += rec.NumRows()
+	}
+	require.Equal(t, int64(sakila.TblActorCount), count)
+}
+
 func TestCmdSLQ_Join(t *testing.T) {
 	const queryTpl = `%s.customer, %s.address | join(.address_id) | .customer_id == %d | .[0] | .customer_id, .email, .city_id`
 	handles := sakila.SQLAll()
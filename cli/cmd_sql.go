@@ -7,13 +7,13 @@ import (
 
 	"github.com/neilotoole/sq/libsq"
 	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
 	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/source"
 
 	"github.com/spf13/cobra"
 
 	"github.com/neilotoole/sq/cli/output"
-	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
 func newSQLCmd() *cobra.Command {
@@ -27,7 +27,22 @@ source.
 If flag --query is set, sq will run the input as a query
 (SELECT) and return the query rows. If flag --exec is set,
 sq will execute the input and return the result. If neither
-flag is set, sq attempts to determine the appropriate mode.`,
+flag is set, sq attempts to determine the appropriate mode.
+
+If the output format supports multiple result sets (--xlsx), and the
+input has multiple statements separated by semicolons, the rows of each
+query are written to a separate result set (e.g. a sheet of the XLSX
+workbook).
+
+If flag --insert=@HANDLE.TABLE is set, the query results are inserted
+into TABLE. Flag --insert-mode determines what happens if TABLE already
+exists: "append" (the default) appends the rows, "truncate" truncates
+the table first, and "replace" drops and recreates the table. Mode
+"upsert" updates the existing row that has the same values of the key
+columns (flag --key, defaulting to the table's primary key), and
+inserts the other rows. Where supported, the database's native upsert
+statement is used (which may require a unique constraint on the key
+columns). The number of inserted and updated rows is reported.`,
 		RunE: execSQL,
 		Example: `  # Select from active source
   $ sq sql 'SELECT * FROM actor'
@@ -39,7 +54,13 @@ flag is set, sq attempts to determine the appropriate mode.`,
   $ sq sql --exec --src=@sakila_pg12 'DROP TABLE actor'
 
   # Select from active source and write results to @sakila_ms17.actor
-  $ sq sql 'SELECT * FROM actor' --insert=@sakila_ms17.actor`,
+  $ sq sql 'SELECT * FROM actor' --insert=@sakila_ms17.actor
+
+  # As above, but first truncate @sakila_ms17.actor
+  $ sq sql 'SELECT * FROM actor' --insert=@sakila_ms17.actor --insert-mode=truncate
+
+  # Upsert into @sakila_ms17.actor: rows with an existing actor_id are updated
+  $ sq sql 'SELECT * FROM actor' --insert=@sakila_ms17.actor --insert-mode=upsert --key=actor_id`,
 	}
 
 	addQueryCmdFlags(cmd)
@@ -76,10 +97,21 @@ func execSQL(cmd *cobra.Command, args []string) error {
 	// determineSources successfully returns.
 	activeSrc := srcs.Active()
 
-	if !cmdFlagChanged(cmd, flagInsert) {
+	insertOpts, err := getInsertOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx, rejectsDone, err := withRejects(cmd.Context(), cmd, rc)
+	if err != nil {
+		return err
+	}
+	defer func() { rc.Log.WarnIfError(rejectsDone()) }()
+
+	if insertOpts == nil {
 		// The user didn't specify the --insert=@src.tbl flag,
 		// so we just want to print the records.
-		return execSQLPrint(cmd.Context(), rc, activeSrc)
+		return execSQLPrint(ctx, rc, activeSrc)
 	}
 
 	// Instead of printing the records, they will be
@@ -99,20 +131,36 @@ func execSQL(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	return execSQLInsert(cmd.Context(), rc, activeSrc, destSrc, destTbl)
+	return execSQLInsert(ctx, rc, activeSrc, destSrc, destTbl, insertOpts)
 }
 
 // execSQLPrint executes the SQL and prints resulting records
 // to the configured writer.
 func execSQLPrint(ctx context.Context, rc *RunContext, fromSrc *source.Source) error {
+	err := checkBinaryOutput(rc)
+	if err != nil {
+		return err
+	}
+
 	args := rc.Args
 	dbase, err := rc.databases.Open(ctx, fromSrc)
 	if err != nil {
 		return err
 	}
 
+	if rc.writers.multiw != nil {
+		if stmts := sqlmodel.SplitStmts(args[0]); len(stmts) > 1 {
+			return execSQLPrintMulti(ctx, rc, dbase, stmts)
+		}
+	}
+
+	query, qargs, err := bindQueryArgs(rc, dbase, args[0])
+	if err != nil {
+		return err
+	}
+
 	recw := output.NewRecordWriterAdapter(rc.writers.recordw)
-	err = libsq.QuerySQL(ctx, rc.Log, dbase, recw, args[0])
+	err = libsq.QuerySQL(ctx, rc.Log, dbase, recw, query, qargs...)
 	if err != nil {
 		return err
 	}
@@ -120,9 +168,71 @@ func execSQLPrint(ctx context.Context, rc *RunContext, fromSrc *source.Source) e
 	return err
 }
 
+// execSQLPrintMulti executes each of stmts, writing the records
+// of each query to a separate result set of rc.writers.multiw (e.g.
+// a separate sheet of an XLSX workbook). The result sets are named
+// "result1", "result2", etc. A statement that doesn't return rows
+// (e.g. "DELETE FROM actor") is executed, but has no result set: this
+// is determined from the statement's result columns, not its text.
+func execSQLPrintMulti(ctx context.Context, rc *RunContext, dbase driver.Database, stmts []string) error {
+	multiw := rc.writers.multiw
+	var n int
+	for i, stmt := range stmts {
+		query, qargs, err := bindQueryArgs(rc, dbase, stmt)
+		if err != nil {
+			return err
+		}
+
+		rows, err := dbase.DB().QueryContext(ctx, query, qargs...)
+		if err != nil {
+			return errz.Wrapf(err, "statement %d", i+1)
+		}
+
+		cols, err := rows.Columns()
+		if err != nil {
+			rc.Log.WarnIfCloseError(rows)
+			return errz.Wrapf(err, "statement %d", i+1)
+		}
+
+		if len(cols) == 0 {
+			// The statement doesn't return rows. Note that some
+			// drivers (e.g. sqlite) only execute the statement
+			// when rows.Next is invoked.
+			for rows.Next() {
+			}
+			err = errz.Combine(rows.Err(), rows.Close())
+			if err != nil {
+				return errz.Wrapf(err, "statement %d", i+1)
+			}
+			continue
+		}
+
+		n++
+		recw, err := multiw.NewRecordWriter(fmt.Sprintf("result%d", n))
+		if err != nil {
+			rc.Log.WarnIfCloseError(rows)
+			return err
+		}
+
+		// The adapter closes recw when the query completes.
+		adapter := output.NewRecordWriterAdapter(recw)
+		err = libsq.QuerySQLRows(ctx, rc.Log, dbase, adapter, rows)
+		if err != nil {
+			return errz.Wrapf(err, "statement %d", i+1)
+		}
+
+		_, err = adapter.Wait()
+		if err != nil {
+			return errz.Wrapf(err, "statement %d", i+1)
+		}
+	}
+
+	return multiw.Close()
+}
+
 // execSQLInsert executes the SQL and inserts resulting records
-// into destTbl in destSrc.
-func execSQLInsert(ctx context.Context, rc *RunContext, fromSrc, destSrc *source.Source, destTbl string) error {
+// into destTbl in destSrc, per insertOpts.
+func execSQLInsert(ctx context.Context, rc *RunContext, fromSrc, destSrc *source.Source, destTbl string, insertOpts *insertOptions) error {
 	args := rc.Args
 	dbases := rc.databases
 	ctx, cancelFn := context.WithCancel(ctx)
@@ -143,25 +253,20 @@ func execSQLInsert(ctx context.Context, rc *RunContext, fromSrc, destSrc *source
 	// is invoked by rc.Close, and rc is closed further up the
 	// stack.
 
-	inserter := libsq.NewDBWriter(
-		rc.Log,
-		destDB,
-		destTbl,
-		driver.Tuning.RecordChSize,
-		libsq.DBWriterCreateTableIfNotExistsHook(destTbl),
-	)
-	err = libsq.QuerySQL(ctx, rc.Log, fromDB, inserter, args[0])
+	query, qargs, err := bindQueryArgs(rc, fromDB, args[0])
 	if err != nil {
-		return errz.Wrapf(err, "insert %s.%s failed", destSrc.Handle, destTbl)
+		return err
 	}
 
-	affected, err := inserter.Wait() // Wait for the writer to finish processing
+	inserter, err := newInsertWriter(ctx, rc, destDB, destTbl, insertOpts)
 	if err != nil {
-		return errz.Wrapf(err, "insert %s.%s failed", destSrc.Handle, destTbl)
+		return err
 	}
 
-	rc.Log.Debugf("Rows affected: %d", affected)
+	err = libsq.QuerySQL(ctx, rc.Log, fromDB, inserter, query, qargs...)
+	if err != nil {
+		return errz.Wrapf(err, "insert %s.%s failed", destSrc.Handle, destTbl)
+	}
 
-	fmt.Fprintf(rc.Out, stringz.Plu("Inserted %d row(s) into %s.%s\n", int(affected)), affected, destSrc.Handle, destTbl)
-	return nil
+	return waitInsert(rc, inserter, destSrc.Handle, destTbl)
 }
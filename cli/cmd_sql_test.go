@@ -3,11 +3,13 @@ package cli_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/neilotoole/sq/drivers/sqlite3"
 	"github.com/neilotoole/sq/drivers/userdriver"
 	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh"
@@ -62,6 +64,136 @@ func TestCmdSQL_Insert(t *testing.T) {
 	}
 }
 
+// TestCmdSQL_InsertMode tests "sq sql QUERY --insert=dest.tbl --insert-mode=MODE".
+func TestCmdSQL_InsertMode(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+	destSrc := &source.Source{
+		Handle:   "@dest",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(t.TempDir(), "dest.db"),
+	}
+
+	const insertTo = "--insert=@dest.actor"
+	const query = "SELECT * FROM data"
+
+	ru := newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, query))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor"))
+
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--insert-mode=append", query))
+	require.Equal(t, int64(sakila.TblActorCount*2), th.RowCount(destSrc, "actor"))
+
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--insert-mode=truncate", query))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor"))
+
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--insert-mode=replace", "SELECT actor_id, first_name FROM data"))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor"))
+
+	// The insert fails because the table has no last_name column, and
+	// the table is truncated in the same tx, so its rows are retained.
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--insert-mode=truncate", "SELECT actor_id, first_name, last_name FROM data"))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor"))
+
+	// The table has no primary key, so --key is required
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--insert-mode=upsert", query))
+
+	// --key is only valid with upsert
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--insert-mode=append", "--key=actor_id", query))
+
+	// --insert-mode is only valid with --insert
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", "--insert-mode=truncate", query))
+
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--insert-mode=not_a_mode", query))
+
+	// Update 10 existing rows, and insert 5 new rows.
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--insert-mode=upsert", "--key=actor_id",
+		"SELECT actor_id, 'X' AS first_name FROM data WHERE actor_id <= 10"))
+	require.Equal(t, "Inserted 0 rows, updated 10 rows in @dest.actor\n", ru.out.String())
+
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--insert-mode=upsert", "--key=actor_id",
+		"SELECT actor_id + 1000 AS actor_id, first_name FROM data WHERE actor_id <= 5"))
+	require.Equal(t, "Inserted 5 rows, updated 0 rows in @dest.actor\n", ru.out.String())
+	require.Equal(t, int64(sakila.TblActorCount+5), th.RowCount(destSrc, "actor"))
+
+	sink, err := th.QuerySQL(destSrc, "SELECT * FROM actor WHERE first_name = 'X'")
+	require.NoError(t, err)
+	require.Equal(t, 10, len(sink.Recs))
+
+	// Upsert to a new table, which is created with a unique
+	// constraint on the key.
+	const upsertTo = "--insert=@dest.actor_new"
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", upsertTo, "--insert-mode=upsert", "--key=actor_id", query))
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", upsertTo, "--insert-mode=upsert", "--key=actor_id", query))
+	require.Equal(t, fmt.Sprintf("Inserted 0 rows, updated %d rows in @dest.actor_new\n", sakila.TblActorCount), ru.out.String())
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", upsertTo, query), "unique constraint violated")
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor_new"))
+}
+
+// TestCmdSQL_InsertRejects tests "sq sql QUERY --insert=dest.tbl"
+// with --max-errors and --rejects.
+func TestCmdSQL_InsertRejects(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+	destSrc := &source.Source{
+		Handle:   "@dest",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(t.TempDir(), "dest.db"),
+	}
+
+	// The first 3 rows already exist, and thus will fail to insert.
+	th.ExecSQL(destSrc, "CREATE TABLE actor (actor_id INTEGER PRIMARY KEY, first_name TEXT)")
+	th.ExecSQL(destSrc, "INSERT INTO actor (actor_id, first_name) VALUES (1, 'A'), (2, 'B'), (3, 'C')")
+
+	const insertTo = "--insert=@dest.actor"
+	const query = "SELECT actor_id, first_name FROM data"
+
+	// Without --max-errors, the first error is fatal.
+	ru := newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, query))
+	require.Equal(t, int64(3), th.RowCount(destSrc, "actor"))
+
+	// The error budget is too small.
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--max-errors=2", query))
+	require.Equal(t, int64(3), th.RowCount(destSrc, "actor"))
+
+	rejectsPath := filepath.Join(t.TempDir(), "rejects.jsonl")
+	ru = newRun(t).add(*src, *destSrc)
+	require.NoError(t, ru.exec("sql", insertTo, "--max-errors=3", "--rejects="+rejectsPath, query))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, "actor"))
+	require.Equal(t, fmt.Sprintf("Inserted %d rows into @dest.actor\n", sakila.TblActorCount-3), ru.out.String())
+	require.Contains(t, ru.errOut.String(), "Rejected 3 rows")
+
+	data, err := os.ReadFile(rejectsPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, 3, len(lines))
+	require.Contains(t, lines[0], `"table":"actor","row":1,`)
+	require.Contains(t, lines[2], `"table":"actor","row":3,`)
+
+	// --max-errors must not be negative
+	ru = newRun(t).add(*src, *destSrc)
+	require.Error(t, ru.exec("sql", insertTo, "--max-errors=-1", query))
+}
+
 func TestCmdSQL_SelectFromUserDriver(t *testing.T) {
 	testCases := map[string][]struct {
 		tblName  string
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/neilotoole/sq/libsq"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/stringz"
 	"github.com/neilotoole/sq/libsq/driver"
@@ -33,9 +36,20 @@ func newTblCmd() *cobra.Command {
 
 func newTblCopyCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:               "copy @HANDLE.TABLE NEWTABLE",
-		Short:             "Make a copy of a table",
-		Long:              `Make a copy of a table in the same database. The table data is also copied by default.`,
+		Use:   "copy @HANDLE.TABLE [@HANDLE.]NEWTABLE",
+		Short: "Make a copy of a table",
+		Long: `Make a copy of a table, in the same source or in another source. The
+table data is also copied by default.
+
+When copying to another source (which may be of a different type), the
+destination table is created with column types translated to the
+destination's native types, and the data is streamed across. Use --where
+to copy only the rows matching a SLQ filter. If the destination table
+already exists, the behavior is determined by --if-exists:
+
+  fail     return an error (default)
+  replace  drop and recreate the table
+  append   insert the rows into the existing table`,
 		ValidArgsFunction: completeTblCopy,
 		RunE:              execTblCopy,
 		Example: `  # Copy table "actor" in @sakila_sl3 to new table "actor2"
@@ -49,17 +63,36 @@ func newTblCopyCmd() *cobra.Command {
 
   # Copy table structure, but don't copy table data
   $ sq tbl copy --data=false .actor
+
+  # Copy table "actor" in @sakila_pg to table "actor_backup" in @sakila_sl3
+  $ sq tbl copy @sakila_pg.actor @sakila_sl3.actor_backup
+
+  # Copy only some rows, replacing the destination table if it exists
+  $ sq tbl copy --if-exists=replace --where='.actor_id > 100' @sakila_pg.actor @sakila_sl3.actor_backup
 `,
 	}
 
 	cmd.Flags().BoolP(flagJSON, flagJSONShort, false, flagJSONUsage)
 	cmd.Flags().Bool(flagTblData, true, flagTblDataUsage)
+	cmd.Flags().String(flagTblIfExists, tblIfExistsFail, flagTblIfExistsUsage)
+	_ = cmd.RegisterFlagCompletionFunc(flagTblIfExists, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{tblIfExistsFail, tblIfExistsReplace, tblIfExistsAppend}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.Flags().String(flagTblWhere, "", flagTblWhereUsage)
 
 	return cmd
 }
 
+// Values for flag --if-exists.
+const (
+	tblIfExistsFail    = "fail"
+	tblIfExistsReplace = "replace"
+	tblIfExistsAppend  = "append"
+)
+
 func execTblCopy(cmd *cobra.Command, args []string) error {
-	rc := RunContextFrom(cmd.Context())
+	ctx := cmd.Context()
+	rc := RunContextFrom(ctx)
 	if len(args) == 0 || len(args) > 2 {
 		return errz.New("one or two table args required")
 	}
@@ -70,7 +103,7 @@ func execTblCopy(cmd *cobra.Command, args []string) error {
 	}
 
 	if tblHandles[0].tbl == "" {
-		return errz.Errorf("arg %q does not specify a table name")
+		return errz.Errorf("arg %q does not specify a table name", args[0])
 	}
 
 	switch len(tblHandles) {
@@ -87,19 +120,15 @@ func execTblCopy(cmd *cobra.Command, args []string) error {
 		return errz.New("one or two table args required")
 	}
 
-	if tblHandles[0].src.Handle != tblHandles[1].src.Handle {
-		return errz.Errorf("tbl copy only works on the same source, but got %s.%s --> %s.%s",
-			tblHandles[0].handle, tblHandles[0].tbl,
-			tblHandles[1].handle, tblHandles[1].tbl)
-	}
+	from, dest := tblHandles[0], tblHandles[1]
+	sameSrc := from.src.Handle == dest.src.Handle
 
-	if tblHandles[0].tbl == tblHandles[1].tbl {
-		return errz.Errorf("cannot copy table %s.%s to itself", tblHandles[0].handle, tblHandles[0].tbl)
+	if sameSrc && from.tbl == dest.tbl {
+		return errz.Errorf("cannot copy table %s.%s to itself", from.handle, from.tbl)
 	}
 
-	sqlDrvr, ok := tblHandles[0].drvr.(driver.SQLDriver)
-	if !ok {
-		return errz.Errorf("source type %q (%s) doesn't support dropping tables", tblHandles[0].src.Type, tblHandles[0].src.Handle)
+	if !dest.drvr.DriverMetadata().IsSQL {
+		return errz.Errorf("source type %q (%s) doesn't support creating tables", dest.src.Type, dest.src.Handle)
 	}
 
 	copyData := true // copy data by default
@@ -110,22 +139,64 @@ func execTblCopy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	var dbase driver.Database
-	dbase, err = rc.databases.Open(cmd.Context(), tblHandles[0].src)
+	ifExists, _ := cmd.Flags().GetString(flagTblIfExists)
+	switch ifExists {
+	case tblIfExistsFail, tblIfExistsReplace, tblIfExistsAppend:
+	default:
+		return errz.Errorf("invalid --%s value %q: must be one of: %s, %s, %s",
+			flagTblIfExists, ifExists, tblIfExistsFail, tblIfExistsReplace, tblIfExistsAppend)
+	}
+
+	where, _ := cmd.Flags().GetString(flagTblWhere)
+	where = strings.TrimSpace(where)
+
+	destDB, err := rc.databases.Open(ctx, dest.src)
 	if err != nil {
 		return err
 	}
 
-	copied, err := sqlDrvr.CopyTable(cmd.Context(), dbase.DB(), tblHandles[0].tbl, tblHandles[1].tbl, copyData)
+	destSQLDrvr := destDB.SQLDriver()
+	exists, err := destSQLDrvr.TableExists(ctx, destDB.DB(), dest.tbl)
 	if err != nil {
-		return errz.Wrapf(err, "failed tbl copy %s.%s --> %s.%s",
-			tblHandles[0].handle, tblHandles[0].tbl,
-			tblHandles[1].handle, tblHandles[1].tbl)
+		return err
 	}
 
-	msg := fmt.Sprintf("Copied table: %s.%s --> %s.%s",
-		tblHandles[0].handle, tblHandles[0].tbl,
-		tblHandles[1].handle, tblHandles[1].tbl)
+	appending := false
+	copyDest := dest
+	if exists {
+		switch ifExists {
+		case tblIfExistsFail:
+			return errz.Errorf("table %s.%s already exists: see flag --%s", dest.handle, dest.tbl, flagTblIfExists)
+		case tblIfExistsReplace:
+			// We copy to a temp table, which replaces the dest table
+			// only if the copy succeeds: if the copy fails, the dest
+			// table is left untouched.
+			copyDest.tbl = stringz.UniqTableName(dest.tbl + "_tmp")
+		case tblIfExistsAppend:
+			appending = true
+		}
+	}
+
+	var copied int64
+	if sameSrc && where == "" && !appending {
+		// The copy can be performed natively by the database.
+		copied, err = execTblCopyNative(cmd, rc, from, copyDest, copyData)
+	} else {
+		copied, err = execTblCopyStream(cmd, rc, from, copyDest, copyData, !appending, where)
+	}
+	if err != nil {
+		return errz.Wrapf(err, "failed tbl copy %s.%s --> %s.%s", from.handle, from.tbl, dest.handle, dest.tbl)
+	}
+
+	if copyDest.tbl != dest.tbl {
+		err = replaceTable(ctx, destDB, copyDest.tbl, dest.tbl)
+		if err != nil {
+			rc.Log.WarnIfError(destSQLDrvr.DropTable(context.Background(), destDB.DB(), copyDest.tbl, true))
+			return errz.Wrapf(err, "failed to replace table %s.%s", dest.handle, dest.tbl)
+		}
+	}
+
+	msg := fmt.Sprintf("Copied table: %s.%s --> %s.%s", from.handle, from.tbl, dest.handle, dest.tbl)
 
 	if copyData {
 		switch copied {
@@ -140,6 +211,106 @@ func execTblCopy(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// replaceTable replaces table tbl in dbase with table tmpTbl: tbl is
+// dropped, and tmpTbl is renamed to tbl. This is done in a single tx,
+// although note that some databases (e.g. MySQL) commit DDL implicitly.
+func replaceTable(ctx context.Context, dbase driver.Database, tmpTbl, tbl string) error {
+	tx, err := dbase.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	sqlDrvr := dbase.SQLDriver()
+	err = sqlDrvr.DropTable(ctx, tx, tbl, false)
+	if err == nil {
+		err = sqlDrvr.RenameTable(ctx, tx, tmpTbl, tbl)
+	}
+	if err != nil {
+		return errz.Append(err, errz.Err(tx.Rollback()))
+	}
+
+	return errz.Err(tx.Commit())
+}
+
+// execTblCopyNative copies table from to dest (which must be in
+// the same source) using the database's native functionality.
+func execTblCopyNative(cmd *cobra.Command, rc *RunContext, from, dest tblHandle, copyData bool) (copied int64, err error) {
+	sqlDrvr, ok := from.drvr.(driver.SQLDriver)
+	if !ok {
+		return 0, errz.Errorf("source type %q (%s) doesn't support copying tables", from.src.Type, from.src.Handle)
+	}
+
+	dbase, err := rc.databases.Open(cmd.Context(), from.src)
+	if err != nil {
+		return 0, err
+	}
+
+	return sqlDrvr.CopyTable(cmd.Context(), dbase.DB(), from.tbl, dest.tbl, copyData)
+}
+
+// execTblCopyStream copies table from to dest, which may be in
+// another source. If create is true, the dest table is created
+// (and is dropped again if the copy fails). If copyData is true,
+// the rows of from (filtered by the SLQ where clause, if non-empty)
+// are streamed to dest.
+func execTblCopyStream(cmd *cobra.Command, rc *RunContext, from, dest tblHandle, copyData, create bool, where string) (copied int64, err error) {
+	ctx := cmd.Context()
+
+	fromDB, err := rc.databases.Open(ctx, from.src)
+	if err != nil {
+		return 0, err
+	}
+
+	destDB, err := rc.databases.Open(ctx, dest.src)
+	if err != nil {
+		return 0, err
+	}
+
+	if create {
+		var tblMeta *source.TableMetadata
+		tblMeta, err = fromDB.TableMetadata(ctx, from.tbl)
+		if err != nil {
+			return 0, err
+		}
+
+		tblDef := libsq.NewTableDefFromMetadata(tblMeta, dest.tbl)
+		err = destDB.SQLDriver().CreateTable(ctx, destDB.DB(), tblDef)
+		if err != nil {
+			return 0, errz.Wrapf(err, "failed to create table %s.%s", dest.handle, dest.tbl)
+		}
+
+		defer func() {
+			if err != nil {
+				// Don't leave a half-filled table behind. We don't
+				// use ctx, as it may be the reason for the failure.
+				rc.Log.WarnIfError(destDB.SQLDriver().DropTable(context.Background(), destDB.DB(), dest.tbl, true))
+			}
+		}()
+	}
+
+	if !copyData {
+		return 0, nil
+	}
+
+	slq := from.handle + "." + from.tbl
+	if where != "" {
+		slq += " | " + where
+	}
+
+	inserter := libsq.NewDBWriter(rc.Log, destDB, dest.tbl, driver.Tuning.RecordChSize)
+	execErr := libsq.ExecuteSLQ(ctx, rc.Log, rc.databases, rc.databases, rc.Config.Sources, slq, inserter)
+	copied, waitErr := inserter.Wait() // Wait for the writer to finish processing
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	if waitErr != nil {
+		return 0, waitErr
+	}
+
+	return copied, nil
+}
+
 func newTblTruncateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "truncate @HANDLE.TABLE|.TABLE",
@@ -1,11 +1,14 @@
 package cli_test
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/neilotoole/sq/drivers/sqlite3"
 	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh"
 	"github.com/neilotoole/sq/testh/sakila"
 )
@@ -39,6 +42,59 @@ func TestCmdTblCopy(t *testing.T) {
 	}
 }
 
+func TestCmdTblCopy_CrossSource(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := th.Source(sakila.CSVActor)
+	srcTblHandle := src.Handle + ".data"
+
+	destSrc := source.Source{
+		Handle:   "@dest",
+		Type:     sqlite3.Type,
+		Location: "sqlite3://" + filepath.Join(t.TempDir(), "dest.db"),
+	}
+	destTblHandle := destSrc.Handle + ".actor_backup"
+
+	ru := newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("tbl", "copy", srcTblHandle, destTblHandle))
+	require.Equal(t, int64(sakila.TblActorCount), th.RowCount(&destSrc, "actor_backup"))
+
+	// Should fail because the dest table exists
+	ru = newRun(t).add(*src, destSrc)
+	require.Error(t, ru.exec("tbl", "copy", srcTblHandle, destTblHandle))
+
+	ru = newRun(t).add(*src, destSrc)
+	require.Error(t, ru.exec("tbl", "copy", "--if-exists=not_valid", srcTblHandle, destTblHandle))
+
+	ru = newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("tbl", "copy", "--if-exists=replace", "--where=.actor_id <= 10", srcTblHandle, destTblHandle))
+	require.Equal(t, int64(10), th.RowCount(&destSrc, "actor_backup"))
+	require.Contains(t, ru.out.String(), "(10 rows copied)")
+
+	ru = newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("tbl", "copy", "--if-exists=append", "--where=.actor_id > 190", srcTblHandle, destTblHandle))
+	require.Equal(t, int64(20), th.RowCount(&destSrc, "actor_backup"))
+
+	// Should fail because of the invalid where clause: the dest table
+	// must be left intact, and the temp table must not remain.
+	ru = newRun(t).add(*src, destSrc)
+	require.Error(t, ru.exec("tbl", "copy", "--if-exists=replace", "--where=.actor_id >", srcTblHandle, destTblHandle))
+	require.Equal(t, int64(20), th.RowCount(&destSrc, "actor_backup"))
+	ru = newRun(t).add(destSrc)
+	require.NoError(t, ru.exec("sql", "--src="+destSrc.Handle, "--csv", "--header=false",
+		"SELECT count(*) FROM sqlite_master WHERE type = 'table'"))
+	require.Equal(t, "1\n", ru.out.String())
+
+	ru = newRun(t).add(*src, destSrc)
+	require.NoError(t, ru.exec("tbl", "copy", "--if-exists=replace", "--data=false", srcTblHandle, destTblHandle))
+	require.Equal(t, int64(0), th.RowCount(&destSrc, "actor_backup"))
+
+	// Should fail because the dest isn't a SQL source
+	ru = newRun(t).add(*src, destSrc)
+	require.Error(t, ru.exec("tbl", "copy", destTblHandle, src.Handle+".data2"))
+}
+
 func TestCmdTblDrop(t *testing.T) {
 	for _, handle := range sakila.SQLAll() {
 		handle := handle
@@ -16,6 +16,7 @@ var (
 	_ completionFunc = completeDriverType
 	_ completionFunc = completeSLQ
 	_ completionFunc = new(handleTableCompleter).complete
+	_ completionFunc = completeQueryName(0)
 )
 
 // completeHandle is a completionFunc that suggests handles.
@@ -34,6 +35,28 @@ func completeHandle(max int) completionFunc {
 	}
 }
 
+// completeQueryName is a completionFunc that suggests saved
+// query names. The max arg is the maximum number of completions.
+// Set to 0 for no limit.
+func completeQueryName(max int) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if max > 0 && len(args) >= max {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		rc := RunContextFrom(cmd.Context())
+
+		var names []string
+		for _, name := range rc.Config.Queries.Names() {
+			if strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
 // completeSLQ is a completionFunc that completes SLQ queries.
 // The completion functionality is rudimentary: it only
 // completes the "table select" segment (that is, the @HANDLE.NAME)
@@ -72,23 +95,35 @@ func completeTblCopy(cmd *cobra.Command, args []string, toComplete string) ([]st
 	// Example invocation:
 	//
 	//  sq tbl copy @sakila_sl3.actor .new_table
+	//  sq tbl copy @sakila_pg.actor @sakila_sl3.actor_backup
 	//
-	// Note that the second arg can only be a table name (and
-	// not a @HANDLE.TABLE), and it must also be a _new_ table
-	// (because we can't copy over an existing table), thus
-	// we only suggest "." for the second arg, forcing the user
-	// to supply the rest of that new table name.
+	// Note that the second arg can only be a SQL source, as
+	// the table is created in the destination source.
 	switch len(args) {
 	case 0:
-		c := &handleTableCompleter{onlySQL: true}
+		c := &handleTableCompleter{}
 		return c.complete(cmd, args, toComplete)
 	case 1:
-		return []string{"."}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		c := &handleTableCompleter{onlySQL: true}
+		return c.complete(cmd, args, toComplete)
 	default:
 		return nil, cobra.ShellCompDirectiveError
 	}
 }
 
+// completeDiff is a completionFunc for the diff command.
+func completeDiff(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Example invocation:
+	//
+	//  sq diff @sakila_sl3.actor @sakila_pg.actor
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	c := &handleTableCompleter{}
+	return c.complete(cmd, args, toComplete)
+}
+
 // handleTableCompleter encapsulates completion of a handle
 // ("@sakila_sl3"), table (".actor"), or @HANDLE.TABLE
 // ("@sakila_sl3.actor"). Its complete method is a completionFunc.
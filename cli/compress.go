@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+// Output compression formats, per flag --compress.
+const (
+	compressNone  = "none"
+	compressGzip  = "gzip"
+	compressZstd  = "zstd"
+	compressBzip2 = "bzip2"
+)
+
+// compressExts maps output file extensions to the
+// compression format implied by the extension.
+var compressExts = map[string]string{
+	".gz":  compressGzip,
+	".zst": compressZstd,
+	".bz2": compressBzip2,
+}
+
+// outputCompression returns the compression format for the output
+// of cmd, or empty string if the output is not to be compressed. If
+// flag --compress is set, its value determines the format. Otherwise
+// the format is determined by the extension (e.g. ".gz") of fpath,
+// which is the value of flag --output, and may be empty.
+func outputCompression(cmd *cobra.Command, fpath string) (string, error) {
+	if cmdFlagChanged(cmd, flagCompress) {
+		compression, _ := cmd.Flags().GetString(flagCompress)
+		compression = strings.ToLower(strings.TrimSpace(compression))
+		switch compression {
+		case compressNone:
+			return "", nil
+		case compressGzip, compressZstd, compressBzip2:
+			return compression, nil
+		default:
+			return "", errz.Errorf("invalid --%s value %q: must be one of %s, %s, %s or %s",
+				flagCompress, compression, compressGzip, compressZstd, compressBzip2, compressNone)
+		}
+	}
+
+	if fpath == "" {
+		return "", nil
+	}
+
+	return compressExts[strings.ToLower(filepath.Ext(fpath))], nil
+}
+
+// newCompressWriter returns a writer that writes the data written to
+// it to w, compressed per compression. The returned writer must be
+// closed to finalize the compressed data; w is not closed.
+func newCompressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case compressGzip:
+		return gzip.NewWriter(w), nil
+	case compressZstd:
+		zw, err := zstd.NewWriter(w)
+		return zw, errz.Err(err)
+	case compressBzip2:
+		bw, err := bzip2.NewWriter(w, nil)
+		return bw, errz.Err(err)
+	default:
+		return nil, errz.Errorf("unknown compression format: %s", compression)
+	}
+}
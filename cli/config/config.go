@@ -22,6 +22,9 @@ type Config struct {
 	// Sources is the set of data sources.
 	Sources *source.Set `yaml:"sources" json:"sources"`
 
+	// Queries is the set of saved queries.
+	Queries Queries `yaml:"queries,omitempty" json:"queries,omitempty"`
+
 	// Ext holds sq config extensions, such as user driver config.
 	Ext Ext `yaml:"-" json:"-"`
 }
@@ -51,6 +54,21 @@ type Defaults struct {
 	// ShellCompletionTimeout is the time allowed for the shell
 	// completion callback to execute.
 	ShellCompletionTimeout time.Duration `yaml:"shell_completion_timeout" json:"shell_completion_timeout"`
+
+	// XLSX holds XLSX output settings.
+	XLSX XLSXDefaults `yaml:"xlsx,omitempty" json:"xlsx,omitempty"`
+}
+
+// XLSXDefaults holds the Excel number formats used for XLSX
+// output, e.g. "yyyy-mm-dd" for dates. If a format is empty,
+// sq's default format is used.
+type XLSXDefaults struct {
+	IntFormat      string `yaml:"int_format,omitempty" json:"int_format,omitempty"`
+	FloatFormat    string `yaml:"float_format,omitempty" json:"float_format,omitempty"`
+	DecimalFormat  string `yaml:"decimal_format,omitempty" json:"decimal_format,omitempty"`
+	DatetimeFormat string `yaml:"datetime_format,omitempty" json:"datetime_format,omitempty"`
+	DateFormat     string `yaml:"date_format,omitempty" json:"date_format,omitempty"`
+	TimeFormat     string `yaml:"time_format,omitempty" json:"time_format,omitempty"`
 }
 
 // New returns a config instance with default options set.
@@ -97,7 +115,8 @@ func (f *Format) UnmarshalText(text []byte) error {
 	default:
 		return errz.Errorf("unknown output format %q", string(text))
 	case FormatJSON, FormatJSONA, FormatJSONL, FormatTable, FormatRaw,
-		FormatHTML, FormatMarkdown, FormatXLSX, FormatXML, FormatCSV, FormatTSV:
+		FormatHTML, FormatMarkdown, FormatXLSX, FormatXML, FormatCSV, FormatTSV,
+		FormatParquet, FormatArrow, FormatYAML:
 	}
 
 	*f = Format(text)
@@ -117,4 +136,18 @@ const (
 	FormatXML      Format = "xml"
 	FormatCSV      Format = "csv"
 	FormatTSV      Format = "tsv"
+	FormatParquet  Format = "parquet"
+	FormatArrow    Format = "arrow"
+	FormatYAML     Format = "yaml"
 )
+
+// IsBinary returns true if f is a binary format, such as Parquet,
+// which is not suited to output to a terminal.
+func (f Format) IsBinary() bool {
+	switch f {
+	case FormatParquet, FormatArrow:
+		return true
+	default:
+		return false
+	}
+}
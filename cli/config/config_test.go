@@ -1,8 +1,10 @@
 package config_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -89,3 +91,116 @@ func TestFileStore_Load(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExt(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title     string
+		yml       string
+		wantLines []int
+	}{
+		{
+			title: "valid",
+			yml: `user_drivers:
+- driver: ppl
+  genre: xml
+  title: People
+  selector: /people
+  tables:
+  - table: person
+    selector: /people/person
+    primary_key:
+      - person_id
+    cols:
+    - col: person_id
+      kind: int`,
+		},
+		{
+			title: "invalid root selector, empty tables",
+			yml: `user_drivers:
+- driver: orders
+  genre: json
+  title: Orders
+  selector: /orders
+  tables: []`,
+			wantLines: []int{5, 6},
+		},
+		{
+			title: "missing primary key, missing kind, invalid foreign",
+			yml: `user_drivers:
+- driver: orders
+  genre: json
+  title: Orders
+  selector: $
+  tables:
+  - table: order
+    selector: $.orders[*]
+    cols:
+    - col: order_id
+    - col: customer_id
+      kind: int
+      foreign: customer_id`,
+			wantLines: []int{7, 10, 13},
+		},
+		{
+			title: "invalid kind",
+			yml: `user_drivers:
+- driver: ppl
+  genre: xml
+  title: People
+  selector: /people
+  tables:
+  - table: person
+    selector: /people/person
+    cols:
+    - col: person_id
+      kind: not_a_kind`,
+			wantLines: []int{11},
+		},
+		{
+			title: "duplicate driver name",
+			yml: `user_drivers:
+- driver: ppl
+  genre: csv
+  title: People
+  selector: /
+  tables:
+  - table: person
+    selector: /
+    primary_key:
+      - name
+    cols:
+    - col: name
+      kind: text
+- driver: ppl
+  genre: csv
+  title: People
+  selector: /
+  tables:
+  - table: person
+    selector: /
+    primary_key:
+      - name
+    cols:
+    - col: name
+      kind: text`,
+			wantLines: []int{14},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			_, errs := config.ValidateExt([]byte(tc.yml))
+			require.Equal(t, len(tc.wantLines), len(errs), "%v", errs)
+			for i, err := range errs {
+				require.True(t, strings.HasPrefix(err.Error(), fmt.Sprintf("line %d: ", tc.wantLines[i])),
+					"wanted line %d: %v", tc.wantLines[i], err)
+			}
+		})
+	}
+}
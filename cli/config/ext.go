@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+)
+
+// ExtSuffix is the file name suffix of ext config files,
+// e.g. "rss.sq.yml".
+const ExtSuffix = ".sq.yml"
+
+// ParseExt parses ext config from data, which was read
+// from file path. The File field of each user driver def
+// is set to path.
+func ParseExt(path string, data []byte) (*Ext, error) {
+	ext := &Ext{}
+	err := yaml.Unmarshal(data, ext)
+	if err != nil {
+		return nil, errz.Wrapf(err, "error parsing config ext file %q", path)
+	}
+
+	for _, def := range ext.UserDrivers {
+		def.File = path
+	}
+
+	return ext, nil
+}
+
+// ValidateExt parses and validates the ext config data, returning
+// any errors, each prefixed with the line number of data at which
+// the error occurs. The errors include a definition having the same
+// driver name as an earlier definition.
+func ValidateExt(data []byte) (*Ext, []error) {
+	// We use yaml.v3 to parse the data into a node tree, for the
+	// line number of each node.
+	var doc yaml3.Node
+	err := yaml3.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, []error{errz.Err(err)}
+	}
+
+	ext := &Ext{}
+	err = yaml.UnmarshalStrict(data, ext)
+	if err != nil {
+		// Most yaml.v2 errors already include the line, but an
+		// invalid kind value doesn't, so we look for those first.
+		if errs := kindErrors(&doc); len(errs) > 0 {
+			return nil, errs
+		}
+		return nil, []error{errz.Err(err)}
+	}
+
+	if len(ext.UserDrivers) == 0 {
+		return nil, []error{errz.New("no user driver definitions found (expected key \"user_drivers\")")}
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	for i, def := range ext.UserDrivers {
+		for _, err := range userdriver.ValidateDriverDef(def) {
+			line := lineOfDefError(&doc, i, err)
+			errs = append(errs, errz.Errorf("line %d: %v", line, err))
+		}
+
+		if def.Name != "" {
+			if names[def.Name] {
+				line := nodeLine(lookupNode(&doc, "user_drivers", i, "driver"))
+				errs = append(errs, errz.Errorf("line %d: duplicate driver name %q", line, def.Name))
+			}
+			names[def.Name] = true
+		}
+	}
+
+	return ext, errs
+}
+
+// kindErrors returns an error for each col kind of doc that is
+// not a valid kind name.
+func kindErrors(doc *yaml3.Node) []error {
+	var errs []error
+	for i := 0; lookupNode(doc, "user_drivers", i) != nil; i++ {
+		for j := 0; lookupNode(doc, "user_drivers", i, "tables", j) != nil; j++ {
+			for k := 0; lookupNode(doc, "user_drivers", i, "tables", j, "cols", k) != nil; k++ {
+				n := lookupNode(doc, "user_drivers", i, "tables", j, "cols", k, "kind")
+				if n == nil {
+					continue
+				}
+
+				var knd kind.Kind
+				if err := knd.UnmarshalText([]byte(n.Value)); err != nil {
+					errs = append(errs, errz.Errorf("line %d: %v", n.Line, err))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// lineOfDefError returns the line of doc at which err (an error
+// returned by userdriver.ValidateDriverDef for the def at index
+// defIndex of user_drivers) occurs.
+func lineOfDefError(doc *yaml3.Node, defIndex int, err error) int {
+	path := []interface{}{"user_drivers", defIndex}
+
+	var defErr *userdriver.DefError
+	if errors.As(err, &defErr) {
+		if defErr.Table >= 0 {
+			path = append(path, "tables", defErr.Table)
+			if defErr.Col >= 0 {
+				path = append(path, "cols", defErr.Col)
+			}
+		}
+
+		// If the field is absent, we fall back
+		// to the line of its parent.
+		if defErr.Field != "" {
+			if n := lookupNode(doc, append(path, defErr.Field)...); n != nil {
+				return n.Line
+			}
+		}
+	}
+
+	return nodeLine(lookupNode(doc, path...))
+}
+
+// lookupNode returns the node of doc at path, whose elements are
+// mapping keys (string) or sequence indices (int), or nil.
+func lookupNode(doc *yaml3.Node, path ...interface{}) *yaml3.Node {
+	n := doc
+	if n.Kind == yaml3.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case string:
+			if n.Kind != yaml3.MappingNode {
+				return nil
+			}
+
+			var val *yaml3.Node
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == elem {
+					val = n.Content[i+1]
+					break
+				}
+			}
+			if val == nil {
+				return nil
+			}
+			n = val
+		case int:
+			if n.Kind != yaml3.SequenceNode || elem >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[elem]
+		default:
+			panic(fmt.Sprintf("invalid path element type %T", elem))
+		}
+	}
+
+	return n
+}
+
+// nodeLine returns the line of n, or 0 if n is nil.
+func nodeLine(n *yaml3.Node) int {
+	if n == nil {
+		return 0
+	}
+	return n.Line
+}
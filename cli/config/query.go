@@ -0,0 +1,132 @@
+package config
+
+import (
+	"regexp"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+// Query is a saved, named query.
+type Query struct {
+	// Name is the query name, e.g. "daily_sales".
+	Name string `yaml:"name" json:"name"`
+
+	// Query is the query text. It is SLQ unless field SQL is true.
+	Query string `yaml:"query" json:"query"`
+
+	// SQL is true if Query is database-native SQL instead of SLQ.
+	SQL bool `yaml:"sql,omitempty" json:"sql,omitempty"`
+
+	// Handle is the handle of the default source for the query. It
+	// is used when the query does not itself specify a source. If
+	// empty, the active source is used.
+	Handle string `yaml:"handle,omitempty" json:"handle,omitempty"`
+
+	// Format is the default output format for the query. If empty,
+	// Defaults.Format is used.
+	Format Format `yaml:"output_format,omitempty" json:"output_format,omitempty"`
+}
+
+// Queries is the set of saved queries.
+type Queries []*Query
+
+// Get returns the query with name, or an error.
+func (q Queries) Get(name string) (*Query, error) {
+	for _, query := range q {
+		if query.Name == name {
+			return query, nil
+		}
+	}
+
+	return nil, errz.Errorf("unknown saved query %q", name)
+}
+
+// Exists returns true if a query with name exists.
+func (q Queries) Exists(name string) bool {
+	_, err := q.Get(name)
+	return err == nil
+}
+
+// Names returns the names of the queries.
+func (q Queries) Names() []string {
+	names := make([]string, len(q))
+	for i := range q {
+		names[i] = q[i].Name
+	}
+
+	return names
+}
+
+// Add adds query to the set. It is an error if query's name
+// is illegal, or if a query with the same name already exists.
+func (q *Queries) Add(query *Query) error {
+	if query == nil {
+		return errz.New("query is nil")
+	}
+
+	err := VerifyLegalQueryName(query.Name)
+	if err != nil {
+		return err
+	}
+
+	if q.Exists(query.Name) {
+		return errz.Errorf("saved query %q already exists", query.Name)
+	}
+
+	*q = append(*q, query)
+	return nil
+}
+
+// Remove removes the query with name from the set.
+func (q *Queries) Remove(name string) error {
+	for i, query := range *q {
+		if query.Name == name {
+			*q = append((*q)[:i], (*q)[i+1:]...)
+			return nil
+		}
+	}
+
+	return errz.Errorf("unknown saved query %q", name)
+}
+
+var queryNamePattern = regexp.MustCompile(`\A[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// VerifyLegalQueryName returns an error if name is not an
+// acceptable saved query name. Valid input must match:
+//
+//   \A[a-zA-Z][a-zA-Z0-9_-]*$
+func VerifyLegalQueryName(name string) error {
+	if !queryNamePattern.MatchString(name) {
+		return errz.Errorf(`invalid query name %q: must begin with a letter, followed by zero or more letters, digits, underscores or hyphens, e.g. "daily_sales"`, name)
+	}
+
+	return nil
+}
+
+// verifyQueriesIntegrity verifies the internal state of queries.
+// Typically this func is invoked after the queries have been loaded
+// from config, verifying that the config is not corrupt.
+func verifyQueriesIntegrity(queries Queries) error {
+	names := map[string]struct{}{}
+	for i, query := range queries {
+		if query == nil {
+			return errz.Errorf("saved query %d is nil", i)
+		}
+
+		err := VerifyLegalQueryName(query.Name)
+		if err != nil {
+			return errz.Wrapf(err, "saved query %d", i)
+		}
+
+		if query.Query == "" {
+			return errz.Errorf("saved query %d (%s) is empty", i, query.Name)
+		}
+
+		if _, exists := names[query.Name]; exists {
+			return errz.Errorf("saved query %d duplicates name %s", i, query.Name)
+		}
+		names[query.Name] = struct{}{}
+	}
+
+	return nil
+}
@@ -79,6 +79,11 @@ func (fs *YAMLFileStore) Load() (*Config, error) {
 		return nil, errz.Wrapf(err, "config: %s", fs.Path)
 	}
 
+	err = verifyQueriesIntegrity(cfg.Queries)
+	if err != nil {
+		return nil, errz.Wrapf(err, "config: %s", fs.Path)
+	}
+
 	err = fs.loadExt(cfg)
 	if err != nil {
 		return nil, err
@@ -89,7 +94,6 @@ func (fs *YAMLFileStore) Load() (*Config, error) {
 
 // loadExt loads extension config files into cfg.
 func (fs *YAMLFileStore) loadExt(cfg *Config) error {
-	const extSuffix = ".sq.yml"
 	var extCfgCandidates []string
 
 	for _, extPath := range fs.ExtPaths {
@@ -113,7 +117,7 @@ func (fs *YAMLFileStore) loadExt(cfg *Config) error {
 						continue
 					}
 
-					if !strings.HasSuffix(file.Name(), extSuffix) {
+					if !strings.HasSuffix(file.Name(), ExtSuffix) {
 						continue
 					}
 
@@ -124,7 +128,7 @@ func (fs *YAMLFileStore) loadExt(cfg *Config) error {
 			}
 
 			// it's a file
-			if !strings.HasSuffix(fiExtPath.Name(), extSuffix) {
+			if !strings.HasSuffix(fiExtPath.Name(), ExtSuffix) {
 				continue
 			}
 			extCfgCandidates = append(extCfgCandidates, filepath.Join(extPath, fiExtPath.Name()))
@@ -136,11 +140,9 @@ func (fs *YAMLFileStore) loadExt(cfg *Config) error {
 		if err != nil {
 			return errz.Wrapf(err, "error reading config ext file %q", f)
 		}
-		ext := &Ext{}
-
-		err = yaml.Unmarshal(bytes, ext)
+		ext, err := ParseExt(f, bytes)
 		if err != nil {
-			return errz.Wrapf(err, "error parsing config ext file %q", f)
+			return err
 		}
 
 		cfg.Ext.UserDrivers = append(cfg.Ext.UserDrivers, ext.UserDrivers...)
@@ -9,10 +9,31 @@ const (
 	flagCSVShort = "c"
 	flagCSVUsage = "Output CSV"
 
+	flagCompress      = "compress"
+	flagCompressUsage = "Compress the output: gzip, zstd, bzip2 or none (default per --output file extension, e.g. .gz)"
+
+	flagCopyTables      = "tables"
+	flagCopyTablesUsage = "Comma-separated names of the tables to copy (default is all tables)"
+
+	flagCopySchemaOnly      = "schema-only"
+	flagCopySchemaOnlyUsage = "Create the tables, but don't copy data"
+
+	flagDiffKey      = "key"
+	flagDiffKeyUsage = "Comma-separated key columns that identify a row (default is the primary key)"
+
+	flagDiffSchema      = "schema"
+	flagDiffSchemaUsage = "Compare source schemas instead of table data"
+
 	flagDriver      = "driver"
 	flagDriverShort = "d"
 	flagDriverUsage = "Explicitly specify the data source driver to use"
 
+	flagDriverGenDefName      = "name"
+	flagDriverGenDefNameUsage = "Name of the generated driver (default is the document element name)"
+
+	flagExportTables      = "tables"
+	flagExportTablesUsage = "Comma-separated names of the tables to export (default is all tables)"
+
 	flagHTML      = "html"
 	flagHTMLUsage = "Output HTML table"
 
@@ -29,9 +50,18 @@ const (
 	flagInsert      = "insert"
 	flagInsertUsage = "Insert query results into @HANDLE.TABLE. If not existing, TABLE will be created."
 
+	flagInsertMode      = "insert-mode"
+	flagInsertModeUsage = "How --insert writes to an existing table: append, truncate, replace or upsert"
+
+	flagInsertKey      = "key"
+	flagInsertKeyUsage = "Comma-separated key columns for --insert-mode=upsert (default is the primary key)"
+
 	flagInspectFull      = "full"
 	flagInspectFullUsage = "Output full data source details (JSON only)"
 
+	flagInspectSnapshot      = "snapshot"
+	flagInspectSnapshotUsage = "Output source schema snapshot (JSON), for use with \"sq diff --schema\""
+
 	flagJSON       = "json"
 	flagJSONUsage  = "Output JSON"
 	flagJSONShort  = "j"
@@ -42,6 +72,9 @@ const (
 	flagJSONLShort = "l"
 	flagJSONLUsage = "Output LF-delimited JSON objects"
 
+	flagMaxErrors      = "max-errors"
+	flagMaxErrorsUsage = "Maximum number of rows that can be rejected (skipped) on insert or import before failing"
+
 	flagMarkdown      = "markdown"
 	flagMarkdownUsage = "Output Markdown"
 
@@ -49,6 +82,9 @@ const (
 	flagMonochromeShort = "M"
 	flagMonochromeUsage = "Don't colorize output"
 
+	flagNoCache      = "no-cache"
+	flagNoCacheUsage = "Don't use (or populate) the import cache for file-based sources such as CSV"
+
 	flagOutput      = "output"
 	flagOutputShort = "o"
 	flagOutputUsage = "Write output to <file> instead of stdout"
@@ -59,10 +95,19 @@ const (
 	flagQueryDriverUsage     = "Explicitly specify the data source driver to use when piping input"
 	flagQuerySrcOptionsUsage = "Driver-dependent data source options when piping input"
 
+	flagQueryArg      = "arg"
+	flagQueryArgUsage = "Saved query argument NAME=VALUE, substituted for ${NAME} in the query"
+
+	flagQuerySQL      = "sql"
+	flagQuerySQLUsage = "The query is database-native SQL (as opposed to SLQ)"
+
 	flagRaw      = "raw"
 	flagRawShort = "r"
 	flagRawUsage = "Output each record field in raw format without any encoding or delimiter"
 
+	flagRejects      = "rejects"
+	flagRejectsUsage = "Write rows rejected on insert or import to this JSONL file (implies unlimited --max-errors if not set)"
+
 	flagSQLExec      = "exec"
 	flagSQLExecUsage = "Execute the SQL as a statement (as opposed to query)"
 
@@ -83,6 +128,12 @@ const (
 	flagTblData      = "data"
 	flagTblDataUsage = "Copy table data (default true)"
 
+	flagTblIfExists      = "if-exists"
+	flagTblIfExistsUsage = "Action if the destination table exists: fail, replace or append"
+
+	flagTblWhere      = "where"
+	flagTblWhereUsage = "Copy only the rows matching this SLQ filter, e.g. '.actor_id > 100'"
+
 	flagPingTimeout      = "timeout"
 	flagPingTimeoutUsage = "Max time to wait for ping"
 
@@ -101,6 +152,16 @@ const (
 	flagXLSXShort = "x"
 	flagXLSXUsage = "Output Excel XLSX"
 
+	flagParquet      = "parquet"
+	flagParquetUsage = "Output Apache Parquet (requires --output)"
+
+	flagArrow      = "arrow"
+	flagArrowUsage = "Output Apache Arrow IPC file (requires --output)"
+
+	flagYAML      = "yaml"
+	flagYAMLShort = "y"
+	flagYAMLUsage = "Output YAML"
+
 	flagXML      = "xml"
 	flagXMLShort = "X"
 	flagXMLUsage = "Output XML"
@@ -116,4 +177,5 @@ const (
 	envarLogPath     = "SQ_LOGFILE"
 	envarLogTruncate = "SQ_LOGFILE_TRUNCATE"
 	envarConfigDir   = "SQ_CONFIGDIR"
+	envarCacheDir    = "SQ_CACHEDIR"
 )
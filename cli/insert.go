@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// Values for flag --insert-mode.
+const (
+	insertModeAppend   = "append"
+	insertModeTruncate = "truncate"
+	insertModeReplace  = "replace"
+	insertModeUpsert   = "upsert"
+)
+
+// insertOptions holds the values of the flags that
+// control how query results are inserted via --insert.
+type insertOptions struct {
+	mode    string
+	keyCols []string
+}
+
+// addInsertFlags adds the --insert and related flags to cmd.
+func addInsertFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(flagInsert, "", "", flagInsertUsage)
+	_ = cmd.RegisterFlagCompletionFunc(flagInsert, (&handleTableCompleter{onlySQL: true, handleRequired: true}).complete)
+
+	cmd.Flags().String(flagInsertMode, insertModeAppend, flagInsertModeUsage)
+	_ = cmd.RegisterFlagCompletionFunc(flagInsertMode, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{insertModeAppend, insertModeTruncate, insertModeReplace, insertModeUpsert}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	cmd.Flags().String(flagInsertKey, "", flagInsertKeyUsage)
+}
+
+// getInsertOptions returns the insertOptions from cmd's flags. It
+// is an error if the flags are set but flag --insert is not.
+func getInsertOptions(cmd *cobra.Command) (*insertOptions, error) {
+	if !cmdFlagChanged(cmd, flagInsert) {
+		for _, flag := range []string{flagInsertMode, flagInsertKey} {
+			if cmdFlagChanged(cmd, flag) {
+				return nil, errz.Errorf("flag --%s requires flag --%s", flag, flagInsert)
+			}
+		}
+		return nil, nil
+	}
+
+	opts := &insertOptions{}
+	opts.mode, _ = cmd.Flags().GetString(flagInsertMode)
+	switch opts.mode {
+	case insertModeAppend, insertModeTruncate, insertModeReplace, insertModeUpsert:
+	default:
+		return nil, errz.Errorf("invalid --%s value %q: must be one of: %s, %s, %s, %s", flagInsertMode, opts.mode,
+			insertModeAppend, insertModeTruncate, insertModeReplace, insertModeUpsert)
+	}
+
+	if !cmdFlagChanged(cmd, flagInsertKey) {
+		return opts, nil
+	}
+
+	if opts.mode != insertModeUpsert {
+		return nil, errz.Errorf("flag --%s can only be used with --%s=%s", flagInsertKey, flagInsertMode, insertModeUpsert)
+	}
+
+	val, _ := cmd.Flags().GetString(flagInsertKey)
+	for _, col := range strings.Split(val, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" {
+			return nil, errz.Errorf("invalid --%s value %q", flagInsertKey, val)
+		}
+		opts.keyCols = append(opts.keyCols, col)
+	}
+
+	return opts, nil
+}
+
+// newInsertWriter returns a writer that inserts records into destTbl
+// in destDB, preparing destTbl per opts.mode. If destTbl does not
+// exist, it is created by the writer. The table is prepared (e.g.
+// truncated) in the same tx as the records are written, such that
+// a failed insert doesn't leave the table emptied.
+func newInsertWriter(ctx context.Context, rc *RunContext, destDB driver.Database, destTbl string, opts *insertOptions) (libsq.RecordWriter, error) {
+	destSrc := destDB.Source()
+
+	var hooks []libsq.DBWriterPreWriteHook
+	switch opts.mode {
+	case insertModeTruncate:
+		hooks = append(hooks, libsq.DBWriterDeleteRowsHook(destTbl))
+	case insertModeReplace:
+		hooks = append(hooks, libsq.DBWriterDropTableHook(destTbl))
+	case insertModeUpsert:
+		keyCols := opts.keyCols
+		if len(keyCols) == 0 {
+			exists, err := destDB.SQLDriver().TableExists(ctx, destDB.DB(), destTbl)
+			if err != nil {
+				return nil, err
+			}
+
+			if !exists {
+				return nil, errz.Errorf("flag --%s is required: table %s.%s does not exist",
+					flagInsertKey, destSrc.Handle, destTbl)
+			}
+
+			tblMeta, err := destDB.TableMetadata(ctx, destTbl)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, col := range tblMeta.PKCols() {
+				keyCols = append(keyCols, col.Name)
+			}
+
+			if len(keyCols) == 0 {
+				return nil, errz.Errorf("flag --%s is required: table %s.%s has no primary key",
+					flagInsertKey, destSrc.Handle, destTbl)
+			}
+		}
+
+		// If the table is created, it gets a unique constraint on
+		// the key cols, which some drivers' upsert statements require.
+		createHook := libsq.DBWriterCreateUniqueTableIfNotExistsHook(destTbl, keyCols)
+		return libsq.NewDBUpsertWriter(rc.Log, destDB, destTbl, keyCols, driver.Tuning.RecordChSize, createHook), nil
+	}
+
+	hooks = append(hooks, libsq.DBWriterCreateTableIfNotExistsHook(destTbl))
+	w := libsq.NewDBWriter(rc.Log, destDB, destTbl, driver.Tuning.RecordChSize, hooks...)
+	if opts.mode != insertModeAppend {
+		w.DisableBulkLoad()
+	}
+
+	return w, nil
+}
+
+// waitInsert waits for inserter to complete, and prints
+// the number of rows inserted (and updated, if upserting).
+func waitInsert(rc *RunContext, inserter libsq.RecordWriter, destHandle, destTbl string) error {
+	affected, err := inserter.Wait() // Wait for the writer to finish processing
+	if err != nil {
+		return errz.Wrapf(err, "insert %s.%s failed", destHandle, destTbl)
+	}
+
+	rc.Log.Debugf("Rows affected: %d", affected)
+
+	if upserter, ok := inserter.(*libsq.DBUpsertWriter); ok {
+		inserted, updated, unchanged := upserter.Counts()
+		msg := fmt.Sprintf(stringz.Plu("Inserted %d row(s)", int(inserted)), inserted) + ", " +
+			fmt.Sprintf(stringz.Plu("updated %d row(s)", int(updated)), updated)
+		if unchanged > 0 {
+			msg += ", " + fmt.Sprintf(stringz.Plu("%d row(s) unchanged", int(unchanged)), unchanged)
+		}
+		fmt.Fprintf(rc.Out, "%s in %s.%s\n", msg, destHandle, destTbl)
+		return nil
+	}
+
+	fmt.Fprintf(rc.Out, stringz.Plu("Inserted %d row(s) into %s.%s\n", int(affected)), affected, destHandle, destTbl)
+	return nil
+}
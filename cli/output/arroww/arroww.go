@@ -0,0 +1,381 @@
+// Package arroww implements output writers for Apache Arrow
+// and Apache Parquet. Both formats are binary and columnar:
+// records are buffered into batches of typed columns (Arrow
+// record batches or Parquet row groups), which are written
+// as each batch fills.
+package arroww
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/decimal128"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/shopspring/decimal"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+)
+
+// ipcBatchSize is the number of rows per Arrow record batch.
+const ipcBatchSize = 10000
+
+// maxDecimalPrecision is the max precision of arrow.Decimal128Type.
+const maxDecimalPrecision = 38
+
+type ipcRecordWriter struct {
+	out io.Writer
+	w   *ipc.FileWriter
+	bt  *batcher
+}
+
+// NewIPCRecordWriter returns an output.RecordWriter instance for
+// the Arrow IPC file format (also known as Feather V2).
+func NewIPCRecordWriter(out io.Writer) output.RecordWriter {
+	return &ipcRecordWriter{out: out}
+}
+
+// Open implements output.RecordWriter.
+func (w *ipcRecordWriter) Open(recMeta sqlz.RecordMeta) error {
+	schema := newSchema(recMeta)
+
+	var err error
+	w.w, err = ipc.NewFileWriter(&offsetWriter{w: w.out}, ipc.WithSchema(schema))
+	if err != nil {
+		return errz.Wrap(err, "unable to create Arrow writer")
+	}
+
+	w.bt = newBatcher(recMeta, schema, ipcBatchSize, w.w.Write)
+	return nil
+}
+
+// WriteRecords implements output.RecordWriter.
+func (w *ipcRecordWriter) WriteRecords(recs []sqlz.Record) error {
+	return w.bt.appendRecords(recs)
+}
+
+// Flush implements output.RecordWriter. Note that Flush does not
+// write a partial batch: batches are written as they fill.
+func (w *ipcRecordWriter) Flush() error {
+	return nil
+}
+
+// Close implements output.RecordWriter.
+func (w *ipcRecordWriter) Close() error {
+	if w.w == nil {
+		return nil
+	}
+
+	defer w.bt.release()
+
+	err := w.bt.flush()
+	if err != nil {
+		return errz.Wrap(err, "unable to write Arrow record batch")
+	}
+
+	return errz.Wrap(w.w.Close(), "unable to write Arrow")
+}
+
+// newSchema returns the Arrow schema for recMeta. A field is
+// nullable unless the column is reported to be non-nullable.
+func newSchema(recMeta sqlz.RecordMeta) *arrow.Schema {
+	fields := make([]arrow.Field, len(recMeta))
+	for i, fm := range recMeta {
+		nullable, ok := fm.Nullable()
+		fields[i] = arrow.Field{
+			Name:     fm.Name(),
+			Type:     arrowType(fm),
+			Nullable: nullable || !ok,
+		}
+	}
+
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowType returns the Arrow type for the field's kind.
+func arrowType(fm *sqlz.FieldMeta) arrow.DataType {
+	switch fm.Kind() {
+	default:
+		// kind.Text, kind.Null, kind.Unknown
+		return arrow.BinaryTypes.String
+	case kind.Int:
+		return arrow.PrimitiveTypes.Int64
+	case kind.Float:
+		return arrow.PrimitiveTypes.Float64
+	case kind.Bool:
+		return arrow.FixedWidthTypes.Boolean
+	case kind.Bytes:
+		return arrow.BinaryTypes.Binary
+	case kind.Decimal:
+		precision, scale, ok := fm.DecimalSize()
+		if ok && precision > 0 && precision <= maxDecimalPrecision && scale >= 0 && scale <= precision {
+			return &arrow.Decimal128Type{Precision: int32(precision), Scale: int32(scale)}
+		}
+
+		// The precision and scale are not known (e.g. SQLite),
+		// so the decimal text is written as is.
+		return arrow.BinaryTypes.String
+	case kind.Datetime:
+		return arrow.FixedWidthTypes.Timestamp_us
+	case kind.Date:
+		return arrow.FixedWidthTypes.Date32
+	case kind.Time:
+		return arrow.FixedWidthTypes.Time64us
+	}
+}
+
+// batcher buffers records into an Arrow record batch, passing
+// the batch to writeFn when it contains size rows.
+type batcher struct {
+	recMeta sqlz.RecordMeta
+	schema  *arrow.Schema
+	b       *array.RecordBuilder
+	size    int
+	rows    int
+	writeFn func(rec arrow.Record) error
+}
+
+func newBatcher(recMeta sqlz.RecordMeta, schema *arrow.Schema, size int, writeFn func(rec arrow.Record) error) *batcher {
+	return &batcher{
+		recMeta: recMeta,
+		schema:  schema,
+		b:       array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		size:    size,
+		writeFn: writeFn,
+	}
+}
+
+func (bt *batcher) appendRecords(recs []sqlz.Record) error {
+	for _, rec := range recs {
+		for i, val := range rec {
+			err := appendValue(bt.b.Field(i), bt.schema.Field(i).Type, val)
+			if err != nil {
+				return errz.Wrapf(err, "column %q", bt.recMeta[i].Name())
+			}
+		}
+
+		bt.rows++
+		if bt.rows >= bt.size {
+			err := bt.flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// flush passes any buffered rows to writeFn.
+func (bt *batcher) flush() error {
+	if bt.rows == 0 {
+		return nil
+	}
+
+	rec := bt.b.NewRecord()
+	defer rec.Release()
+
+	bt.rows = 0
+	return bt.writeFn(rec)
+}
+
+func (bt *batcher) release() {
+	bt.b.Release()
+}
+
+// appendValue appends val, which is one of the sqlz.Record
+// value types, to b, converting val to typ if necessary.
+func appendValue(b array.Builder, typ arrow.DataType, val interface{}) error {
+	if val == nil {
+		b.AppendNull()
+		return nil
+	}
+
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		s, ok := stringValue(val)
+		if ok {
+			b.Append(s)
+			return nil
+		}
+	case *array.Int64Builder:
+		switch val := val.(type) {
+		case *int64:
+			b.Append(*val)
+			return nil
+		case *float64:
+			// Don't silently truncate a value such as 1.5.
+			f := *val
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return errz.Errorf("float value %v can't be converted to %s", f, typ)
+			}
+			b.Append(int64(f))
+			return nil
+		case *string:
+			i, err := strconv.ParseInt(*val, 10, 64)
+			if err != nil {
+				return errz.Err(err)
+			}
+			b.Append(i)
+			return nil
+		}
+	case *array.Float64Builder:
+		switch val := val.(type) {
+		case *float64:
+			b.Append(*val)
+			return nil
+		case *int64:
+			b.Append(float64(*val))
+			return nil
+		case *string:
+			f, err := strconv.ParseFloat(*val, 64)
+			if err != nil {
+				return errz.Err(err)
+			}
+			b.Append(f)
+			return nil
+		}
+	case *array.BooleanBuilder:
+		switch val := val.(type) {
+		case *bool:
+			b.Append(*val)
+			return nil
+		case *int64:
+			b.Append(*val != 0)
+			return nil
+		case *string:
+			v, err := stringz.ParseBool(*val)
+			if err != nil {
+				return err
+			}
+			b.Append(v)
+			return nil
+		}
+	case *array.BinaryBuilder:
+		switch val := val.(type) {
+		case *[]byte:
+			b.Append(*val)
+			return nil
+		case *string:
+			b.AppendString(*val)
+			return nil
+		}
+	case *array.Decimal128Builder:
+		s, ok := stringValue(val)
+		if ok {
+			d, err := decimal.NewFromString(s)
+			if err != nil {
+				return errz.Err(err)
+			}
+
+			scale := typ.(*arrow.Decimal128Type).Scale
+			b.Append(decimal128.FromBigInt(d.Shift(scale).Round(0).Coefficient()))
+			return nil
+		}
+	case *array.TimestampBuilder:
+		t, err := timeValue(val, stringz.DatetimeFormat, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05")
+		if err != nil {
+			return err
+		}
+		// Note that t.UnixNano overflows for years
+		// outside (approximately) 1678 to 2262.
+		b.Append(arrow.Timestamp(t.Unix()*1e6 + int64(t.Nanosecond()/1e3)))
+		return nil
+	case *array.Date32Builder:
+		t, err := timeValue(val, stringz.DateFormat)
+		if err != nil {
+			return err
+		}
+		b.Append(arrow.Date32FromTime(t))
+		return nil
+	case *array.Time64Builder:
+		t, err := timeValue(val, stringz.TimeFormat, "15:04:05.999999999")
+		if err != nil {
+			return err
+		}
+		h, m, s := t.Clock()
+		d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+			time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+		b.Append(arrow.Time64(d / time.Microsecond))
+		return nil
+	}
+
+	return errz.Errorf("unexpected value type %T", val)
+}
+
+// stringValue returns the text of val, which may be any
+// of the sqlz.Record value types.
+func stringValue(val interface{}) (s string, ok bool) {
+	switch val := val.(type) {
+	case *string:
+		return *val, true
+	case *[]byte:
+		return string(*val), true
+	case *int64:
+		return strconv.FormatInt(*val, 10), true
+	case *float64:
+		return strconv.FormatFloat(*val, 'f', -1, 64), true
+	case *bool:
+		return strconv.FormatBool(*val), true
+	case *time.Time:
+		return val.Format(stringz.DatetimeFormat), true
+	}
+
+	return "", false
+}
+
+// timeValue returns val as a time.Time. If val is a string, it
+// is parsed using the first of layouts that succeeds.
+func timeValue(val interface{}, layouts ...string) (time.Time, error) {
+	switch val := val.(type) {
+	case *time.Time:
+		return *val, nil
+	case *string:
+		var err error
+		var t time.Time
+		for _, layout := range layouts {
+			t, err = time.Parse(layout, *val)
+			if err == nil {
+				return t, nil
+			}
+		}
+		return t, errz.Err(err)
+	}
+
+	return time.Time{}, errz.Errorf("unexpected value type %T", val)
+}
+
+// offsetWriter is an io.Writer that tracks the offset of the data
+// written. It implements io.Seeker only to report the current
+// offset, as required by the Arrow IPC file writer. Note that
+// offsetWriter doesn't implement io.Closer: the Arrow and Parquet
+// writers close their destination if it's an io.Closer, but the
+// destination (typically the --output file) is closed elsewhere.
+type offsetWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+// Write implements io.Writer.
+func (w *offsetWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.offset += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (w *offsetWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return 0, errz.New("seek not supported")
+	}
+
+	return w.offset, nil
+}
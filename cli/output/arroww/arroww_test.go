@@ -0,0 +1,176 @@
+package arroww_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/cli/output/arroww"
+	"github.com/neilotoole/sq/drivers/parquet"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+)
+
+var (
+	testColNames = []string{"id", "name", "price", "amount", "ratio", "active", "data", "created", "born", "alarm"}
+	testColKinds = []kind.Kind{kind.Int, kind.Text, kind.Decimal, kind.Decimal, kind.Float, kind.Bool,
+		kind.Bytes, kind.Datetime, kind.Date, kind.Time}
+)
+
+// testRecords returns records with a column of each kind. The
+// "price" column is DECIMAL(10,2); the precision and scale of
+// the "amount" column are unknown.
+func testRecords() (sqlz.RecordMeta, []sqlz.Record) {
+	recMeta := testh.NewRecordMeta(testColNames, testColKinds)
+	recMeta[2] = sqlz.NewFieldMeta(&sqlz.ColumnTypeData{
+		Name:              "price",
+		HasNullable:       true,
+		Nullable:          true,
+		HasPrecisionScale: true,
+		Precision:         10,
+		Scale:             2,
+		DatabaseTypeName:  "DECIMAL",
+		ScanType:          testh.KindScanType(kind.Decimal),
+		Kind:              kind.Decimal,
+	})
+
+	id, name, price, amount, ratio, active := int64(7), "alice", "19.99", "3.14159", 0.5, true
+	data := []byte{1, 2}
+	created := time.Date(2021, 3, 4, 5, 6, 7, 8000, time.UTC)
+	born := time.Date(1990, 7, 14, 0, 0, 0, 0, time.UTC)
+	alarm := "06:30:00"
+
+	recs := []sqlz.Record{
+		{&id, &name, &price, &amount, &ratio, &active, &data, &created, &born, &alarm},
+		make(sqlz.Record, len(testColNames)), // all nulls
+	}
+
+	return recMeta, recs
+}
+
+func writeRecords(t *testing.T, w output.RecordWriter) {
+	recMeta, recs := testRecords()
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.WriteRecords(recs))
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Close())
+}
+
+func TestIPCRecordWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeRecords(t, arroww.NewIPCRecordWriter(buf))
+
+	r, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer r.Close()
+
+	wantTypes := []arrow.DataType{
+		arrow.PrimitiveTypes.Int64,
+		arrow.BinaryTypes.String,
+		&arrow.Decimal128Type{Precision: 10, Scale: 2},
+		arrow.BinaryTypes.String,
+		arrow.PrimitiveTypes.Float64,
+		arrow.FixedWidthTypes.Boolean,
+		arrow.BinaryTypes.Binary,
+		arrow.FixedWidthTypes.Timestamp_us,
+		arrow.FixedWidthTypes.Date32,
+		arrow.FixedWidthTypes.Time64us,
+	}
+	fields := r.Schema().Fields()
+	require.Equal(t, len(testColNames), len(fields))
+	for i := range fields {
+		require.Equal(t, testColNames[i], fields[i].Name)
+		require.True(t, arrow.TypeEqual(wantTypes[i], fields[i].Type), "field %s", fields[i].Name)
+		require.True(t, fields[i].Nullable)
+	}
+
+	require.Equal(t, 1, r.NumRecords())
+	rec, err := r.Record(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rec.NumRows())
+
+	require.Equal(t, int64(7), rec.Column(0).(*array.Int64).Value(0))
+	require.Equal(t, "alice", rec.Column(1).(*array.String).Value(0))
+	require.Equal(t, int64(1999), rec.Column(2).(*array.Decimal128).Value(0).BigInt().Int64())
+	require.Equal(t, "3.14159", rec.Column(3).(*array.String).Value(0))
+	require.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 8000, time.UTC),
+		rec.Column(7).(*array.Timestamp).Value(0).ToTime(arrow.Microsecond))
+	require.Equal(t, arrow.Time64((6*time.Hour+30*time.Minute)/time.Microsecond),
+		rec.Column(9).(*array.Time64).Value(0))
+
+	for i := range testColNames {
+		require.True(t, rec.Column(i).IsNull(1), "field %s", testColNames[i])
+	}
+}
+
+// TestIPCRecordWriter_Conversions verifies conversions that could
+// overflow or lose data.
+func TestIPCRecordWriter_Conversions(t *testing.T) {
+	recMeta := testh.NewRecordMeta([]string{"id", "created"}, []kind.Kind{kind.Int, kind.Datetime})
+
+	// The timestamp is outside the range of time.UnixNano.
+	id := 7.0
+	created := time.Date(9999, 12, 31, 23, 59, 59, 123456000, time.UTC)
+
+	buf := &bytes.Buffer{}
+	w := arroww.NewIPCRecordWriter(buf)
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.WriteRecords([]sqlz.Record{{&id, &created}}))
+	require.NoError(t, w.Close())
+
+	r, err := ipc.NewFileReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	defer r.Close()
+	rec, err := r.Record(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), rec.Column(0).(*array.Int64).Value(0))
+	require.Equal(t, arrow.Timestamp(created.Unix()*1e6+123456), rec.Column(1).(*array.Timestamp).Value(0))
+
+	// A float with a fractional part can't be written to an int column.
+	fractional := 7.5
+	w = arroww.NewIPCRecordWriter(&bytes.Buffer{})
+	require.NoError(t, w.Open(recMeta))
+	require.Error(t, w.WriteRecords([]sqlz.Record{{&fractional, &created}}))
+}
+
+func TestParquetRecordWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeRecords(t, arroww.NewParquetRecordWriter(buf))
+
+	// Read the data back via the parquet driver.
+	fpath := filepath.Join(t.TempDir(), "test.parquet")
+	require.NoError(t, ioutil.WriteFile(fpath, buf.Bytes(), 0600))
+
+	th := testh.New(t)
+	src := &source.Source{Handle: "@test_parquetw", Type: parquet.Type, Location: fpath}
+	sink, err := th.QuerySQL(src, "SELECT * FROM data")
+	require.NoError(t, err)
+
+	require.Equal(t, testColNames, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Int, kind.Text, kind.Decimal, kind.Text, kind.Float, kind.Bool,
+		kind.Bytes, kind.Datetime, kind.Date, kind.Time}, sink.RecMeta.Kinds())
+	require.Equal(t, 2, len(sink.Recs))
+
+	require.Equal(t, int64(7), testh.Val(sink.Recs[0][0]))
+	require.Equal(t, "alice", testh.Val(sink.Recs[0][1]))
+	require.Equal(t, "19.99", testh.Val(sink.Recs[0][2]))
+	require.Equal(t, "3.14159", testh.Val(sink.Recs[0][3]))
+	require.Equal(t, true, testh.Val(sink.Recs[0][5]))
+	require.Equal(t, []byte{1, 2}, testh.Val(sink.Recs[0][6]))
+	require.Equal(t, time.Date(1990, 7, 14, 0, 0, 0, 0, time.UTC), testh.Val(sink.Recs[0][8]))
+	require.Equal(t, "06:30:00", testh.Val(sink.Recs[0][9]))
+
+	for i := range testColNames {
+		require.Nil(t, sink.Recs[1][i], "field %s", testColNames[i])
+	}
+}
@@ -0,0 +1,75 @@
+package arroww
+
+import (
+	"io"
+
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/compress"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+)
+
+// parquetRowGroupSize is the number of rows per Parquet row group.
+const parquetRowGroupSize = 64 * 1024
+
+type parquetRecordWriter struct {
+	out io.Writer
+	w   *pqarrow.FileWriter
+	bt  *batcher
+}
+
+// NewParquetRecordWriter returns an output.RecordWriter instance
+// for Parquet. The data is Snappy-compressed.
+func NewParquetRecordWriter(out io.Writer) output.RecordWriter {
+	return &parquetRecordWriter{out: out}
+}
+
+// Open implements output.RecordWriter.
+func (w *parquetRecordWriter) Open(recMeta sqlz.RecordMeta) error {
+	schema := newSchema(recMeta)
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	// Storing the Arrow schema in the Parquet metadata means that
+	// Arrow-based readers (e.g. pandas) get the exact types back.
+	arrProps := pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())
+
+	var err error
+	w.w, err = pqarrow.NewFileWriter(schema, &offsetWriter{w: w.out}, props, arrProps)
+	if err != nil {
+		return errz.Wrap(err, "unable to create Parquet writer")
+	}
+
+	// Each batch is written as a row group.
+	w.bt = newBatcher(recMeta, schema, parquetRowGroupSize, w.w.Write)
+	return nil
+}
+
+// WriteRecords implements output.RecordWriter.
+func (w *parquetRecordWriter) WriteRecords(recs []sqlz.Record) error {
+	return w.bt.appendRecords(recs)
+}
+
+// Flush implements output.RecordWriter. Note that Flush does not
+// write a partial row group: row groups are written as they fill.
+func (w *parquetRecordWriter) Flush() error {
+	return nil
+}
+
+// Close implements output.RecordWriter.
+func (w *parquetRecordWriter) Close() error {
+	if w.w == nil {
+		return nil
+	}
+
+	defer w.bt.release()
+
+	err := w.bt.flush()
+	if err != nil {
+		return errz.Wrap(err, "unable to write Parquet row group")
+	}
+
+	return errz.Wrap(w.w.Close(), "unable to write Parquet")
+}
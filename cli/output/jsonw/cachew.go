@@ -0,0 +1,29 @@
+package jsonw
+
+import (
+	"io"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// cacheWriter implements output.CacheWriter for JSON.
+type cacheWriter struct {
+	mdw *mdWriter
+}
+
+// NewCacheWriter returns a new output.CacheWriter instance
+// that outputs import cache details in JSON.
+func NewCacheWriter(out io.Writer, fm *output.Formatting) output.CacheWriter {
+	return &cacheWriter{mdw: &mdWriter{out: out, fm: fm}}
+}
+
+// CacheEntries implements output.CacheWriter.
+func (w *cacheWriter) CacheEntries(entries []*driver.ImportCacheEntry) error {
+	if entries == nil {
+		// Output an empty array rather than null.
+		entries = []*driver.ImportCacheEntry{}
+	}
+
+	return w.mdw.write(entries)
+}
@@ -0,0 +1,146 @@
+package jsonw
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/cli/output/jsonw/internal"
+	jcolorenc "github.com/neilotoole/sq/cli/output/jsonw/internal/jcolorenc"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/diff"
+)
+
+// diffWriter implements output.DiffWriter for JSON. For a data
+// diff, the row diffs are streamed as they are received, as elements of the
+// "diffs" array, followed by the "summary" object. For example:
+//
+//   {
+//     "diffs": [
+//       {"status": "changed", "key": [5], "cols": [...]}
+//     ],
+//     "summary": {...}
+//   }
+type diffWriter struct {
+	out   io.Writer
+	fm    *output.Formatting
+	count int
+}
+
+// NewDiffWriter returns a new output.DiffWriter instance
+// that outputs diffs in JSON.
+func NewDiffWriter(out io.Writer, fm *output.Formatting) output.DiffWriter {
+	return &diffWriter{out: out, fm: fm}
+}
+
+// Open implements output.DiffWriter.
+func (w *diffWriter) Open(summary *diff.DataSummary) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString(w.fm.Punc.Sprint("{"))
+	w.newline(buf, 1)
+	buf.WriteString(w.fm.Key.Sprint(`"diffs"`))
+	buf.WriteString(w.fm.Punc.Sprint(":"))
+	if w.fm.Pretty {
+		buf.WriteString(" ")
+	}
+	buf.WriteString(w.fm.Punc.Sprint("["))
+
+	return w.flush(buf)
+}
+
+// RowDiff implements output.DiffWriter.
+func (w *diffWriter) RowDiff(rd *diff.RowDiff) error {
+	buf := &bytes.Buffer{}
+	if w.count > 0 {
+		buf.WriteString(w.fm.Punc.Sprint(","))
+	}
+	w.count++
+
+	w.newline(buf, 2)
+	err := w.encode(buf, rd, 2)
+	if err != nil {
+		return err
+	}
+
+	return w.flush(buf)
+}
+
+// Close implements output.DiffWriter.
+func (w *diffWriter) Close(summary *diff.DataSummary) error {
+	buf := &bytes.Buffer{}
+	if w.count > 0 {
+		w.newline(buf, 1)
+	}
+	buf.WriteString(w.fm.Punc.Sprint("]"))
+	buf.WriteString(w.fm.Punc.Sprint(","))
+	w.newline(buf, 1)
+	buf.WriteString(w.fm.Key.Sprint(`"summary"`))
+	buf.WriteString(w.fm.Punc.Sprint(":"))
+	if w.fm.Pretty {
+		buf.WriteString(" ")
+	}
+
+	err := w.encode(buf, summary, 1)
+	if err != nil {
+		return err
+	}
+
+	w.newline(buf, 0)
+	buf.WriteString(w.fm.Punc.Sprint("}"))
+	buf.WriteString("\n")
+	return w.flush(buf)
+}
+
+// SchemaDiff implements output.DiffWriter.
+func (w *diffWriter) SchemaDiff(sd *diff.SchemaDiff) error {
+	buf := &bytes.Buffer{}
+	err := w.encode(buf, sd, 0)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString("\n")
+	return w.flush(buf)
+}
+
+// encode writes the JSON encoding of v to buf, indented to
+// the specified depth. The trailing newline is not written.
+func (w *diffWriter) encode(buf *bytes.Buffer, v interface{}, depth int) error {
+	encBuf := &bytes.Buffer{}
+	enc := jcolorenc.NewEncoder(encBuf)
+	enc.SetColors(internal.NewColors(w.fm))
+	enc.SetEscapeHTML(false)
+	if w.fm.Pretty {
+		enc.SetIndent(w.indent(depth), w.fm.Indent)
+	}
+
+	err := enc.Encode(v)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	buf.Write(bytes.TrimRight(encBuf.Bytes(), "\n"))
+	return nil
+}
+
+// newline writes a newline and indent of depth to buf,
+// if pretty-printing.
+func (w *diffWriter) newline(buf *bytes.Buffer, depth int) {
+	if !w.fm.Pretty {
+		return
+	}
+
+	buf.WriteString("\n")
+	buf.WriteString(w.indent(depth))
+}
+
+func (w *diffWriter) indent(depth int) string {
+	return strings.Repeat(w.fm.Indent, depth)
+}
+
+func (w *diffWriter) flush(buf *bytes.Buffer) error {
+	_, err := fmt.Fprint(w.out, buf.String())
+	return errz.Err(err)
+}
@@ -0,0 +1,29 @@
+package jsonw
+
+import (
+	"io"
+
+	"github.com/neilotoole/sq/cli/config"
+	"github.com/neilotoole/sq/cli/output"
+)
+
+// queryWriter implements output.QueryWriter for JSON.
+type queryWriter struct {
+	mdw *mdWriter
+}
+
+// NewQueryWriter returns a new output.QueryWriter instance
+// that outputs saved query details in JSON.
+func NewQueryWriter(out io.Writer, fm *output.Formatting) output.QueryWriter {
+	return &queryWriter{mdw: &mdWriter{out: out, fm: fm}}
+}
+
+// Queries implements output.QueryWriter.
+func (w *queryWriter) Queries(queries config.Queries) error {
+	if queries == nil {
+		// Output an empty array rather than null.
+		queries = config.Queries{}
+	}
+
+	return w.mdw.write(queries)
+}
@@ -0,0 +1,44 @@
+package tablew
+
+import (
+	"io"
+	"time"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+type cacheWriter struct {
+	tbl *table
+}
+
+// NewCacheWriter returns a cache writer that outputs import
+// cache details in text table format.
+func NewCacheWriter(out io.Writer, fm *output.Formatting, header bool) output.CacheWriter {
+	tbl := &table{out: out, fm: fm, header: header}
+	w := &cacheWriter{tbl: tbl}
+	w.tbl.reset()
+	return w
+}
+
+// CacheEntries implements output.CacheWriter.
+func (w *cacheWriter) CacheEntries(entries []*driver.ImportCacheEntry) error {
+	var rows [][]string
+	for _, entry := range entries {
+		row := []string{
+			entry.Handle,
+			string(entry.Type),
+			entry.Location,
+			stringz.ByteSized(entry.Size, 1, ""),
+			entry.Created.Format(time.RFC3339),
+		}
+		rows = append(rows, row)
+	}
+
+	w.tbl.tblImpl.SetHeaderDisable(!w.tbl.header)
+	w.tbl.tblImpl.SetColTrans(0, w.tbl.fm.Handle.SprintFunc())
+	w.tbl.tblImpl.SetHeader([]string{"SOURCE", "DRIVER", "LOCATION", "SIZE", "CREATED"})
+	w.tbl.appendRowsAndRenderAll(rows)
+	return nil
+}
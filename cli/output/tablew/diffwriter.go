@@ -0,0 +1,155 @@
+package tablew
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/diff"
+)
+
+// diffWriter implements output.DiffWriter.
+type diffWriter struct {
+	tbl *table
+	key []string
+}
+
+// NewDiffWriter returns a diff writer that outputs diffs
+// in text format. A data diff is followed by a summary table.
+func NewDiffWriter(out io.Writer, fm *output.Formatting, header bool) output.DiffWriter {
+	tbl := &table{out: out, fm: fm, header: header}
+	w := &diffWriter{tbl: tbl}
+	w.tbl.reset()
+	return w
+}
+
+// Open implements output.DiffWriter.
+func (w *diffWriter) Open(summary *diff.DataSummary) error {
+	w.key = summary.Key
+
+	fm, out := w.tbl.fm, w.tbl.out
+	fm.Error.Fprint(out, "---")
+	fmt.Fprint(out, " ")
+	fm.Handle.Fprintln(out, summary.Left)
+	fm.Success.Fprint(out, "+++")
+	fmt.Fprint(out, " ")
+	fm.Handle.Fprintln(out, summary.Right)
+	return nil
+}
+
+// RowDiff implements output.DiffWriter.
+func (w *diffWriter) RowDiff(rd *diff.RowDiff) error {
+	fm, out := w.tbl.fm, w.tbl.out
+
+	w.fprintStatus(rd.Status, 0)
+
+	keyVals := make([]string, len(rd.Key))
+	for i := range rd.Key {
+		keyVals[i] = fm.Key.Sprint(w.key[i]) + fm.Punc.Sprint("=") + w.sprintVal(rd.Key[i])
+	}
+	fmt.Fprintln(out, strings.Join(keyVals, " "))
+
+	if rd.Status != diff.StatusChanged {
+		return nil
+	}
+
+	for _, col := range rd.Cols {
+		fmt.Fprintf(out, "    %s%s %s %s %s\n", fm.Key.Sprint(col.Name), fm.Punc.Sprint(":"),
+			w.sprintVal(col.Left), fm.Faint.Sprint("→"), w.sprintVal(col.Right))
+	}
+
+	return nil
+}
+
+// Close implements output.DiffWriter.
+func (w *diffWriter) Close(summary *diff.DataSummary) error {
+	fmt.Fprintln(w.tbl.out)
+
+	row := []string{
+		summary.Left,
+		summary.Right,
+		strings.Join(summary.Key, ","),
+		strconv.FormatInt(summary.LeftRows, 10),
+		strconv.FormatInt(summary.RightRows, 10),
+		strconv.FormatInt(summary.Same, 10),
+		strconv.FormatInt(summary.Changed, 10),
+		strconv.FormatInt(summary.Added, 10),
+		strconv.FormatInt(summary.Removed, 10),
+	}
+
+	w.tbl.tblImpl.SetHeaderDisable(!w.tbl.header)
+	w.tbl.tblImpl.SetColTrans(0, w.tbl.fm.Handle.SprintFunc())
+	w.tbl.tblImpl.SetColTrans(1, w.tbl.fm.Handle.SprintFunc())
+	w.tbl.tblImpl.SetHeader([]string{"LEFT", "RIGHT", "KEY", "LEFT_ROWS", "RIGHT_ROWS", "SAME", "CHANGED", "ADDED", "REMOVED"})
+	w.tbl.appendRowsAndRenderAll([][]string{row})
+	return nil
+}
+
+// SchemaDiff implements output.DiffWriter. Consistent with
+// diff(1), nothing is output if there are no differences.
+func (w *diffWriter) SchemaDiff(sd *diff.SchemaDiff) error {
+	if !sd.Differs() {
+		return nil
+	}
+
+	fm, out := w.tbl.fm, w.tbl.out
+	fm.Error.Fprint(out, "---")
+	fmt.Fprint(out, " ")
+	fm.Handle.Fprintln(out, sd.Left)
+	fm.Success.Fprint(out, "+++")
+	fmt.Fprint(out, " ")
+	fm.Handle.Fprintln(out, sd.Right)
+
+	for _, td := range sd.Tables {
+		w.fprintStatus(td.Status, 0)
+		fmt.Fprintf(out, "table %s\n", fm.Bold.Sprint(td.Name))
+
+		for _, col := range td.Cols {
+			w.fprintStatus(col.Status, 1)
+			fmt.Fprintf(out, "column %s\n", fm.Bold.Sprint(col.Name))
+
+			for _, change := range col.Changes {
+				fmt.Fprintf(out, "        %s%s %s %s %s\n", fm.Key.Sprint(change.Field), fm.Punc.Sprint(":"),
+					w.sprintVal(change.Left), fm.Faint.Sprint("→"), w.sprintVal(change.Right))
+			}
+		}
+	}
+
+	return nil
+}
+
+// fprintStatus prints the status symbol, indented to depth.
+func (w *diffWriter) fprintStatus(status diff.Status, depth int) {
+	fm, out := w.tbl.fm, w.tbl.out
+	fmt.Fprint(out, strings.Repeat("    ", depth))
+
+	switch status {
+	case diff.StatusRemoved:
+		fm.Error.Fprint(out, "-")
+	case diff.StatusAdded:
+		fm.Success.Fprint(out, "+")
+	default:
+		fm.Hilite.Fprint(out, "~")
+	}
+
+	fmt.Fprint(out, " ")
+}
+
+func (w *diffWriter) sprintVal(val interface{}) string {
+	switch val := val.(type) {
+	case string:
+		return w.tbl.fm.String.Sprint(val)
+	case time.Time:
+		return w.tbl.fm.Datetime.Sprint(val.Format(stringz.DatetimeFormat))
+	case fmt.Stringer:
+		// For example, kind.Kind.
+		return w.tbl.fm.String.Sprint(val.String())
+	default:
+		return w.tbl.renderResultCell(kind.Unknown, val)
+	}
+}
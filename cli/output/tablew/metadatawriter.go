@@ -28,12 +28,28 @@ func NewMetadataWriter(out io.Writer, fm *output.Formatting) output.MetadataWrit
 // DriverMetadata implements output.MetadataWriter.
 func (w *mdWriter) DriverMetadata(drvrs []driver.Metadata) error {
 	headers := []string{"DRIVER", "DESCRIPTION", "USER-DEFINED", "DOC"}
+
+	// The FILE column is only shown if there are user drivers.
+	var showFile bool
+	for _, md := range drvrs {
+		if md.File != "" {
+			showFile = true
+			break
+		}
+	}
+	if showFile {
+		headers = append(headers, "FILE")
+	}
+
 	w.tbl.tblImpl.SetHeader(headers)
 	w.tbl.tblImpl.SetColTrans(2, w.tbl.fm.Bool.SprintFunc())
 
 	var rows [][]string
 	for _, md := range drvrs {
 		row := []string{string(md.Type), md.Description, strconv.FormatBool(md.UserDefined), md.Doc}
+		if showFile {
+			row = append(row, md.File)
+		}
 		rows = append(rows, row)
 	}
 	w.tbl.appendRowsAndRenderAll(rows)
@@ -156,5 +172,28 @@ func (w *mdWriter) SourceMetadata(meta *source.Metadata) error {
 	w.tbl.tblImpl.SetColTrans(4, w.tbl.fm.Number.SprintFunc())
 
 	w.tbl.appendRowsAndRenderAll(rows)
+	w.foreignKeys(meta.Tables)
 	return nil
 }
+
+// foreignKeys renders the foreign keys of tbls, if any.
+func (w *mdWriter) foreignKeys(tbls []*source.TableMetadata) {
+	var rows [][]string
+	for _, tbl := range tbls {
+		for _, col := range tbl.Columns {
+			if col.ForeignKey != nil {
+				rows = append(rows, []string{tbl.Name, col.Name, col.ForeignKey.String()})
+			}
+		}
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	w.tbl.reset()
+	fmt.Fprintln(w.tbl.out)
+	w.tbl.tblImpl.SetHeader([]string{"TABLE", "COLUMN", "REFERENCES"})
+	w.tbl.tblImpl.SetColTrans(0, w.tbl.fm.Handle.SprintFunc())
+	w.tbl.appendRowsAndRenderAll(rows)
+}
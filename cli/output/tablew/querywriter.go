@@ -0,0 +1,48 @@
+package tablew
+
+import (
+	"io"
+
+	"github.com/neilotoole/sq/cli/config"
+	"github.com/neilotoole/sq/cli/output"
+)
+
+type queryWriter struct {
+	tbl *table
+}
+
+// NewQueryWriter returns a query writer that outputs saved
+// query details in text table format.
+func NewQueryWriter(out io.Writer, fm *output.Formatting, header bool) output.QueryWriter {
+	tbl := &table{out: out, fm: fm, header: header}
+	w := &queryWriter{tbl: tbl}
+	w.tbl.reset()
+	return w
+}
+
+// Queries implements output.QueryWriter.
+func (w *queryWriter) Queries(queries config.Queries) error {
+	var rows [][]string
+	for _, query := range queries {
+		typ := "slq"
+		if query.SQL {
+			typ = "sql"
+		}
+
+		row := []string{
+			query.Name,
+			typ,
+			query.Handle,
+			string(query.Format),
+			query.Query,
+		}
+		rows = append(rows, row)
+	}
+
+	w.tbl.tblImpl.SetHeaderDisable(!w.tbl.header)
+	w.tbl.tblImpl.SetColTrans(0, w.tbl.fm.Bold.SprintFunc())
+	w.tbl.tblImpl.SetColTrans(2, w.tbl.fm.Handle.SprintFunc())
+	w.tbl.tblImpl.SetHeader([]string{"NAME", "TYPE", "SOURCE", "FORMAT", "QUERY"})
+	w.tbl.appendRowsAndRenderAll(rows)
+	return nil
+}
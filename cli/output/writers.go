@@ -9,7 +9,9 @@ package output
 import (
 	"time"
 
+	"github.com/neilotoole/sq/cli/config"
 	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/diff"
 	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/notify"
 	"github.com/neilotoole/sq/libsq/source"
@@ -41,6 +43,21 @@ type RecordWriter interface {
 	Close() error
 }
 
+// MultiRecordWriter writes multiple named result sets, such as
+// each of the tables of a source, to a single destination. For
+// example, the XLSX writer writes each result set to a separate
+// sheet of a workbook.
+type MultiRecordWriter interface {
+	// NewRecordWriter returns a RecordWriter for the result set
+	// identified by name. The returned writer must be closed
+	// before NewRecordWriter is invoked again.
+	NewRecordWriter(name string) (RecordWriter, error)
+
+	// Close writes the destination. It must be invoked when
+	// all of the result sets are written.
+	Close() error
+}
+
 // MetadataWriter can output metadata.
 type MetadataWriter interface {
 	// TableMetadata writes the table metadata.
@@ -62,6 +79,36 @@ type SourceWriter interface {
 	Source(src *source.Source) error
 }
 
+// QueryWriter can output saved query details.
+type QueryWriter interface {
+	// Queries outputs details of the saved queries.
+	Queries(queries config.Queries) error
+}
+
+// CacheWriter can output import cache details.
+type CacheWriter interface {
+	// CacheEntries outputs details of the import cache entries.
+	CacheEntries(entries []*driver.ImportCacheEntry) error
+}
+
+// DiffWriter outputs the differences between tables or source
+// schemas. It implements diff.DataHandler.
+type DiffWriter interface {
+	// Open is invoked before any row differences are written.
+	// The summary counts are not yet populated.
+	Open(summary *diff.DataSummary) error
+
+	// RowDiff outputs a row that differs between the tables.
+	RowDiff(rd *diff.RowDiff) error
+
+	// Close outputs the summary, after all row differences
+	// have been written.
+	Close(summary *diff.DataSummary) error
+
+	// SchemaDiff outputs the schema differences between sources.
+	SchemaDiff(sd *diff.SchemaDiff) error
+}
+
 // NotificationWriter outputs notification destination details.
 type NotificationWriter interface {
 	// NotifyDestinations outputs details of the notification
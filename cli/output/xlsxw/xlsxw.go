@@ -1,56 +1,181 @@
 // Package xlsxw implements output writers for Microsoft Excel.
+// Cells are typed per the kind of their column: numbers, booleans,
+// and dates/times are written as such (with a number format), as
+// opposed to as text.
 package xlsxw
 
 import (
 	"io"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/neilotoole/sq/libsq/core/kind"
-	"github.com/neilotoole/sq/libsq/core/stringz"
-
-	"github.com/neilotoole/sq/cli/output"
+	"unicode/utf8"
 
 	"github.com/tealeg/xlsx/v2"
 
+	"github.com/neilotoole/sq/cli/output"
 	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
 	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
-type recordWriter struct {
-	recMeta sqlz.RecordMeta
-	out     io.Writer
-	header  bool
-	xfile   *xlsx.File
-	sheet   *xlsx.Sheet
+const (
+	// maxSheetNameLen is the max length of an Excel sheet name.
+	maxSheetNameLen = 31
+
+	// minColWidth and maxColWidth bound the width (in characters)
+	// of an auto-sized column.
+	minColWidth = 6
+	maxColWidth = 80
+)
+
+// Formats holds the Excel number formats applied to cells
+// by kind, e.g. "yyyy-mm-dd" for kind.Date. A format
+// of "general" is Excel's default format.
+type Formats struct {
+	Int      string
+	Float    string
+	Decimal  string
+	Datetime string
+	Date     string
+	Time     string
 }
 
-// NewRecordWriter returns an output.RecordWriter instance for XLSX.
-func NewRecordWriter(out io.Writer, header bool) output.RecordWriter {
-	return &recordWriter{out: out, header: header}
+// DefaultFormats returns the default Formats.
+func DefaultFormats() Formats {
+	return Formats{
+		Int:      "0",
+		Float:    "general",
+		Decimal:  "general",
+		Datetime: "yyyy-mm-dd hh:mm:ss",
+		Date:     "yyyy-mm-dd",
+		Time:     "hh:mm:ss",
+	}
 }
 
-// Open implements output.RecordWriter.
-func (w *recordWriter) Open(recMeta sqlz.RecordMeta) error {
-	w.recMeta = recMeta
-	w.xfile = xlsx.NewFile()
+// Workbook writes each of multiple result sets to a separate
+// sheet of a single XLSX workbook. It implements
+// output.MultiRecordWriter.
+type Workbook struct {
+	out         io.Writer
+	header      bool
+	formats     Formats
+	xfile       *xlsx.File
+	headerStyle *xlsx.Style
+}
+
+var _ output.MultiRecordWriter = (*Workbook)(nil)
 
-	sheet, err := w.xfile.AddSheet("data")
+// NewWorkbook returns a new Workbook that writes to out when
+// closed. If header is true, the first row of each sheet
+// contains the column names.
+func NewWorkbook(out io.Writer, header bool, formats Formats) *Workbook {
+	headerStyle := xlsx.NewStyle()
+	headerStyle.Font.Bold = true
+	headerStyle.ApplyFont = true
+
+	return &Workbook{
+		out:         out,
+		header:      header,
+		formats:     formats,
+		xfile:       xlsx.NewFile(),
+		headerStyle: headerStyle,
+	}
+}
+
+// NewRecordWriter implements output.MultiRecordWriter. The
+// records are written to a new sheet named for name. If name
+// is not a valid sheet name, it is munged to be so.
+func (wb *Workbook) NewRecordWriter(name string) (output.RecordWriter, error) {
+	sheet, err := wb.xfile.AddSheet(wb.sheetName(name))
 	if err != nil {
-		return errz.Wrap(err, "unable to create XLSX sheet")
+		return nil, errz.Wrap(err, "unable to create XLSX sheet")
 	}
 
-	w.sheet = sheet
+	return &sheetWriter{wb: wb, sheet: sheet}, nil
+}
 
-	if w.header {
-		headerRow := w.sheet.AddRow()
+// Close implements output.MultiRecordWriter.
+func (wb *Workbook) Close() error {
+	err := wb.xfile.Write(wb.out)
+	if err != nil {
+		return errz.Wrap(err, "unable to write XLSX")
+	}
+	return nil
+}
 
-		for _, colName := range w.recMeta.Names() {
-			cell := headerRow.AddCell()
-			cell.SetString(colName)
+// sheetName returns a valid sheet name derived from name:
+// Excel forbids some chars, limits the length of the name,
+// and sheet names must be unique (case-insensitive).
+func (wb *Workbook) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '\\', '/', '?', '*', '[', ']':
+			return '_'
+		default:
+			return r
 		}
+	}, name)
+
+	if name == "" {
+		name = "sheet"
 	}
 
-	return nil
+	name = truncate(name, maxSheetNameLen)
+
+	unique := name
+	for i := 2; wb.hasSheet(unique); i++ {
+		suffix := "_" + strconv.Itoa(i)
+		unique = truncate(name, maxSheetNameLen-len(suffix)) + suffix
+	}
+
+	return unique
+}
+
+func (wb *Workbook) hasSheet(name string) bool {
+	for _, sheet := range wb.xfile.Sheets {
+		if strings.EqualFold(sheet.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate returns s truncated to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// recordWriter writes the records to a sheet named "data" of
+// a single-sheet workbook.
+type recordWriter struct {
+	wb *Workbook
+	sw *sheetWriter
+}
+
+// NewRecordWriter returns an output.RecordWriter instance for XLSX.
+func NewRecordWriter(out io.Writer, header bool, formats Formats) output.RecordWriter {
+	return &recordWriter{wb: NewWorkbook(out, header, formats)}
+}
+
+// Open implements output.RecordWriter.
+func (w *recordWriter) Open(recMeta sqlz.RecordMeta) error {
+	recw, err := w.wb.NewRecordWriter("data")
+	if err != nil {
+		return err
+	}
+
+	w.sw = recw.(*sheetWriter)
+	return w.sw.Open(recMeta)
+}
+
+// WriteRecords implements output.RecordWriter.
+func (w *recordWriter) WriteRecords(recs []sqlz.Record) error {
+	return w.sw.WriteRecords(recs)
 }
 
 // Flush implements output.RecordWriter.
@@ -60,51 +185,205 @@ func (w *recordWriter) Flush() error {
 
 // Close implements output.RecordWriter.
 func (w *recordWriter) Close() error {
-	err := w.xfile.Write(w.out)
+	if w.sw == nil {
+		return nil
+	}
+
+	err := w.sw.Close()
 	if err != nil {
-		return errz.Wrap(err, "unable to write XLSX")
+		return err
 	}
+
+	return w.wb.Close()
+}
+
+// sheetWriter writes records to a sheet of a Workbook. Note
+// that Close does not write the workbook.
+type sheetWriter struct {
+	wb      *Workbook
+	sheet   *xlsx.Sheet
+	recMeta sqlz.RecordMeta
+
+	// widths holds the max width of each column's values,
+	// which is used to size the columns.
+	widths []int
+}
+
+// Open implements output.RecordWriter.
+func (w *sheetWriter) Open(recMeta sqlz.RecordMeta) error {
+	w.recMeta = recMeta
+	w.widths = make([]int, len(recMeta))
+
+	if !w.wb.header {
+		return nil
+	}
+
+	headerRow := w.sheet.AddRow()
+	for i, colName := range recMeta.Names() {
+		cell := headerRow.AddCell()
+		cell.SetString(colName)
+		cell.SetStyle(w.wb.headerStyle)
+		w.widths[i] = utf8.RuneCountInString(colName)
+	}
+
+	// Freeze the header row, so that it remains visible
+	// when scrolling.
+	w.sheet.SheetViews = []xlsx.SheetView{{Pane: &xlsx.Pane{
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+		State:       "frozen",
+	}}}
+
 	return nil
 }
 
 // WriteRecords implements output.RecordWriter.
-func (w *recordWriter) WriteRecords(recs []sqlz.Record) error {
+func (w *sheetWriter) WriteRecords(recs []sqlz.Record) error {
 	for _, rec := range recs {
 		row := w.sheet.AddRow()
 
 		for i, val := range rec {
-			cell := row.AddCell()
-
-			switch val := val.(type) {
-			case nil:
-			case *[]byte:
-				cell.SetValue(*val)
-			case *string:
-				cell.SetString(*val)
-			case *bool:
-				cell.SetBool(*val)
-			case *int64:
-				cell.SetInt64(*val)
-			case *float64:
-				cell.SetFloat(*val)
-			case *time.Time:
-				switch w.recMeta[i].Kind() {
-				default:
-					cell.SetDateTime(*val)
-				case kind.Date:
-					cell.SetDate(*val)
-				case kind.Time:
-					// TODO: Maybe there's a way of setting a specific
-					//  time (as opposed to date or datetime) value, but
-					//  for now we just use a string.
-					cell.SetValue(val.Format(stringz.TimeFormat))
-				}
-			default:
-				// should never happen
-				cell.SetValue(val)
+			width := w.setCell(row.AddCell(), w.recMeta[i].Kind(), val)
+			if width > w.widths[i] {
+				w.widths[i] = width
 			}
 		}
 	}
 
 	return nil
 }
+
+// Flush implements output.RecordWriter.
+func (w *sheetWriter) Flush() error {
+	return nil
+}
+
+// Close implements output.RecordWriter. The columns are
+// sized to fit their content.
+func (w *sheetWriter) Close() error {
+	for i, width := range w.widths {
+		width += 2 // allow for padding and the filter button
+		if width < minColWidth {
+			width = minColWidth
+		}
+		if width > maxColWidth {
+			width = maxColWidth
+		}
+
+		w.sheet.SetColWidth(i+1, i+1, float64(width))
+	}
+
+	return nil
+}
+
+// setCell sets the cell's value to val, which is a value of a column
+// of kind knd. The returned width is the (approximate) display width
+// of the value.
+func (w *sheetWriter) setCell(cell *xlsx.Cell, knd kind.Kind, val interface{}) (width int) {
+	formats := w.wb.formats
+
+	switch val := val.(type) {
+	case nil:
+		return 0
+	case *[]byte:
+		cell.SetValue(*val)
+		return len(cell.Value)
+	case *bool:
+		cell.SetBool(*val)
+		return len("FALSE")
+	case *int64:
+		cell.SetInt64(*val)
+		cell.SetFormat(formats.Int)
+		return len(cell.Value)
+	case *float64:
+		cell.SetFloatWithFormat(*val, formats.Float)
+		return len(cell.Value)
+	case *time.Time:
+		return setTimeCell(cell, knd, *val, formats)
+	case *string:
+		switch knd {
+		case kind.Decimal, kind.Int, kind.Float:
+			// A decimal value is a string (to preserve its precision),
+			// but it's written as a number as long as it is valid.
+			if _, err := strconv.ParseFloat(*val, 64); err == nil {
+				cell.SetNumeric(*val)
+				if knd == kind.Int {
+					cell.SetFormat(formats.Int)
+				} else {
+					cell.SetFormat(formats.Decimal)
+				}
+				return len(*val)
+			}
+		case kind.Datetime, kind.Date, kind.Time:
+			if t, ok := parseTime(knd, *val); ok {
+				return setTimeCell(cell, knd, t, formats)
+			}
+		}
+
+		cell.SetString(*val)
+		return utf8.RuneCountInString(*val)
+	default:
+		// should never happen
+		cell.SetValue(val)
+		return len(cell.Value)
+	}
+}
+
+// setTimeCell sets the cell's value to an Excel date or time,
+// with the format for knd.
+func setTimeCell(cell *xlsx.Cell, knd kind.Kind, t time.Time, formats Formats) (width int) {
+	// Excel dates have no time zone: the wall clock time is written.
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+
+	midnight := time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, time.UTC)
+
+	// An Excel date is the number of days since the epoch, and
+	// an Excel time is the fraction of the day. Note that the days
+	// are computed from Unix seconds: a time.Duration would overflow
+	// for dates more than ~292 years after the epoch.
+	days := float64((midnight.Unix() - excelEpoch.Unix()) / 86400)
+	dayFraction := float64(wall.Sub(midnight)) / float64(24*time.Hour)
+
+	var format string
+	switch knd {
+	default:
+		format = formats.Datetime
+		cell.SetDateTimeWithFormat(days+dayFraction, format)
+	case kind.Date:
+		format = formats.Date
+		cell.SetDateTimeWithFormat(days, format)
+	case kind.Time:
+		format = formats.Time
+		cell.SetDateTimeWithFormat(dayFraction, format)
+	}
+
+	return len(format)
+}
+
+// excelEpoch is the epoch of Excel dates (in the 1900 date system,
+// allowing for Excel's 1900 leap year bug).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// parseTime parses s, which is a value of a column of kind
+// knd, in the formats used by sq.
+func parseTime(knd kind.Kind, s string) (t time.Time, ok bool) {
+	var layouts []string
+	switch knd {
+	case kind.Date:
+		layouts = []string{stringz.DateFormat}
+	case kind.Time:
+		layouts = []string{stringz.TimeFormat, "15:04:05.999999999"}
+	default:
+		layouts = []string{stringz.DatetimeFormat, "2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05"}
+	}
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return t, false
+}
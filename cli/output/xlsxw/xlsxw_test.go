@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/neilotoole/sq/testh/testsrc"
 
@@ -11,6 +12,8 @@ import (
 
 	"github.com/tealeg/xlsx/v2"
 
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
 	"github.com/neilotoole/sq/testh"
 	"github.com/neilotoole/sq/testh/sakila"
 	"github.com/stretchr/testify/require"
@@ -39,7 +42,7 @@ func TestRecordWriter(t *testing.T) {
 			}
 
 			buf := &bytes.Buffer{}
-			w := xlsxw.NewRecordWriter(buf, true)
+			w := xlsxw.NewRecordWriter(buf, true, xlsxw.DefaultFormats())
 			require.NoError(t, w.Open(recMeta))
 
 			require.NoError(t, w.WriteRecords(recs))
@@ -52,6 +55,122 @@ func TestRecordWriter(t *testing.T) {
 	}
 }
 
+func TestRecordWriter_Types(t *testing.T) {
+	recMeta := testh.NewRecordMeta(
+		[]string{"id", "price", "ratio", "active", "created", "born", "alarm", "name"},
+		[]kind.Kind{kind.Int, kind.Decimal, kind.Float, kind.Bool, kind.Datetime, kind.Date, kind.Time, kind.Text})
+
+	id, price, ratio, active := int64(7), "19.99", 0.5, true
+	created := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	born := time.Date(1990, 7, 14, 0, 0, 0, 0, time.UTC)
+	alarm, name := "06:00:00", "a long name for column width"
+	recs := []sqlz.Record{
+		{&id, &price, &ratio, &active, &created, &born, &alarm, &name},
+		make(sqlz.Record, len(recMeta)),
+	}
+
+	formats := xlsxw.DefaultFormats()
+	formats.Date = "dd/mm/yyyy"
+
+	buf := &bytes.Buffer{}
+	w := xlsxw.NewRecordWriter(buf, true, formats)
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.WriteRecords(recs))
+	require.NoError(t, w.Close())
+
+	xfile, err := xlsx.OpenBinary(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(xfile.Sheets))
+	sheet := xfile.Sheets[0]
+	require.Equal(t, "data", sheet.Name)
+	require.Equal(t, 3, len(sheet.Rows))
+
+	header := sheet.Rows[0].Cells
+	require.Equal(t, "id", header[0].String())
+	require.True(t, header[0].GetStyle().Font.Bold)
+
+	cells := sheet.Rows[1].Cells
+	require.Equal(t, xlsx.CellTypeNumeric, cells[0].Type())
+	require.Equal(t, "7", cells[0].Value)
+	require.Equal(t, xlsx.CellTypeNumeric, cells[1].Type())
+	require.Equal(t, "19.99", cells[1].Value)
+	require.Equal(t, xlsx.CellTypeNumeric, cells[2].Type())
+	require.Equal(t, xlsx.CellTypeBool, cells[3].Type())
+	require.True(t, cells[3].Bool())
+
+	require.True(t, cells[4].IsTime())
+	// Excel date serial: days since 1899-12-30, plus the fraction of the day.
+	require.InDelta(t, 44259+(5*3600+6*60+7)/86400.0, mustFloat(t, cells[4]), 1e-9)
+	require.Equal(t, "14/07/1990", cells[5].String())
+	require.Equal(t, "06:00:00", cells[6].String())
+	require.Equal(t, 0.25, mustFloat(t, cells[6]))
+	require.Equal(t, xlsx.CellTypeString, cells[7].Type())
+
+	for _, cell := range sheet.Rows[2].Cells {
+		require.Equal(t, "", cell.Value)
+	}
+
+	// The "name" column is sized to fit its widest value.
+	require.Equal(t, float64(len(name)+2), sheet.Cols.FindColByIndex(8).Width)
+}
+
+// TestRecordWriter_FarFutureDate verifies that dates far from the
+// Excel epoch don't overflow when converted to a date serial.
+func TestRecordWriter_FarFutureDate(t *testing.T) {
+	recMeta := testh.NewRecordMeta([]string{"created", "born"}, []kind.Kind{kind.Datetime, kind.Date})
+
+	created := time.Date(9999, 12, 31, 12, 0, 0, 0, time.UTC)
+	born := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	recs := []sqlz.Record{{&created, &born}}
+
+	buf := &bytes.Buffer{}
+	w := xlsxw.NewRecordWriter(buf, false, xlsxw.DefaultFormats())
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.WriteRecords(recs))
+	require.NoError(t, w.Close())
+
+	xfile, err := xlsx.OpenBinary(buf.Bytes())
+	require.NoError(t, err)
+	cells := xfile.Sheets[0].Rows[0].Cells
+	require.Equal(t, 2958465.5, mustFloat(t, cells[0]))
+	require.Equal(t, 2958465.0, mustFloat(t, cells[1]))
+}
+
+func TestWorkbook(t *testing.T) {
+	recMeta := testh.NewRecordMeta([]string{"id"}, []kind.Kind{kind.Int})
+	id := int64(1)
+
+	buf := &bytes.Buffer{}
+	wb := xlsxw.NewWorkbook(buf, false, xlsxw.DefaultFormats())
+
+	names := []string{"actor", "ACTOR", "a/b:c", "a_name_that_is_longer_than_31_chars"}
+	for _, name := range names {
+		w, err := wb.NewRecordWriter(name)
+		require.NoError(t, err)
+		require.NoError(t, w.Open(recMeta))
+		require.NoError(t, w.WriteRecords([]sqlz.Record{{&id}}))
+		require.NoError(t, w.Close())
+	}
+	require.NoError(t, wb.Close())
+
+	xfile, err := xlsx.OpenBinary(buf.Bytes())
+	require.NoError(t, err)
+
+	var gotNames []string
+	for _, sheet := range xfile.Sheets {
+		gotNames = append(gotNames, sheet.Name)
+		require.Equal(t, 1, len(sheet.Rows))
+		require.Equal(t, "1", sheet.Rows[0].Cells[0].Value)
+	}
+	require.Equal(t, []string{"actor", "ACTOR_2", "a_b_c", "a_name_that_is_longer_than_31_c"}, gotNames)
+}
+
+func mustFloat(t *testing.T, cell *xlsx.Cell) float64 {
+	f, err := cell.Float()
+	require.NoError(t, err)
+	return f
+}
+
 func requireEqualXLSX(t *testing.T, data1, data2 []byte) {
 	xl1, err := xlsx.OpenBinary(data1)
 	require.NoError(t, err)
@@ -0,0 +1,182 @@
+// Package yamlw implements output writers for YAML. Query results
+// are written as a sequence of mappings (one per record), with
+// the keys in column order.
+package yamlw
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/neilotoole/sq/cli/output"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// recordWriter implements output.RecordWriter.
+type recordWriter struct {
+	out     io.Writer
+	recMeta sqlz.RecordMeta
+	buf     *bytes.Buffer
+
+	// recsWritten indicates that at least one record has
+	// been written to buf.
+	recsWritten bool
+}
+
+// NewRecordWriter returns an output.RecordWriter for YAML.
+func NewRecordWriter(out io.Writer) output.RecordWriter {
+	return &recordWriter{out: out}
+}
+
+// Open implements output.RecordWriter.
+func (w *recordWriter) Open(recMeta sqlz.RecordMeta) error {
+	w.recMeta = recMeta
+	w.buf = &bytes.Buffer{}
+	return nil
+}
+
+// WriteRecords implements output.RecordWriter.
+func (w *recordWriter) WriteRecords(recs []sqlz.Record) error {
+	names := w.recMeta.Names()
+
+	for _, rec := range recs {
+		item := make(yaml.MapSlice, len(rec))
+		for i, val := range rec {
+			item[i] = yaml.MapItem{Key: names[i], Value: yamlValue(w.recMeta[i].Kind(), val)}
+		}
+
+		// Each record is marshalled as a single-element sequence, which
+		// can be appended to the output of the previous records.
+		b, err := yaml.Marshal([]yaml.MapSlice{item})
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		w.buf.Write(b)
+		w.recsWritten = true
+
+		if w.buf.Len() > output.FlushThreshold {
+			err = w.Flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Flush implements output.RecordWriter.
+func (w *recordWriter) Flush() error {
+	_, err := w.buf.WriteTo(w.out) // resets buf
+	return errz.Err(err)
+}
+
+// Close implements output.RecordWriter.
+func (w *recordWriter) Close() error {
+	if !w.recsWritten {
+		// An empty sequence.
+		w.buf.WriteString("[]\n")
+	}
+
+	return w.Flush()
+}
+
+// yamlValue returns the value to marshal for val, which is
+// a value of a column of kind knd.
+func yamlValue(knd kind.Kind, val interface{}) interface{} {
+	switch val := val.(type) {
+	case nil:
+		return nil
+	case *int64:
+		return *val
+	case *float64:
+		return *val
+	case *bool:
+		return *val
+	case *string:
+		return *val
+	case *[]byte:
+		return base64.StdEncoding.EncodeToString(*val)
+	case *time.Time:
+		switch knd {
+		case kind.Date:
+			return val.Format(stringz.DateFormat)
+		case kind.Time:
+			return val.Format(stringz.TimeFormat)
+		default:
+			return val.Format(stringz.DatetimeFormat)
+		}
+	default:
+		// should never happen
+		return val
+	}
+}
+
+// mdWriter implements output.MetadataWriter for YAML.
+type mdWriter struct {
+	out io.Writer
+}
+
+// NewMetadataWriter returns a new output.MetadataWriter instance
+// that outputs metadata in YAML.
+func NewMetadataWriter(out io.Writer) output.MetadataWriter {
+	return &mdWriter{out: out}
+}
+
+// write writes v as YAML. The metadata types have JSON field tags
+// (which determine the field names and omitted fields), so v is
+// first encoded to JSON. As JSON is valid YAML, the JSON is then
+// decoded into ordered YAML mappings, which are encoded as YAML.
+func (w *mdWriter) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	var ordered interface{}
+	if bytes.HasPrefix(b, []byte("[")) {
+		var items []yaml.MapSlice
+		err = yaml.Unmarshal(b, &items)
+		ordered = items
+	} else {
+		var item yaml.MapSlice
+		err = yaml.Unmarshal(b, &item)
+		ordered = item
+	}
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	b, err = yaml.Marshal(ordered)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	_, err = w.out.Write(b)
+	return errz.Err(err)
+}
+
+// DriverMetadata implements output.MetadataWriter.
+func (w *mdWriter) DriverMetadata(md []driver.Metadata) error {
+	return w.write(md)
+}
+
+// TableMetadata implements output.MetadataWriter.
+func (w *mdWriter) TableMetadata(md *source.TableMetadata) error {
+	return w.write(md)
+}
+
+// SourceMetadata implements output.MetadataWriter.
+func (w *mdWriter) SourceMetadata(md *source.Metadata) error {
+	return w.write(md)
+}
@@ -0,0 +1,83 @@
+package yamlw_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/cli/output/yamlw"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/fixt"
+)
+
+func TestRecordWriter(t *testing.T) {
+	const want = `- col_int: 64
+  col_float: 64.64
+  col_decimal: "10000000000000000.99"
+  col_bool: true
+  col_text: hello
+  col_datetime: "1970-01-01T00:00:00Z"
+  col_date: "1970-01-01"
+  col_time: "00:00:00"
+  col_bytes: aGVsbG8=
+- col_int: null
+  col_float: null
+  col_decimal: null
+  col_bool: null
+  col_text: null
+  col_datetime: null
+  col_date: null
+  col_time: null
+  col_bytes: null
+`
+
+	colNames, kinds := fixt.ColNamePerKind(false, false, false)
+	recMeta := testh.NewRecordMeta(colNames, kinds)
+
+	v0, v1, v2, v3, v4, v5, v6, v7, v8 := int64(64), float64(64.64), "10000000000000000.99", true, "hello", time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC(), []byte("hello")
+
+	recs := []sqlz.Record{
+		{&v0, &v1, &v2, &v3, &v4, &v5, &v6, &v7, &v8},
+		{nil, nil, nil, nil, nil, nil, nil, nil, nil},
+	}
+
+	buf := &bytes.Buffer{}
+	w := yamlw.NewRecordWriter(buf)
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.WriteRecords(recs))
+	require.NoError(t, w.Close())
+	require.Equal(t, want, buf.String())
+
+	// No records
+	buf.Reset()
+	w = yamlw.NewRecordWriter(buf)
+	require.NoError(t, w.Open(recMeta))
+	require.NoError(t, w.Close())
+	require.Equal(t, "[]\n", buf.String())
+}
+
+func TestMetadataWriter(t *testing.T) {
+	tblMeta := &source.TableMetadata{
+		Name:      "actor",
+		TableType: "table",
+		RowCount:  200,
+		Columns: []*source.ColMetadata{
+			{Name: "actor_id", Position: 0, PrimaryKey: true, BaseType: "INTEGER", ColumnType: "INTEGER", Kind: kind.Int},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w := yamlw.NewMetadataWriter(buf)
+	require.NoError(t, w.TableMetadata(tblMeta))
+
+	got := buf.String()
+	require.Contains(t, got, "name: actor\n")
+	require.Contains(t, got, "row_count: 200\n")
+	require.Contains(t, got, "columns:\n- name: actor_id\n")
+	require.Contains(t, got, "  kind: int\n")
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// addRejectsFlags adds the --max-errors and --rejects flags to cmd.
+func addRejectsFlags(cmd *cobra.Command) {
+	cmd.Flags().Int(flagMaxErrors, 0, flagMaxErrorsUsage)
+	cmd.Flags().String(flagRejects, "", flagRejectsUsage)
+}
+
+// withRejects returns ctx with a driver.Rejects added, per the
+// --max-errors and --rejects flags. If neither flag is set, ctx is
+// returned unchanged. The returned done func must be invoked when
+// the operation completes: it closes the rejects file, and prints
+// the number of rejected rows (if any) to rc.ErrOut.
+func withRejects(ctx context.Context, cmd *cobra.Command, rc *RunContext) (context.Context, func() error, error) {
+	if !cmdFlagChanged(cmd, flagMaxErrors) && !cmdFlagChanged(cmd, flagRejects) {
+		return ctx, func() error { return nil }, nil
+	}
+
+	maxErrors := -1
+	if cmdFlagChanged(cmd, flagMaxErrors) {
+		maxErrors, _ = cmd.Flags().GetInt(flagMaxErrors)
+		if maxErrors < 0 {
+			return nil, nil, errz.Errorf("invalid --%s value: must be >= 0", flagMaxErrors)
+		}
+	}
+
+	var rejectsFile *os.File
+	if cmdFlagChanged(cmd, flagRejects) {
+		fpath, _ := cmd.Flags().GetString(flagRejects)
+		if fpath == "" {
+			return nil, nil, errz.Errorf("invalid --%s value: empty", flagRejects)
+		}
+
+		var err error
+		rejectsFile, err = os.Create(fpath)
+		if err != nil {
+			return nil, nil, errz.Wrapf(err, "failed to create --%s file", flagRejects)
+		}
+	}
+
+	var rejects *driver.Rejects
+	if rejectsFile != nil {
+		rejects = driver.NewRejects(maxErrors, rejectsFile)
+	} else {
+		rejects = driver.NewRejects(maxErrors, nil)
+	}
+
+	done := func() error {
+		if count := rejects.Count(); count > 0 {
+			fmt.Fprintf(rc.ErrOut, stringz.Plu("Rejected %d row(s)\n", count), count)
+		}
+
+		if rejectsFile == nil {
+			return nil
+		}
+		return errz.Err(rejectsFile.Close())
+	}
+
+	return driver.WithRejects(ctx, rejects), done, nil
+}
@@ -79,14 +79,9 @@ func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database
 	//}
 
 	var err error
-	dbase.impl, err = d.scratcher.OpenScratch(ctx, src.Handle)
-	if err != nil {
-		//d.log.WarnIfCloseError(r)
-		//d.log.WarnIfFuncError(dbase.clnup.Run)
-		return nil, err
-	}
-
-	err = importCSV(ctx, d.log, src, d.files.OpenFunc(src), dbase.impl)
+	dbase.impl, err = driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return importCSV(ctx, d.log, src, d.files.OpenFunc(src), destDB)
+	})
 	if err != nil {
 		//d.log.WarnIfCloseError(r)
 		//d.log.WarnIfFuncError(dbase.clnup.Run)
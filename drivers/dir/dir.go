@@ -0,0 +1,300 @@
+// Package dir implements the sq driver for directory sources. The
+// location of a directory source is a directory (or a file path glob
+// pattern such as "/path/to/*.csv", or a zip or tar archive), and each
+// matched file, of any file-based type such as CSV or JSON, becomes a
+// table of the source. Alternatively, via option "union", the data of
+// all the files is combined into a single table.
+package dir
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+const (
+	// Type is the directory driver type.
+	Type = source.Type("dir")
+
+	// OptUnion is the source option that, if true, combines the
+	// data of all the files into a single table named "data",
+	// with an additional column (FileColName) holding the name
+	// of each row's file.
+	OptUnion = "union"
+
+	// OptMember is the source option that selects the files of
+	// the source via a glob pattern, e.g. "*.csv". For an archive,
+	// the pattern is matched against the member's path in the
+	// archive, e.g. "data/*.csv".
+	OptMember = "member"
+
+	// FileColName is the name of the column holding the file name
+	// of each row, when the source has option "union=true".
+	FileColName = "_file"
+)
+
+// Provider implements driver.Provider.
+type Provider struct {
+	Log       lg.Log
+	Scratcher driver.ScratchDatabaseOpener
+	Files     *source.Files
+
+	// Drivers provides the drivers for the files of a
+	// directory source, e.g. the CSV driver for a CSV file.
+	Drivers driver.Provider
+}
+
+// DriverFor implements driver.Provider.
+func (d *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
+	if typ != Type {
+		return nil, errz.Errorf("unsupported driver type %q", typ)
+	}
+
+	return &driveri{log: d.Log, scratcher: d.Scratcher, files: d.Files, drvrs: d.Drivers}, nil
+}
+
+// Driver implements driver.Driver.
+type driveri struct {
+	log       lg.Log
+	scratcher driver.ScratchDatabaseOpener
+	files     *source.Files
+	drvrs     driver.Provider
+}
+
+// DriverMetadata implements driver.Driver.
+func (d *driveri) DriverMetadata() driver.Metadata {
+	return driver.Metadata{
+		Type:        Type,
+		Description: "Directory, glob or archive of files",
+	}
+}
+
+// Open implements driver.Driver.
+func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
+	union, err := isUnion(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	impl, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return d.importFiles(ctx, src, union, destDB)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{log: d.log, src: src, impl: impl, drvr: d}, nil
+}
+
+// Truncate implements driver.Driver.
+func (d *driveri) Truncate(ctx context.Context, src *source.Source, tbl string, reset bool) (int64, error) {
+	return 0, errz.Errorf("truncate not supported for %s", Type)
+}
+
+// ValidateSource implements driver.Driver.
+func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
+	if src.Type != Type {
+		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
+	}
+
+	if !source.IsDirLocation(src.Location) && !source.IsArchiveLocation(src.Location) {
+		return nil, errz.Errorf("location is not a directory, glob or archive: %s", src.Location)
+	}
+
+	_, err := isUnion(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	if member := src.Options.Get(OptMember); member != "" {
+		_, err = path.Match(member, "")
+		if err != nil {
+			return nil, errz.Errorf("option %q: invalid pattern %q", OptMember, member)
+		}
+	}
+
+	return src, nil
+}
+
+// Ping implements driver.Driver. It verifies that src matches at
+// least one file, and that there's a driver for each file's type.
+func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
+	srcFiles, err := d.sourceFiles(src)
+	if err != nil {
+		return err
+	}
+
+	for _, srcFile := range srcFiles {
+		typ, err := d.files.Type(ctx, srcFile.path)
+		if err != nil {
+			return errz.Wrapf(err, "%s: file %s", src.Handle, srcFile.name)
+		}
+
+		_, err = d.drvrs.DriverFor(typ)
+		if err != nil {
+			return errz.Wrapf(err, "%s: file %s", src.Handle, srcFile.name)
+		}
+	}
+
+	return nil
+}
+
+// srcFile is a file of a directory source.
+type srcFile struct {
+	// path is the path of the file on disk.
+	path string
+
+	// name is the name of the file, e.g. "actor.csv". For an
+	// archive member, name is the member's path in the archive,
+	// e.g. "data/actor.csv".
+	name string
+}
+
+// sourceFiles returns the files of src, as selected by
+// option OptMember if set. For an archive location, the
+// archive is extracted to a temp dir.
+func (d *driveri) sourceFiles(src *source.Source) ([]srcFile, error) {
+	var root string
+	var fpaths []string
+	var err error
+
+	if source.IsArchiveLocation(src.Location) {
+		root, fpaths, err = d.files.ArchiveFiles(src.Location)
+	} else {
+		fpaths, err = source.DirLocationFiles(src.Location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	member := src.Options.Get(OptMember)
+
+	var srcFiles []srcFile
+	for _, fpath := range fpaths {
+		name := filepath.Base(fpath)
+		if root != "" {
+			name, err = filepath.Rel(root, fpath)
+			if err != nil {
+				return nil, errz.Err(err)
+			}
+			name = filepath.ToSlash(name)
+		}
+
+		if member != "" {
+			ok, err := path.Match(member, name)
+			if err != nil {
+				return nil, errz.Errorf("option %q: invalid pattern %q", OptMember, member)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		srcFiles = append(srcFiles, srcFile{path: fpath, name: name})
+	}
+
+	if len(srcFiles) == 0 {
+		return nil, errz.Errorf("%s: no files match option %s=%s", src.Handle, OptMember, member)
+	}
+
+	return srcFiles, nil
+}
+
+// isUnion returns true if opts has "union=true".
+func isUnion(opts options.Options) (bool, error) {
+	val := opts.Get(OptUnion)
+	if val == "" {
+		return false, nil
+	}
+
+	union, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, errz.Errorf("option %q: %v", OptUnion, err)
+	}
+
+	return union, nil
+}
+
+// database implements driver.Database.
+type database struct {
+	log  lg.Log
+	src  *source.Source
+	impl driver.Database
+	drvr *driveri
+}
+
+// DB implements driver.Database.
+func (d *database) DB() *sql.DB {
+	return d.impl.DB()
+}
+
+// SQLDriver implements driver.Database.
+func (d *database) SQLDriver() driver.SQLDriver {
+	return d.impl.SQLDriver()
+}
+
+// Source implements driver.Database.
+func (d *database) Source() *source.Source {
+	return d.src
+}
+
+// TableMetadata implements driver.Database.
+func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
+	srcMeta, err := d.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return source.TableFromSourceMetadata(srcMeta, tblName)
+}
+
+// SourceMetadata implements driver.Database.
+func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error) {
+	md, err := d.impl.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Handle = d.src.Handle
+	md.Location = d.src.Location
+	md.SourceType = d.src.Type
+
+	md.Name, err = source.LocationFileName(d.src)
+	if err != nil {
+		return nil, err
+	}
+	md.FQName = md.Name
+
+	// The size is the total size of the files.
+	srcFiles, err := d.drvr.sourceFiles(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Size = 0
+	for _, srcFile := range srcFiles {
+		fi, err := os.Stat(srcFile.path)
+		if err != nil {
+			return nil, errz.Err(err)
+		}
+		md.Size += fi.Size()
+	}
+
+	return md, nil
+}
+
+// Close implements driver.Database.
+func (d *database) Close() error {
+	d.log.Debugf("Close database: %s", d.src)
+
+	return errz.Err(d.impl.Close())
+}
@@ -0,0 +1,141 @@
+package dir_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/dir"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+const categoryCount = 16
+
+// newDirSource returns a new dir source, whose location is
+// a temp dir containing sakila actor.csv and category.csv.
+func newDirSource(t *testing.T, pattern string, opts options.Options) *source.Source {
+	fdir := t.TempDir()
+	for _, name := range []string{"actor.csv", "category.csv"} {
+		data, err := ioutil.ReadFile(proj.Abs(filepath.Join("drivers/csv/testdata/sakila-csv", name)))
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(fdir, name), data, 0600))
+	}
+
+	return &source.Source{
+		Handle:   "@exports_dir",
+		Type:     dir.Type,
+		Location: filepath.Join(fdir, pattern),
+		Options:  opts,
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newDirSource(t, "", options.Options{"header": []string{"true"}})
+
+	md, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"actor", "category"}, md.TableNames())
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM actor")
+	require.NoError(t, err)
+	require.Equal(t, sakila.TblActorCount, len(sink.Recs))
+	require.Equal(t, sakila.TblActorCols(), sink.RecMeta.Names())
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM category")
+	require.NoError(t, err)
+	require.Equal(t, categoryCount, len(sink.Recs))
+}
+
+func TestDir_GlobUnion(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newDirSource(t, "*.csv", options.Options{"header": []string{"true"}, dir.OptUnion: []string{"true"}})
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM data")
+	require.NoError(t, err)
+	require.Equal(t, sakila.TblActorCount+categoryCount, len(sink.Recs))
+
+	wantCols := append(sakila.TblActorCols(), "category_id", "name", dir.FileColName)
+	require.Equal(t, wantCols, sink.RecMeta.Names())
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM data WHERE _file = 'category.csv'")
+	require.NoError(t, err)
+	require.Equal(t, categoryCount, len(sink.Recs))
+}
+
+func TestDir_NoFiles(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newDirSource(t, "*.json", nil)
+
+	drvr := th.DriverFor(src)
+	require.Error(t, drvr.Ping(th.Context, src))
+}
+
+// newArchiveSource returns a new dir source, whose location is
+// a zip archive containing sakila actor.csv and data/category.csv.
+func newArchiveSource(t *testing.T, opts options.Options) *source.Source {
+	fpath := filepath.Join(t.TempDir(), "exports.zip")
+	f, err := os.Create(fpath)
+	require.NoError(t, err)
+
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"actor.csv", "data/category.csv"} {
+		data, err := ioutil.ReadFile(proj.Abs(filepath.Join("drivers/csv/testdata/sakila-csv", filepath.Base(name))))
+		require.NoError(t, err)
+
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	return &source.Source{
+		Handle:   "@exports_zip",
+		Type:     dir.Type,
+		Location: fpath,
+		Options:  opts,
+	}
+}
+
+func TestDir_Archive(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newArchiveSource(t, options.Options{"header": []string{"true"}})
+
+	md, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"actor", "category"}, md.TableNames())
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM category")
+	require.NoError(t, err)
+	require.Equal(t, categoryCount, len(sink.Recs))
+
+	src = newArchiveSource(t, options.Options{"header": []string{"true"}, dir.OptMember: []string{"data/*"}})
+	src.Handle = "@exports_zip_member"
+	md, err = th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"category"}, md.TableNames())
+
+	src = newArchiveSource(t, options.Options{"header": []string{"true"}, dir.OptUnion: []string{"true"}})
+	src.Handle = "@exports_zip_union"
+	sink, err = th.QuerySQL(src, "SELECT * FROM data WHERE _file = 'data/category.csv'")
+	require.NoError(t, err)
+	require.Equal(t, categoryCount, len(sink.Recs))
+}
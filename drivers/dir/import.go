@@ -0,0 +1,253 @@
+package dir
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// fileTable is a table of a file of a directory source.
+type fileTable struct {
+	// fileName is the name of the file, e.g. "actor.csv" (see srcFile).
+	fileName string
+
+	// tblMeta is the metadata of the table in the file's database.
+	tblMeta *source.TableMetadata
+
+	// destTbl is the name of the table in the directory source's
+	// scratch database to which the file table's data is copied.
+	destTbl string
+}
+
+// importFiles imports the files of src into destDB. Each file
+// is opened via its own driver (e.g. the CSV driver for a CSV file),
+// and each of the file's tables is copied to destDB. If union is
+// true, the tables of all the files are then combined into a single
+// table. Each file's database is closed once its tables are copied,
+// so that at most one file is open at a time.
+func (d *driveri) importFiles(ctx context.Context, src *source.Source, union bool, destDB driver.Database) error {
+	srcFiles, err := d.sourceFiles(src)
+	if err != nil {
+		return err
+	}
+
+	// The files' data only needs to be cached as part of src.
+	ctx = driver.WithoutImportCache(ctx)
+
+	var fileTbls []*fileTable
+	takenNames := map[string]bool{}
+	if union {
+		// The union table's name can't be taken by a file table.
+		takenNames[source.MonotableName] = true
+	}
+
+	for _, srcFile := range srcFiles {
+		fi, err := os.Stat(srcFile.path)
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		if fi.Size() == 0 {
+			d.log.Warnf("Skipping empty %s file: %s", src.Handle, srcFile.name)
+			continue
+		}
+
+		tbls, err := d.importFile(ctx, src, srcFile, takenNames, destDB)
+		if err != nil {
+			return err
+		}
+		fileTbls = append(fileTbls, tbls...)
+	}
+
+	if union {
+		return d.importUnion(ctx, fileTbls, destDB)
+	}
+
+	return nil
+}
+
+// importFile copies each of the tables of srcFile, which is one
+// of the files of src, to a new table in destDB, and returns those
+// tables. The file's database is closed before importFile returns.
+func (d *driveri) importFile(ctx context.Context, src *source.Source, srcFile srcFile, takenNames map[string]bool,
+	destDB driver.Database) ([]*fileTable, error) {
+	fileDB, monotable, err := d.openFile(ctx, src, srcFile)
+	if err != nil {
+		return nil, err
+	}
+	defer d.log.WarnIfCloseError(fileDB)
+
+	md, err := fileDB.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fileTbls := make([]*fileTable, len(md.Tables))
+	for i, tblMeta := range md.Tables {
+		fileTbl := &fileTable{
+			fileName: srcFile.name,
+			tblMeta:  tblMeta,
+			destTbl:  uniqTableName(takenNames, path.Base(srcFile.name), tblMeta.Name, monotable),
+		}
+
+		tblDef := libsq.NewTableDefFromMetadata(tblMeta, fileTbl.destTbl)
+		err = destDB.SQLDriver().CreateTable(ctx, destDB.DB(), tblDef)
+		if err != nil {
+			return nil, err
+		}
+
+		query := "SELECT * FROM " + fileDB.SQLDriver().Dialect().Enquote(tblMeta.Name)
+		_, err = libsq.CopyTableData(ctx, d.log, fileDB, query, destDB, fileTbl.destTbl)
+		if err != nil {
+			return nil, err
+		}
+
+		fileTbls[i] = fileTbl
+	}
+
+	return fileTbls, nil
+}
+
+// importUnion combines fileTbls, which have already been copied to
+// destDB, into a single table in destDB, and then drops the tables of
+// fileTbls. The table's columns are the union of the columns of
+// fileTbls, plus FileColName.
+func (d *driveri) importUnion(ctx context.Context, fileTbls []*fileTable, destDB driver.Database) error {
+	tblDef := &sqlmodel.TableDef{Name: source.MonotableName}
+	colDefs := map[string]*sqlmodel.ColDef{}
+
+	for _, fileTbl := range fileTbls {
+		for _, col := range fileTbl.tblMeta.Columns {
+			colKind := col.Kind
+			if colKind == kind.Unknown || colKind == kind.Null {
+				colKind = kind.Text
+			}
+
+			colDef, ok := colDefs[col.Name]
+			if !ok {
+				colDef = &sqlmodel.ColDef{Table: tblDef, Name: col.Name, Kind: colKind}
+				colDefs[col.Name] = colDef
+				tblDef.Cols = append(tblDef.Cols, colDef)
+				continue
+			}
+
+			if colDef.Kind != colKind {
+				// The files disagree on the column's kind.
+				colDef.Kind = kind.Text
+			}
+		}
+	}
+
+	if _, ok := colDefs[FileColName]; ok {
+		return errz.Errorf("option %s: files must not have a column named %q", OptUnion, FileColName)
+	}
+	tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{Table: tblDef, Name: FileColName, Kind: kind.Text, NotNull: true})
+
+	drvr := destDB.SQLDriver()
+	err := drvr.CreateTable(ctx, destDB.DB(), tblDef)
+	if err != nil {
+		return err
+	}
+
+	dialect := drvr.Dialect()
+	for _, fileTbl := range fileTbls {
+		cols := make([]string, len(fileTbl.tblMeta.Columns))
+		for i, col := range fileTbl.tblMeta.Columns {
+			cols[i] = dialect.Enquote(col.Name)
+		}
+
+		fileNameLiteral := stringz.Surround(strings.ReplaceAll(fileTbl.fileName, "'", "''"), "'")
+		stmt := fmt.Sprintf("INSERT INTO %s (%s, %s) SELECT %s, %s FROM %s", dialect.Enquote(source.MonotableName),
+			strings.Join(cols, ", "), dialect.Enquote(FileColName), strings.Join(cols, ", "), fileNameLiteral,
+			dialect.Enquote(fileTbl.destTbl))
+
+		_, err = destDB.DB().ExecContext(ctx, stmt)
+		if err != nil {
+			return errz.Wrapf(err, "option %s: file %s", OptUnion, fileTbl.fileName)
+		}
+
+		err = drvr.DropTable(ctx, destDB.DB(), fileTbl.destTbl, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openFile opens srcFile, which is one of the files of src, via
+// the driver for the file's type. The returned bool is true if
+// the file's driver is monotable (e.g. CSV).
+func (d *driveri) openFile(ctx context.Context, src *source.Source, srcFile srcFile) (driver.Database, bool, error) {
+	fpath := srcFile.path
+	typ, err := d.files.Type(ctx, fpath)
+	if err != nil {
+		return nil, false, errz.Wrapf(err, "%s: file %s", src.Handle, srcFile.name)
+	}
+
+	drvr, err := d.drvrs.DriverFor(typ)
+	if err != nil {
+		return nil, false, errz.Wrapf(err, "%s: file %s", src.Handle, srcFile.name)
+	}
+
+	// The file source gets src's options (e.g. "header=true"),
+	// other than those specific to the directory driver.
+	opts := src.Options.Clone()
+	if opts != nil {
+		delete(opts, OptUnion)
+		delete(opts, OptMember)
+	}
+
+	fileSrc := &source.Source{
+		Handle:   src.Handle + "_" + stringz.SanitizeAlphaNumeric(srcFile.name, '_'),
+		Type:     typ,
+		Location: fpath,
+		Options:  opts,
+	}
+
+	fileSrc, err = drvr.ValidateSource(fileSrc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	d.log.Debugf("Opening %s file %s as %s", src.Handle, srcFile.name, typ)
+	fileDB, err := drvr.Open(ctx, fileSrc)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return fileDB, drvr.DriverMetadata().Monotable, nil
+}
+
+// uniqTableName returns a table name for table tblName of file
+// fileName, which is not already in takenNames (and is then added
+// to takenNames). The name is the base name of the file (sans
+// extension): for example, "actor" for "actor.csv". If the file's
+// driver is not monotable, the name is suffixed with tblName, e.g.
+// "sakila_actor" for the "actor" sheet of "sakila.xlsx".
+func uniqTableName(takenNames map[string]bool, fileName, tblName string, monotable bool) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	if !monotable {
+		name += "_" + tblName
+	}
+	name = stringz.SanitizeAlphaNumeric(name, '_')
+
+	candidate := name
+	for i := 1; takenNames[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+
+	takenNames[candidate] = true
+	return candidate
+}
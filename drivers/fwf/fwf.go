@@ -0,0 +1,179 @@
+// Package fwf implements the sq driver for fixed-width fields (FWF)
+// text data, such as mainframe or bank exports. Like CSV, a FWF
+// source is a monotable: its data is imported into a single table
+// named "data".
+//
+// The column boundaries are specified by source option "cols", e.g.
+// "cols=name:1-10,amount:11-20", where positions are 1-based and
+// inclusive, and the end of the last column may be omitted, e.g.
+// "note:21-". If there's no "cols" option, the boundaries are
+// detected from the whitespace alignment of the leading lines of the
+// data. Option "header=true" indicates that the first line is a header
+// line, which (if "cols" is not specified) provides the column names.
+// Field values are trimmed of whitespace, unless "trim=false".
+//
+// The type of a fixed-width file is not detected (almost any text
+// file could be fixed-width), so the driver must be specified
+// explicitly, e.g. "sq add payments.txt --driver=fwf".
+package fwf
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// Type is the fixed-width fields driver type.
+const Type = source.Type("fwf")
+
+// Provider implements driver.Provider.
+type Provider struct {
+	Log       lg.Log
+	Scratcher driver.ScratchDatabaseOpener
+	Files     *source.Files
+}
+
+// DriverFor implements driver.Provider.
+func (d *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
+	if typ != Type {
+		return nil, errz.Errorf("unsupported driver type %q", typ)
+	}
+
+	return &driveri{log: d.Log, scratcher: d.Scratcher, files: d.Files}, nil
+}
+
+// Driver implements driver.Driver.
+type driveri struct {
+	log       lg.Log
+	scratcher driver.ScratchDatabaseOpener
+	files     *source.Files
+}
+
+// DriverMetadata implements driver.Driver.
+func (d *driveri) DriverMetadata() driver.Metadata {
+	return driver.Metadata{
+		Type:        Type,
+		Description: "Fixed-Width Fields",
+		Monotable:   true,
+	}
+}
+
+// Open implements driver.Driver.
+func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
+	impl, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return importFWF(ctx, d.log, src, d.files.OpenFunc(src), destDB)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{log: d.log, src: src, impl: impl, files: d.files}, nil
+}
+
+// Truncate implements driver.Driver.
+func (d *driveri) Truncate(ctx context.Context, src *source.Source, tbl string, reset bool) (int64, error) {
+	return 0, errz.Errorf("truncate not supported for %s", Type)
+}
+
+// ValidateSource implements driver.Driver.
+func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
+	if src.Type != Type {
+		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
+	}
+
+	_, err := getOptions(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return src, nil
+}
+
+// Ping implements driver.Driver.
+func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
+	d.log.Debugf("driver %q attempting to ping %q", Type, src)
+
+	r, err := d.files.Open(src)
+	if err != nil {
+		return err
+	}
+	defer d.log.WarnIfCloseError(r)
+
+	return nil
+}
+
+// database implements driver.Database.
+type database struct {
+	log   lg.Log
+	src   *source.Source
+	impl  driver.Database
+	files *source.Files
+}
+
+// DB implements driver.Database.
+func (d *database) DB() *sql.DB {
+	return d.impl.DB()
+}
+
+// SQLDriver implements driver.Database.
+func (d *database) SQLDriver() driver.SQLDriver {
+	return d.impl.SQLDriver()
+}
+
+// Source implements driver.Database.
+func (d *database) Source() *source.Source {
+	return d.src
+}
+
+// TableMetadata implements driver.Database.
+func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
+	if tblName != source.MonotableName {
+		return nil, errz.Errorf("table name should be %s for fixed-width data, but got: %s",
+			source.MonotableName, tblName)
+	}
+
+	srcMeta, err := d.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// There will only ever be one table for fixed-width data.
+	return srcMeta.Tables[0], nil
+}
+
+// SourceMetadata implements driver.Database.
+func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error) {
+	md, err := d.impl.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Handle = d.src.Handle
+	md.Location = d.src.Location
+	md.SourceType = d.src.Type
+
+	md.Name, err = source.LocationFileName(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Size, err = d.files.Size(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.FQName = md.Name
+	return md, nil
+}
+
+// Close implements driver.Database.
+func (d *database) Close() error {
+	d.log.Debugf("Close database: %s", d.src)
+
+	return errz.Err(d.impl.Close())
+}
@@ -0,0 +1,116 @@
+package fwf_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/fwf"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+)
+
+const (
+	pathPayments         = "drivers/fwf/testdata/payments.txt"
+	pathPaymentsNoHeader = "drivers/fwf/testdata/payments_noheader.txt"
+)
+
+func newSource(t *testing.T, fpath, opts string) *source.Source {
+	o, err := options.ParseOptions(opts)
+	require.NoError(t, err)
+
+	return &source.Source{
+		Handle:   "@fwf_" + stringz.Uniq8(),
+		Type:     fwf.Type,
+		Location: proj.Abs(fpath),
+		Options:  o,
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		fpath     string
+		opts      string
+		wantNames []string
+	}{
+		{
+			name:      "detect_header",
+			fpath:     pathPayments,
+			opts:      "header=true",
+			wantNames: []string{"NAME", "AMOUNT", "DATE", "PAID"},
+		},
+		{
+			name:      "detect_noheader",
+			fpath:     pathPaymentsNoHeader,
+			wantNames: []string{"A", "B", "C", "D"},
+		},
+		{
+			name:      "cols_header",
+			fpath:     pathPayments,
+			opts:      "header=true&cols=name:1-20,amount:21-30,date:33-44,paid:45-",
+			wantNames: []string{"name", "amount", "date", "paid"},
+		},
+		{
+			name:      "cols_noheader",
+			fpath:     pathPaymentsNoHeader,
+			opts:      "cols=name:1-20,amount:21-30,date:33-44,paid:45-",
+			wantNames: []string{"name", "amount", "date", "paid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			th := testh.New(t)
+			src := newSource(t, tc.fpath, tc.opts)
+
+			sink, err := th.QuerySQL(src, "SELECT * FROM data")
+			require.NoError(t, err)
+			require.Equal(t, tc.wantNames, sink.RecMeta.Names())
+			require.Equal(t, []kind.Kind{kind.Text, kind.Decimal, kind.Text, kind.Bool}, sink.RecMeta.Kinds())
+			require.Equal(t, 4, len(sink.Recs))
+			require.Equal(t, "Acme Corp", testh.Val(sink.Recs[0][0]))
+			require.Equal(t, "2021-03-05", testh.Val(sink.Recs[2][2]))
+			require.Equal(t, false, testh.Val(sink.Recs[1][3]))
+			require.Nil(t, sink.Recs[3][3])
+		})
+	}
+}
+
+func TestImport_NoTrim(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newSource(t, pathPaymentsNoHeader, "trim=false&cols=name:1-20,amount:21-30")
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM data")
+	require.NoError(t, err)
+	require.Equal(t, "Globex              ", testh.Val(sink.Recs[1][0]))
+	require.Equal(t, kind.Decimal, sink.RecMeta.Kinds()[1])
+}
+
+func TestValidateSource(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	drvr := th.DriverFor(newSource(t, pathPayments, ""))
+
+	_, err := drvr.ValidateSource(newSource(t, pathPayments, "cols=name:1-20,amount:21-30"))
+	require.NoError(t, err)
+
+	_, err = drvr.ValidateSource(newSource(t, pathPayments, "cols=name:1-20,amount:15-30"))
+	require.Error(t, err)
+
+	_, err = drvr.ValidateSource(newSource(t, pathPayments, "trim=maybe"))
+	require.Error(t, err)
+}
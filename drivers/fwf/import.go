@@ -0,0 +1,472 @@
+package fwf
+
+import (
+	"bufio"
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/neilotoole/lg"
+	"github.com/shopspring/decimal"
+
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+const (
+	// readAheadBufferSize is the number of lines read ahead to
+	// detect the column boundaries and predict the column kinds.
+	readAheadBufferSize = 100
+
+	// maxLineSize is the maximum size of a line of the data.
+	maxLineSize = 1024 * 1024
+)
+
+// optTrim is the key for the trim option.
+const optTrim = "trim"
+
+// column is a fixed-width column. The start and end of the column
+// are zero-based rune offsets into a line, where end is exclusive,
+// or -1 if the column extends to the end of the line.
+type column struct {
+	name  string
+	start int
+	end   int
+}
+
+// fwfOptions holds the options of a fwf source.
+type fwfOptions struct {
+	// cols holds the columns specified by the "cols" option.
+	// If nil, the columns are detected from the data.
+	cols []column
+
+	header bool
+	trim   bool
+}
+
+// getOptions returns the fwf options of opts.
+func getOptions(opts options.Options) (*fwfOptions, error) {
+	fo := &fwfOptions{trim: true}
+
+	var err error
+	fo.header, _, err = options.HasHeader(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := opts.Get(optTrim); v != "" {
+		fo.trim, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, errz.Errorf(`unable to parse option %q: %v`, optTrim, err)
+		}
+	}
+
+	if v := opts.Get(options.OptCols); v != "" {
+		fo.cols, err = parseCols(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return fo, nil
+}
+
+// parseCols parses the value of the "cols" option, such as
+// "name:1-10,amount:11-20,note:21-". Positions are 1-based and
+// inclusive. Only the last column may omit its end position.
+func parseCols(val string) ([]column, error) {
+	parts := strings.Split(val, ",")
+	cols := make([]column, len(parts))
+	for i, part := range parts {
+		errInvalid := errz.Errorf("option %q: invalid column %q: should be like name:1-10", options.OptCols, part)
+
+		colon := strings.LastIndexByte(part, ':')
+		if colon < 1 {
+			return nil, errInvalid
+		}
+		cols[i].name = strings.TrimSpace(part[:colon])
+
+		rng := strings.SplitN(part[colon+1:], "-", 2)
+		if len(rng) != 2 {
+			return nil, errInvalid
+		}
+
+		start, err := strconv.Atoi(strings.TrimSpace(rng[0]))
+		if err != nil || start < 1 {
+			return nil, errInvalid
+		}
+		cols[i].start = start - 1
+
+		cols[i].end = -1
+		if end := strings.TrimSpace(rng[1]); end != "" {
+			cols[i].end, err = strconv.Atoi(end)
+			if err != nil || cols[i].end < start {
+				return nil, errInvalid
+			}
+		} else if i != len(parts)-1 {
+			return nil, errz.Errorf("option %q: only the last column may omit its end position: %q",
+				options.OptCols, part)
+		}
+
+		if i > 0 && cols[i].start < cols[i-1].end {
+			return nil, errz.Errorf("option %q: column %q overlaps the previous column", options.OptCols, part)
+		}
+	}
+
+	return cols, nil
+}
+
+// detectCols returns the columns (without names) detected from the
+// whitespace alignment of lines. A column begins at each position
+// that is not whitespace in some line, but which follows a position
+// that is whitespace in every line. Each column extends to the start
+// of the next column, and the last column to the end of the line.
+func detectCols(lines []string) []column {
+	var occupied []bool
+	for _, line := range lines {
+		var i int
+		for _, r := range line {
+			if i == len(occupied) {
+				occupied = append(occupied, false)
+			}
+
+			if !unicode.IsSpace(r) {
+				occupied[i] = true
+			}
+			i++
+		}
+	}
+
+	var cols []column
+	for i := range occupied {
+		if occupied[i] && (i == 0 || !occupied[i-1]) {
+			if len(cols) > 0 {
+				cols[len(cols)-1].end = i
+			}
+			cols = append(cols, column{start: i, end: -1})
+		}
+	}
+
+	return cols
+}
+
+// splitLine returns the fields of line per cols. A field of a column
+// beyond the end of line is empty. If trim is true, whitespace is
+// trimmed from each field.
+func splitLine(line string, cols []column, trim bool) []string {
+	var runes []rune
+	if utf8.RuneCountInString(line) != len(line) {
+		runes = []rune(line)
+	}
+
+	n := len(line)
+	if runes != nil {
+		n = len(runes)
+	}
+
+	fields := make([]string, len(cols))
+	for i, col := range cols {
+		if col.start >= n {
+			continue
+		}
+
+		end := col.end
+		if end == -1 || end > n {
+			end = n
+		}
+
+		if runes != nil {
+			fields[i] = string(runes[col.start:end])
+		} else {
+			fields[i] = line[col.start:end]
+		}
+
+		if trim {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+	}
+
+	return fields
+}
+
+// importFWF loads the src fixed-width data to scratchDB.
+func importFWF(ctx context.Context, log lg.Log, src *source.Source, openFn source.FileOpenFunc, scratchDB driver.Database) error {
+	opts, err := getOptions(src.Options)
+	if err != nil {
+		return err
+	}
+
+	r, err := openFn()
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	// readAheadLines temporarily holds lines read from r for the
+	// purpose of determining the columns and their kinds.
+	readAheadLines, err := readLines(sc, readAheadBufferSize)
+	if err != nil {
+		return err
+	}
+
+	if len(readAheadLines) == 0 {
+		return errz.Errorf("data source %s has no data", src.Handle)
+	}
+
+	cols := opts.cols
+	if cols == nil {
+		cols = detectCols(readAheadLines)
+		if len(cols) == 0 {
+			return errz.Errorf("data source %s: unable to detect columns", src.Handle)
+		}
+		log.Debugf("Detected %d columns for %s", len(cols), src.Handle)
+	}
+
+	if opts.header {
+		if opts.cols == nil {
+			header := splitLine(readAheadLines[0], cols, true)
+			for i := range cols {
+				cols[i].name = header[i]
+			}
+		}
+		readAheadLines = readAheadLines[1:]
+	}
+
+	colNames := make([]string, len(cols))
+	for i := range cols {
+		colNames[i] = cols[i].name
+		if colNames[i] == "" {
+			colNames[i] = stringz.GenerateAlphaColName(i, false)
+		}
+	}
+
+	readAheadRecs := make([][]string, len(readAheadLines))
+	for i := range readAheadLines {
+		readAheadRecs[i] = splitLine(readAheadLines[i], cols, opts.trim)
+	}
+
+	colKinds := predictColKinds(len(cols), readAheadRecs)
+
+	// And now we need to create the dest table in scratchDB
+	tblDef := createTblDef(source.MonotableName, colNames, colKinds)
+
+	err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tblDef)
+	if err != nil {
+		return errz.Wrap(err, "fwf: failed to create dest scratch table")
+	}
+
+	recMeta, err := getRecMeta(ctx, scratchDB, tblDef)
+	if err != nil {
+		return err
+	}
+
+	insertWriter := libsq.NewDBWriter(log, scratchDB, tblDef.Name, driver.Tuning.RecordChSize)
+	err = execInsert(ctx, insertWriter, recMeta, colKinds, readAheadRecs, func() ([]string, error) {
+		lines, err := readLines(sc, 1)
+		if err != nil || len(lines) == 0 {
+			return nil, err
+		}
+		return splitLine(lines[0], cols, opts.trim), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	inserted, err := insertWriter.Wait()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Inserted %d rows to %s.%s", inserted, scratchDB.Source().Handle, tblDef.Name)
+	return nil
+}
+
+// readLines reads up to n non-blank lines from sc.
+func readLines(sc *bufio.Scanner, n int) ([]string, error) {
+	var lines []string
+	for len(lines) < n && sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, errz.Err(sc.Err())
+}
+
+// execInsert inserts the records in readAheadRecs (followed by the
+// records returned by next, until it returns nil) via recw. The
+// caller should wait on recw to complete.
+func execInsert(ctx context.Context, recw libsq.RecordWriter, recMeta sqlz.RecordMeta, kinds []kind.Kind,
+	readAheadRecs [][]string, next func() ([]string, error)) error {
+	ctx, cancelFn := context.WithCancel(ctx)
+
+	recordCh, errCh, err := recw.Open(ctx, cancelFn, recMeta)
+	if err != nil {
+		return err
+	}
+	defer close(recordCh)
+
+	for i := 0; ; i++ {
+		var fields []string
+		if i < len(readAheadRecs) {
+			fields = readAheadRecs[i]
+		} else {
+			fields, err = next()
+			if err != nil {
+				cancelFn()
+				return errz.Wrap(err, "read from fixed-width data source")
+			}
+			if fields == nil {
+				// We're done reading
+				return nil
+			}
+		}
+
+		rec := mungeFields2InsertRecord(fields, kinds)
+
+		select {
+		case err = <-errCh:
+			cancelFn()
+			return err
+		case <-ctx.Done():
+			cancelFn()
+			return ctx.Err()
+		case recordCh <- rec:
+		}
+	}
+}
+
+// mungeFields2InsertRecord returns a new []interface{} containing
+// the values of fields. An empty field of a non-text column is nil.
+func mungeFields2InsertRecord(fields []string, kinds []kind.Kind) []interface{} {
+	a := make([]interface{}, len(fields))
+	for i := range fields {
+		if kinds[i] == kind.Text {
+			a[i] = fields[i]
+			continue
+		}
+
+		if v := strings.TrimSpace(fields[i]); v != "" {
+			a[i] = v
+		}
+	}
+	return a
+}
+
+// getRecMeta returns RecordMeta to use with RecordWriter.Open.
+func getRecMeta(ctx context.Context, scratchDB driver.Database, tblDef *sqlmodel.TableDef) (sqlz.RecordMeta, error) {
+	colTypes, err := scratchDB.SQLDriver().TableColumnTypes(ctx, scratchDB.DB(), tblDef.Name, tblDef.ColNames())
+	if err != nil {
+		return nil, err
+	}
+
+	destMeta, _, err := scratchDB.SQLDriver().RecordMeta(colTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return destMeta, nil
+}
+
+func createTblDef(tblName string, colNames []string, kinds []kind.Kind) *sqlmodel.TableDef {
+	tbl := &sqlmodel.TableDef{Name: tblName}
+
+	cols := make([]*sqlmodel.ColDef, len(colNames))
+	for i := range colNames {
+		cols[i] = &sqlmodel.ColDef{Table: tbl, Name: colNames[i], Kind: kinds[i]}
+	}
+
+	tbl.Cols = cols
+	return tbl
+}
+
+// predictColKinds examines recs to guess the kind of each of the
+// fieldCount fields, in the same manner as the csv driver.
+//
+// This func considers these candidate kinds, in order of
+// precedence: kind.Int, kind.Bool, kind.Decimal.
+//
+// If any field value cannot be parsed into a particular kind, that
+// kind is excluded from the list of candidate kinds. The first of any
+// remaining candidate kinds for each field is returned, or kind.Text if
+// no candidate kinds.
+func predictColKinds(fieldCount int, recs [][]string) []kind.Kind {
+	candidateKinds := newCandidateFieldKinds(fieldCount)
+	for _, rec := range recs {
+		for fieldIndex, fieldValue := range rec {
+			candidateKinds[fieldIndex] = excludeFieldKinds(candidateKinds[fieldIndex], strings.TrimSpace(fieldValue))
+		}
+	}
+
+	resultKinds := make([]kind.Kind, fieldCount)
+	for i := range resultKinds {
+		switch len(candidateKinds[i]) {
+		case 0:
+			// If all candidate kinds have been excluded, kind.Text is
+			// the fallback option.
+			resultKinds[i] = kind.Text
+		default:
+			// If there's one or more candidate kinds remaining, pick the first
+			// one available, as it should be the most specific kind.
+			resultKinds[i] = candidateKinds[i][0]
+		}
+	}
+	return resultKinds
+}
+
+// newCandidateFieldKinds returns a new slice of kind.Kind containing
+// potential kinds for a field/column. The kinds are in an order of
+// precedence.
+func newCandidateFieldKinds(n int) [][]kind.Kind {
+	kinds := make([][]kind.Kind, n)
+	for i := range kinds {
+		kinds[i] = []kind.Kind{kind.Int, kind.Bool, kind.Decimal}
+	}
+
+	return kinds
+}
+
+// excludeFieldKinds returns a filter of fieldCandidateKinds, removing those
+// kinds which fieldVal cannot be converted to.
+func excludeFieldKinds(fieldCandidateKinds []kind.Kind, fieldVal string) []kind.Kind {
+	if fieldVal == "" {
+		// If the field is empty, this could indicate a NULL value
+		// for any kind. That is, we don't exclude a candidate kind.
+		return fieldCandidateKinds
+	}
+
+	var resultCandidateKinds []kind.Kind
+	for _, knd := range fieldCandidateKinds {
+		var err error
+
+		switch knd {
+		case kind.Int:
+			_, err = strconv.Atoi(fieldVal)
+		case kind.Bool:
+			_, err = strconv.ParseBool(fieldVal)
+		case kind.Decimal:
+			_, err = decimal.NewFromString(fieldVal)
+		default:
+		}
+
+		if err == nil {
+			resultCandidateKinds = append(resultCandidateKinds, knd)
+		}
+	}
+
+	return resultCandidateKinds
+}
@@ -0,0 +1,80 @@
+package fwf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/libsq/core/kind"
+)
+
+func Test_parseCols(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input   string
+		want    []column
+		wantErr bool
+	}{
+		{input: "a:1-10", want: []column{{name: "a", start: 0, end: 10}}},
+		{input: "a:1-10,b:11-20", want: []column{{name: "a", start: 0, end: 10}, {name: "b", start: 10, end: 20}}},
+		{input: "a:1-10,b:15-", want: []column{{name: "a", start: 0, end: 10}, {name: "b", start: 14, end: -1}}},
+		{input: "a:1-1", want: []column{{name: "a", start: 0, end: 1}}},
+		{input: "a", wantErr: true},
+		{input: ":1-10", wantErr: true},
+		{input: "a:0-10", wantErr: true},
+		{input: "a:5-4", wantErr: true},
+		{input: "a:1-x", wantErr: true},
+		{input: "a:1-,b:11-20", wantErr: true},
+		{input: "a:1-10,b:10-20", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseCols(tc.input)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_detectCols(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"id  name       amount",
+		" 1  Café au    12.50",
+		"12  Latte       3.00",
+	}
+
+	cols := detectCols(lines)
+	require.Equal(t, []column{{start: 0, end: 4}, {start: 4, end: 15}, {start: 15, end: -1}}, cols)
+
+	require.Equal(t, []string{"1", "Café au", "12.50"}, splitLine(lines[1], cols, true))
+	require.Equal(t, []string{" 1  ", "Café au    ", "12.50"}, splitLine(lines[1], cols, false))
+	require.Equal(t, []string{"", "", ""}, splitLine("", cols, true))
+}
+
+func Test_predictColKinds(t *testing.T) {
+	t.Parallel()
+
+	recs := [][]string{
+		{"1", "12.50", "true", "2021-03-01", "Acme", ""},
+		{" 2 ", "-3", "false", "2021-03-02", "Globex", ""},
+		{"", "", "", "", "", ""},
+	}
+
+	kinds := predictColKinds(len(recs[0]), recs)
+	require.Equal(t, []kind.Kind{kind.Int, kind.Decimal, kind.Bool, kind.Text, kind.Text, kind.Int}, kinds)
+	require.Equal(t, []interface{}{"2", "-3", "false", "2021-03-02", " Globex", nil},
+		mungeFields2InsertRecord([]string{"2", "-3", "false", "2021-03-02", " Globex", " "}, kinds))
+}
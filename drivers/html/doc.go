@@ -1,11 +1,14 @@
-// Package html is the future home of the HTML table import driver.
+// Package html implements the sq driver for HTML tables. Each
+// <table> element of an HTML document (a file, a URL, or stdin)
+// is imported as a table of the source. A table is named per
+// its id attribute or its <caption> if present, and otherwise
+// per its position in the document: table1, table2, etc.
 //
-// BRAINDUMP:
 // A particular use case is this:
 // In your browser, select a table, and copy that HTML.
 // Then (on macOS):
 //
-//  > pbpaste | sq .data --json
+//  > pbpaste | sq .table1 --json
 //
 // Should output that HTML table as JSON, etc.
 package html
@@ -0,0 +1,86 @@
+package html_test
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/html"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+)
+
+const pathTables = "drivers/html/testdata/tables.html"
+
+func newSource() *source.Source {
+	return &source.Source{
+		Handle:   "@tables_html_" + stringz.Uniq8(),
+		Type:     html.Type,
+		Location: proj.Abs(pathTables),
+	}
+}
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newSource()
+
+	md, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"City_Population", "actor", "table3"}, md.TableNames())
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM actor")
+	require.NoError(t, err)
+	require.Equal(t, []string{"actor_id", "first_name", "last_name"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Decimal, kind.Text, kind.Text}, sink.RecMeta.Kinds())
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, "NICK", testh.Val(sink.Recs[1][1]))
+
+	// The header cells span multiple rows and columns.
+	sink, err = th.QuerySQL(src, "SELECT * FROM City_Population")
+	require.NoError(t, err)
+	require.Equal(t, []string{"city", "population_2010", "population_2020"}, sink.RecMeta.Names())
+	require.Equal(t, 3, len(sink.Recs))
+
+	// The "Cork" cell spans two rows; the final empty cell is NULL.
+	require.Equal(t, "Cork", testh.Val(sink.Recs[2][0]))
+	require.Equal(t, "119231", testh.Val(sink.Recs[2][1]))
+	require.Nil(t, sink.Recs[2][2])
+
+	// No header cells, thus generated column names.
+	sink, err = th.QuerySQL(src, "SELECT * FROM table3")
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "B", "C"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Text, kind.Decimal, kind.Bool}, sink.RecMeta.Kinds())
+	require.Equal(t, "b c", testh.Val(sink.Recs[1][0]))
+}
+
+func TestDetectHTML(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+
+	typ, err := th.Files().Type(th.Context, proj.Abs(pathTables))
+	require.NoError(t, err)
+	require.Equal(t, html.Type, typ)
+
+	openFn := func(fpath string) source.FileOpenFunc {
+		return func() (io.ReadCloser, error) { return os.Open(proj.Abs(fpath)) }
+	}
+
+	typ, score, err := html.DetectHTML(th.Context, th.Log, openFn(pathTables))
+	require.NoError(t, err)
+	require.Equal(t, html.Type, typ)
+	require.Equal(t, float32(1.0), score)
+
+	typ, score, err = html.DetectHTML(th.Context, th.Log, openFn("drivers/csv/testdata/sakila-csv/actor.csv"))
+	require.NoError(t, err)
+	require.Equal(t, source.TypeNone, typ)
+	require.Equal(t, float32(0), score)
+}
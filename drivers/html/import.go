@@ -0,0 +1,541 @@
+package html
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/neilotoole/lg"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+const (
+	// maxColspan is the maximum colspan value, per the HTML spec.
+	maxColspan = 1000
+
+	// maxRowspan is the maximum rowspan value, per the HTML spec.
+	maxRowspan = 65534
+)
+
+// htmlTable is a <table> parsed from an HTML document. The cells
+// of rows have been expanded per colspan and rowspan, so that
+// a cell spanning two columns appears in both columns.
+type htmlTable struct {
+	// name is the name of the table in the scratch DB.
+	name string
+
+	// rows holds the text of each cell of each row.
+	// A nil value indicates there's no cell.
+	rows [][]*string
+
+	// headerRows is the number of leading rows of rows that
+	// are header rows, i.e. rows of <thead>, or rows consisting
+	// only of <th> cells.
+	headerRows int
+}
+
+// importHTML imports each <table> of the HTML document returned
+// by openFn into scratchDB.
+func importHTML(ctx context.Context, log lg.Log, src *source.Source, openFn source.FileOpenFunc, scratchDB driver.Database) error {
+	hasHeader, headerSet, err := options.HasHeader(src.Options)
+	if err != nil {
+		return err
+	}
+
+	r, err := openFn()
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r)
+
+	tbls, err := parseTables(r)
+	if err != nil {
+		return err
+	}
+
+	if len(tbls) == 0 {
+		return errz.Errorf("html: no <table> found in %s", src.Handle)
+	}
+
+	for _, tbl := range tbls {
+		if headerSet {
+			// The header option overrides the detected header rows.
+			tbl.headerRows = 0
+			if hasHeader && len(tbl.rows) > 0 {
+				tbl.headerRows = 1
+			}
+		}
+
+		err = importTable(ctx, log, tbl, scratchDB)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importTable creates tbl in scratchDB, and inserts tbl's data.
+func importTable(ctx context.Context, log lg.Log, tbl *htmlTable, scratchDB driver.Database) error {
+	tblDef, mungeFns, err := buildTblDef(tbl)
+	if err != nil {
+		return err
+	}
+
+	err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tblDef)
+	if err != nil {
+		return err
+	}
+
+	conn, err := scratchDB.DB().Conn(ctx)
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer log.WarnIfCloseError(conn)
+
+	drvr := scratchDB.SQLDriver()
+	batchSize := driver.MaxBatchRows(drvr, len(tblDef.Cols))
+	bi, err := driver.NewBatchInsert(ctx, log, drvr, conn, tblDef.Name, tblDef.ColNames(), batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range tbl.rows[tbl.headerRows:] {
+		if isEmptyRow(row) {
+			continue
+		}
+
+		rec, err := rowToRecord(tblDef, mungeFns, row)
+		if err == nil {
+			err = bi.Munge(rec)
+		}
+		if err != nil {
+			close(bi.RecordCh)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			close(bi.RecordCh)
+			return ctx.Err()
+		case err = <-bi.ErrCh:
+			if err != nil {
+				close(bi.RecordCh)
+				return err
+			}
+
+			// The batch inserter successfully completed
+			break
+		case bi.RecordCh <- rec:
+		}
+	}
+
+	close(bi.RecordCh) // Indicate that we're finished writing records
+
+	err = <-bi.ErrCh // Wait for bi to complete
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Inserted %d rows into %s.%s", bi.Written(), scratchDB.Source().Handle, tblDef.Name)
+	return nil
+}
+
+// buildTblDef returns the table def for tbl, with column names taken
+// from tbl's header rows, and column kinds detected from tbl's data.
+// The returned munge funcs (which may be nil) convert the values of
+// each column to the column's kind.
+func buildTblDef(tbl *htmlTable) (*sqlmodel.TableDef, []kind.MungeFunc, error) {
+	var numCols int
+	for _, row := range tbl.rows {
+		if len(row) > numCols {
+			numCols = len(row)
+		}
+	}
+
+	colNames := make([]string, numCols)
+	for i := range colNames {
+		// A header cell spanning multiple header rows appears
+		// in each of those rows, so we skip duplicate text.
+		var parts []string
+		for _, row := range tbl.rows[:tbl.headerRows] {
+			if i < len(row) && row[i] != nil && *row[i] != "" && !stringz.InSlice(parts, *row[i]) {
+				parts = append(parts, *row[i])
+			}
+		}
+
+		colNames[i] = strings.Join(parts, "_")
+		if colNames[i] == "" {
+			colNames[i] = stringz.GenerateAlphaColName(i, false)
+		}
+	}
+	colNames = uniqNames(colNames)
+
+	detectors := make([]*kind.Detector, numCols)
+	for i := range detectors {
+		detectors[i] = kind.NewDetector()
+	}
+
+	for _, row := range tbl.rows[tbl.headerRows:] {
+		for i, val := range row {
+			if val != nil {
+				detectors[i].Sample(*val)
+			}
+		}
+	}
+
+	tblDef := &sqlmodel.TableDef{Name: tbl.name}
+	mungeFns := make([]kind.MungeFunc, numCols)
+	for i := range colNames {
+		var colKind kind.Kind
+		var err error
+		colKind, mungeFns[i], err = detectors[i].Detect()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if colKind == kind.Null || colKind == kind.Unknown {
+			colKind = kind.Text
+		}
+
+		tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{Table: tblDef, Name: colNames[i], Kind: colKind})
+	}
+
+	return tblDef, mungeFns, nil
+}
+
+// rowToRecord returns the record to insert for row. An empty
+// value in a non-text column is inserted as NULL.
+func rowToRecord(tblDef *sqlmodel.TableDef, mungeFns []kind.MungeFunc, row []*string) ([]interface{}, error) {
+	rec := make([]interface{}, len(tblDef.Cols))
+	for i := range rec {
+		if i >= len(row) || row[i] == nil {
+			continue
+		}
+
+		val := *row[i]
+		if val == "" && tblDef.Cols[i].Kind != kind.Text {
+			continue
+		}
+
+		if mungeFns[i] == nil {
+			rec[i] = val
+			continue
+		}
+
+		v, err := mungeFns[i](val)
+		if err != nil {
+			return nil, errz.Wrapf(err, "html: table %s: column %s", tblDef.Name, tblDef.Cols[i].Name)
+		}
+		rec[i] = v
+	}
+
+	return rec, nil
+}
+
+// isEmptyRow returns true if row has no cells.
+func isEmptyRow(row []*string) bool {
+	for _, val := range row {
+		if val != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCells returns true if tbl has at least one cell.
+func hasCells(tbl *htmlTable) bool {
+	for _, row := range tbl.rows {
+		if !isEmptyRow(row) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTables parses the <table> elements of the HTML document
+// read from r. Tables without any data are omitted.
+func parseTables(r io.Reader) ([]*htmlTable, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, errz.Wrap(err, "html")
+	}
+
+	var tblNodes []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Table {
+			tblNodes = append(tblNodes, n)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var tbls []*htmlTable
+	takenNames := map[string]bool{}
+	for i, tblNode := range tblNodes {
+		tbl := parseTable(tblNode)
+		if !hasCells(tbl) {
+			continue
+		}
+
+		tbl.name = tableName(tblNode, i, takenNames)
+		tbls = append(tbls, tbl)
+	}
+
+	return tbls, nil
+}
+
+// htmlCell is a <td> or <th> cell.
+type htmlCell struct {
+	text     string
+	isHeader bool
+	colspan  int
+	rowspan  int
+}
+
+// parseTable parses the rows of tblNode. Rows of nested
+// tables are not included.
+func parseTable(tblNode *html.Node) *htmlTable {
+	// First we gather the rows (<tr>) of the table,
+	// which may be children of <thead>, <tbody> or <tfoot>.
+	var trs [][]*htmlCell
+	var headTRs []bool
+	for c := tblNode.FirstChild; c != nil; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Tr:
+			trs = append(trs, parseRow(c))
+			headTRs = append(headTRs, false)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for tr := c.FirstChild; tr != nil; tr = tr.NextSibling {
+				if tr.DataAtom == atom.Tr {
+					trs = append(trs, parseRow(tr))
+					headTRs = append(headTRs, c.DataAtom == atom.Thead)
+				}
+			}
+		}
+	}
+
+	tbl := &htmlTable{rows: expandSpans(trs)}
+
+	// The header rows are the leading rows that are in <thead>,
+	// or that consist only of <th> cells.
+	for i, tr := range trs {
+		if !headTRs[i] && !isHeaderRow(tr) {
+			break
+		}
+		tbl.headerRows++
+	}
+
+	if tbl.headerRows == len(tbl.rows) && len(tbl.rows) > 0 && !headTRs[0] {
+		// A table consisting only of <th> cells: we treat
+		// it as a data table.
+		tbl.headerRows = 0
+	}
+
+	return tbl
+}
+
+// isHeaderRow returns true if cells is non-empty, and
+// each of cells is a <th> cell.
+func isHeaderRow(cells []*htmlCell) bool {
+	if len(cells) == 0 {
+		return false
+	}
+
+	for _, cell := range cells {
+		if !cell.isHeader {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseRow parses the cells of <tr> node tr.
+func parseRow(tr *html.Node) []*htmlCell {
+	var cells []*htmlCell
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom != atom.Td && c.DataAtom != atom.Th {
+			continue
+		}
+
+		cells = append(cells, &htmlCell{
+			text:     nodeText(c),
+			isHeader: c.DataAtom == atom.Th,
+			colspan:  spanAttr(c, "colspan", maxColspan),
+			rowspan:  spanAttr(c, "rowspan", maxRowspan),
+		})
+	}
+
+	return cells
+}
+
+// expandSpans returns the grid of cell values for trs, with
+// each cell occupying the area per its colspan and rowspan.
+// Per the HTML spec, rowspan=0 extends the cell to the last row.
+func expandSpans(trs [][]*htmlCell) [][]*string {
+	grid := make([][]*string, len(trs))
+
+	set := func(row, col int, val *string) {
+		for len(grid[row]) <= col {
+			grid[row] = append(grid[row], nil)
+		}
+		grid[row][col] = val
+	}
+
+	for i, tr := range trs {
+		col := 0
+		for _, cell := range tr {
+			// Skip the columns occupied by cells (of earlier rows)
+			// that span into this row.
+			for col < len(grid[i]) && grid[i][col] != nil {
+				col++
+			}
+
+			lastRow := i + cell.rowspan - 1
+			if cell.rowspan == 0 || lastRow >= len(trs) {
+				lastRow = len(trs) - 1
+			}
+
+			text := cell.text
+			for row := i; row <= lastRow; row++ {
+				for j := 0; j < cell.colspan; j++ {
+					set(row, col+j, &text)
+				}
+			}
+
+			col += cell.colspan
+		}
+	}
+
+	return grid
+}
+
+// spanAttr returns the value of the colspan or rowspan attribute
+// attr of n, or 1 if not set or invalid. The value is capped
+// at maxVal. For rowspan, 0 is a valid value.
+func spanAttr(n *html.Node, attr string, maxVal int) int {
+	val, ok := nodeAttr(n, attr)
+	if !ok {
+		return 1
+	}
+
+	i, err := strconv.Atoi(strings.TrimSpace(val))
+	switch {
+	case err != nil, i < 0, i == 0 && attr != "rowspan":
+		return 1
+	case i > maxVal:
+		return maxVal
+	default:
+		return i
+	}
+}
+
+// nodeAttr returns the value of attribute key of n.
+func nodeAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// nodeText returns the text content of n, with whitespace
+// collapsed. The content of nested tables is excluded.
+func nodeText(n *html.Node) string {
+	sb := &strings.Builder{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			sb.WriteString(" ")
+		case n.DataAtom == atom.Table, n.DataAtom == atom.Script, n.DataAtom == atom.Style:
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// tableName returns a name for tblNode, which is the table at
+// index of the document. The name is the table's id attribute,
+// or its caption, or else "table1", "table2", etc. The name
+// is unique in takenNames (and is then added to takenNames).
+func tableName(tblNode *html.Node, index int, takenNames map[string]bool) string {
+	var name string
+	if id, ok := nodeAttr(tblNode, "id"); ok {
+		name = strings.TrimSpace(id)
+	}
+
+	if name == "" {
+		for c := tblNode.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Caption {
+				name = nodeText(c)
+				break
+			}
+		}
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("table%d", index+1)
+	}
+
+	name = stringz.SanitizeAlphaNumeric(name, '_')
+	if !unicode.IsLetter([]rune(name)[0]) {
+		name = "table_" + name
+	}
+
+	candidate := name
+	for i := 1; takenNames[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+
+	takenNames[candidate] = true
+	return candidate
+}
+
+// uniqNames returns names, with any duplicate names
+// suffixed, e.g. "name", "name_1", "name_2".
+func uniqNames(names []string) []string {
+	taken := map[string]bool{}
+	uniq := make([]string, len(names))
+	for i, name := range names {
+		candidate := name
+		for j := 1; taken[candidate]; j++ {
+			candidate = fmt.Sprintf("%s_%d", name, j)
+		}
+
+		taken[candidate] = true
+		uniq[i] = candidate
+	}
+
+	return uniq
+}
@@ -38,6 +38,15 @@ type importJob struct {
 	// imported as fields of the single top-level table, with a
 	// scoped column name.
 	flatten bool
+
+	// normalize specifies that each nested JSON array is imported
+	// to its own table, with a generated key column linking each
+	// row to its parent row. See OptNormalize.
+	normalize bool
+
+	// roots holds the root selectors of the values to import,
+	// each to its own table. See OptRoot.
+	roots []*rootSel
 }
 
 type importFunc func(ctx context.Context, log lg.Log, job importJob) error
@@ -86,6 +95,10 @@ type processor struct {
 	// if flattened is true, the JSON object will be flattened into a single table.
 	flatten bool
 
+	// if normalize is true, each nested array is imported to
+	// a child table. See import_normalize.go.
+	normalize bool
+
 	root   *entity
 	schema *importSchema
 
@@ -96,13 +109,26 @@ type processor struct {
 
 	unwrittenObjVals []objectValueSet
 	curObjVals       objectValueSet
+
+	// unwrittenRows holds the rows yet to be inserted when
+	// normalize is true, with each parent row preceding its
+	// child rows.
+	unwrittenRows []*normRow
+
+	// rowIDs holds the most recent generated row ID per table,
+	// when normalize is true.
+	rowIDs map[string]int64
 }
 
-func newProcessor(flatten bool) *processor {
+// newProcessor returns a new processor, whose objects are
+// imported to table tblName.
+func newProcessor(tblName string, flatten, normalize bool) *processor {
 	return &processor{
 		flatten:             flatten,
+		normalize:           normalize,
+		rowIDs:              map[string]int64{},
 		schema:              &importSchema{},
-		root:                &entity{name: source.MonotableName, detectors: map[string]*kind.Detector{}},
+		root:                &entity{name: tblName, detectors: map[string]*kind.Detector{}},
 		schemaDirtyEntities: map[*entity]struct{}{},
 	}
 }
@@ -126,8 +152,9 @@ func (p *processor) calcColName(ent *entity, fieldName string) string {
 		return fieldName
 	}
 
-	// Otherwise we namespace the column name.
-	if ent.parent == nil {
+	// Otherwise we namespace the column name, up to the
+	// entity of the table.
+	if ent.parent == nil || (p.normalize && ent.isArray) {
 		return fieldName
 	}
 
@@ -135,10 +162,27 @@ func (p *processor) calcColName(ent *entity, fieldName string) string {
 	return p.calcColName(ent.parent, colName)
 }
 
-// buildSchemaFlat currently only builds a flat (single table) schema.
+// buildSchema builds the schema for the objects processed so far.
+func (p *processor) buildSchema() (*importSchema, error) {
+	if p.normalize {
+		return p.buildSchemaNormalized()
+	}
+	return p.buildSchemaFlat()
+}
+
+// buildInsertions builds a set of DB insertions for the objects
+// processed since the previous invocation.
+func (p *processor) buildInsertions(schema *importSchema) ([]*insertion, error) {
+	if p.normalize {
+		return p.buildInsertionsNormalized(schema)
+	}
+	return p.buildInsertionsFlat(schema)
+}
+
+// buildSchemaFlat builds a flat (single table) schema.
 func (p *processor) buildSchemaFlat() (*importSchema, error) {
 	tblDef := &sqlmodel.TableDef{
-		Name: source.MonotableName,
+		Name: p.root.name,
 	}
 
 	var colDefs []*sqlmodel.ColDef
@@ -201,6 +245,13 @@ func (p *processor) buildSchemaFlat() (*importSchema, error) {
 // processObject processes the parsed JSON object m. If the structure
 // of the importSchema changes due to this object, dirtySchema returns true.
 func (p *processor) processObject(m map[string]interface{}, chunk []byte) (dirtySchema bool, err error) {
+	if p.normalize {
+		// The object is decoded again from chunk, so that the
+		// order of the fields of nested objects is known.
+		err = p.processObjectNormalized(chunk)
+		return len(p.schemaDirtyEntities) > 0, err
+	}
+
 	p.curObjVals = objectValueSet{}
 	err = p.doAddObject(p.root, m)
 	dirtySchema = len(p.schemaDirtyEntities) > 0
@@ -584,7 +635,11 @@ func decoderFindArrayClose(dec *stdj.Decoder) error {
 }
 
 // execInsertions performs db INSERT for each of the insertions.
-func execInsertions(ctx context.Context, log lg.Log, drvr driver.SQLDriver, db sqlz.DB, insertions []*insertion) error {
+// Arg rowCounts tracks the number of rows per table, and is used
+// to report the row number of a failed insertion. If ctx has a
+// driver.Rejects, a failed insertion is rejected, until the rejects
+// error budget is exhausted.
+func execInsertions(ctx context.Context, log lg.Log, drvr driver.SQLDriver, db sqlz.DB, insertions []*insertion, rowCounts map[string]int64) error {
 	// FIXME: This is an inefficient way of performing insertion.
 	//  We should be re-using the prepared statement, and probably
 	//  should batch the inserts as well. See driver.BatchInsert.
@@ -592,8 +647,10 @@ func execInsertions(ctx context.Context, log lg.Log, drvr driver.SQLDriver, db s
 	var err error
 	var execer *driver.StmtExecer
 	//var affected int64
+	rejects := driver.RejectsFrom(ctx)
 
 	for _, insert := range insertions {
+		rowCounts[insert.tbl]++
 		execer, err = drvr.PrepareInsertStmt(ctx, db, insert.tbl, insert.cols, 1)
 		if err != nil {
 			return err
@@ -606,6 +663,9 @@ func execInsertions(ctx context.Context, log lg.Log, drvr driver.SQLDriver, db s
 		}
 
 		_, err = execer.Exec(ctx, insert.vals...)
+		if err != nil && rejects != nil {
+			err = rejects.Reject(insert.tbl, rowCounts[insert.tbl], insert.vals, err)
+		}
 		if err != nil {
 			log.WarnIfCloseError(execer)
 			return err
@@ -131,6 +131,19 @@ func DetectJSON(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (de
 //func detectJSONObjectsInArray(ctx context.Context, r io.Reader)
 
 func importJSON(ctx context.Context, log lg.Log, job importJob) error {
+	for _, root := range job.roots {
+		err := importJSONRoot(ctx, log, job, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importJSONRoot imports the objects selected by root to
+// the table root.tbl.
+func importJSONRoot(ctx context.Context, log lg.Log, job importJob, root *rootSel) error {
 	r, err := job.openFn()
 	if err != nil {
 		return err
@@ -144,8 +157,14 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 	}
 	defer log.WarnIfCloseError(db)
 
-	proc := newProcessor(job.flatten)
+	proc := newProcessor(root.tbl, job.flatten, job.normalize)
 	scan := newObjectInArrayScanner(r)
+	if len(root.path) > 0 {
+		err = scan.selectRoot(root.path)
+		if err != nil {
+			return err
+		}
+	}
 
 	var (
 		obj            map[string]interface{}
@@ -156,6 +175,9 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 		hasMore        bool
 	)
 
+	// rowCounts holds the number of rows inserted per table.
+	rowCounts := map[string]int64{}
+
 	for {
 		obj, chunk, err = scan.next()
 		if err != nil {
@@ -173,7 +195,7 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 				}
 
 				var newSchema *importSchema
-				newSchema, err = proc.buildSchemaFlat()
+				newSchema, err = proc.buildSchema()
 				if err != nil {
 					return err
 				}
@@ -190,12 +212,12 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 				curSchema = newSchema
 				newSchema = nil
 
-				insertions, err = proc.buildInsertionsFlat(curSchema)
+				insertions, err = proc.buildInsertions(curSchema)
 				if err != nil {
 					return err
 				}
 
-				err = execInsertions(ctx, log, drvr, db, insertions)
+				err = execInsertions(ctx, log, drvr, db, insertions, rowCounts)
 				if err != nil {
 					return err
 				}
@@ -227,12 +249,12 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 
 		// The schema exists in the DB, and the current JSON chunk hasn't
 		// dirtied the schema, so it's safe to insert the recent rows.
-		insertions, err = proc.buildInsertionsFlat(curSchema)
+		insertions, err = proc.buildInsertions(curSchema)
 		if err != nil {
 			return err
 		}
 
-		err = execInsertions(ctx, log, drvr, db, insertions)
+		err = execInsertions(ctx, log, drvr, db, insertions, rowCounts)
 		if err != nil {
 			return err
 		}
@@ -240,6 +262,9 @@ func importJSON(ctx context.Context, log lg.Log, job importJob) error {
 	}
 
 	if scan.objCount == 0 {
+		if len(root.path) > 0 {
+			return errz.Errorf("root %s: no JSON objects", formatPath(root.path))
+		}
 		return errz.New("empty JSON input")
 	}
 
@@ -280,6 +305,19 @@ type objectsInArrayScanner struct {
 
 	// objCount is the count of objects processed by method next.
 	objCount int
+
+	// nested is true if the scanned array (or object) was selected
+	// by selectRoot, and thus is nested in the input. If so, the
+	// input following the array is not read.
+	nested bool
+
+	// rootObj and rootObjChunk are set if selectRoot selected
+	// a single object instead of an array.
+	rootObj      map[string]interface{}
+	rootObjChunk []byte
+
+	// done is true when the end of the array has been reached.
+	done bool
 }
 
 // newObjectInArrayScanner returns a new instance that
@@ -299,6 +337,16 @@ func newObjectInArrayScanner(r io.Reader) *objectsInArrayScanner {
 func (s *objectsInArrayScanner) next() (obj map[string]interface{}, chunk []byte, err error) {
 	var tok stdj.Token
 
+	if s.done {
+		return nil, nil, nil
+	}
+
+	if s.rootObj != nil {
+		s.done = true
+		s.objCount++
+		return s.rootObj, s.rootObjChunk, nil
+	}
+
 	if s.bufOffset == 0 {
 		// This is only invoked on the first call to next().
 
@@ -318,6 +366,10 @@ func (s *objectsInArrayScanner) next() (obj map[string]interface{}, chunk []byte
 	more := s.dec.More()
 	if !more {
 		// We've reached the end of the stream.
+		s.done = true
+		if s.nested {
+			return nil, nil, nil
+		}
 
 		// Make sure there's no trailing invalid stuff
 		s.decBuf, err = ioutil.ReadAll(s.dec.Buffered())
@@ -375,6 +427,13 @@ func (s *objectsInArrayScanner) next() (obj map[string]interface{}, chunk []byte
 			return nil, nil, errz.New("unexpected additional JSON input after closing ']'")
 		}
 
+		// The next invocation of next returns nil, without reading
+		// further input.
+		s.done = true
+		if s.nested {
+			break
+		}
+
 		// Make sure there's no invalid trailing stuff
 		s.decBuf, err = ioutil.ReadAll(s.dec.Buffered())
 		if err != nil {
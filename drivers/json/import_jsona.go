@@ -89,6 +89,19 @@ func DetectJSONA(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (d
 }
 
 func importJSONA(ctx context.Context, log lg.Log, job importJob) error {
+	for _, root := range job.roots {
+		err := importJSONARoot(ctx, log, job, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importJSONARoot imports the array selected by root from
+// each line to the table root.tbl.
+func importJSONARoot(ctx context.Context, log lg.Log, job importJob, root *rootSel) error {
 	predictR, err := job.openFn()
 	if err != nil {
 		return errz.Err(err)
@@ -96,7 +109,7 @@ func importJSONA(ctx context.Context, log lg.Log, job importJob) error {
 
 	defer log.WarnIfCloseError(predictR)
 
-	colKinds, readMungeFns, err := detectColKindsJSONA(ctx, predictR)
+	colKinds, readMungeFns, err := detectColKindsJSONA(ctx, predictR, root.path)
 	if err != nil {
 		return err
 	}
@@ -111,7 +124,7 @@ func importJSONA(ctx context.Context, log lg.Log, job importJob) error {
 	}
 
 	// And now we need to create the dest table in destDB
-	tblDef := sqlmodel.NewTableDef(source.MonotableName, colNames, colKinds)
+	tblDef := sqlmodel.NewTableDef(root.tbl, colNames, colKinds)
 	err = job.destDB.SQLDriver().CreateTable(ctx, job.destDB.DB(), tblDef)
 	if err != nil {
 		return errz.Wrapf(err, "import %s: failed to create dest scratch table", TypeJSONA)
@@ -141,7 +154,7 @@ func importJSONA(ctx context.Context, log lg.Log, job importJob) error {
 
 	// After startInsertJSONA returns, we sill need to wait
 	// for the insertWriter to finish.
-	err = startInsertJSONA(ctx, recordCh, errCh, r, readMungeFns)
+	err = startInsertJSONA(ctx, recordCh, errCh, r, readMungeFns, root.path)
 	if err != nil {
 		return err
 	}
@@ -156,8 +169,10 @@ func importJSONA(ctx context.Context, log lg.Log, job importJob) error {
 }
 
 // startInsertJSONA reads JSON records from r and sends
-// them on recordCh.
-func startInsertJSONA(ctx context.Context, recordCh chan<- sqlz.Record, errCh <-chan error, r io.Reader, mungeFns []kind.MungeFunc) error {
+// them on recordCh. If path is non-empty, the record is
+// the array selected by path from each line.
+func startInsertJSONA(ctx context.Context, recordCh chan<- sqlz.Record, errCh <-chan error, r io.Reader,
+	mungeFns []kind.MungeFunc, path []pathSeg) error {
 	defer close(recordCh)
 
 	sc := bufio.NewScanner(r)
@@ -182,9 +197,13 @@ func startInsertJSONA(ctx context.Context, recordCh chan<- sqlz.Record, errCh <-
 
 		// If the line is JSONA, it should marshal into []interface{}
 		var rec []interface{}
-		err = stdj.Unmarshal(line, &rec)
+		rec, err = lineArray(line, path)
 		if err != nil {
-			return errz.Err(err)
+			return err
+		}
+
+		if len(rec) != len(mungeFns) {
+			return errz.Errorf("inconsistent field count: expected %d but got %d", len(mungeFns), len(rec))
 		}
 
 		for i := 0; i < len(rec); i++ {
@@ -217,7 +236,9 @@ func startInsertJSONA(ctx context.Context, recordCh chan<- sqlz.Record, errCh <-
 // detectColKindsJSONA reads JSONA lines from r, and returns
 // the kind of each field. The []readMungeFunc may contain a munge
 // func that should be applied to each value (or the element may be nil).
-func detectColKindsJSONA(ctx context.Context, r io.Reader) ([]kind.Kind, []kind.MungeFunc, error) {
+// If path is non-empty, the fields are those of the array selected
+// by path from each line.
+func detectColKindsJSONA(ctx context.Context, r io.Reader, path []pathSeg) ([]kind.Kind, []kind.MungeFunc, error) {
 	var (
 		err            error
 		totalLineCount int
@@ -261,9 +282,9 @@ func detectColKindsJSONA(ctx context.Context, r io.Reader) ([]kind.Kind, []kind.
 
 		// If the line is JSONA, it should marshall into []interface{}
 		var vals []interface{}
-		err = stdj.Unmarshal(line, &vals)
+		vals, err = lineArray(line, path)
 		if err != nil {
-			return nil, nil, errz.Err(err)
+			return nil, nil, errz.Wrapf(err, "line %d", totalLineCount)
 		}
 
 		if len(vals) == 0 {
@@ -75,6 +75,19 @@ func DetectJSONL(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (d
 }
 
 func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
+	for _, root := range job.roots {
+		err := importJSONLRoot(ctx, log, job, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importJSONLRoot imports the objects selected by root from
+// each line to the table root.tbl.
+func importJSONLRoot(ctx context.Context, log lg.Log, job importJob, root *rootSel) error {
 	r, err := job.openFn()
 	if err != nil {
 		return err
@@ -88,7 +101,7 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 	}
 	defer log.WarnIfCloseError(db)
 
-	proc := newProcessor(job.flatten)
+	proc := newProcessor(root.tbl, job.flatten, job.normalize)
 	scan := newLineScanner(ctx, r, '{')
 
 	var (
@@ -97,8 +110,12 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 		line           []byte
 		curSchema      *importSchema
 		insertions     []*insertion
+		objCount       int
 	)
 
+	// rowCounts holds the number of rows inserted per table.
+	rowCounts := map[string]int64{}
+
 	for {
 		hasMore, line, err = scan.next()
 		if err != nil {
@@ -113,7 +130,7 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 				}
 
 				var newSchema *importSchema
-				newSchema, err = proc.buildSchemaFlat()
+				newSchema, err = proc.buildSchema()
 				if err != nil {
 					return err
 				}
@@ -130,12 +147,12 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 				curSchema = newSchema
 				newSchema = nil
 
-				insertions, err = proc.buildInsertionsFlat(curSchema)
+				insertions, err = proc.buildInsertions(curSchema)
 				if err != nil {
 					return err
 				}
 
-				err = execInsertions(ctx, log, drvr, db, insertions)
+				err = execInsertions(ctx, log, drvr, db, insertions, rowCounts)
 				if err != nil {
 					return err
 				}
@@ -147,22 +164,29 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 			}
 		}
 
-		var m map[string]interface{}
-		dec := stdj.NewDecoder(bytes.NewReader(line))
+		if !hasMore {
+			break
+		}
 
-		err = dec.Decode(&m)
+		var objs []map[string]interface{}
+		var chunks [][]byte
+		objs, chunks, err = lineObjects(line, root.path)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return errz.Err(err)
+			return errz.Wrapf(err, "line %d", scan.totalLineCount-1)
 		}
 
-		schemaModified, err = proc.processObject(m, line)
-		if err != nil {
-			return err
+		objCount += len(objs)
+		for i := range objs {
+			_, err = proc.processObject(objs[i], chunks[i])
+			if err != nil {
+				return err
+			}
 		}
 
+		// A line may have any number of objects (including none)
+		// when root is selected, so we check the processor directly.
+		schemaModified = len(proc.schemaDirtyEntities) > 0
+
 		// Initial schema has not been created: we're still in
 		// the sampling phase. So we loop.
 		if curSchema == nil {
@@ -178,12 +202,12 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 
 		// The schema exists in the DB, and the current JSON chunk hasn't
 		// dirtied the schema, so it's safe to insert the recent rows.
-		insertions, err = proc.buildInsertionsFlat(curSchema)
+		insertions, err = proc.buildInsertions(curSchema)
 		if err != nil {
 			return err
 		}
 
-		err = execInsertions(ctx, log, drvr, db, insertions)
+		err = execInsertions(ctx, log, drvr, db, insertions, rowCounts)
 		if err != nil {
 			return err
 		}
@@ -194,6 +218,10 @@ func importJSONL(ctx context.Context, log lg.Log, job importJob) error {
 		return errz.New("empty JSONL input")
 	}
 
+	if objCount == 0 {
+		return errz.Errorf("root %s: no JSON objects", formatPath(root.path))
+	}
+
 	return nil
 }
 
@@ -0,0 +1,453 @@
+package json
+
+// import_normalize.go contains the functionality for the normalized
+// import of JSON objects, where each nested array is imported to
+// its own table. For example, for this object:
+//
+//  {"order_id": 1, "items": [{"sku": "A1", "tags": ["new"]}]}
+//
+// The import creates these tables:
+//
+//  data(sq_id, order_id)
+//  data_items(sq_id, sq_parent_id, sku)
+//  data_items_tags(sq_id, sq_parent_id, value)
+//
+// The sq_id column is the generated key of each table's rows, and
+// the sq_parent_id column is a foreign key referencing the sq_id
+// of the parent row. The name of a child table is the name of the
+// parent table, suffixed with the (flattened) name of the array
+// field. An element of an array that is not an object is imported
+// to the "value" column.
+
+import (
+	"bytes"
+	stdj "encoding/json"
+	"sort"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+)
+
+const (
+	// colRowID is the name of the generated key column of
+	// each table of a normalized import.
+	colRowID = "sq_id"
+
+	// colParentID is the name of the generated column of a child
+	// table of a normalized import, which references the colRowID
+	// column of the parent table.
+	colParentID = "sq_parent_id"
+
+	// fieldValue is the name of the column of a child table that
+	// holds the array elements that are not objects.
+	fieldValue = "value"
+)
+
+// orderedField is a field of an orderedObject.
+type orderedField struct {
+	name string
+	val  interface{}
+}
+
+// orderedObject is a JSON object, with its fields in the
+// order in which they appear in the JSON text.
+type orderedObject []orderedField
+
+// decodeOrdered decodes the next JSON value from dec. An object
+// is decoded as orderedObject, and an array as []interface{}.
+func decodeOrdered(dec *stdj.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	delim, ok := tok.(stdj.Delim)
+	if !ok {
+		// It's a regular value
+		return tok, nil
+	}
+
+	switch delim {
+	case leftBrace:
+		obj := orderedObject{}
+		for dec.More() {
+			tok, err = dec.Token()
+			if err != nil {
+				return nil, errz.Err(err)
+			}
+
+			name, ok := tok.(string)
+			if !ok {
+				return nil, errz.Errorf("expected string field name but got %T: %s", tok, formatToken(tok))
+			}
+
+			var val interface{}
+			val, err = decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			obj = append(obj, orderedField{name: name, val: val})
+		}
+
+		_, err = requireDelimToken(dec, rightBrace)
+		if err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case leftBracket:
+		arr := []interface{}{}
+		for dec.More() {
+			var val interface{}
+			val, err = decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			arr = append(arr, val)
+		}
+
+		_, err = requireDelimToken(dec, rightBracket)
+		if err != nil {
+			return nil, err
+		}
+		return arr, nil
+
+	default:
+		return nil, errz.Errorf("unexpected JSON delimiter: %s", formatToken(tok))
+	}
+}
+
+// normRow is a row of a normalized import.
+type normRow struct {
+	// ent is the entity of the row's table: the root entity,
+	// or an array entity.
+	ent *entity
+
+	// parent is the parent row, or nil for a row of the root entity.
+	parent *normRow
+
+	// vals is a map of column name to value.
+	vals map[string]interface{}
+
+	// id is the generated row ID, which is assigned when
+	// the row's insertion is built.
+	id int64
+}
+
+// processObjectNormalized processes the JSON object in chunk.
+func (p *processor) processObjectNormalized(chunk []byte) error {
+	v, err := decodeOrdered(stdj.NewDecoder(bytes.NewReader(chunk)))
+	if err != nil {
+		return err
+	}
+
+	obj, ok := v.(orderedObject)
+	if !ok {
+		return errz.Errorf("expected JSON object but got %T", v)
+	}
+
+	row := &normRow{ent: p.root, vals: map[string]interface{}{}}
+	p.unwrittenRows = append(p.unwrittenRows, row)
+	return p.addObjectNormalized(p.root, row, obj)
+}
+
+// addObjectNormalized adds the fields of obj (an object of entity
+// ent) to row. The elements of any array field are added as rows
+// of a child table.
+func (p *processor) addObjectNormalized(ent *entity, row *normRow, obj orderedObject) error {
+	for _, field := range obj {
+		switch val := field.val.(type) {
+		case orderedObject:
+			child, err := p.getOrAddChild(ent, field.name, false)
+			if err != nil {
+				return err
+			}
+
+			err = p.addObjectNormalized(child, row, val)
+			if err != nil {
+				return err
+			}
+
+		case []interface{}:
+			child, err := p.getOrAddChild(ent, field.name, true)
+			if err != nil {
+				return err
+			}
+
+			err = p.addArrayNormalized(child, row, val)
+			if err != nil {
+				return err
+			}
+
+		default:
+			err := p.addValueNormalized(ent, row, field.name, val)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addArrayNormalized adds a row (a child row of parent) for each
+// element of arr, which is an array of entity arrEnt.
+func (p *processor) addArrayNormalized(arrEnt *entity, parent *normRow, arr []interface{}) error {
+	for _, elem := range arr {
+		row := &normRow{ent: arrEnt, parent: parent, vals: map[string]interface{}{}}
+		p.unwrittenRows = append(p.unwrittenRows, row)
+
+		var err error
+		switch elem := elem.(type) {
+		case orderedObject:
+			err = p.addObjectNormalized(arrEnt, row, elem)
+		case []interface{}:
+			// An array of arrays: the elements of the nested
+			// array are rows of a further child table.
+			var child *entity
+			child, err = p.getOrAddChild(arrEnt, fieldValue, true)
+			if err == nil {
+				err = p.addArrayNormalized(child, row, elem)
+			}
+		default:
+			err = p.addValueNormalized(arrEnt, row, fieldValue, elem)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOrAddChild returns ent's child entity for the object or
+// array field fieldName, adding the child if necessary.
+func (p *processor) getOrAddChild(ent *entity, fieldName string, isArray bool) (*entity, error) {
+	child := ent.getChild(fieldName)
+	if child != nil {
+		if child.isArray != isArray {
+			return nil, errz.Errorf("JSON field %q was previously detected as %s, but now detected as %s",
+				ent.fqFieldName(fieldName), entityKind(child.isArray), entityKind(isArray))
+		}
+		return child, nil
+	}
+
+	if detector, ok := ent.detectors[fieldName]; ok {
+		if k, _, _ := detector.Detect(); k != kind.Null {
+			return nil, errz.Errorf("JSON field %q was previously detected as a value, but now detected as %s",
+				ent.fqFieldName(fieldName), entityKind(isArray))
+		}
+
+		// The field was previously only null: it's not a
+		// value after all.
+		delete(ent.detectors, fieldName)
+	}
+
+	p.markSchemaDirty(ent)
+	if !stringz.InSlice(ent.fieldNames, fieldName) {
+		ent.fieldNames = append(ent.fieldNames, fieldName)
+	}
+
+	child = &entity{
+		name:      fieldName,
+		isArray:   isArray,
+		parent:    ent,
+		detectors: map[string]*kind.Detector{},
+	}
+	ent.children = append(ent.children, child)
+	return child, nil
+}
+
+func entityKind(isArray bool) string {
+	if isArray {
+		return "array"
+	}
+	return "object"
+}
+
+// addValueNormalized sets the value of the field fieldName of
+// entity ent, in row. A null value is not set in row, as the
+// column is NULL anyway if it's not inserted.
+func (p *processor) addValueNormalized(ent *entity, row *normRow, fieldName string, val interface{}) error {
+	detector, ok := ent.detectors[fieldName]
+	if !ok {
+		if ent.getChild(fieldName) != nil {
+			if val == nil {
+				// A null object or array.
+				return nil
+			}
+
+			return errz.Errorf("JSON field %q was previously detected as a nested field (object or array)",
+				ent.fqFieldName(fieldName))
+		}
+
+		p.markSchemaDirty(ent)
+		ent.fieldNames = append(ent.fieldNames, fieldName)
+		detector = kind.NewDetector()
+		ent.detectors[fieldName] = detector
+	}
+
+	if val == nil {
+		return nil
+	}
+
+	row.vals[p.calcColName(ent, fieldName)] = val
+	detector.Sample(maybeFloatToInt(val))
+	return nil
+}
+
+// buildSchemaNormalized builds a schema with a table for the root
+// entity, and a child table for each array entity.
+func (p *processor) buildSchemaNormalized() (*importSchema, error) {
+	schema := &importSchema{
+		colMungeFns: map[*sqlmodel.ColDef]kind.MungeFunc{},
+		entityTbls:  map[*entity]*sqlmodel.TableDef{},
+	}
+
+	err := p.buildTblDefNormalized(schema, p.root, p.root.name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// buildTblDefNormalized adds to schema the def of table tblName for
+// entity tblEnt (the root entity, or an array entity), and the defs
+// of the child tables of tblEnt's arrays. Arg parentTbl is nil for
+// the root entity.
+func (p *processor) buildTblDefNormalized(schema *importSchema, tblEnt *entity, tblName string,
+	parentTbl *sqlmodel.TableDef) error {
+	if schema.getTableDef(tblName) != nil {
+		return errz.Errorf("JSON array %q: table name %q is already used by another array", tblEnt, tblName)
+	}
+
+	tblDef := &sqlmodel.TableDef{Name: tblName, PKColName: colRowID}
+	tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{Name: colRowID, Table: tblDef, Kind: kind.Int})
+	if parentTbl != nil {
+		tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{
+			Name:       colParentID,
+			Table:      tblDef,
+			Kind:       kind.Int,
+			ForeignKey: &sqlmodel.FKConstraint{RefTable: parentTbl.Name, RefCol: colRowID},
+		})
+	}
+	schema.tblDefs = append(schema.tblDefs, tblDef)
+
+	// The fields of nested objects are columns of this table, while
+	// the elements of nested arrays are rows of a child table.
+	var childArrays []*entity
+	var addCols func(ent *entity) error
+	addCols = func(ent *entity) error {
+		schema.entityTbls[ent] = tblDef
+
+		for _, field := range ent.fieldNames {
+			detector, ok := ent.detectors[field]
+			if !ok {
+				child := ent.getChild(field)
+				if child == nil {
+					continue
+				}
+
+				if child.isArray {
+					childArrays = append(childArrays, child)
+					continue
+				}
+
+				err := addCols(child)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			k, mungeFn, err := detector.Detect()
+			if err != nil {
+				return errz.Err(err)
+			}
+
+			if k == kind.Null {
+				k = kind.Text
+			}
+
+			colName := p.calcColName(ent, field)
+			if colName == colRowID || colName == colParentID {
+				return errz.Errorf("JSON field %q: column name %q is reserved for the generated key",
+					ent.fqFieldName(field), colName)
+			}
+
+			colDef := &sqlmodel.ColDef{Name: colName, Table: tblDef, Kind: k}
+			tblDef.Cols = append(tblDef.Cols, colDef)
+			if mungeFn != nil {
+				schema.colMungeFns[colDef] = mungeFn
+			}
+		}
+
+		return nil
+	}
+
+	err := addCols(tblEnt)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range childArrays {
+		childTblName := tblName + colScopeSep + p.calcColName(child.parent, child.name)
+		err = p.buildTblDefNormalized(schema, child, childTblName, tblDef)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildInsertionsNormalized builds a set of DB insertions from
+// the processor's unwrittenRows. A parent row's insertion precedes
+// the insertions of its child rows. After a non-error return,
+// unwrittenRows is empty.
+func (p *processor) buildInsertionsNormalized(schema *importSchema) ([]*insertion, error) {
+	insertions := make([]*insertion, 0, len(p.unwrittenRows))
+
+	for _, row := range p.unwrittenRows {
+		tblDef, ok := schema.entityTbls[row.ent]
+		if !ok {
+			return nil, errz.Errorf("no table for JSON entity %q", row.ent)
+		}
+
+		p.rowIDs[tblDef.Name]++
+		row.id = p.rowIDs[tblDef.Name]
+
+		colNames := make([]string, 0, len(row.vals))
+		for colName := range row.vals {
+			colNames = append(colNames, colName)
+		}
+		sort.Strings(colNames)
+
+		vals := make([]interface{}, 0, len(colNames)+2)
+		vals = append(vals, row.id)
+		if row.parent != nil {
+			vals = append(vals, row.parent.id)
+		}
+		for _, colName := range colNames {
+			vals = append(vals, row.vals[colName])
+		}
+
+		if row.parent != nil {
+			colNames = append([]string{colRowID, colParentID}, colNames...)
+		} else {
+			colNames = append([]string{colRowID}, colNames...)
+		}
+
+		insertions = append(insertions, newInsertion(tblDef.Name, colNames, vals))
+	}
+
+	p.unwrittenRows = p.unwrittenRows[:0]
+
+	return insertions, nil
+}
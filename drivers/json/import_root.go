@@ -0,0 +1,401 @@
+package json
+
+// import_root.go contains the functionality for the root selectors
+// of OptRoot, which select the part of the JSON input that is
+// imported. For example, for this input:
+//
+//  {"meta": {"page": 1}, "data": {"results": [{"a": 1}, {"a": 2}]}}
+//
+// The root "$.data.results" selects the array of objects to import.
+// A selector is a JSONPath-style expression: "$" (the entire input),
+// followed by any number of field segments (".results" or
+// "['results']") and index segments ("[0]"). A trailing wildcard
+// segment "[*]" is permitted, and has no effect.
+
+import (
+	"bytes"
+	stdj "encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// rootSel is a root selector, which selects the JSON value that
+// is imported to a table.
+type rootSel struct {
+	// tbl is the name of the table that the value is imported to.
+	tbl string
+
+	// path is the path to the value. If empty, the entire
+	// input is selected.
+	path []pathSeg
+}
+
+// defaultRoots returns the root selectors used when OptRoot is
+// not set: the entire input is imported to table "data".
+func defaultRoots() []*rootSel {
+	return []*rootSel{{tbl: source.MonotableName}}
+}
+
+// pathSeg is a segment of a root selector path. A segment
+// either selects the field of an object, or the element of
+// an array (if isIndex is true).
+type pathSeg struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+func (s pathSeg) String() string {
+	if s.isIndex {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return "." + s.name
+}
+
+// formatPath returns the selector expression of path,
+// e.g. "$.data.results".
+func formatPath(path []pathSeg) string {
+	sb := strings.Builder{}
+	sb.WriteString("$")
+	for _, seg := range path {
+		sb.WriteString(seg.String())
+	}
+	return sb.String()
+}
+
+// getRoots returns the root selectors of OptRoot, or defaultRoots
+// if not set. A value of OptRoot is a selector expression such as
+// "$.data.results", optionally prefixed with a table name, as in
+// "users:$.data.users". If there is a single root, the table name
+// defaults to "data"; otherwise it defaults to the last field name
+// of the path.
+func getRoots(opts options.Options) ([]*rootSel, error) {
+	vals := opts[OptRoot]
+	if len(vals) == 0 {
+		return defaultRoots(), nil
+	}
+
+	roots := make([]*rootSel, len(vals))
+	for i, val := range vals {
+		root, err := parseRoot(val)
+		if err != nil {
+			return nil, errz.Errorf("option %q: %v", OptRoot, err)
+		}
+		roots[i] = root
+	}
+
+	tblNames := map[string]bool{}
+	for _, root := range roots {
+		if root.tbl == "" {
+			switch {
+			case len(roots) == 1 || len(root.path) == 0:
+				root.tbl = source.MonotableName
+			case root.path[len(root.path)-1].isIndex:
+				return nil, errz.Errorf("option %q: root %s requires a table name, e.g. \"tbl:%s\"",
+					OptRoot, formatPath(root.path), formatPath(root.path))
+			default:
+				root.tbl = root.path[len(root.path)-1].name
+			}
+		}
+
+		if tblNames[root.tbl] {
+			return nil, errz.Errorf("option %q: duplicate table name %q", OptRoot, root.tbl)
+		}
+		tblNames[root.tbl] = true
+	}
+
+	return roots, nil
+}
+
+// parseRoot parses a value of OptRoot, such as "$.data.results"
+// or "users:$.data.users".
+func parseRoot(val string) (*rootSel, error) {
+	root := &rootSel{}
+	expr := val
+	if !strings.HasPrefix(val, "$") {
+		i := strings.IndexByte(val, ':')
+		if i <= 0 {
+			return nil, errz.Errorf("invalid root %q: expected a selector such as \"$.data\" or \"tbl:$.data\"", val)
+		}
+		root.tbl, expr = val[:i], val[i+1:]
+	}
+
+	var err error
+	root.path, err = parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parsePath parses a selector expression such as "$.data.results".
+func parsePath(expr string) ([]pathSeg, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, errz.Errorf("invalid root %q: should begin with '$'", expr)
+	}
+
+	var path []pathSeg
+	s := expr[1:]
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			i := strings.IndexAny(s, ".[")
+			if i == -1 {
+				i = len(s)
+			}
+
+			if i == 0 {
+				return nil, errz.Errorf("invalid root %q: empty field name", expr)
+			}
+
+			path = append(path, pathSeg{name: s[:i]})
+			s = s[i:]
+		case '[':
+			i := strings.IndexByte(s, ']')
+			if i == -1 {
+				return nil, errz.Errorf("invalid root %q: missing ']'", expr)
+			}
+
+			inner := s[1:i]
+			s = s[i+1:]
+
+			switch {
+			case inner == "*":
+				if len(s) > 0 {
+					return nil, errz.Errorf("invalid root %q: wildcard '[*]' is only permitted at the end", expr)
+				}
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, pathSeg{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, errz.Errorf("invalid root %q: invalid index %q", expr, inner)
+				}
+				path = append(path, pathSeg{index: n, isIndex: true})
+			}
+		default:
+			return nil, errz.Errorf("invalid root %q: unexpected character %q", expr, s[0])
+		}
+	}
+
+	return path, nil
+}
+
+// selectRoot advances s to the JSON value at path, which must be an
+// array of objects or a single object. The subsequent invocations of
+// s.next return the objects of the array, or the single object. The
+// input following the selected value is not read.
+func (s *objectsInArrayScanner) selectRoot(path []pathSeg) error {
+	for i, seg := range path {
+		found, err := decoderSelect(s.dec, seg)
+		if err != nil {
+			return errz.Wrapf(err, "root %s", formatPath(path[:i+1]))
+		}
+
+		if !found {
+			return errz.Errorf("root %s: not found", formatPath(path[:i+1]))
+		}
+	}
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		return errz.Wrapf(err, "root %s", formatPath(path))
+	}
+
+	s.nested = true
+
+	switch tok {
+	case leftBracket:
+		// Sync s.buf with the position in the stream, as next
+		// would do for an array at the start of the input.
+		s.prevDecPos = int(s.dec.InputOffset())
+		s.buf.b = s.buf.b[s.prevDecPos:]
+		s.bufOffset = s.prevDecPos
+		return nil
+
+	case leftBrace:
+		start := int(s.dec.InputOffset()) - 1
+		err = decoderFindObjectClose(s.dec)
+		if err != nil {
+			return errz.Wrapf(err, "root %s", formatPath(path))
+		}
+		end := int(s.dec.InputOffset())
+
+		s.rootObjChunk = make([]byte, end-start)
+		copy(s.rootObjChunk, s.buf.b[start:end])
+
+		err = stdj.Unmarshal(s.rootObjChunk, &s.rootObj)
+		if err != nil {
+			return errz.Wrapf(err, "root %s", formatPath(path))
+		}
+		return nil
+
+	default:
+		return errz.Errorf("root %s: expected an array or object but got: %s", formatPath(path), formatToken(tok))
+	}
+}
+
+// decoderSelect advances dec to the value of the field (or array
+// element) of seg, of the object (or array) whose opening delimiter
+// is the next token of dec. If the field or element does not exist,
+// found is false.
+func decoderSelect(dec *stdj.Decoder, seg pathSeg) (found bool, err error) {
+	want := leftBrace
+	if seg.isIndex {
+		want = leftBracket
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return false, errz.Err(err)
+	}
+
+	if tok != want {
+		return false, errz.Errorf("expected %q but got: %s", string(want), formatToken(tok))
+	}
+
+	for i := 0; dec.More(); i++ {
+		if seg.isIndex {
+			if i == seg.index {
+				return true, nil
+			}
+		} else {
+			// Consume the field name
+			tok, err = dec.Token()
+			if err != nil {
+				return false, errz.Err(err)
+			}
+
+			if tok == seg.name {
+				return true, nil
+			}
+		}
+
+		err = decoderSkipValue(dec)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// decoderSkipValue advances dec past the next JSON value.
+func decoderSkipValue(dec *stdj.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	switch tok {
+	case leftBrace:
+		return decoderFindObjectClose(dec)
+	case leftBracket:
+		return decoderFindArrayClose(dec)
+	default:
+		return nil
+	}
+}
+
+// decoderFindObjectClose advances dec until a closing
+// right-brace '}' is located at the correct nesting level.
+// The most-recently returned decoder token should have been
+// the opening left-brace '{'.
+func decoderFindObjectClose(dec *stdj.Decoder) error {
+	var depth int
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		switch tok {
+		case leftBrace:
+			depth++
+		case rightBrace:
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// lineObjects returns the objects of a JSONL line selected by path,
+// and the chunk of JSON that each object was decoded from. If path is
+// empty, the line itself is the single object.
+func lineObjects(line []byte, path []pathSeg) (objs []map[string]interface{}, chunks [][]byte, err error) {
+	if len(path) == 0 {
+		var m map[string]interface{}
+		err = stdj.NewDecoder(bytes.NewReader(line)).Decode(&m)
+		if err != nil {
+			return nil, nil, errz.Err(err)
+		}
+		return []map[string]interface{}{m}, [][]byte{line}, nil
+	}
+
+	sc := newObjectInArrayScanner(bytes.NewReader(line))
+	err = sc.selectRoot(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		obj, chunk, err := sc.next()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if obj == nil {
+			return objs, chunks, nil
+		}
+
+		objs = append(objs, obj)
+		chunks = append(chunks, chunk)
+	}
+}
+
+// lineArray returns the array of a JSONA line selected by path. If
+// path is empty, the line itself is the array.
+func lineArray(line []byte, path []pathSeg) ([]interface{}, error) {
+	var v interface{}
+	err := stdj.Unmarshal(line, &v)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	for i, seg := range path {
+		var ok bool
+		switch {
+		case seg.isIndex:
+			var arr []interface{}
+			if arr, ok = v.([]interface{}); ok && seg.index < len(arr) {
+				v = arr[seg.index]
+			} else {
+				ok = false
+			}
+		default:
+			var obj map[string]interface{}
+			if obj, ok = v.(map[string]interface{}); ok {
+				v, ok = obj[seg.name]
+			}
+		}
+
+		if !ok {
+			return nil, errz.Errorf("root %s: not found", formatPath(path[:i+1]))
+		}
+	}
+
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, errz.Errorf("root %s: expected an array but got %T", formatPath(path), v)
+	}
+
+	return arr, nil
+}
@@ -14,7 +14,10 @@ import (
 
 	"github.com/neilotoole/sq/drivers/json"
 	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
 	"github.com/neilotoole/sq/testh/sakila"
 	"github.com/neilotoole/sq/testh/testsrc"
 )
@@ -119,6 +122,165 @@ func TestImportJSON_Flat(t *testing.T) {
 	require.Equal(t, sakila.TblActorCount, len(sink.Recs))
 }
 
+func TestImportJSON_Normalize(t *testing.T) {
+	th := testh.New(t)
+	src := &source.Source{
+		Handle:   "@orders_nested",
+		Type:     json.TypeJSON,
+		Location: proj.Abs("drivers/json/testdata/orders_nested.json"),
+		Options:  options.Options{json.OptNormalize: []string{"true"}},
+	}
+
+	testCases := []struct {
+		tbl      string
+		wantCols []string
+		wantRecs [][]interface{}
+	}{
+		{
+			tbl:      "data",
+			wantCols: []string{"sq_id", "order_id", "customer_name"},
+			wantRecs: [][]interface{}{{int64(1), int64(1001), "Alice"}, {int64(2), int64(1002), "Bob"}},
+		},
+		{
+			tbl:      "data_items",
+			wantCols: []string{"sq_id", "sq_parent_id", "sku", "qty"},
+			wantRecs: [][]interface{}{
+				{int64(1), int64(1), "A1", int64(2)},
+				{int64(2), int64(1), "B7", int64(1)},
+				{int64(3), int64(2), "C3", int64(5)},
+			},
+		},
+		{
+			tbl:      "data_items_options",
+			wantCols: []string{"sq_id", "sq_parent_id", "value"},
+			wantRecs: [][]interface{}{
+				{int64(1), int64(1), "red"},
+				{int64(2), int64(1), "large"},
+				{int64(3), int64(3), "blue"},
+			},
+		},
+		{
+			tbl:      "data_customer_tags",
+			wantCols: []string{"sq_id", "sq_parent_id", "value"},
+			wantRecs: [][]interface{}{
+				{int64(1), int64(1), "vip"},
+				{int64(2), int64(1), "early"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.tbl, func(t *testing.T) {
+			sink, err := th.QuerySQL(src, "SELECT * FROM "+tc.tbl+" ORDER BY sq_id")
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCols, sink.RecMeta.Names())
+			require.Equal(t, len(tc.wantRecs), len(sink.Recs))
+			for i := range tc.wantRecs {
+				for j := range tc.wantRecs[i] {
+					require.Equal(t, tc.wantRecs[i][j], testh.Val(sink.Recs[i][j]))
+				}
+			}
+		})
+	}
+
+	dbase := th.Open(src)
+	srcMeta, err := dbase.SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(srcMeta.Tables))
+
+	tblMeta, err := dbase.TableMetadata(th.Context, "data_items_options")
+	require.NoError(t, err)
+	require.Nil(t, tblMeta.Columns[0].ForeignKey)
+	require.NotNil(t, tblMeta.Columns[1].ForeignKey)
+	require.Equal(t, "data_items.sq_id", tblMeta.Columns[1].ForeignKey.String())
+}
+
+func TestImportRoot(t *testing.T) {
+	testCases := []struct {
+		name     string
+		typ      source.Type
+		fpath    string
+		roots    []string
+		wantTbls []string
+		wantCols [][]string
+		wantRows []int
+	}{
+		{
+			name:     "json_array",
+			typ:      json.TypeJSON,
+			fpath:    "api_response.json",
+			roots:    []string{"$.data.results"},
+			wantTbls: []string{"data"},
+			wantCols: [][]string{{"id", "name", "score"}},
+			wantRows: []int{3},
+		},
+		{
+			name:     "json_multiple",
+			typ:      json.TypeJSON,
+			fpath:    "api_response.json",
+			roots:    []string{"$.data.results", "grp:$.data.groups", "$.meta", "$.links"},
+			wantTbls: []string{"results", "grp", "meta", "links"},
+			wantCols: [][]string{{"id", "name", "score"}, {"group_id", "label"}, {"page", "total"}, {"rel", "href"}},
+			wantRows: []int{3, 2, 1, 1},
+		},
+		{
+			name:     "jsonl",
+			typ:      json.TypeJSONL,
+			fpath:    "api_response.jsonl",
+			roots:    []string{"$.data.results"},
+			wantTbls: []string{"data"},
+			wantCols: [][]string{{"id", "name"}},
+			wantRows: []int{3},
+		},
+		{
+			name:     "jsona",
+			typ:      json.TypeJSONA,
+			fpath:    "api_response.jsona",
+			roots:    []string{"$[1]"},
+			wantTbls: []string{"data"},
+			wantCols: [][]string{{"a", "b", "c"}},
+			wantRows: []int{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			th := testh.New(t)
+			src := &source.Source{
+				Handle:   "@root_" + tc.name,
+				Type:     tc.typ,
+				Location: proj.Abs(filepath.Join("drivers/json/testdata", tc.fpath)),
+				Options:  options.Options{json.OptRoot: tc.roots},
+			}
+
+			for i, tbl := range tc.wantTbls {
+				sink, err := th.QuerySQL(src, "SELECT * FROM "+tbl)
+				require.NoError(t, err)
+				require.Equal(t, tc.wantCols[i], sink.RecMeta.Names())
+				require.Equal(t, tc.wantRows[i], len(sink.Recs))
+			}
+		})
+	}
+}
+
+func TestImportRoot_NotFound(t *testing.T) {
+	th := testh.New(t)
+	src := &source.Source{
+		Handle:   "@root_not_found",
+		Type:     json.TypeJSON,
+		Location: proj.Abs("drivers/json/testdata/api_response.json"),
+		Options:  options.Options{json.OptRoot: []string{"$.data.nope"}},
+	}
+
+	_, err := th.Databases().Open(th.Context, src)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "$.data.nope")
+}
+
 func TestScanObjectsInArray(t *testing.T) {
 	var (
 		m1 = []map[string]interface{}{{"a": float64(1)}}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
 	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh/sakila"
@@ -36,6 +37,7 @@ func NewImportJob(fromSrc *source.Source, openFn source.FileOpenFunc, destDB dri
 		destDB:     destDB,
 		sampleSize: sampleSize,
 		flatten:    flatten,
+		roots:      defaultRoots(),
 	}
 }
 
@@ -57,7 +59,7 @@ func TestDetectColKindsJSONA(t *testing.T) {
 			require.NoError(t, err)
 			t.Cleanup(func() { require.NoError(t, f.Close()) })
 
-			kinds, _, err := detectColKindsJSONA(context.Background(), f)
+			kinds, _, err := detectColKindsJSONA(context.Background(), f, nil)
 			require.NoError(t, err)
 			require.Equal(t, tc.wantKinds, kinds)
 		})
@@ -89,3 +91,54 @@ func ScanObjectsInArray(r io.Reader) (objs []map[string]interface{}, chunks [][]
 
 	return objs, chunks, nil
 }
+
+func TestGetRoots(t *testing.T) {
+	testCases := []struct {
+		opts      string
+		wantTbls  []string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{opts: "", wantTbls: []string{"data"}, wantPaths: []string{"$"}},
+		{opts: "root=$", wantTbls: []string{"data"}, wantPaths: []string{"$"}},
+		{opts: "root=$.data.results", wantTbls: []string{"data"}, wantPaths: []string{"$.data.results"}},
+		{opts: "root=$.data.results[*]", wantTbls: []string{"data"}, wantPaths: []string{"$.data.results"}},
+		{opts: "root=$['data'][\"the results\"][2]", wantTbls: []string{"data"}, wantPaths: []string{"$.data.the results[2]"}},
+		{opts: "root=res:$.data.results", wantTbls: []string{"res"}, wantPaths: []string{"$.data.results"}},
+		{
+			opts:      "root=$.data.results&root=$.meta&root=first:$.items[0]",
+			wantTbls:  []string{"results", "meta", "first"},
+			wantPaths: []string{"$.data.results", "$.meta", "$.items[0]"},
+		},
+		{opts: "root=data.results", wantErr: true},
+		{opts: "root=$.", wantErr: true},
+		{opts: "root=$.data[", wantErr: true},
+		{opts: "root=$.data[-1]", wantErr: true},
+		{opts: "root=$.data[*].results", wantErr: true},
+		{opts: "root=$.a.results&root=$.b.results", wantErr: true},
+		{opts: "root=$.a[0]&root=$.b", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.opts, func(t *testing.T) {
+			opts, err := options.ParseOptions(tc.opts)
+			require.NoError(t, err)
+
+			roots, err := getRoots(opts)
+			if tc.wantErr {
+				require.Error(t, err)
+				t.Log(err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, len(tc.wantTbls), len(roots))
+			for i, root := range roots {
+				require.Equal(t, tc.wantTbls[i], root.tbl)
+				require.Equal(t, tc.wantPaths[i], formatPath(root.path))
+			}
+		})
+	}
+}
@@ -8,11 +8,13 @@ package json
 import (
 	"context"
 	"database/sql"
+	"strconv"
 
 	"github.com/neilotoole/lg"
 
 	"github.com/neilotoole/sq/libsq/core/cleanup"
 	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/options"
 	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/source"
 )
@@ -30,6 +32,20 @@ const (
 	TypeJSONL = source.Type("jsonl")
 )
 
+// OptNormalize is the source option that specifies that each nested
+// array of a JSON or JSONL source is imported to its own table
+// (e.g. "data_items"), with a generated key column linking each row
+// to its parent row. By default, nested arrays are not imported.
+const OptNormalize = "normalize"
+
+// OptRoot is the source option that selects the part of the input
+// to import, using a JSONPath-style expression such as
+// "$.data.results". For JSONA and JSONL, the root is selected from
+// each line. The option may be repeated to import several roots,
+// each to its own table: the table name can be specified as a
+// prefix, e.g. "users:$.data.users".
+const OptRoot = "root"
+
 // Provider implements driver.Provider.
 type Provider struct {
 	Log       lg.Log
@@ -98,22 +114,31 @@ func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database
 		return nil, err
 	}
 
-	dbase.impl, err = d.scratcher.OpenScratch(ctx, src.Handle)
+	normalize, err := getNormalize(src.Options)
 	if err != nil {
 		d.log.WarnIfCloseError(r)
-		d.log.WarnIfFuncError(dbase.clnup.Run)
 		return nil, err
 	}
 
-	job := importJob{
-		fromSrc:    src,
-		openFn:     d.files.OpenFunc(src),
-		destDB:     dbase.impl,
-		sampleSize: driver.Tuning.SampleSize,
-		flatten:    true, // TODO: Should come from src.Options
+	roots, err := getRoots(src.Options)
+	if err != nil {
+		d.log.WarnIfCloseError(r)
+		return nil, err
 	}
 
-	err = d.importFn(ctx, d.log, job)
+	dbase.impl, err = driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		job := importJob{
+			fromSrc:    src,
+			openFn:     d.files.OpenFunc(src),
+			destDB:     destDB,
+			sampleSize: driver.Tuning.SampleSize,
+			flatten:    true, // TODO: Should come from src.Options
+			normalize:  normalize,
+			roots:      roots,
+		}
+
+		return d.importFn(ctx, d.log, job)
+	})
 	if err != nil {
 		d.log.WarnIfCloseError(r)
 		d.log.WarnIfFuncError(dbase.clnup.Run)
@@ -139,9 +164,34 @@ func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
 		return nil, errz.Errorf("expected source type %q but got %q", d.typ, src.Type)
 	}
 
+	_, err := getNormalize(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = getRoots(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	return src, nil
 }
 
+// getNormalize returns the value of OptNormalize, or false if not set.
+func getNormalize(opts options.Options) (bool, error) {
+	val := opts.Get(OptNormalize)
+	if val == "" {
+		return false, nil
+	}
+
+	normalize, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, errz.Errorf("option %q: %v", OptNormalize, err)
+	}
+
+	return normalize, nil
+}
+
 // Ping implements driver.Driver.
 func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
 	d.log.Debugf("driver %q attempting to ping %q", d.typ, src)
@@ -181,18 +231,13 @@ func (d *database) Source() *source.Source {
 
 // TableMetadata implements driver.Database.
 func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
-	if tblName != source.MonotableName {
-		return nil, errz.Errorf("table name should be %s for CSV/TSV etc., but got: %s",
-			source.MonotableName, tblName)
-	}
-
 	srcMeta, err := d.SourceMetadata(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// There will only ever be one table for CSV.
-	return srcMeta.Tables[0], nil
+	// There is only one table, unless OptNormalize is set.
+	return source.TableFromSourceMetadata(srcMeta, tblName)
 }
 
 // SourceMetadata implements driver.Database.
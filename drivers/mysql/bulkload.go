@@ -0,0 +1,217 @@
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+var _ driver.BulkLoader = (*driveri)(nil)
+
+// CanBulkLoad implements driver.BulkLoader. It returns true if
+// the server's local_infile variable is enabled.
+func (d *driveri) CanBulkLoad(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string) (bool, error) {
+	var localInfile bool
+	err := db.QueryRowContext(ctx, "SELECT @@GLOBAL.local_infile").Scan(&localInfile)
+	if err != nil {
+		return false, errz.Err(err)
+	}
+
+	return localInfile, nil
+}
+
+// NewBulkLoad implements driver.BulkLoader. The records are loaded
+// via "LOAD DATA LOCAL INFILE", using a reader handler registered
+// with the MySQL driver.
+func (d *driveri) NewBulkLoad(ctx context.Context, log lg.Log, db *sql.DB, destTbl string, destColNames []string) (*driver.BatchInsert, error) {
+	destColsMeta, err := d.getTableRecordMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	loadFn := func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		pr, pw := io.Pipe()
+
+		handlerName := "sq_" + stringz.Uniq8()
+		mysql.RegisterReaderHandler(handlerName, func() io.Reader { return pr })
+		defer mysql.DeregisterReaderHandler(handlerName)
+
+		go func() {
+			// Note that CloseWithError(nil) is equivalent to Close.
+			_ = pw.CloseWithError(writeLoadData(ctx, pw, recCh))
+		}()
+
+		// Closing pr unblocks the writer goroutine if the
+		// LOAD DATA statement fails before reading all data.
+		defer func() { _ = pr.Close() }()
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		query := buildLoadDataStmt(handlerName, destTbl, destColsMeta.Names())
+		loaded, err := sqlz.ExecAffected(ctx, tx, query)
+		if err != nil {
+			return 0, errz.Append(err, errz.Err(tx.Rollback()))
+		}
+
+		// LOAD DATA LOCAL behaves as if IGNORE were specified: errors
+		// such as a duplicate key or a data conversion error are
+		// demoted to warnings, and the row is skipped or truncated.
+		// We don't accept that, as INSERT would have failed.
+		err = checkLoadDataWarnings(ctx, log, tx, destTbl)
+		if err != nil {
+			return 0, errz.Append(err, errz.Err(tx.Rollback()))
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		return loaded, nil
+	}
+
+	bi := driver.NewBulkLoadBatchInsert(ctx, newInsertMungeFunc(destTbl, destColsMeta), loadFn)
+	return bi, nil
+}
+
+// checkLoadDataWarnings returns an error if the LOAD DATA statement
+// just executed on tx into tbl generated any warnings or errors.
+// Notes (the lowest level of diagnostic) are ignored.
+func checkLoadDataWarnings(ctx context.Context, log lg.Log, tx *sql.Tx, tbl string) error {
+	rows, err := tx.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer log.WarnIfCloseError(rows)
+
+	var msgs []string
+	for rows.Next() {
+		var level, msg string
+		var code int
+		err = rows.Scan(&level, &code, &msg)
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		if level == "Note" {
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%s %d: %s", level, code, msg))
+	}
+
+	if err = rows.Err(); err != nil {
+		return errz.Err(err)
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	const maxMsgs = 3
+	if len(msgs) > maxMsgs {
+		msgs = append(msgs[:maxMsgs], fmt.Sprintf("and %d more", len(msgs)-maxMsgs))
+	}
+
+	return errz.Errorf("bulk load into %s failed: %s", tbl, strings.Join(msgs, "; "))
+}
+
+// buildLoadDataStmt returns a "LOAD DATA LOCAL INFILE" statement
+// that reads from the reader handler named handlerName. The data
+// format is that written by writeLoadData.
+func buildLoadDataStmt(handlerName, tbl string, cols []string) string {
+	quoted := make([]string, len(cols))
+	for i := range cols {
+		quoted[i] = enquoteIdent(cols[i])
+	}
+
+	return fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s CHARACTER SET utf8mb4 "+
+		`FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (%s)`,
+		handlerName, enquoteIdent(tbl), strings.Join(quoted, driver.Comma))
+}
+
+// enquoteIdent returns the identifier s quoted with backticks, with
+// any backtick in s doubled, e.g. "my`tbl" becomes "`my``tbl`".
+func enquoteIdent(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// writeLoadData writes the records received on recCh to w in
+// LOAD DATA's default format: tab-separated fields, LF-terminated
+// lines, with special chars escaped by backslash, and NULL as \N.
+func writeLoadData(ctx context.Context, w io.Writer, recCh <-chan []interface{}) error {
+	bw := bufio.NewWriter(w)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case rec, ok := <-recCh:
+			if !ok {
+				return errz.Err(bw.Flush())
+			}
+
+			driver.DerefRecord(rec)
+			for i, val := range rec {
+				if i > 0 {
+					_ = bw.WriteByte('\t')
+				}
+				_, _ = bw.WriteString(loadDataValue(val))
+			}
+
+			err := bw.WriteByte('\n')
+			if err != nil {
+				return errz.Err(err)
+			}
+		}
+	}
+}
+
+// loadDataEscaper escapes the chars that are special to LOAD DATA.
+var loadDataEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x00", `\0`,
+)
+
+// loadDataValue returns the LOAD DATA representation of val.
+func loadDataValue(val interface{}) string {
+	switch val := val.(type) {
+	case nil:
+		return `\N`
+	case string:
+		return loadDataEscaper.Replace(val)
+	case []byte:
+		return loadDataEscaper.Replace(string(val))
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return val.Format("2006-01-02 15:04:05.999999")
+	default:
+		return loadDataEscaper.Replace(fmt.Sprintf("%v", val))
+	}
+}
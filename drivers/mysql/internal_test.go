@@ -2,11 +2,14 @@ package mysql
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/require"
 
 	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
 )
 
 var KindFromDBTypeName = kindFromDBTypeName
@@ -43,3 +46,38 @@ func TestHasErrCode(t *testing.T) {
 	err = errz.Err(err)
 	require.True(t, hasErrCode(err, errNumTableNotExist))
 }
+
+func TestLoadDataValue(t *testing.T) {
+	testCases := []struct {
+		val  interface{}
+		want string
+	}{
+		{val: nil, want: `\N`},
+		{val: "hello", want: "hello"},
+		{val: "a\tb\nc\\d", want: `a\tb\nc\\d`},
+		{val: int64(7), want: "7"},
+		{val: float64(1.5), want: "1.5"},
+		{val: true, want: "1"},
+		{val: false, want: "0"},
+		{val: time.Date(2020, 2, 15, 6, 59, 28, 0, time.UTC), want: "2020-02-15 06:59:28"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, loadDataValue(tc.val))
+	}
+}
+
+func TestBuildLoadDataStmt(t *testing.T) {
+	got := buildLoadDataStmt("sq_abc", "my`tbl", []string{"id", "na`me"})
+	require.Equal(t, "LOAD DATA LOCAL INFILE 'Reader::sq_abc' INTO TABLE `my``tbl` CHARACTER SET utf8mb4 "+
+		`FIELDS TERMINATED BY '\t' ESCAPED BY '\\' LINES TERMINATED BY '\n' (`+"`id`, `na``me`)", got)
+}
+
+func TestBuildCreateTableStmt_UniqueCols(t *testing.T) {
+	tblDef := sqlmodel.NewTableDef("actor", []string{"actor_id", "email"}, []kind.Kind{kind.Int, kind.Text})
+	tblDef.UniqueCols = []string{"email"}
+
+	got, err := buildCreateTableStmt(tblDef)
+	require.NoError(t, err)
+	require.Equal(t, "CREATE TABLE `actor` (\n`actor_id` INT,\n`email` VARCHAR(255),\nUNIQUE KEY `actor_uindex` (`email`)\n)", got)
+}
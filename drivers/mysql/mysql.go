@@ -45,6 +45,7 @@ func (p *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
 }
 
 var _ driver.Driver = (*driveri)(nil)
+var _ driver.Upserter = (*driveri)(nil)
 
 // driveri is the MySQL implementation of driver.Driver.
 type driveri struct {
@@ -69,9 +70,16 @@ func (d *driveri) Dialect() driver.Dialect {
 		Quote:          '`',
 		IntBool:        true,
 		MaxBatchValues: 250,
+		BinaryOrder:    binaryOrder,
 	}
 }
 
+// binaryOrder implements driver.Dialect.BinaryOrder. The value is
+// converted to utf8mb4 first, as the column may use another charset.
+func binaryOrder(expr string) string {
+	return "CONVERT(" + expr + " USING utf8mb4) COLLATE utf8mb4_bin"
+}
+
 func placeholders(numCols, numRows int) string {
 	rows := make([]string, numRows)
 	for i := 0; i < numRows; i++ {
@@ -152,6 +160,117 @@ func (d *driveri) PrepareUpdateStmt(ctx context.Context, db sqlz.DB, destTbl str
 	return execer, nil
 }
 
+// PrepareUpsertStmt implements driver.Upserter.
+func (d *driveri) PrepareUpsertStmt(ctx context.Context, db sqlz.DB, destTbl string, destColNames, keyColNames []string) (*driver.StmtExecer, error) {
+	destColsMeta, err := d.getTableRecordMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.checkUpsertKey(ctx, db, destTbl, destColNames, keyColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildUpsertStmt(destTbl, destColNames, keyColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	execFn := func(ctx context.Context, args ...interface{}) (int64, error) {
+		res, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		// For "ON DUPLICATE KEY UPDATE", the affected value is 1 if
+		// the row is inserted, 2 if an existing row is updated, and
+		// 0 if an existing row is unchanged, as per driver.UpsertInserted
+		// etc. Note that the DSN must not set clientFoundRows, which
+		// changes the value for an unchanged row to 1.
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		return affected, nil
+	}
+
+	execer := driver.NewStmtExecer(stmt, newInsertMungeFunc(destTbl, destColsMeta), execFn, destColsMeta)
+	return execer, nil
+}
+
+// checkUpsertKey returns an error if destTbl doesn't have a primary
+// key or unique index on exactly keyColNames, or if it has another
+// unique index on columns that are all in destColNames. This is
+// because "ON DUPLICATE KEY UPDATE" matches an existing row on any
+// of the table's unique indexes, and not on particular columns.
+func (d *driveri) checkUpsertKey(ctx context.Context, db sqlz.DB, destTbl string, destColNames, keyColNames []string) error {
+	const query = `SELECT INDEX_NAME, COLUMN_NAME FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND NON_UNIQUE = 0
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+
+	rows, err := db.QueryContext(ctx, query, destTbl)
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer d.log.WarnIfCloseError(rows)
+
+	var idxNames []string
+	idxCols := map[string][]string{}
+	for rows.Next() {
+		var idxName, colName string
+		err = rows.Scan(&idxName, &colName)
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		if _, ok := idxCols[idxName]; !ok {
+			idxNames = append(idxNames, idxName)
+		}
+		idxCols[idxName] = append(idxCols[idxName], colName)
+	}
+	if err = rows.Err(); err != nil {
+		return errz.Err(err)
+	}
+
+	var found bool
+	for _, idxName := range idxNames {
+		cols := idxCols[idxName]
+		if len(cols) == len(keyColNames) && containsAll(keyColNames, cols) {
+			found = true
+			continue
+		}
+
+		if containsAll(destColNames, cols) {
+			return errz.Errorf("upsert %s: unique index %s (%s) would also match existing rows: only key (%s) may be unique",
+				destTbl, idxName, strings.Join(cols, ", "), strings.Join(keyColNames, ", "))
+		}
+	}
+
+	if !found {
+		return errz.Errorf("upsert %s: no primary key or unique index on exactly key (%s)",
+			destTbl, strings.Join(keyColNames, ", "))
+	}
+
+	return nil
+}
+
+// containsAll returns true if each of needles is in haystack.
+func containsAll(haystack, needles []string) bool {
+	for _, needle := range needles {
+		if !stringz.InSlice(haystack, needle) {
+			return false
+		}
+	}
+	return true
+}
+
 func newStmtExecFunc(stmt *sql.Stmt) driver.StmtExecFunc {
 	return func(ctx context.Context, args ...interface{}) (int64, error) {
 		res, err := stmt.ExecContext(ctx, args...)
@@ -206,6 +325,12 @@ func (d *driveri) DropTable(ctx context.Context, db sqlz.DB, tbl string, ifExist
 	return errz.Err(err)
 }
 
+// RenameTable implements driver.SQLDriver.
+func (d *driveri) RenameTable(ctx context.Context, db sqlz.DB, tbl, newName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("RENAME TABLE `%s` TO `%s`", tbl, newName))
+	return errz.Err(err)
+}
+
 // TableColumnTypes implements driver.SQLDriver.
 func (d *driveri) TableColumnTypes(ctx context.Context, db sqlz.DB, tblName string, colNames []string) ([]*sql.ColumnType, error) {
 	const queryTpl = "SELECT %s FROM %s LIMIT 0"
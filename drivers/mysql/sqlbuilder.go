@@ -12,6 +12,7 @@ import (
 
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
 func newFragmentBuilder(log lg.Log) *sqlbuilder.BaseFragmentBuilder {
@@ -75,7 +76,18 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) (string, error) {
 		buf.WriteRune('`')
 		buf.WriteString(col.Name)
 		buf.WriteString("` ")
-		buf.WriteString(dbTypeNameFromKind(col.Kind))
+		typ := dbTypeNameFromKind(col.Kind)
+		if stringz.InSlice(tblDef.UniqueCols, col.Name) {
+			// A TEXT or BLOB column can't be a key column without
+			// a prefix length, which wouldn't enforce uniqueness.
+			switch col.Kind {
+			case kind.Text:
+				typ = "VARCHAR(255)"
+			case kind.Bytes:
+				typ = "VARBINARY(255)"
+			}
+		}
+		buf.WriteString(typ)
 
 		if col.HasDefault {
 			buf.WriteRune(' ')
@@ -129,6 +141,16 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) (string, error) {
 			buf.WriteString("`)")
 		}
 	}
+	if len(tblDef.UniqueCols) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.WriteString("UNIQUE KEY `")
+		buf.WriteString(tblDef.Name)
+		buf.WriteString("_uindex` (`")
+		buf.WriteString(strings.Join(tblDef.UniqueCols, "`, `"))
+		buf.WriteString("`)")
+	}
 	uniq = buf.String()
 
 	fk := ""
@@ -223,3 +245,48 @@ func buildUpdateStmt(tbl string, cols []string, where string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// buildUpsertStmt builds an "INSERT ... ON DUPLICATE KEY UPDATE"
+// statement for a single row. Note that MySQL determines a
+// duplicate by the table's primary key and unique indexes, and
+// not by keyCols: keyCols are merely excluded from the update
+// clause. Thus the caller should first verify the table's
+// indexes via checkUpsertKey.
+func buildUpsertStmt(tbl string, cols, keyCols []string) (string, error) {
+	if len(cols) == 0 {
+		return "", errz.Errorf("no columns provided")
+	}
+
+	if len(keyCols) == 0 {
+		return "", errz.Errorf("no key columns provided")
+	}
+
+	var updateCols []string
+	for _, col := range cols {
+		if !stringz.InSlice(keyCols, col) {
+			updateCols = append(updateCols, col)
+		}
+	}
+
+	if len(updateCols) == 0 {
+		// The update clause requires at least one column.
+		updateCols = keyCols
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString("INSERT INTO `")
+	buf.WriteString(tbl)
+	buf.WriteString("` (`")
+	buf.WriteString(strings.Join(cols, "`, `"))
+	buf.WriteString("`) VALUES (")
+	buf.WriteString(stringz.RepeatJoin("?", len(cols), ", "))
+	buf.WriteString(") ON DUPLICATE KEY UPDATE ")
+	for i, col := range updateCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(fmt.Sprintf("`%s` = VALUES(`%s`)", col, col))
+	}
+
+	return buf.String(), nil
+}
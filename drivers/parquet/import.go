@@ -0,0 +1,310 @@
+package parquet
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet/file"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	"github.com/neilotoole/lg"
+	"github.com/shopspring/decimal"
+
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// readBatchSize is the number of rows read from the
+// Parquet data per batch.
+const readBatchSize = 1024
+
+// timeFormat is the format of kind.Time values. Fractional
+// seconds are only present if non-zero.
+const timeFormat = "15:04:05.999999999"
+
+// column is a Parquet column that is imported.
+type column struct {
+	name string
+	kind kind.Kind
+
+	// leaf is the Parquet leaf column index of the column.
+	leaf int
+}
+
+// importParquet loads the src Parquet data to scratchDB. The data
+// is streamed into scratchDB a batch of rows at a time, and only
+// the Parquet columns that can be represented in a table (i.e. not
+// nested columns such as lists or structs) are read.
+func importParquet(ctx context.Context, log lg.Log, src *source.Source, files *source.Files, scratchDB driver.Database) error {
+	// Parquet requires random access (the metadata is at the
+	// end of the data), which is provided by the Files cache.
+	size, err := files.Size(src)
+	if err != nil {
+		return err
+	}
+
+	r, err := files.Open(src)
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r)
+
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return errz.Errorf("parquet: reader for %s does not support random access", src.Handle)
+	}
+
+	pr, err := file.NewParquetReader(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return errz.Wrapf(err, "parquet: failed to read %s", src.Handle)
+	}
+
+	fr, err := pqarrow.NewFileReader(pr, pqarrow.ArrowReadProperties{BatchSize: readBatchSize}, memory.DefaultAllocator)
+	if err != nil {
+		return errz.Wrapf(err, "parquet: failed to read schema of %s", src.Handle)
+	}
+
+	cols := selectColumns(log, fr.Manifest)
+	if len(cols) == 0 {
+		return errz.Errorf("parquet: %s has no supported columns", src.Handle)
+	}
+
+	tblDef := createTblDef(source.MonotableName, cols)
+	err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tblDef)
+	if err != nil {
+		return errz.Wrap(err, "parquet: failed to create dest scratch table")
+	}
+
+	recMeta, err := getRecMeta(ctx, scratchDB, tblDef)
+	if err != nil {
+		return err
+	}
+
+	leaves := make([]int, len(cols))
+	for i := range cols {
+		leaves[i] = cols[i].leaf
+	}
+
+	rr, err := fr.GetRecordReader(ctx, leaves, nil)
+	if err != nil {
+		return errz.Wrapf(err, "parquet: failed to read %s", src.Handle)
+	}
+	defer rr.Release()
+
+	insertWriter := libsq.NewDBWriter(log, scratchDB, tblDef.Name, driver.Tuning.RecordChSize)
+	err = execInsert(ctx, insertWriter, recMeta, rr)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := insertWriter.Wait()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Inserted %d rows from %d row groups to %s.%s",
+		inserted, pr.NumRowGroups(), scratchDB.Source().Handle, tblDef.Name)
+	return nil
+}
+
+// selectColumns returns the columns of manifest that are to be
+// imported. Nested columns, and columns of an unsupported type,
+// are skipped.
+func selectColumns(log lg.Log, manifest *pqarrow.SchemaManifest) []column {
+	var cols []column
+	for _, field := range manifest.Fields {
+		if !field.IsLeaf() || len(field.Children) > 0 {
+			log.Warnf("Skipping nested Parquet column %q of type %s", field.Field.Name, field.Field.Type)
+			continue
+		}
+
+		knd, ok := kindFromArrowType(field.Field.Type)
+		if !ok {
+			log.Warnf("Skipping Parquet column %q of unsupported type %s", field.Field.Name, field.Field.Type)
+			continue
+		}
+
+		cols = append(cols, column{name: field.Field.Name, kind: knd, leaf: field.ColIndex})
+	}
+
+	return cols
+}
+
+// kindFromArrowType returns the kind for typ, which is the Arrow
+// type that the Parquet physical and logical types of a column
+// are read as. For example, INT64 is read as arrow.INT64, and
+// BYTE_ARRAY with logical type UTF8 is read as arrow.STRING.
+// Return value ok is false if typ is not supported.
+func kindFromArrowType(typ arrow.DataType) (knd kind.Kind, ok bool) {
+	switch typ.ID() {
+	default:
+		return kind.Unknown, false
+	case arrow.BOOL:
+		return kind.Bool, true
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32:
+		return kind.Int, true
+	case arrow.UINT64:
+		// A UINT64 value may not fit in kind.Int.
+		return kind.Decimal, true
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return kind.Float, true
+	case arrow.DECIMAL:
+		return kind.Decimal, true
+	case arrow.STRING:
+		return kind.Text, true
+	case arrow.BINARY, arrow.FIXED_SIZE_BINARY:
+		return kind.Bytes, true
+	case arrow.DATE32, arrow.DATE64:
+		return kind.Date, true
+	case arrow.TIMESTAMP:
+		return kind.Datetime, true
+	case arrow.TIME32, arrow.TIME64:
+		return kind.Time, true
+	}
+}
+
+// execInsert inserts the records read from rr via recw. The
+// caller should wait on recw to complete.
+func execInsert(ctx context.Context, recw libsq.RecordWriter, recMeta sqlz.RecordMeta, rr pqarrow.RecordReader) error {
+	ctx, cancelFn := context.WithCancel(ctx)
+
+	recordCh, errCh, err := recw.Open(ctx, cancelFn, recMeta)
+	if err != nil {
+		return err
+	}
+	defer close(recordCh)
+
+	for {
+		batch, err := rr.Read()
+		if err == io.EOF {
+			// We're done reading
+			return nil
+		}
+		if err != nil {
+			cancelFn()
+			return errz.Wrap(err, "read from Parquet data source")
+		}
+
+		arrs := batch.Columns()
+		for i := 0; i < int(batch.NumRows()); i++ {
+			rec := make([]interface{}, len(arrs))
+			for j := range arrs {
+				rec[j] = arrowValue(arrs[j], i)
+			}
+
+			select {
+			case err = <-errCh:
+				cancelFn()
+				return err
+			case <-ctx.Done():
+				cancelFn()
+				return ctx.Err()
+			case recordCh <- rec:
+			}
+		}
+	}
+}
+
+// arrowValue returns the value of arr at index i, as a value
+// suitable for inserting into a column of the kind returned by
+// kindFromArrowType. The returned value does not reference
+// arr's memory.
+func arrowValue(arr arrow.Array, i int) interface{} {
+	if arr.IsNull(i) {
+		return nil
+	}
+
+	switch arr := arr.(type) {
+	case *array.Boolean:
+		return arr.Value(i)
+	case *array.Int8:
+		return int64(arr.Value(i))
+	case *array.Int16:
+		return int64(arr.Value(i))
+	case *array.Int32:
+		return int64(arr.Value(i))
+	case *array.Int64:
+		return arr.Value(i)
+	case *array.Uint8:
+		return int64(arr.Value(i))
+	case *array.Uint16:
+		return int64(arr.Value(i))
+	case *array.Uint32:
+		return int64(arr.Value(i))
+	case *array.Uint64:
+		return strconv.FormatUint(arr.Value(i), 10)
+	case *array.Float16:
+		return float64(arr.Value(i).Float32())
+	case *array.Float32:
+		return float64(arr.Value(i))
+	case *array.Float64:
+		return arr.Value(i)
+	case *array.Decimal128:
+		typ := arr.DataType().(*arrow.Decimal128Type)
+		return decimal.NewFromBigInt(arr.Value(i).BigInt(), -typ.Scale).String()
+	case *array.String:
+		return string([]byte(arr.Value(i)))
+	case *array.Binary:
+		return copyBytes(arr.Value(i))
+	case *array.FixedSizeBinary:
+		return copyBytes(arr.Value(i))
+	case *array.Date32:
+		return arr.Value(i).ToTime()
+	case *array.Date64:
+		return arr.Value(i).ToTime()
+	case *array.Timestamp:
+		typ := arr.DataType().(*arrow.TimestampType)
+		return arr.Value(i).ToTime(typ.Unit)
+	case *array.Time32:
+		typ := arr.DataType().(*arrow.Time32Type)
+		return arr.Value(i).ToTime(typ.Unit).Format(timeFormat)
+	case *array.Time64:
+		typ := arr.DataType().(*arrow.Time64Type)
+		return arr.Value(i).ToTime(typ.Unit).Format(timeFormat)
+	default:
+		// Shouldn't happen: the column would have been
+		// skipped by selectColumns.
+		return nil
+	}
+}
+
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+// getRecMeta returns RecordMeta to use with RecordWriter.Open.
+func getRecMeta(ctx context.Context, scratchDB driver.Database, tblDef *sqlmodel.TableDef) (sqlz.RecordMeta, error) {
+	colTypes, err := scratchDB.SQLDriver().TableColumnTypes(ctx, scratchDB.DB(), tblDef.Name, tblDef.ColNames())
+	if err != nil {
+		return nil, err
+	}
+
+	destMeta, _, err := scratchDB.SQLDriver().RecordMeta(colTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return destMeta, nil
+}
+
+func createTblDef(tblName string, cols []column) *sqlmodel.TableDef {
+	tbl := &sqlmodel.TableDef{Name: tblName}
+
+	tbl.Cols = make([]*sqlmodel.ColDef, len(cols))
+	for i := range cols {
+		tbl.Cols[i] = &sqlmodel.ColDef{Table: tbl, Name: cols[i].name, Kind: cols[i].kind}
+	}
+
+	return tbl
+}
@@ -0,0 +1,193 @@
+// Package parquet implements the sq driver for Apache Parquet.
+// Like CSV, a Parquet source is a monotable: its data is
+// imported into a single table named "data".
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// Type is the Parquet driver type.
+const Type = source.Type("parquet")
+
+// magic is the "magic number" that begins (and ends)
+// every Parquet file.
+var magic = []byte("PAR1")
+
+// Provider implements driver.Provider.
+type Provider struct {
+	Log       lg.Log
+	Scratcher driver.ScratchDatabaseOpener
+	Files     *source.Files
+}
+
+// DriverFor implements driver.Provider.
+func (d *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
+	if typ != Type {
+		return nil, errz.Errorf("unsupported driver type %q", typ)
+	}
+
+	return &driveri{log: d.Log, scratcher: d.Scratcher, files: d.Files}, nil
+}
+
+// Driver implements driver.Driver.
+type driveri struct {
+	log       lg.Log
+	scratcher driver.ScratchDatabaseOpener
+	files     *source.Files
+}
+
+// DriverMetadata implements driver.Driver.
+func (d *driveri) DriverMetadata() driver.Metadata {
+	return driver.Metadata{
+		Type:        Type,
+		Description: "Apache Parquet",
+		Doc:         "https://parquet.apache.org",
+		Monotable:   true,
+	}
+}
+
+// Open implements driver.Driver.
+func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
+	impl, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return importParquet(ctx, d.log, src, d.files, destDB)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{log: d.log, src: src, impl: impl, files: d.files}, nil
+}
+
+// Truncate implements driver.Driver.
+func (d *driveri) Truncate(ctx context.Context, src *source.Source, tbl string, reset bool) (int64, error) {
+	return 0, errz.Errorf("truncate not supported for %s", Type)
+}
+
+// ValidateSource implements driver.Driver.
+func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
+	if src.Type != Type {
+		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
+	}
+
+	return src, nil
+}
+
+// Ping implements driver.Driver.
+func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
+	d.log.Debugf("driver %q attempting to ping %q", Type, src)
+
+	r, err := d.files.Open(src)
+	if err != nil {
+		return err
+	}
+	defer d.log.WarnIfCloseError(r)
+
+	return nil
+}
+
+// database implements driver.Database.
+type database struct {
+	log   lg.Log
+	src   *source.Source
+	impl  driver.Database
+	files *source.Files
+}
+
+// DB implements driver.Database.
+func (d *database) DB() *sql.DB {
+	return d.impl.DB()
+}
+
+// SQLDriver implements driver.Database.
+func (d *database) SQLDriver() driver.SQLDriver {
+	return d.impl.SQLDriver()
+}
+
+// Source implements driver.Database.
+func (d *database) Source() *source.Source {
+	return d.src
+}
+
+// TableMetadata implements driver.Database.
+func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
+	if tblName != source.MonotableName {
+		return nil, errz.Errorf("table name should be %s for Parquet, but got: %s",
+			source.MonotableName, tblName)
+	}
+
+	srcMeta, err := d.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// There will only ever be one table for Parquet.
+	return srcMeta.Tables[0], nil
+}
+
+// SourceMetadata implements driver.Database.
+func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error) {
+	md, err := d.impl.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Handle = d.src.Handle
+	md.Location = d.src.Location
+	md.SourceType = d.src.Type
+
+	md.Name, err = source.LocationFileName(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Size, err = d.files.Size(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.FQName = md.Name
+	return md, nil
+}
+
+// Close implements driver.Database.
+func (d *database) Close() error {
+	d.log.Debugf("Close database: %s", d.src)
+
+	return errz.Err(d.impl.Close())
+}
+
+var _ source.TypeDetectFunc = DetectParquet
+
+// DetectParquet implements source.TypeDetectFunc. The data is
+// Parquet if it begins with the Parquet magic number "PAR1".
+func DetectParquet(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (detected source.Type, score float32, err error) {
+	var r io.ReadCloser
+	r, err = openFn()
+	if err != nil {
+		return source.TypeNone, 0, errz.Err(err)
+	}
+	defer log.WarnIfCloseError(r)
+
+	head := make([]byte, len(magic))
+	_, err = io.ReadFull(r, head)
+	if err != nil {
+		// Less data than the magic number: can't be Parquet.
+		return source.TypeNone, 0, nil
+	}
+
+	if !bytes.Equal(head, magic) {
+		return source.TypeNone, 0, nil
+	}
+
+	return Type, 1.0, nil
+}
@@ -0,0 +1,116 @@
+package parquet_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/decimal128"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	pq "github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/parquet"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+)
+
+// writeTestFile writes a Parquet file to a temp dir, returning
+// the file path. The file has three rows, written as two row
+// groups, and a column of each supported type, as well as a
+// nested (list) column, which is not imported.
+func writeTestFile(t *testing.T) string {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "price", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}},
+		{Name: "active", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "ratio", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "born", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "updated", Type: arrow.FixedWidthTypes.Timestamp_ms},
+		{Name: "data", Type: arrow.BinaryTypes.Binary},
+		{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+	}, nil)
+
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2, 3}, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues([]string{"alice", "", "carol"}, []bool{true, false, true})
+	b.Field(2).(*array.Decimal128Builder).AppendValues([]decimal128.Num{
+		decimal128.FromI64(1999), decimal128.FromI64(5), decimal128.FromI64(-250)}, nil)
+	b.Field(3).(*array.BooleanBuilder).AppendValues([]bool{true, false, true}, nil)
+	b.Field(4).(*array.Float64Builder).AppendValues([]float64{0.5, 1.25, 2}, nil)
+
+	born := time.Date(1990, 7, 14, 0, 0, 0, 0, time.UTC)
+	b.Field(5).(*array.Date32Builder).AppendValues([]arrow.Date32{
+		arrow.Date32FromTime(born), arrow.Date32FromTime(born), arrow.Date32FromTime(born)}, nil)
+
+	updated := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	ts := arrow.Timestamp(updated.UnixNano() / int64(time.Millisecond))
+	b.Field(6).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{ts, ts, ts}, nil)
+	b.Field(7).(*array.BinaryBuilder).AppendValues([][]byte{{1, 2}, {3}, {}}, nil)
+
+	lb := b.Field(8).(*array.ListBuilder)
+	vb := lb.ValueBuilder().(*array.StringBuilder)
+	for i := 0; i < 3; i++ {
+		lb.Append(true)
+		vb.Append("tag")
+	}
+
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	tbl := array.NewTableFromRecords(schema, []arrow.Record{rec})
+	defer tbl.Release()
+
+	fpath := filepath.Join(t.TempDir(), "test.parquet")
+	f, err := os.Create(fpath)
+	require.NoError(t, err)
+
+	// Note that WriteTable closes f.
+	err = pqarrow.WriteTable(tbl, f, 2, pq.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	require.NoError(t, err)
+	return fpath
+}
+
+func TestParquet(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := &source.Source{Handle: "@test_parquet", Type: parquet.Type, Location: writeTestFile(t)}
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM data")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, []string{"id", "name", "price", "active", "ratio", "born", "updated", "data"},
+		sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Int, kind.Text, kind.Decimal, kind.Bool, kind.Float,
+		kind.Date, kind.Datetime, kind.Bytes}, sink.RecMeta.Kinds())
+
+	require.Equal(t, int64(1), testh.Val(sink.Recs[0][0]))
+	require.Equal(t, "alice", testh.Val(sink.Recs[0][1]))
+	require.Nil(t, sink.Recs[1][1])
+	require.Equal(t, "19.99", testh.Val(sink.Recs[0][2]))
+	require.Equal(t, "-2.5", testh.Val(sink.Recs[2][2]))
+	require.Equal(t, false, testh.Val(sink.Recs[1][3]))
+	require.Equal(t, 1.25, testh.Val(sink.Recs[1][4]))
+	require.Equal(t, time.Date(1990, 7, 14, 0, 0, 0, 0, time.UTC), testh.Val(sink.Recs[2][5]))
+	require.Equal(t, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), testh.Val(sink.Recs[2][6]))
+	require.Equal(t, []byte{1, 2}, testh.Val(sink.Recs[0][7]))
+}
+
+func TestDetectParquet(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+
+	typ, err := th.Files().Type(th.Context, writeTestFile(t))
+	require.NoError(t, err)
+	require.Equal(t, parquet.Type, typ)
+}
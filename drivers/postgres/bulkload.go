@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+var _ driver.BulkLoader = (*driveri)(nil)
+
+// CanBulkLoad implements driver.BulkLoader.
+func (d *driveri) CanBulkLoad(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string) (bool, error) {
+	return true, nil
+}
+
+// NewBulkLoad implements driver.BulkLoader. The records are loaded
+// via "COPY FROM", using pgx's CopyFrom.
+func (d *driveri) NewBulkLoad(ctx context.Context, log lg.Log, db *sql.DB, destTbl string, destColNames []string) (*driver.BatchInsert, error) {
+	destColsMeta, err := d.getTableRecordMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	loadFn := func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		// The stdlib (database/sql) wrapper doesn't support COPY,
+		// so we use the underlying pgx conn.
+		conn, err := stdlib.AcquireConn(db)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+		defer func() { log.WarnIfError(errz.Err(stdlib.ReleaseConn(db, conn))) }()
+
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		src := &copyFromChan{ctx: ctx, recCh: recCh}
+		loaded, err := tx.CopyFrom(ctx, pgx.Identifier{destTbl}, destColsMeta.Names(), src)
+		if err != nil {
+			return 0, errz.Append(errz.Err(err), errz.Err(tx.Rollback(ctx)))
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		return loaded, nil
+	}
+
+	bi := driver.NewBulkLoadBatchInsert(ctx, driver.DefaultInsertMungeFunc(destTbl, destColsMeta), loadFn)
+	return bi, nil
+}
+
+// copyFromChan implements pgx.CopyFromSource, returning
+// the records received on recCh.
+type copyFromChan struct {
+	ctx   context.Context
+	recCh <-chan []interface{}
+	rec   []interface{}
+	err   error
+}
+
+// Next implements pgx.CopyFromSource.
+func (c *copyFromChan) Next() bool {
+	select {
+	case <-c.ctx.Done():
+		c.err = c.ctx.Err()
+		return false
+	case rec, ok := <-c.recCh:
+		if !ok {
+			return false
+		}
+
+		driver.DerefRecord(rec)
+		c.rec = rec
+		return true
+	}
+}
+
+// Values implements pgx.CopyFromSource.
+func (c *copyFromChan) Values() ([]interface{}, error) {
+	return c.rec, nil
+}
+
+// Err implements pgx.CopyFromSource.
+func (c *copyFromChan) Err() error {
+	return c.err
+}
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
 )
 
 var GetTableColumnNames = getTableColumnNames
@@ -44,3 +47,25 @@ func TestReplacePlaceholders(t *testing.T) {
 		require.Equal(t, want, got)
 	}
 }
+
+func TestBuildUpsertStmt(t *testing.T) {
+	got, err := buildUpsertStmt("actor", []string{"actor_id", "first_name"}, []string{"actor_id"})
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "actor" ("actor_id", "first_name") VALUES ($1, $2) ON CONFLICT ("actor_id") DO UPDATE SET "first_name" = EXCLUDED."first_name" RETURNING (xmax = 0)`, got)
+
+	// All cols are key cols
+	got, err = buildUpsertStmt("actor", []string{"actor_id"}, []string{"actor_id"})
+	require.NoError(t, err)
+	require.Equal(t, `INSERT INTO "actor" ("actor_id") VALUES ($1) ON CONFLICT ("actor_id") DO UPDATE SET "actor_id" = EXCLUDED."actor_id" RETURNING (xmax = 0)`, got)
+
+	_, err = buildUpsertStmt("actor", []string{"actor_id"}, nil)
+	require.Error(t, err)
+}
+
+func TestBuildCreateTableStmt_UniqueCols(t *testing.T) {
+	tblDef := sqlmodel.NewTableDef("actor", []string{"actor_id", "email"}, []kind.Kind{kind.Int, kind.Text})
+	tblDef.UniqueCols = []string{"actor_id", "email"}
+
+	got := buildCreateTableStmt(tblDef)
+	require.Equal(t, "CREATE TABLE \"actor\" (\n\"actor_id\" BIGINT,\n\"email\" TEXT,\nUNIQUE (\"actor_id\", \"email\")\n)", got)
+}
@@ -44,6 +44,8 @@ func (p *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
 	return &driveri{log: p.Log}, nil
 }
 
+var _ driver.Upserter = (*driveri)(nil)
+
 // driveri is the postgres implementation of driver.Driver.
 type driveri struct {
 	log lg.Log
@@ -66,9 +68,15 @@ func (d *driveri) Dialect() driver.Dialect {
 		Placeholders:   placeholders,
 		Quote:          '"',
 		MaxBatchValues: 1000,
+		BinaryOrder:    binaryOrder,
 	}
 }
 
+// binaryOrder implements driver.Dialect.BinaryOrder.
+func binaryOrder(expr string) string {
+	return expr + ` COLLATE "C"`
+}
+
 func placeholders(numCols, numRows int) string {
 	rows := make([]string, numRows)
 
@@ -224,6 +232,40 @@ func (d *driveri) PrepareUpdateStmt(ctx context.Context, db sqlz.DB, destTbl str
 	return execer, nil
 }
 
+// PrepareUpsertStmt implements driver.Upserter.
+func (d *driveri) PrepareUpsertStmt(ctx context.Context, db sqlz.DB, destTbl string, destColNames, keyColNames []string) (*driver.StmtExecer, error) {
+	destColsMeta, err := d.getTableRecordMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildUpsertStmt(destTbl, destColNames, keyColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	execFn := func(ctx context.Context, args ...interface{}) (int64, error) {
+		var inserted bool
+		err := stmt.QueryRowContext(ctx, args...).Scan(&inserted)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		if inserted {
+			return driver.UpsertInserted, nil
+		}
+		return driver.UpsertUpdated, nil
+	}
+
+	execer := driver.NewStmtExecer(stmt, driver.DefaultInsertMungeFunc(destTbl, destColsMeta), execFn, destColsMeta)
+	return execer, nil
+}
+
 func newStmtExecFunc(stmt *sql.Stmt) driver.StmtExecFunc {
 	return func(ctx context.Context, args ...interface{}) (int64, error) {
 		res, err := stmt.ExecContext(ctx, args...)
@@ -279,6 +321,12 @@ func (d *driveri) DropTable(ctx context.Context, db sqlz.DB, tbl string, ifExist
 	return errz.Err(err)
 }
 
+// RenameTable implements driver.SQLDriver.
+func (d *driveri) RenameTable(ctx context.Context, db sqlz.DB, tbl, newName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %q RENAME TO %q", tbl, newName))
+	return errz.Err(err)
+}
+
 // TableColumnTypes implements driver.SQLDriver.
 func (d *driveri) TableColumnTypes(ctx context.Context, db sqlz.DB, tblName string, colNames []string) ([]*sql.ColumnType, error) {
 	// We have to do some funky stuff to get the column types
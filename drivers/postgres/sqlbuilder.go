@@ -12,6 +12,7 @@ import (
 
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
 func newFragmentBuilder(log lg.Log) *sqlbuilder.BaseFragmentBuilder {
@@ -89,6 +90,12 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) string {
 			sb.WriteRune(',')
 		}
 	}
+
+	if len(tblDef.UniqueCols) > 0 {
+		sb.WriteString(",\nUNIQUE (\"")
+		sb.WriteString(strings.Join(tblDef.UniqueCols, `", "`))
+		sb.WriteString(`")`)
+	}
 	sb.WriteString("\n)")
 
 	return sb.String()
@@ -145,3 +152,53 @@ func replacePlaceholders(input string) string {
 
 	return sb.String()
 }
+
+// buildUpsertStmt builds an "INSERT ... ON CONFLICT DO UPDATE"
+// statement for a single row. The statement returns a single bool
+// column, which is true if the row was inserted (as opposed
+// to updated). Note that Postgres requires a unique constraint
+// on keyCols.
+func buildUpsertStmt(tbl string, cols, keyCols []string) (string, error) {
+	if len(cols) == 0 {
+		return "", errz.Errorf("no columns provided")
+	}
+
+	if len(keyCols) == 0 {
+		return "", errz.Errorf("no key columns provided")
+	}
+
+	var updateCols []string
+	for _, col := range cols {
+		if !stringz.InSlice(keyCols, col) {
+			updateCols = append(updateCols, col)
+		}
+	}
+
+	if len(updateCols) == 0 {
+		// The update clause requires at least one column.
+		updateCols = keyCols
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(`INSERT INTO "`)
+	sb.WriteString(tbl)
+	sb.WriteString(`" ("`)
+	sb.WriteString(strings.Join(cols, `", "`))
+	sb.WriteString(`") VALUES (`)
+	sb.WriteString(stringz.RepeatJoin("?", len(cols), ", "))
+	sb.WriteString(`) ON CONFLICT ("`)
+	sb.WriteString(strings.Join(keyCols, `", "`))
+	sb.WriteString(`") DO UPDATE SET `)
+	for i, col := range updateCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf(`"%s" = EXCLUDED."%s"`, col, col))
+	}
+
+	// xmax is zero for a newly inserted row.
+	sb.WriteString(" RETURNING (xmax = 0)")
+
+	s := replacePlaceholders(sb.String())
+	return s, nil
+}
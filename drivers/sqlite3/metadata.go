@@ -290,6 +290,11 @@ func getTableMetadata(ctx context.Context, log lg.Log, db sqlz.DB, tblName strin
 		return nil, errz.Err(err)
 	}
 
+	err = setForeignKeys(ctx, log, db, []*source.TableMetadata{tblMeta})
+	if err != nil {
+		return nil, err
+	}
+
 	return tblMeta, nil
 }
 
@@ -397,9 +402,62 @@ ORDER BY m.name, p.cid
 		tblMetas[i].RowCount = rowCounts[i]
 	}
 
+	err = setForeignKeys(ctx, log, db, tblMetas)
+	if err != nil {
+		return nil, err
+	}
+
 	return tblMetas, nil
 }
 
+// setForeignKeys sets the ForeignKey field of the columns of
+// tblMetas, per the foreign key constraints of the tables.
+func setForeignKeys(ctx context.Context, log lg.Log, db sqlz.DB, tblMetas []*source.TableMetadata) error {
+	// Results will look like:
+	//
+	// table_name	from		table		to
+	// film_actor	actor_id	actor		actor_id
+	// film_actor	film_id		film		film_id
+	//
+	// Note that "to" is NULL if the constraint implicitly
+	// references the primary key of the parent table.
+	const query = `
+SELECT m.name AS table_name, f."from", f."table", f."to"
+FROM sqlite_master AS m JOIN pragma_foreign_key_list(m.name) AS f
+WHERE m.type = 'table'
+ORDER BY m.name, f.id, f.seq
+`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer log.WarnIfCloseError(rows)
+
+	for rows.Next() {
+		var tblName, colName, refTable string
+		refCol := &sql.NullString{}
+		err = rows.Scan(&tblName, &colName, &refTable, refCol)
+		if err != nil {
+			return errz.Err(err)
+		}
+
+		for _, tblMeta := range tblMetas {
+			if tblMeta.Name != tblName {
+				continue
+			}
+
+			for _, col := range tblMeta.Columns {
+				if col.Name == colName {
+					col.ForeignKey = &source.ForeignKey{RefTable: refTable, RefCol: refCol.String}
+				}
+			}
+		}
+	}
+
+	return errz.Err(rows.Err())
+}
+
 // getTblRowCounts returns the number of rows in each table.
 func getTblRowCounts(ctx context.Context, log lg.Log, db sqlz.DB, tblNames []string) ([]int64, error) {
 	// See: https://stackoverflow.com/questions/7524612/how-to-count-rows-from-multiple-tables-in-sqlite
@@ -117,6 +117,12 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) (string, error) {
 	}
 	buf.WriteString(cols[len(cols)-1])
 
+	if len(tblDef.UniqueCols) > 0 {
+		buf.WriteString(",\nUNIQUE (\"")
+		buf.WriteString(strings.Join(tblDef.UniqueCols, `", "`))
+		buf.WriteString(`")`)
+	}
+
 	if fk != "" {
 		buf.WriteString(",\n")
 		buf.WriteString(fk)
@@ -18,6 +18,7 @@ import (
 	"github.com/neilotoole/sq/libsq/ast/sqlbuilder"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/options"
 	"github.com/neilotoole/sq/libsq/core/sqlmodel"
 	"github.com/neilotoole/sq/libsq/core/sqlz"
 	"github.com/neilotoole/sq/libsq/core/stringz"
@@ -34,6 +35,10 @@ const (
 
 	// Prefix is the scheme+separator value "sqlite3://".
 	Prefix = "sqlite3://"
+
+	// optReadOnly is the key of the source option that, if true,
+	// opens the db such that its data can't be changed.
+	optReadOnly = "readonly"
 )
 
 var _ driver.Provider = (*Provider)(nil)
@@ -77,6 +82,16 @@ func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database
 	if err != nil {
 		return nil, err
 	}
+
+	if src.Options.Get(optReadOnly) == "true" {
+		// See https://www.sqlite.org/pragma.html#pragma_query_only
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "_query_only=true"
+	}
+
 	db, err := sql.Open(dbDrvr, dsn)
 	if err != nil {
 		return nil, errz.Wrapf(err, "failed to open sqlite3 source with DSN %q", dsn)
@@ -155,9 +170,16 @@ func (d *driveri) Dialect() driver.Dialect {
 		Placeholders:   placeholders,
 		Quote:          '"',
 		MaxBatchValues: 500,
+		BinaryOrder:    binaryOrder,
 	}
 }
 
+// binaryOrder implements driver.Dialect.BinaryOrder. BINARY is
+// SQLite's default collation, but a column may declare another.
+func binaryOrder(expr string) string {
+	return expr + " COLLATE BINARY"
+}
+
 func placeholders(numCols, numRows int) string {
 	rows := make([]string, numRows)
 	for i := 0; i < numRows; i++ {
@@ -241,6 +263,12 @@ func (d *driveri) DropTable(ctx context.Context, db sqlz.DB, tbl string, ifExist
 	return errz.Err(err)
 }
 
+// RenameTable implements driver.SQLDriver.
+func (d *driveri) RenameTable(ctx context.Context, db sqlz.DB, tbl, newName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %q RENAME TO %q", tbl, newName))
+	return errz.Err(err)
+}
+
 // CreateTable implements driver.SQLDriver.
 func (d *driveri) CreateTable(ctx context.Context, db sqlz.DB, tblDef *sqlmodel.TableDef) error {
 	query, err := buildCreateTableStmt(tblDef)
@@ -521,6 +549,25 @@ func NewScratchSource(log lg.Log, name string) (src *source.Source, clnup func()
 	return src, cleanFn, nil
 }
 
+// NewCacheSource returns a source for the SQLite import cache
+// database file at fpath. Unlike NewScratchSource, the file is not
+// removed when the source is closed. If readOnly is true, the db
+// opened for the source rejects changes to its data, such that
+// the cached import can't be modified by a query.
+func NewCacheSource(fpath string, readOnly bool) *source.Source {
+	src := &source.Source{
+		Type:     Type,
+		Handle:   source.ScratchHandle,
+		Location: Prefix + fpath,
+	}
+
+	if readOnly {
+		src.Options = options.Options{optReadOnly: []string{"true"}}
+	}
+
+	return src
+}
+
 // PathFromLocation returns the absolute file path
 // from the source location, which should have the "sqlite3://" prefix.
 func PathFromLocation(src *source.Source) (string, error) {
@@ -0,0 +1,113 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+var _ driver.BulkLoader = (*driveri)(nil)
+
+// CanBulkLoad implements driver.BulkLoader. It returns false if
+// any of destColNames is an identity column, because the bulk
+// copy implementation does not support "KEEPIDENTITY".
+func (d *driveri) CanBulkLoad(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string) (bool, error) {
+	const query = `SELECT name FROM sys.identity_columns WHERE object_id = OBJECT_ID(@p1)`
+
+	rows, err := db.QueryContext(ctx, query, destTbl)
+	if err != nil {
+		return false, errz.Err(err)
+	}
+	defer d.log.WarnIfCloseError(rows)
+
+	for rows.Next() {
+		var colName string
+		err = rows.Scan(&colName)
+		if err != nil {
+			return false, errz.Err(err)
+		}
+
+		if stringz.InSlice(destColNames, colName) {
+			return false, nil
+		}
+	}
+
+	return true, errz.Err(rows.Err())
+}
+
+// NewBulkLoad implements driver.BulkLoader. The records are loaded
+// via the SQL Server bulk copy mechanism.
+func (d *driveri) NewBulkLoad(ctx context.Context, log lg.Log, db *sql.DB, destTbl string, destColNames []string) (*driver.BatchInsert, error) {
+	destColsMeta, err := d.getTableColsMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	loadFn := func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		loaded, err := bulkCopy(ctx, tx, destTbl, destColsMeta.Names(), recCh)
+		if err != nil {
+			return 0, errz.Append(err, errz.Err(tx.Rollback()))
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		return loaded, nil
+	}
+
+	bi := driver.NewBulkLoadBatchInsert(ctx, driver.DefaultInsertMungeFunc(destTbl, destColsMeta), loadFn)
+	return bi, nil
+}
+
+// bulkCopy bulk copies the records received on recCh to tbl.
+func bulkCopy(ctx context.Context, tx *sql.Tx, tbl string, cols []string, recCh <-chan []interface{}) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, mssql.CopyIn(tbl, mssql.BulkOptions{KeepNulls: true}, cols...))
+	if err != nil {
+		return 0, errz.Err(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, errz.Append(ctx.Err(), errz.Err(stmt.Close()))
+		case rec, ok := <-recCh:
+			if ok {
+				driver.DerefRecord(rec)
+				_, err = stmt.ExecContext(ctx, rec...)
+				if err != nil {
+					return 0, errz.Append(errz.Err(err), errz.Err(stmt.Close()))
+				}
+				continue
+			}
+
+			// recCh is closed: executing the stmt without
+			// args flushes the buffered rows.
+			res, err := stmt.ExecContext(ctx)
+			if err != nil {
+				return 0, errz.Append(errz.Err(err), errz.Err(stmt.Close()))
+			}
+
+			err = errz.Err(stmt.Close())
+			if err != nil {
+				return 0, err
+			}
+
+			loaded, err := res.RowsAffected()
+			return loaded, errz.Err(err)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/neilotoole/sq/libsq/ast"
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
 type fragBuilder struct {
@@ -138,7 +139,13 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) string {
 		sb.WriteString("\n\"")
 		sb.WriteString(colDef.Name)
 		sb.WriteString("\" ")
-		sb.WriteString(dbTypeNameFromKind(colDef.Kind))
+		if colDef.Kind == kind.Text && stringz.InSlice(tblDef.UniqueCols, colDef.Name) {
+			// NVARCHAR(MAX) can't be a key column: 450 is the
+			// maximum length within the index key size limit.
+			sb.WriteString("NVARCHAR(450)")
+		} else {
+			sb.WriteString(dbTypeNameFromKind(colDef.Kind))
+		}
 
 		if colDef.NotNull {
 			sb.WriteRune(' ')
@@ -150,6 +157,12 @@ func buildCreateTableStmt(tblDef *sqlmodel.TableDef) string {
 			sb.WriteRune(',')
 		}
 	}
+
+	if len(tblDef.UniqueCols) > 0 {
+		sb.WriteString(",\nUNIQUE (\"")
+		sb.WriteString(strings.Join(tblDef.UniqueCols, `", "`))
+		sb.WriteString(`")`)
+	}
 	sb.WriteString("\n)")
 
 	return sb.String()
@@ -206,3 +219,61 @@ func replacePlaceholders(input string) string {
 
 	return sb.String()
 }
+
+// buildUpsertStmt builds a "MERGE" statement for a single row.
+// The statement outputs a single column, $action, whose value
+// is "INSERT" or "UPDATE".
+func buildUpsertStmt(tbl string, cols, keyCols []string) (string, error) {
+	if len(cols) == 0 {
+		return "", errz.Errorf("no columns provided")
+	}
+
+	if len(keyCols) == 0 {
+		return "", errz.Errorf("no key columns provided")
+	}
+
+	var updateCols []string
+	for _, col := range cols {
+		if !stringz.InSlice(keyCols, col) {
+			updateCols = append(updateCols, col)
+		}
+	}
+
+	if len(updateCols) == 0 {
+		// The update clause requires at least one column.
+		updateCols = keyCols
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(`MERGE INTO "`)
+	sb.WriteString(tbl)
+	sb.WriteString(`" AS tgt USING (SELECT `)
+	for i, col := range cols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf(`? AS "%s"`, col))
+	}
+	sb.WriteString(`) AS src ON `)
+	for i, col := range keyCols {
+		if i > 0 {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString(fmt.Sprintf(`tgt."%s" = src."%s"`, col, col))
+	}
+	sb.WriteString(` WHEN MATCHED THEN UPDATE SET `)
+	for i, col := range updateCols {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf(`tgt."%s" = src."%s"`, col, col))
+	}
+	sb.WriteString(` WHEN NOT MATCHED THEN INSERT ("`)
+	sb.WriteString(strings.Join(cols, `", "`))
+	sb.WriteString(`") VALUES (src."`)
+	sb.WriteString(strings.Join(cols, `", src."`))
+	sb.WriteString(`") OUTPUT $action;`)
+
+	s := replacePlaceholders(sb.String())
+	return s, nil
+}
@@ -47,6 +47,7 @@ func (p *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
 }
 
 var _ driver.Driver = (*driveri)(nil)
+var _ driver.Upserter = (*driveri)(nil)
 
 // driveri is the SQL Server implementation of driver.Driver.
 type driveri struct {
@@ -70,9 +71,22 @@ func (d *driveri) Dialect() driver.Dialect {
 		Placeholders:   placeholders,
 		Quote:          '"',
 		MaxBatchValues: 1000,
+		Savepoint:      savepoint,
+		BinaryOrder:    binaryOrder,
 	}
 }
 
+// binaryOrder implements driver.Dialect.BinaryOrder.
+func binaryOrder(expr string) string {
+	return expr + " COLLATE Latin1_General_BIN2"
+}
+
+// savepoint implements driver.Dialect.Savepoint. SQL Server uses
+// "SAVE TRANSACTION", and has no equivalent of RELEASE SAVEPOINT.
+func savepoint(name string) (create, rollback, release string) {
+	return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+}
+
 func placeholders(numCols, numRows int) string {
 	rows := make([]string, numRows)
 
@@ -307,6 +321,13 @@ func (d *driveri) DropTable(ctx context.Context, db sqlz.DB, tbl string, ifExist
 	return errz.Err(err)
 }
 
+// RenameTable implements driver.SQLDriver.
+func (d *driveri) RenameTable(ctx context.Context, db sqlz.DB, tbl, newName string) error {
+	_, err := db.ExecContext(ctx, "exec sp_rename @objname = @p1, @newname = @p2, @objtype = 'OBJECT'",
+		"dbo."+tbl, newName)
+	return errz.Err(err)
+}
+
 // PrepareInsertStmt implements driver.SQLDriver.
 func (d *driveri) PrepareInsertStmt(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string, numRows int) (*driver.StmtExecer, error) {
 	destColsMeta, err := d.getTableColsMeta(ctx, db, destTbl, destColNames)
@@ -344,6 +365,27 @@ func (d *driveri) PrepareUpdateStmt(ctx context.Context, db sqlz.DB, destTbl str
 	return execer, nil
 }
 
+// PrepareUpsertStmt implements driver.Upserter.
+func (d *driveri) PrepareUpsertStmt(ctx context.Context, db sqlz.DB, destTbl string, destColNames, keyColNames []string) (*driver.StmtExecer, error) {
+	destColsMeta, err := d.getTableColsMeta(ctx, db, destTbl, destColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := buildUpsertStmt(destTbl, destColNames, keyColNames)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	execer := driver.NewStmtExecer(stmt, driver.DefaultInsertMungeFunc(destTbl, destColsMeta), newUpsertStmtExecFunc(stmt, db, destTbl), destColsMeta)
+	return execer, nil
+}
+
 func (d *driveri) getTableColsMeta(ctx context.Context, db sqlz.DB, tblName string, colNames []string) (sqlz.RecordMeta, error) {
 	// SQLServer has this unusual incantation for its LIMIT equivalent:
 	//
@@ -476,6 +518,33 @@ func newStmtExecFunc(stmt *sql.Stmt, db sqlz.DB, tbl string) driver.StmtExecFunc
 	}
 }
 
+// newUpsertStmtExecFunc returns a StmtExecFunc for a statement
+// built by buildUpsertStmt. Like newStmtExecFunc, it enables
+// "identity insert" if required.
+func newUpsertStmtExecFunc(stmt *sql.Stmt, db sqlz.DB, tbl string) driver.StmtExecFunc {
+	return func(ctx context.Context, args ...interface{}) (int64, error) {
+		var action string
+		err := stmt.QueryRowContext(ctx, args...).Scan(&action)
+		if err != nil && hasErrCode(err, errCodeIdentityInsert) {
+			idErr := setIdentityInsert(ctx, db, tbl, true)
+			if idErr != nil {
+				return 0, errz.Combine(err, idErr)
+			}
+
+			err = stmt.QueryRowContext(ctx, args...).Scan(&action)
+		}
+
+		if err != nil {
+			return 0, errz.Err(err)
+		}
+
+		if action == "INSERT" {
+			return driver.UpsertInserted, nil
+		}
+		return driver.UpsertUpdated, nil
+	}
+}
+
 // setIdentityInsert enables (or disables) "identity insert" for tbl on db.
 // SQLServer is fussy about inserting values to the identity col. This
 // error can be returned from the driver:
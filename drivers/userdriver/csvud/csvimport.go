@@ -0,0 +1,223 @@
+// Package csvud provides user driver CSV import functionality.
+//
+// The first record of the CSV data is the header record, and each
+// subsequent record is a row of each table of the driver def. Thus
+// the selectors of the def and its tables select nothing, and by
+// convention are "/". A table can be used to import a subset of
+// the fields of each record, with different column names and kinds.
+//
+// The selector of a column is the name of a field of the header
+// record, or the zero-based index of a field, such as "[2]". If
+// omitted, the selector is the column name. As with the xml genre,
+// "../sequence()" generates a sequence value.
+package csvud
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/libsq/core/cleanup"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// Genre is the user driver genre that this package supports.
+const Genre = "csv"
+
+const selSequence = "../sequence()"
+
+// Import implements userdriver.ImportFunc.
+func Import(ctx context.Context, log lg.Log, def *userdriver.DriverDef, data io.Reader, destDB driver.Database) error {
+	if def.Genre != Genre {
+		return errz.Errorf("csvud.Import does not support genre %q", def.Genre)
+	}
+
+	clnup := cleanup.New()
+	err := execImport(ctx, log, def, data, destDB, clnup)
+	err2 := clnup.Run()
+	if err != nil {
+		return errz.Wrap(err, "csv import")
+	}
+
+	return errz.Wrap(err2, "csv import: cleanup")
+}
+
+func execImport(ctx context.Context, log lg.Log, def *userdriver.DriverDef, r io.Reader, destDB driver.Database,
+	clnup *cleanup.Cleanup) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return errz.New("no header record")
+		}
+		return errz.Err(err)
+	}
+
+	// fieldIndices holds, for each table, the index of the field of
+	// each column, or -1 for a sequence column.
+	fieldIndices := make([][]int, len(def.Tables))
+	execers := make([]*driver.StmtExecer, len(def.Tables))
+	for i, tbl := range def.Tables {
+		fieldIndices[i], err = getFieldIndices(tbl, header)
+		if err != nil {
+			return err
+		}
+
+		tblDef, err := userdriver.ToTableDef(tbl)
+		if err != nil {
+			return err
+		}
+
+		err = destDB.SQLDriver().CreateTable(ctx, destDB.DB(), tblDef)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Created table %s.%s", destDB.Source().Handle, tblDef.Name)
+
+		execers[i], err = destDB.SQLDriver().PrepareInsertStmt(ctx, destDB.DB(), tbl.Name,
+			userdriver.NamesFromCols(tbl.Cols), 1)
+		if err != nil {
+			return err
+		}
+
+		// Make sure we close stmt eventually.
+		clnup.AddC(execers[i])
+	}
+
+	for rowNum := int64(1); ; rowNum++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		rec, err := cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errz.Err(err)
+		}
+
+		for i, tbl := range def.Tables {
+			vals, err := recordToVals(tbl, fieldIndices[i], rec, rowNum)
+			if err != nil {
+				return err
+			}
+
+			err = execers[i].Munge(vals)
+			if err != nil {
+				return err
+			}
+
+			_, err = execers[i].Exec(ctx, vals...)
+			if err != nil {
+				return errz.Wrapf(err, "failed to insert to table %q", tbl.Name)
+			}
+		}
+	}
+}
+
+// getFieldIndices returns the index of the header field that each
+// column of tbl selects, or -1 for a sequence column.
+func getFieldIndices(tbl *userdriver.TableMapping, header []string) ([]int, error) {
+	indices := make([]int, len(tbl.Cols))
+	for i, col := range tbl.Cols {
+		if col.Foreign != "" {
+			return nil, errz.Errorf("%s.%s: foreign is not supported by genre %q", tbl.Name, col.Name, Genre)
+		}
+
+		sel := col.Selector
+		switch {
+		case sel == selSequence:
+			indices[i] = -1
+			continue
+		case sel == "":
+			sel = col.Name
+		case strings.HasPrefix(sel, "[") && strings.HasSuffix(sel, "]"):
+			n, err := strconv.Atoi(sel[1 : len(sel)-1])
+			if err != nil || n < 0 {
+				return nil, errz.Errorf("%s.%s: invalid field index %q", tbl.Name, col.Name, sel)
+			}
+			indices[i] = n
+			continue
+		}
+
+		var found bool
+		for j, field := range header {
+			if field == sel {
+				indices[i], found = j, true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errz.Errorf("%s.%s: field %q not found in header record", tbl.Name, col.Name, sel)
+		}
+	}
+
+	return indices, nil
+}
+
+// recordToVals returns the values of rec for the columns of tbl,
+// whose field indices are indices. An empty field of a non-text
+// column is NULL.
+func recordToVals(tbl *userdriver.TableMapping, indices []int, rec []string, rowNum int64) ([]interface{}, error) {
+	vals := make([]interface{}, len(tbl.Cols))
+	for i, col := range tbl.Cols {
+		if indices[i] == -1 {
+			vals[i] = rowNum
+			continue
+		}
+
+		if indices[i] >= len(rec) || (rec[indices[i]] == "" && col.Kind != kind.Text) {
+			if col.Required {
+				return nil, errz.Errorf("no value for required column %s.%s (row %d)", tbl.Name, col.Name, rowNum)
+			}
+			continue
+		}
+
+		var err error
+		vals[i], err = convertVal(tbl.Name, col, rec[indices[i]])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return vals, nil
+}
+
+func convertVal(tbl string, col *userdriver.ColMapping, data string) (interface{}, error) {
+	const errTplMsg = `conversion error: %s.%s: expected "%s" but got %q: %v`
+
+	var val interface{}
+	var err error
+
+	switch col.Kind {
+	default:
+		return nil, errz.Errorf("unknown data kind %q for col %s", col.Kind, col.Name)
+	case kind.Text, kind.Decimal, kind.Datetime, kind.Date, kind.Time, kind.Bytes, kind.Null:
+		return data, nil
+	case kind.Int:
+		val, err = strconv.ParseInt(data, 0, 64)
+	case kind.Float:
+		val, err = strconv.ParseFloat(data, 64)
+	case kind.Bool:
+		val, err = strconv.ParseBool(data)
+	}
+
+	if err != nil {
+		return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, err)
+	}
+
+	return val, nil
+}
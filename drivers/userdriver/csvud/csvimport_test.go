@@ -0,0 +1,56 @@
+package csvud_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/userdriver/csvud"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/testsrc"
+)
+
+const driverContacts = "contacts"
+
+func TestImport_Contacts(t *testing.T) {
+	th := testh.New(t)
+
+	defs := testh.DriverDefsFrom(t, testsrc.PathDriverDefContacts)
+	require.Equal(t, 1, len(defs))
+	udDef := defs[0]
+	require.Equal(t, driverContacts, udDef.Name)
+	require.Equal(t, csvud.Genre, udDef.Genre)
+
+	scratchDB, err := th.Databases().OpenScratch(th.Context, driverContacts)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, scratchDB.Close())
+	})
+
+	data := proj.ReadFile("drivers/userdriver/csvud/testdata/contacts.csv")
+	err = csvud.Import(th.Context, th.Log, udDef, bytes.NewReader(data), scratchDB)
+	require.NoError(t, err)
+
+	sink, err := th.QuerySQL(scratchDB.Source(), "SELECT * FROM contact")
+	require.NoError(t, err)
+	require.Equal(t, []string{"contact_id", "name", "email", "age", "subscribed"}, sink.RecMeta.Names())
+	require.Equal(t, 3, len(sink.Recs))
+	for i, rec := range sink.Recs {
+		// Verify that the primary id cols are sequential
+		require.Equal(t, int64(i+1), testh.Val(rec[0]))
+	}
+	require.Equal(t, "Bob Jones", testh.Val(sink.Recs[1][1]))
+	require.Nil(t, sink.Recs[1][3])
+	require.Equal(t, int64(51), testh.Val(sink.Recs[2][3]))
+	require.Equal(t, true, testh.Val(sink.Recs[2][4]))
+
+	sink, err = th.QuerySQL(scratchDB.Source(), "SELECT * FROM company")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, "Initech", testh.Val(sink.Recs[1][1]))
+	require.Nil(t, sink.Recs[1][2])
+	require.Equal(t, "", testh.Val(sink.Recs[2][1]))
+}
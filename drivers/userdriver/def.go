@@ -1,7 +1,11 @@
 package userdriver
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/neilotoole/sq/libsq/core/errz"
@@ -10,12 +14,21 @@ import (
 	"github.com/neilotoole/sq/libsq/core/stringz"
 )
 
+// Genres that have genre-specific validation in ValidateDriverDef.
+// Note that the genres are implemented in sub-packages (such as
+// jsonud), which import this package.
+const (
+	genreJSON  = "json"
+	genreCSV   = "csv"
+	genreRegex = "regex"
+)
+
 // DriverDef is a user-defined driver definition.
 type DriverDef struct {
 	// Name is short name of the driver type, e.g. "rss".
 	Name string `yaml:"driver" json:"driver"`
 
-	// Genre is the generic document type, e.g. XML or JSON etc.
+	// Genre is the generic document type: "xml", "json", "csv" or "regex".
 	Genre string `yaml:"genre" json:"genre"`
 
 	// Title is the full name of the driver
@@ -30,6 +43,10 @@ type DriverDef struct {
 
 	// Tables is the set of tables that define the type.
 	Tables []*TableMapping `yaml:"tables" json:"tables"`
+
+	// File is the path of the file that the def was loaded
+	// from, if any. It is not part of the def itself.
+	File string `yaml:"-" json:"-"`
 }
 
 // TableBySelector returns the TableMapping that matches sel, or nil.
@@ -46,6 +63,18 @@ func (d *DriverDef) String() string {
 	return stringz.SprintJSON(d)
 }
 
+// hash returns a hash of the def, which changes when
+// the def changes. Field File is not part of the hash.
+func (d *DriverDef) hash() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", errz.Err(err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // TableMapping describes how document data is mapped to a table.
 type TableMapping struct {
 	// Name is the table name.
@@ -54,6 +83,10 @@ type TableMapping struct {
 	// Selector specifies how the table data is selected.
 	Selector string `yaml:"selector" json:"selector"`
 
+	// RecordStart is an optional regex that matches the first line
+	// of a multiline record. It is used only by the "regex" genre.
+	RecordStart string `yaml:"record_start,omitempty" json:"record_start,omitempty"`
+
 	// Cols is the set of columns in the table.
 	Cols []*ColMapping `yaml:"cols" json:"cols"`
 
@@ -201,8 +234,40 @@ func (c *ColMapping) String() string {
 	return stringz.SprintJSON(c)
 }
 
+// DefError is an error returned by ValidateDriverDef. Its fields
+// locate the error within the def, which allows (for example) the
+// error to be reported with the line of the def's YAML.
+type DefError struct {
+	// Table is the index of the table in DriverDef.Tables,
+	// or -1 if the error is not specific to a table.
+	Table int
+
+	// Col is the index of the col in TableMapping.Cols,
+	// or -1 if the error is not specific to a col.
+	Col int
+
+	// Field is the YAML key of the field in error, e.g.
+	// "selector". It may be empty.
+	Field string
+
+	Err error
+}
+
+func (e *DefError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DefError) Unwrap() error {
+	return e.Err
+}
+
+func defErr(tbl, col int, field string, err error) error {
+	return &DefError{Table: tbl, Col: col, Field: field, Err: err}
+}
+
 // ValidateDriverDef checks that def is valid, returning one or
-// more errors if not.
+// more errors if not. Each error is a *DefError.
 func ValidateDriverDef(def *DriverDef) []error {
 	drvrName, errs := validateDefRoot(def)
 	if len(errs) > 0 {
@@ -212,25 +277,26 @@ func ValidateDriverDef(def *DriverDef) []error {
 	for i, tbl := range def.Tables {
 		tblName := fmt.Sprintf("%s.table[%d]", drvrName, i)
 		if tbl.Name == "" {
-			errs = append(errs, errz.Errorf("%s name is empty", tblName))
+			errs = append(errs, defErr(i, -1, "table", errz.Errorf("%s name is empty", tblName)))
 		} else {
 			tblName = fmt.Sprintf("%s.table[%s]", drvrName, tbl.Name)
 		}
 
 		if tbl.Selector == "" {
-			errs = append(errs, errz.Errorf("%s selector is empty", tblName))
+			errs = append(errs, defErr(i, -1, "selector", errz.Errorf("%s selector is empty", tblName)))
 		}
 		if len(tbl.Cols) == 0 {
-			errs = append(errs, errz.Errorf("%s cols is empty", tblName))
+			errs = append(errs, defErr(i, -1, "cols", errz.Errorf("%s cols is empty", tblName)))
 			continue
 		}
 
 		if len(tbl.PrimaryKey) == 0 {
-			errs = append(errs, errz.Errorf("%s primary key must list at least one column", tblName))
+			errs = append(errs, defErr(i, -1, "primary_key",
+				errz.Errorf("%s primary key must list at least one column", tblName)))
 		} else {
 			for j, pkColName := range tbl.PrimaryKey {
 				if pkColName == "" {
-					errs = append(errs, errz.Errorf("%s primary key %d has empty name", tblName, j))
+					errs = append(errs, defErr(i, -1, "primary_key", errz.Errorf("%s primary key %d has empty name", tblName, j)))
 					continue
 				}
 
@@ -243,7 +309,8 @@ func ValidateDriverDef(def *DriverDef) []error {
 					}
 				}
 				if !foundIt {
-					errs = append(errs, errz.Errorf("%s specified primary key %q not found in cols", tblName, pkColName))
+					errs = append(errs, defErr(i, -1, "primary_key",
+						errz.Errorf("%s specified primary key %q not found in cols", tblName, pkColName)))
 				}
 			}
 		}
@@ -251,7 +318,7 @@ func ValidateDriverDef(def *DriverDef) []error {
 		for j, col := range tbl.Cols {
 			colName := fmt.Sprintf("%s.col[%d]", tblName, j)
 			if col.Name == "" {
-				errs = append(errs, errz.Errorf("%s name is empty", colName))
+				errs = append(errs, defErr(i, j, "col", errz.Errorf("%s name is empty", colName)))
 			} else {
 				colName = fmt.Sprintf("%s.col[%s]", tblName, col.Name)
 			}
@@ -260,9 +327,73 @@ func ValidateDriverDef(def *DriverDef) []error {
 			switch col.Kind {
 			default:
 			case kind.Unknown, kind.Null:
-				errs = append(errs, errz.Errorf("%s.kind (%s) is invalid", colName, col.Kind))
+				errs = append(errs, defErr(i, j, "kind", errz.Errorf("%s.kind (%s) is invalid", colName, col.Kind)))
+			}
+
+			if col.Foreign != "" {
+				parts := strings.Split(col.Foreign, "/")
+				if len(parts) != 2 || parts[0] != ".." || parts[1] == "" {
+					errs = append(errs, defErr(i, j, "foreign", errz.Errorf(`%s.foreign should be of form "../col_name" but was %q`,
+						colName, col.Foreign)))
+				} else if def.Genre == genreCSV || def.Genre == genreRegex {
+					errs = append(errs, defErr(i, j, "foreign",
+						errz.Errorf("%s.foreign is not supported by genre %q", colName, def.Genre)))
+				}
 			}
 		}
+
+		if def.Genre == genreJSON && tbl.Selector != "" && !strings.HasPrefix(tbl.Selector, "$") {
+			errs = append(errs, defErr(i, -1, "selector", errz.Errorf("%s selector %q should begin with '$' for genre %q",
+				tblName, tbl.Selector, def.Genre)))
+		}
+
+		if def.Genre == genreRegex {
+			errs = append(errs, validateRegexTable(i, tblName, tbl)...)
+		} else if tbl.RecordStart != "" {
+			errs = append(errs, defErr(i, -1, "record_start",
+				errz.Errorf("%s record_start is not supported by genre %q", tblName, def.Genre)))
+		}
+	}
+
+	return errs
+}
+
+// validateRegexTable validates tbl, whose index is tblIndex, for
+// the "regex" genre: the selector and record_start must be valid
+// regexes, and each col selector must name a capture group of the
+// selector.
+func validateRegexTable(tblIndex int, tblName string, tbl *TableMapping) []error {
+	var errs []error
+	if tbl.RecordStart != "" {
+		if _, err := regexp.Compile(tbl.RecordStart); err != nil {
+			errs = append(errs, defErr(tblIndex, -1, "record_start",
+				errz.Errorf("%s record_start is invalid: %v", tblName, err)))
+		}
+	}
+
+	if tbl.Selector == "" {
+		return errs
+	}
+
+	re, err := regexp.Compile(tbl.Selector)
+	if err != nil {
+		errs = append(errs, defErr(tblIndex, -1, "selector", errz.Errorf("%s selector is invalid: %v", tblName, err)))
+		return errs
+	}
+
+	for j, col := range tbl.Cols {
+		group := col.Selector
+		switch {
+		case group == "../sequence()":
+			continue
+		case group == "":
+			group = col.Name
+		}
+
+		if group != "" && re.SubexpIndex(group) == -1 {
+			errs = append(errs, defErr(tblIndex, j, "selector",
+				errz.Errorf("%s.col[%s] capture group %q not found in table selector", tblName, col.Name, group)))
+		}
 	}
 
 	return errs
@@ -271,27 +402,30 @@ func ValidateDriverDef(def *DriverDef) []error {
 func validateDefRoot(def *DriverDef) (drvrName string, errs []error) {
 	if def == nil {
 		// shouldn't happen
-		errs = append(errs, errz.New("def is nil"))
+		errs = append(errs, defErr(-1, -1, "", errz.New("def is nil")))
 		return "", errs
 	}
 
 	if def.Name == "" {
-		errs = append(errs, errz.New("driver name is empty"))
+		errs = append(errs, defErr(-1, -1, "driver", errz.New("driver name is empty")))
 		return "", errs
 	}
 
 	drvrName = fmt.Sprintf("driver[%s]", def.Name)
 	if def.Genre == "" {
-		errs = append(errs, errz.Errorf("%s.genre is empty", drvrName))
+		errs = append(errs, defErr(-1, -1, "genre", errz.Errorf("%s.genre is empty", drvrName)))
 	}
 	if def.Selector == "" {
-		errs = append(errs, errz.Errorf("%s.selector is empty", drvrName))
+		errs = append(errs, defErr(-1, -1, "selector", errz.Errorf("%s.selector is empty", drvrName)))
+	} else if def.Genre == genreJSON && !strings.HasPrefix(def.Selector, "$") {
+		errs = append(errs, defErr(-1, -1, "selector", errz.Errorf("%s.selector %q should begin with '$' for genre %q",
+			drvrName, def.Selector, def.Genre)))
 	}
 	if def.Title == "" {
-		errs = append(errs, errz.Errorf("%s.title is empty", drvrName))
+		errs = append(errs, defErr(-1, -1, "title", errz.Errorf("%s.title is empty", drvrName)))
 	}
 	if len(def.Tables) == 0 {
-		errs = append(errs, errz.Errorf("%s.tables is empty", drvrName))
+		errs = append(errs, defErr(-1, -1, "tables", errz.Errorf("%s.tables is empty", drvrName)))
 	}
 
 	return drvrName, errs
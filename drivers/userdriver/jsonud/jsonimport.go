@@ -0,0 +1,505 @@
+// Package jsonud provides user driver JSON import functionality.
+//
+// The selector of each table is an absolute path expression into
+// the JSON, such as "$.orders[*]", which selects the values that are
+// the rows of the table. A path consists of field segments (".orders"
+// or "['orders']"), index segments ("[0]") and wildcard segments
+// ("[*]"). A table whose selector extends the selector of another
+// table, such as "$.orders[*].items[*]", is a child of that table.
+//
+// The selector of a column is a path relative to the row value, such
+// as "./customer.name" or "tags[0]", or "@" for the row value itself
+// (e.g. for an array of strings). If omitted, the selector is the column
+// name. As with the xml genre, "../sequence()" generates a sequence
+// value, and a column with "foreign: ../order_id" is populated with
+// the order_id value of the parent row.
+//
+// The input may be a single JSON value, or a stream of values (as
+// in JSON Lines), each of which is matched against the selectors.
+package jsonud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/libsq/core/cleanup"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// Genre is the user driver genre that this package supports.
+const Genre = "json"
+
+const selSequence = "../sequence()"
+
+// Import implements userdriver.ImportFunc.
+func Import(ctx context.Context, log lg.Log, def *userdriver.DriverDef, data io.Reader, destDB driver.Database) error {
+	if def.Genre != Genre {
+		return errz.Errorf("jsonud.Import does not support genre %q", def.Genre)
+	}
+
+	im := &importer{
+		log:     log,
+		def:     def,
+		destDB:  destDB,
+		seqs:    map[*tblSel]int64{},
+		execers: map[*tblSel]*driver.StmtExecer{},
+		clnup:   cleanup.New(),
+	}
+
+	err := im.execImport(ctx, data)
+	err2 := im.clnup.Run()
+	if err != nil {
+		return errz.Wrap(err, "json import")
+	}
+
+	return errz.Wrap(err2, "json import: cleanup")
+}
+
+// tblSel is a table mapping, with its parsed selectors.
+type tblSel struct {
+	tbl    *userdriver.TableMapping
+	path   []pathSeg
+	parent *tblSel
+	cols   []*colSel
+}
+
+// colSel is a column mapping, with its parsed selector.
+type colSel struct {
+	col *userdriver.ColMapping
+
+	// isSeq is true if the column is a sequence column.
+	isSeq bool
+
+	// parentCol is the name of the column of the parent row
+	// whose value the column takes.
+	parentCol string
+
+	// path is the path of the column value, relative to the row value.
+	path []pathSeg
+}
+
+// row is a row that has been inserted.
+type row struct {
+	ts   *tblSel
+	vals map[string]interface{}
+}
+
+// importer does the work of importing data from JSON.
+type importer struct {
+	log    lg.Log
+	def    *userdriver.DriverDef
+	destDB driver.Database
+	tbls   []*tblSel
+
+	// seqs holds the last sequence value of each table.
+	seqs map[*tblSel]int64
+
+	// execers holds the prepared insert statement of each table.
+	execers map[*tblSel]*driver.StmtExecer
+
+	// clnup holds cleanup funcs that should be run when the importer
+	// finishes.
+	clnup *cleanup.Cleanup
+}
+
+func (im *importer) execImport(ctx context.Context, r io.Reader) error {
+	err := im.buildSelectors()
+	if err != nil {
+		return err
+	}
+
+	err = im.createTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		var v interface{}
+		err = dec.Decode(&v)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errz.Err(err)
+		}
+
+		err = im.walk(ctx, v, 0, im.tbls, nil)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// buildSelectors parses the selectors of the def's tables
+// and columns, and determines the parent of each table.
+func (im *importer) buildSelectors() error {
+	for _, tbl := range im.def.Tables {
+		ts := &tblSel{tbl: tbl}
+		var err error
+		ts.path, err = parseAbsPath(tbl.Selector)
+		if err != nil {
+			return errz.Wrapf(err, "table %s", tbl.Name)
+		}
+
+		for _, col := range tbl.Cols {
+			cs := &colSel{col: col}
+			switch {
+			case col.Selector == selSequence:
+				cs.isSeq = true
+			case col.Foreign != "" && (col.Selector == "" || col.Selector == col.Foreign):
+				cs.parentCol, err = parentColName(col.Foreign)
+			case strings.HasPrefix(col.Selector, "../"):
+				cs.parentCol, err = parentColName(col.Selector)
+			case col.Selector == "":
+				cs.path = []pathSeg{{name: col.Name}}
+			default:
+				cs.path, err = parseRelPath(col.Selector)
+			}
+			if err != nil {
+				return errz.Wrapf(err, "%s.%s", tbl.Name, col.Name)
+			}
+
+			ts.cols = append(ts.cols, cs)
+		}
+
+		im.tbls = append(im.tbls, ts)
+	}
+
+	// The parent of a table is the table whose selector is
+	// the longest prefix of the table's selector.
+	for _, ts := range im.tbls {
+		for _, other := range im.tbls {
+			if isPrefix(other.path, ts.path) && (ts.parent == nil || len(other.path) > len(ts.parent.path)) {
+				ts.parent = other
+			}
+		}
+
+		for _, cs := range ts.cols {
+			if cs.parentCol == "" {
+				continue
+			}
+
+			if ts.parent == nil {
+				return errz.Errorf("%s.%s: table has no parent table (the selector of a parent table is a prefix of %q)",
+					ts.tbl.Name, cs.col.Name, ts.tbl.Selector)
+			}
+
+			if ts.parent.col(cs.parentCol) == nil {
+				return errz.Errorf("%s.%s: parent table %q has no column %q",
+					ts.tbl.Name, cs.col.Name, ts.parent.tbl.Name, cs.parentCol)
+			}
+		}
+	}
+
+	return nil
+}
+
+// col returns the named column, or nil.
+func (ts *tblSel) col(name string) *colSel {
+	for _, cs := range ts.cols {
+		if cs.col.Name == name {
+			return cs
+		}
+	}
+	return nil
+}
+
+// parentColName returns the column name of a selector
+// of the form "../col_name".
+func parentColName(sel string) (string, error) {
+	parts := strings.Split(sel, "/")
+	if len(parts) != 2 || parts[0] != ".." || parts[1] == "" {
+		return "", errz.Errorf(`selector should be of form "../col_name" but was %q`, sel)
+	}
+	return parts[1], nil
+}
+
+func (im *importer) createTables(ctx context.Context) error {
+	for _, ts := range im.tbls {
+		tblDef, err := userdriver.ToTableDef(ts.tbl)
+		if err != nil {
+			return err
+		}
+
+		for i, cs := range ts.cols {
+			if cs.col.Foreign != "" && cs.parentCol != "" {
+				tblDef.Cols[i].ForeignKey = &sqlmodel.FKConstraint{RefTable: ts.parent.tbl.Name, RefCol: cs.parentCol}
+			}
+		}
+
+		err = im.destDB.SQLDriver().CreateTable(ctx, im.destDB.DB(), tblDef)
+		if err != nil {
+			return err
+		}
+		im.log.Debugf("Created table %s.%s", im.destDB.Source().Handle, tblDef.Name)
+	}
+
+	return nil
+}
+
+// walk matches v, whose depth in the document is depth, against
+// the candidate tables cands, inserting a row if v is selected by
+// a table, and then recursing into the children of v. Arg stack
+// holds the rows of v's ancestors.
+func (im *importer) walk(ctx context.Context, v interface{}, depth int, cands []*tblSel, stack []*row) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	for _, ts := range cands {
+		if len(ts.path) != depth {
+			continue
+		}
+
+		rw, err := im.insertRow(ctx, ts, v, stack)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, rw)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// We iterate over the field names of the selectors (rather than
+		// over v's fields, whose order is random) so that the rows are
+		// imported in a deterministic order.
+		var names []string
+		for _, ts := range cands {
+			if len(ts.path) > depth && ts.path[depth].isField() {
+				names = appendUniq(names, ts.path[depth].name)
+			}
+		}
+
+		for _, name := range names {
+			child, ok := v[name]
+			if !ok {
+				continue
+			}
+
+			var sub []*tblSel
+			for _, ts := range cands {
+				if len(ts.path) > depth && ts.path[depth].isField() && ts.path[depth].name == name {
+					sub = append(sub, ts)
+				}
+			}
+
+			err := im.walk(ctx, child, depth+1, sub, stack)
+			if err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for i, child := range v {
+			var sub []*tblSel
+			for _, ts := range cands {
+				if len(ts.path) > depth && ts.path[depth].matchesIndex(i) {
+					sub = append(sub, ts)
+				}
+			}
+
+			if len(sub) == 0 {
+				continue
+			}
+
+			err := im.walk(ctx, child, depth+1, sub, stack)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertRow inserts the row of table ts for value v.
+func (im *importer) insertRow(ctx context.Context, ts *tblSel, v interface{}, stack []*row) (*row, error) {
+	rw := &row{ts: ts, vals: map[string]interface{}{}}
+
+	im.seqs[ts]++
+	for _, cs := range ts.cols {
+		switch {
+		case cs.isSeq:
+			rw.vals[cs.col.Name] = im.seqs[ts]
+		case cs.parentCol != "":
+			parentRow := findRow(stack, ts.parent)
+			if parentRow == nil {
+				return nil, errz.Errorf("unable to find parent row for %s.%s", ts.tbl.Name, cs.col.Name)
+			}
+			rw.vals[cs.col.Name] = parentRow.vals[cs.parentCol]
+		default:
+			val, ok := lookup(v, cs.path)
+			if !ok || val == nil {
+				continue
+			}
+
+			var err error
+			rw.vals[cs.col.Name], err = convertVal(ts.tbl.Name, cs.col, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, col := range ts.tbl.RequiredCols() {
+		if val, ok := rw.vals[col.Name]; !ok || val == nil {
+			return nil, errz.Errorf("no value for required column %s.%s (row %d)", ts.tbl.Name, col.Name, im.seqs[ts])
+		}
+	}
+
+	execer, ok := im.execers[ts]
+	if !ok {
+		var err error
+		execer, err = im.destDB.SQLDriver().PrepareInsertStmt(ctx, im.destDB.DB(), ts.tbl.Name,
+			userdriver.NamesFromCols(ts.tbl.Cols), 1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Make sure we close stmt eventually.
+		im.clnup.AddC(execer)
+		im.execers[ts] = execer
+	}
+
+	vals := make([]interface{}, len(ts.cols))
+	for i, cs := range ts.cols {
+		vals[i] = rw.vals[cs.col.Name]
+	}
+
+	err := execer.Munge(vals)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = execer.Exec(ctx, vals...)
+	if err != nil {
+		return nil, errz.Wrapf(err, "failed to insert to table %q", ts.tbl.Name)
+	}
+
+	return rw, nil
+}
+
+// findRow returns the nearest row of ts in stack, or nil.
+func findRow(stack []*row, ts *tblSel) *row {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].ts == ts {
+			return stack[i]
+		}
+	}
+	return nil
+}
+
+func appendUniq(a []string, s string) []string {
+	for _, v := range a {
+		if v == s {
+			return a
+		}
+	}
+	return append(a, s)
+}
+
+func convertVal(tbl string, col *userdriver.ColMapping, data interface{}) (interface{}, error) {
+	const errTpl = `conversion error: %s.%s: expected "%s" but got %T(%v)`
+	const errTplMsg = `conversion error: %s.%s: expected "%s" but got %T(%v): %v`
+
+	switch col.Kind {
+	default:
+		return nil, errz.Errorf("unknown data kind %q for col %s", col.Kind, col.Name)
+	case kind.Text:
+		switch data := data.(type) {
+		case string:
+			return data, nil
+		case json.Number:
+			return data.String(), nil
+		case bool:
+			return strconv.FormatBool(data), nil
+		default:
+			// An object or array is imported as JSON text.
+			b, err := json.Marshal(data)
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return string(b), nil
+		}
+	case kind.Int:
+		switch data := data.(type) {
+		case json.Number:
+			val, err := data.Int64()
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return val, nil
+		case string:
+			val, err := strconv.ParseInt(data, 0, 64)
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return val, nil
+		default:
+			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
+		}
+	case kind.Float:
+		switch data := data.(type) {
+		case json.Number:
+			val, err := data.Float64()
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return val, nil
+		case string:
+			val, err := strconv.ParseFloat(data, 64)
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return val, nil
+		default:
+			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
+		}
+	case kind.Decimal:
+		switch data := data.(type) {
+		case json.Number:
+			return data.String(), nil
+		case string:
+			return data, nil
+		default:
+			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
+		}
+	case kind.Bool:
+		switch data := data.(type) {
+		case bool:
+			return data, nil
+		case json.Number:
+			return data.String() != "0", nil
+		case string:
+			val, err := strconv.ParseBool(data)
+			if err != nil {
+				return nil, errz.Errorf(errTplMsg, tbl, col.Name, col.Kind, data, data, err)
+			}
+			return val, nil
+		default:
+			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
+		}
+	case kind.Datetime, kind.Date, kind.Time, kind.Bytes:
+		switch data := data.(type) {
+		case string:
+			return data, nil
+		default:
+			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
+		}
+	case kind.Null:
+		return data, nil
+	}
+}
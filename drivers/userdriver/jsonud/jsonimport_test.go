@@ -0,0 +1,146 @@
+package jsonud_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/userdriver/jsonud"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/testsrc"
+)
+
+const driverOrders = "orders"
+
+func TestImport_Orders(t *testing.T) {
+	th := testh.New(t)
+
+	defs := testh.DriverDefsFrom(t, testsrc.PathDriverDefOrders)
+	require.Equal(t, 1, len(defs))
+	udDef := defs[0]
+	require.Equal(t, driverOrders, udDef.Name)
+	require.Equal(t, jsonud.Genre, udDef.Genre)
+
+	scratchDB, err := th.Databases().OpenScratch(th.Context, driverOrders)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, scratchDB.Close())
+	})
+
+	data := proj.ReadFile("drivers/userdriver/jsonud/testdata/orders.json")
+	err = jsonud.Import(th.Context, th.Log, udDef, bytes.NewReader(data), scratchDB)
+	require.NoError(t, err)
+
+	srcMeta, err := scratchDB.SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"order_item", "order_tag", "orders"}, srcMeta.TableNames())
+
+	sink, err := th.QuerySQL(scratchDB.Source(), "SELECT * FROM orders")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, "A-101", testh.Val(sink.Recs[1][1]))
+	require.Equal(t, "Alice", testh.Val(sink.Recs[0][3]))
+	require.Nil(t, sink.Recs[1][4])
+	require.Equal(t, false, testh.Val(sink.Recs[1][6]))
+
+	sink, err = th.QuerySQL(scratchDB.Source(), "SELECT * FROM order_item")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, []kind.Kind{kind.Int, kind.Int, kind.Text, kind.Int, kind.Float}, sink.RecMeta.Kinds())
+	for i, rec := range sink.Recs {
+		// Verify that the primary id cols are sequential
+		require.Equal(t, int64(i+1), testh.Val(rec[0]))
+	}
+	require.Equal(t, int64(1), testh.Val(sink.Recs[1][1]))
+	require.Equal(t, int64(2), testh.Val(sink.Recs[2][1]))
+	require.Equal(t, 10.25, testh.Val(sink.Recs[0][4]))
+
+	sink, err = th.QuerySQL(scratchDB.Source(), "SELECT * FROM order_tag")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, "express", testh.Val(sink.Recs[1][2]))
+
+	tblMeta, err := scratchDB.TableMetadata(th.Context, "order_item")
+	require.NoError(t, err)
+	require.Equal(t, &source.ForeignKey{RefTable: "orders", RefCol: "order_id"}, tblMeta.Columns[1].ForeignKey)
+}
+
+func TestImport_JSONLines(t *testing.T) {
+	th := testh.New(t)
+
+	def := &userdriver.DriverDef{
+		Name:     "events",
+		Genre:    jsonud.Genre,
+		Title:    "Events",
+		Selector: "$",
+		Tables: []*userdriver.TableMapping{{
+			Name:       "event",
+			Selector:   "$",
+			PrimaryKey: []string{"event_id"},
+			Cols: []*userdriver.ColMapping{
+				{Name: "event_id", Kind: kind.Int, Selector: "../sequence()"},
+				{Name: "type", Kind: kind.Text},
+				{Name: "first_tag", Kind: kind.Text, Selector: "tags[0]"},
+				{Name: "payload", Kind: kind.Text, Selector: "./data"},
+			},
+		}},
+	}
+	require.Empty(t, userdriver.ValidateDriverDef(def))
+
+	scratchDB, err := th.Databases().OpenScratch(th.Context, "events")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, scratchDB.Close())
+	})
+
+	const data = `{"type": "click", "tags": ["a", "b"], "data": {"x": 1}}
+{"type": "view"}
+`
+	err = jsonud.Import(th.Context, th.Log, def, bytes.NewReader([]byte(data)), scratchDB)
+	require.NoError(t, err)
+
+	sink, err := th.QuerySQL(scratchDB.Source(), "SELECT * FROM event")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, "a", testh.Val(sink.Recs[0][2]))
+	require.Equal(t, `{"x":1}`, testh.Val(sink.Recs[0][3]))
+	require.Equal(t, "view", testh.Val(sink.Recs[1][1]))
+	require.Nil(t, sink.Recs[1][2])
+}
+
+func TestImport_NoParentTable(t *testing.T) {
+	th := testh.New(t)
+
+	def := &userdriver.DriverDef{
+		Name:     "bad",
+		Genre:    jsonud.Genre,
+		Title:    "Bad",
+		Selector: "$",
+		Tables: []*userdriver.TableMapping{{
+			Name:       "item",
+			Selector:   "$.items[*]",
+			PrimaryKey: []string{"item_id"},
+			Cols: []*userdriver.ColMapping{
+				{Name: "item_id", Kind: kind.Int, Selector: "../sequence()"},
+				{Name: "order_id", Kind: kind.Int, Foreign: "../order_id"},
+			},
+		}},
+	}
+	require.Empty(t, userdriver.ValidateDriverDef(def))
+
+	scratchDB, err := th.Databases().OpenScratch(th.Context, "bad")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, scratchDB.Close())
+	})
+
+	err = jsonud.Import(th.Context, th.Log, def, bytes.NewReader([]byte(`{"items": []}`)), scratchDB)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no parent table")
+}
@@ -0,0 +1,146 @@
+package jsonud
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+// pathSeg is a segment of a selector path. A segment selects the
+// field of an object, an element of an array (if isIndex is true),
+// or every element of an array (if isWildcard is true).
+type pathSeg struct {
+	name       string
+	index      int
+	isIndex    bool
+	isWildcard bool
+}
+
+// isField returns true if s selects the field of an object.
+func (s pathSeg) isField() bool {
+	return !s.isIndex && !s.isWildcard
+}
+
+// matchesIndex returns true if s selects array element i.
+func (s pathSeg) matchesIndex(i int) bool {
+	return s.isWildcard || (s.isIndex && s.index == i)
+}
+
+// parseAbsPath parses an absolute selector such as "$.orders[*].items[*]".
+func parseAbsPath(sel string) ([]pathSeg, error) {
+	if !strings.HasPrefix(sel, "$") {
+		return nil, errz.Errorf("invalid selector %q: should begin with '$'", sel)
+	}
+
+	return parseSegs(sel, sel[1:], true)
+}
+
+// parseRelPath parses a selector that is relative to a row value,
+// such as "./customer.name", "customer.name", "tags[0]", or "@"
+// (the row value itself).
+func parseRelPath(sel string) ([]pathSeg, error) {
+	s := sel
+	switch {
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	case strings.HasPrefix(s, "./"):
+		s = "." + s[2:]
+	case s != "" && s[0] != '[':
+		s = "." + s
+	}
+
+	return parseSegs(sel, s, false)
+}
+
+// parseSegs parses the segments of selector sel, where s is the
+// remainder of sel following its root ('$' or '@'). Wildcard
+// segments are permitted only if wildcards is true.
+func parseSegs(sel, s string, wildcards bool) ([]pathSeg, error) {
+	var path []pathSeg
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			i := strings.IndexAny(s, ".[")
+			if i == -1 {
+				i = len(s)
+			}
+
+			if i == 0 {
+				return nil, errz.Errorf("invalid selector %q: empty field name", sel)
+			}
+
+			path = append(path, pathSeg{name: s[:i]})
+			s = s[i:]
+		case '[':
+			i := strings.IndexByte(s, ']')
+			if i == -1 {
+				return nil, errz.Errorf("invalid selector %q: missing ']'", sel)
+			}
+
+			inner := s[1:i]
+			s = s[i+1:]
+
+			switch {
+			case inner == "*":
+				if !wildcards {
+					return nil, errz.Errorf("invalid selector %q: wildcard '[*]' is not permitted", sel)
+				}
+				path = append(path, pathSeg{isWildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, pathSeg{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, errz.Errorf("invalid selector %q: invalid index %q", sel, inner)
+				}
+				path = append(path, pathSeg{index: n, isIndex: true})
+			}
+		default:
+			return nil, errz.Errorf("invalid selector %q: unexpected character %q", sel, s[0])
+		}
+	}
+
+	return path, nil
+}
+
+// isPrefix returns true if prefix is a proper prefix of path.
+func isPrefix(prefix, path []pathSeg) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookup returns the value at path relative to v. If there is
+// no such value, ok is false.
+func lookup(v interface{}, path []pathSeg) (val interface{}, ok bool) {
+	for _, seg := range path {
+		switch {
+		case seg.isIndex:
+			arr, isArr := v.([]interface{})
+			if !isArr || seg.index >= len(arr) {
+				return nil, false
+			}
+			v = arr[seg.index]
+		default:
+			obj, isObj := v.(map[string]interface{})
+			if !isObj {
+				return nil, false
+			}
+			if v, ok = obj[seg.name]; !ok {
+				return nil, false
+			}
+		}
+	}
+
+	return v, true
+}
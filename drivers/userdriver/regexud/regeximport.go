@@ -0,0 +1,359 @@
+// Package regexud provides user driver import functionality for
+// line-oriented text, such as log files, using regular expressions.
+//
+// The selector of each table is a regular expression that is matched
+// against each record of the data. A record that doesn't match is not
+// a row of the table. The selector of a column is the name of a named
+// capture group of the table's regex, and if omitted is the column
+// name. A group that doesn't participate in the match is NULL, as is
+// an empty group value for a non-text column. As with the xml genre,
+// "../sequence()" generates a sequence value.
+//
+// By default, each line is a record. If a table's record_start regex
+// is set, a record begins with a line that matches record_start, and
+// includes each following line that doesn't, such as the lines of a
+// stack trace. The lines of a record are joined with "\n", so the
+// table's regex typically uses the (?s) flag to match them with ".".
+//
+// The format of a datetime, date or time column is a Go time layout,
+// such as "02/Jan/2006:15:04:05 -0700", or the name of a layout
+// constant of package time, such as "RFC3339". The format "unix"
+// is seconds since the epoch. If there's no format, the value is
+// imported as is.
+package regexud
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/libsq/core/cleanup"
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// Genre is the user driver genre that this package supports.
+const Genre = "regex"
+
+const selSequence = "../sequence()"
+
+// formatUnix is the col format for seconds since the epoch.
+const formatUnix = "unix"
+
+// namedLayouts maps the names of the layout constants of
+// package time to their values.
+var namedLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"StampNano":   time.StampNano,
+}
+
+// maxLineSize is the maximum size of a line of the data.
+const maxLineSize = 1024 * 1024
+
+// Import implements userdriver.ImportFunc.
+func Import(ctx context.Context, log lg.Log, def *userdriver.DriverDef, data io.Reader, destDB driver.Database) error {
+	if def.Genre != Genre {
+		return errz.Errorf("regexud.Import does not support genre %q", def.Genre)
+	}
+
+	clnup := cleanup.New()
+	err := execImport(ctx, log, def, data, destDB, clnup)
+	err2 := clnup.Run()
+	if err != nil {
+		return errz.Wrap(err, "regex import")
+	}
+
+	return errz.Wrap(err2, "regex import: cleanup")
+}
+
+func execImport(ctx context.Context, log lg.Log, def *userdriver.DriverDef, r io.Reader, destDB driver.Database,
+	clnup *cleanup.Cleanup) error {
+	tbls := make([]*tblParser, len(def.Tables))
+	for i, tbl := range def.Tables {
+		var err error
+		tbls[i], err = newTblParser(tbl)
+		if err != nil {
+			return err
+		}
+
+		tblDef, err := userdriver.ToTableDef(tbl)
+		if err != nil {
+			return err
+		}
+
+		err = destDB.SQLDriver().CreateTable(ctx, destDB.DB(), tblDef)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Created table %s.%s", destDB.Source().Handle, tblDef.Name)
+
+		tbls[i].execer, err = destDB.SQLDriver().PrepareInsertStmt(ctx, destDB.DB(), tbl.Name,
+			userdriver.NamesFromCols(tbl.Cols), 1)
+		if err != nil {
+			return err
+		}
+
+		// Make sure we close stmt eventually.
+		clnup.AddC(tbls[i].execer)
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	var lineNum int
+	for sc.Scan() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		lineNum++
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		for _, tp := range tbls {
+			err := tp.addLine(ctx, line, lineNum)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return errz.Err(err)
+	}
+
+	for _, tp := range tbls {
+		err := tp.flush(ctx)
+		if err != nil {
+			return err
+		}
+
+		if tp.skipped > 0 {
+			log.Warnf("%s: %d record(s) did not match table %q", destDB.Source().Handle, tp.skipped, tp.tbl.Name)
+		}
+	}
+
+	return nil
+}
+
+// tblParser parses the records of a table from lines of data.
+type tblParser struct {
+	tbl         *userdriver.TableMapping
+	re          *regexp.Regexp
+	recordStart *regexp.Regexp
+
+	// groups holds, for each col, the index of the capture group
+	// of re, or -1 for a sequence col.
+	groups []int
+
+	execer *driver.StmtExecer
+
+	// lines holds the lines of the pending record, which
+	// begins at line number startLine.
+	lines     []string
+	startLine int
+
+	rowNum  int64
+	skipped int
+}
+
+func newTblParser(tbl *userdriver.TableMapping) (*tblParser, error) {
+	tp := &tblParser{tbl: tbl, groups: make([]int, len(tbl.Cols))}
+
+	var err error
+	tp.re, err = regexp.Compile(tbl.Selector)
+	if err != nil {
+		return nil, errz.Wrapf(err, "table %s: invalid selector", tbl.Name)
+	}
+
+	if tbl.RecordStart != "" {
+		tp.recordStart, err = regexp.Compile(tbl.RecordStart)
+		if err != nil {
+			return nil, errz.Wrapf(err, "table %s: invalid record_start", tbl.Name)
+		}
+	}
+
+	for i, col := range tbl.Cols {
+		if col.Foreign != "" {
+			return nil, errz.Errorf("%s.%s: foreign is not supported by genre %q", tbl.Name, col.Name, Genre)
+		}
+
+		if col.Selector == selSequence {
+			tp.groups[i] = -1
+			continue
+		}
+
+		group := col.Selector
+		if group == "" {
+			group = col.Name
+		}
+
+		tp.groups[i] = tp.re.SubexpIndex(group)
+		if tp.groups[i] == -1 {
+			return nil, errz.Errorf("%s.%s: no capture group %q in table selector", tbl.Name, col.Name, group)
+		}
+	}
+
+	return tp, nil
+}
+
+// addLine adds line, whose line number is lineNum, to tp.
+func (tp *tblParser) addLine(ctx context.Context, line string, lineNum int) error {
+	if tp.recordStart == nil {
+		tp.lines, tp.startLine = append(tp.lines[:0], line), lineNum
+		return tp.flush(ctx)
+	}
+
+	if tp.recordStart.MatchString(line) {
+		err := tp.flush(ctx)
+		if err != nil {
+			return err
+		}
+
+		tp.lines, tp.startLine = append(tp.lines[:0], line), lineNum
+		return nil
+	}
+
+	if len(tp.lines) == 0 {
+		// The line precedes the first record.
+		tp.skipped++
+		return nil
+	}
+
+	tp.lines = append(tp.lines, line)
+	return nil
+}
+
+// flush inserts the pending record, if any, as a row of the table.
+func (tp *tblParser) flush(ctx context.Context) error {
+	if len(tp.lines) == 0 {
+		return nil
+	}
+
+	rec := strings.Join(tp.lines, "\n")
+	tp.lines = tp.lines[:0]
+
+	match := tp.re.FindStringSubmatchIndex(rec)
+	if match == nil {
+		tp.skipped++
+		return nil
+	}
+
+	tp.rowNum++
+	vals := make([]interface{}, len(tp.tbl.Cols))
+	for i, col := range tp.tbl.Cols {
+		group := tp.groups[i]
+		if group == -1 {
+			vals[i] = tp.rowNum
+			continue
+		}
+
+		start, end := match[2*group], match[2*group+1]
+		if start == -1 || (start == end && col.Kind != kind.Text) {
+			if col.Required {
+				return errz.Errorf("no value for required column %s.%s (line %d)", tp.tbl.Name, col.Name, tp.startLine)
+			}
+			continue
+		}
+
+		var err error
+		vals[i], err = convertVal(col, rec[start:end])
+		if err != nil {
+			return errz.Wrapf(err, "%s.%s (line %d)", tp.tbl.Name, col.Name, tp.startLine)
+		}
+	}
+
+	err := tp.execer.Munge(vals)
+	if err != nil {
+		return err
+	}
+
+	_, err = tp.execer.Exec(ctx, vals...)
+	if err != nil {
+		return errz.Wrapf(err, "failed to insert to table %q", tp.tbl.Name)
+	}
+
+	return nil
+}
+
+func convertVal(col *userdriver.ColMapping, data string) (interface{}, error) {
+	const errTplMsg = `conversion error: expected "%s" but got %q: %v`
+
+	var val interface{}
+	var err error
+
+	switch col.Kind {
+	default:
+		return nil, errz.Errorf("unknown data kind %q for col %s", col.Kind, col.Name)
+	case kind.Text, kind.Decimal, kind.Bytes, kind.Null:
+		return data, nil
+	case kind.Datetime, kind.Date, kind.Time:
+		if col.Format == "" {
+			return data, nil
+		}
+		val, err = parseTime(col.Kind, col.Format, data)
+	case kind.Int:
+		val, err = strconv.ParseInt(data, 0, 64)
+	case kind.Float:
+		val, err = strconv.ParseFloat(data, 64)
+	case kind.Bool:
+		val, err = strconv.ParseBool(data)
+	}
+
+	if err != nil {
+		return nil, errz.Errorf(errTplMsg, col.Kind, data, err)
+	}
+
+	return val, nil
+}
+
+// parseTime parses data per format, which is a layout, the name of
+// a layout constant of package time, or "unix".
+func parseTime(knd kind.Kind, format, data string) (interface{}, error) {
+	var t time.Time
+	if format == formatUnix {
+		secs, err := strconv.ParseFloat(data, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		t = time.Unix(0, int64(secs*float64(time.Second))).UTC()
+	} else {
+		if layout, ok := namedLayouts[format]; ok {
+			format = layout
+		}
+
+		var err error
+		t, err = time.Parse(format, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if knd == kind.Time {
+		return t.Format("15:04:05"), nil
+	}
+
+	return t, nil
+}
@@ -0,0 +1,61 @@
+package regexud_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/userdriver/regexud"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+	"github.com/neilotoole/sq/testh/testsrc"
+)
+
+func TestImport_Nginx(t *testing.T) {
+	th := testh.New(t)
+	src := th.Source(testsrc.NginxUD)
+
+	// The line that isn't in the combined log format is skipped.
+	sink, err := th.QuerySQL(src, "SELECT * FROM access WHERE status >= 500")
+	require.NoError(t, err)
+	require.Equal(t, []string{"access_id", "remote_addr", "remote_user", "time", "method", "path", "status",
+		"bytes", "referer", "user_agent"}, sink.RecMeta.Names())
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, int64(3), testh.Val(sink.Recs[0][0]))
+	require.Equal(t, time.Date(2021, 3, 12, 9, 16, 44, 0, time.UTC), testh.Val(sink.Recs[0][3]))
+	require.Equal(t, "/api/orders/42", testh.Val(sink.Recs[0][5]))
+	require.Equal(t, int64(502), testh.Val(sink.Recs[0][6]))
+	require.Equal(t, "kube-probe/1.20", testh.Val(sink.Recs[1][9]))
+}
+
+func TestImport_Multiline(t *testing.T) {
+	th := testh.New(t)
+
+	defs := testh.DriverDefsFrom(t, testsrc.PathDriverDefAppLog)
+	require.Equal(t, 1, len(defs))
+	udDef := defs[0]
+	require.Equal(t, regexud.Genre, udDef.Genre)
+
+	scratchDB, err := th.Databases().OpenScratch(th.Context, udDef.Name)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, scratchDB.Close())
+	})
+
+	data := proj.ReadFile("drivers/userdriver/regexud/testdata/app.log")
+	err = regexud.Import(th.Context, th.Log, udDef, bytes.NewReader(data), scratchDB)
+	require.NoError(t, err)
+
+	sink, err := th.QuerySQL(scratchDB.Source(), "SELECT * FROM entry")
+	require.NoError(t, err)
+	require.Equal(t, []string{"entry_id", "time", "level", "message", "detail"}, sink.RecMeta.Names())
+	require.Equal(t, 4, len(sink.Recs))
+	require.Equal(t, "ERROR", testh.Val(sink.Recs[1][2]))
+	require.Equal(t, "order lookup failed: upstream timeout", testh.Val(sink.Recs[1][3]))
+	require.Contains(t, testh.Val(sink.Recs[1][4]), "at com.acme.orders.Service.lookup")
+	require.Nil(t, sink.Recs[0][4])
+	require.Equal(t, "slow query (1.2s)", testh.Val(sink.Recs[2][3]))
+}
@@ -72,6 +72,7 @@ func (d *drvr) DriverMetadata() driver.Metadata {
 		Description: d.def.Title,
 		Doc:         d.def.Doc,
 		UserDefined: true,
+		File:        d.def.File,
 	}
 }
 
@@ -79,24 +80,26 @@ func (d *drvr) DriverMetadata() driver.Metadata {
 func (d *drvr) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
 	clnup := cleanup.New()
 
-	r, err := d.files.Open(src)
+	// A change to the def must invalidate any cached import.
+	defHash, err := d.def.hash()
 	if err != nil {
 		return nil, err
 	}
+	ctx = driver.WithImportCacheKey(ctx, defHash)
 
-	defer d.log.WarnIfCloseError(r)
+	scratchDB, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		r, err := d.files.Open(src)
+		if err != nil {
+			return err
+		}
+		defer d.log.WarnIfCloseError(r)
 
-	scratchDB, err := d.scratcher.OpenScratch(ctx, src.Handle)
+		return d.importFn(ctx, d.log, d.def, r, destDB)
+	})
 	if err != nil {
-		return nil, err
-	}
-	clnup.AddE(scratchDB.Close)
-
-	err = d.importFn(ctx, d.log, d.def, r, scratchDB)
-	if err != nil {
-		d.log.WarnIfFuncError(clnup.Run)
 		return nil, errz.Wrap(err, d.def.Name)
 	}
+	clnup.AddE(scratchDB.Close)
 
 	return &database{log: d.log, src: src, impl: scratchDB, clnup: clnup}, nil
 }
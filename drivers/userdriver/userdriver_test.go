@@ -1,6 +1,7 @@
 package userdriver_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,9 @@ func TestDriver(t *testing.T) {
 	}{
 		{handle: testsrc.PplUD, tbl: "person", wantRecs: 3},
 		{handle: testsrc.RSSNYTLocalUD, tbl: "item", wantRecs: 45},
+		{handle: testsrc.OrdersUD, tbl: "order_item", wantRecs: 3},
+		{handle: testsrc.ContactsUD, tbl: "contact", wantRecs: 3},
+		{handle: testsrc.NginxUD, tbl: "access", wantRecs: 5},
 	}
 
 	for _, tc := range testCases {
@@ -61,7 +65,9 @@ func TestDriver(t *testing.T) {
 func TestValidateDriverDef_KnownGood(t *testing.T) {
 	t.Parallel()
 
-	testCases := []string{testsrc.PathDriverDefPpl, testsrc.PathDriverDefRSS}
+	testCases := []string{testsrc.PathDriverDefPpl, testsrc.PathDriverDefRSS,
+		testsrc.PathDriverDefOrders, testsrc.PathDriverDefContacts, testsrc.PathDriverDefNginx,
+		testsrc.PathDriverDefAppLog}
 
 	for _, defFile := range testCases {
 		defFile := defFile
@@ -155,6 +161,124 @@ func TestValidateDriverDef(t *testing.T) {
       kind: int`,
 			wantErrs: 1,
 		},
+		{
+			title: "json table selector doesn't begin with $, foreign is invalid",
+			yml: `user_drivers:
+- driver: orders
+  genre: json
+  title: Orders
+  selector: $
+  tables:
+  - table: item
+    selector: orders.items
+    primary_key:
+      - item_id
+    cols:
+    - col: item_id
+      kind: int
+      selector: ../sequence()
+    - col: order_id
+      kind: int
+      foreign: order_id`,
+			wantErrs: 2,
+		},
+		{
+			title: "json root selector doesn't begin with $",
+			yml: `user_drivers:
+- driver: orders
+  genre: json
+  title: Orders
+  selector: /orders
+  tables:
+  - table: order
+    selector: $.orders[*]
+    primary_key:
+      - order_id
+    cols:
+    - col: order_id
+      kind: int`,
+			wantErrs: 1,
+		},
+		{
+			title: "csv doesn't support foreign",
+			yml: `user_drivers:
+- driver: contacts
+  genre: csv
+  title: Contacts
+  selector: /
+  tables:
+  - table: contact
+    selector: /
+    primary_key:
+      - contact_id
+    cols:
+    - col: contact_id
+      kind: int
+      selector: ../sequence()
+    - col: company_id
+      kind: int
+      foreign: ../company_id`,
+			wantErrs: 1,
+		},
+		{
+			title: "regex selector is invalid, record_start is invalid",
+			yml: `user_drivers:
+- driver: applog
+  genre: regex
+  title: App log
+  selector: /
+  tables:
+  - table: entry
+    selector: '^(?P<level>[A-Z]+'
+    record_start: '['
+    primary_key:
+      - entry_id
+    cols:
+    - col: entry_id
+      kind: int
+      selector: ../sequence()`,
+			wantErrs: 2,
+		},
+		{
+			title: "regex capture group not found",
+			yml: `user_drivers:
+- driver: applog
+  genre: regex
+  title: App log
+  selector: /
+  tables:
+  - table: entry
+    selector: '^(?P<level>[A-Z]+) (?P<msg>.*)$'
+    primary_key:
+      - entry_id
+    cols:
+    - col: entry_id
+      kind: int
+      selector: ../sequence()
+    - col: level
+      kind: text
+    - col: message
+      kind: text`,
+			wantErrs: 1,
+		},
+		{
+			title: "record_start is not supported by genre csv",
+			yml: `user_drivers:
+- driver: contacts
+  genre: csv
+  title: Contacts
+  selector: /
+  tables:
+  - table: contact
+    selector: /
+    record_start: '^[0-9]'
+    primary_key:
+      - name
+    cols:
+    - col: name
+      kind: text`,
+			wantErrs: 1,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -169,6 +293,11 @@ func TestValidateDriverDef(t *testing.T) {
 			errs := userdriver.ValidateDriverDef(def)
 			require.Equal(t, tc.wantErrs, len(errs),
 				"wanted %d errs but got %d: %v", tc.wantErrs, len(errs), errs)
+
+			for _, err := range errs {
+				var defErr *userdriver.DefError
+				require.True(t, errors.As(err, &defErr), "should be *DefError: %v", err)
+			}
 		})
 	}
 }
@@ -105,7 +105,7 @@ func (im *importer) execImport(ctx context.Context, r io.Reader, destDB driver.D
 		switch elem := t.(type) {
 		case xml.StartElement:
 			im.selStack.push(elem.Name.Local)
-			if im.isRootSelector() {
+			if im.isRootSelector() && !im.isRowSelector() {
 				continue
 			}
 
@@ -149,6 +149,13 @@ func (im *importer) execImport(ctx context.Context, r io.Reader, destDB driver.D
 				if msg, ok := im.msgOncef("Skip: element %q is not a column of table %q", elem.Name.Local, curRow.tbl.Name); ok {
 					im.log.Debug(msg)
 				}
+
+				// The element's attributes may still be columns,
+				// e.g. "./address/@type".
+				err = im.handleElemAttrs(elem, curRow)
+				if err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -169,6 +176,10 @@ func (im *importer) execImport(ctx context.Context, r io.Reader, destDB driver.D
 					}
 				}
 				im.rowStack.pop()
+			} else if row := im.rowStack.peek(); row != nil {
+				// The col element is closed: if it had no text (e.g. <born></born>),
+				// don't assign the text that follows to the col.
+				row.curCol = nil
 			}
 			im.selStack.pop()
 
@@ -252,7 +263,7 @@ func (im *importer) convertVal(tbl string, col *userdriver.ColMapping, data inte
 		default:
 			return nil, errz.Errorf(errTpl, tbl, col.Name, col.Kind, data, data)
 		}
-	case kind.Datetime:
+	case kind.Datetime, kind.Date:
 		return data, nil
 	case kind.Bytes:
 		return data, nil
@@ -10,7 +10,6 @@ import (
 
 	"github.com/neilotoole/sq/libsq/core/errz"
 	"github.com/neilotoole/sq/libsq/core/kind"
-	"github.com/neilotoole/sq/libsq/core/options"
 	"github.com/neilotoole/sq/libsq/source"
 
 	"github.com/neilotoole/lg"
@@ -20,24 +19,25 @@ import (
 	"github.com/neilotoole/sq/libsq/driver"
 )
 
-// xlsxToScratch loads the data in xlFile into scratchDB.
-func xlsxToScratch(ctx context.Context, log lg.Log, src *source.Source, xlFile *xlsx.File, scratchDB driver.Database) error {
+// xlsxToScratch loads the data in xlFile into scratchDB. Arg data
+// is the raw XLSX data of xlFile.
+func xlsxToScratch(ctx context.Context, log lg.Log, src *source.Source, xlFile *xlsx.File, data []byte, scratchDB driver.Database) error {
 	start := time.Now()
 	log.Debugf("Beginning import from XLSX %s to %s (%s)...", src.Handle, scratchDB.Source().Handle, scratchDB.Source().RedactedLocation())
 
-	hasHeader, _, err := options.HasHeader(src.Options)
+	ranges, err := getTableRanges(log, src, xlFile, data)
 	if err != nil {
 		return err
 	}
 
-	tblDefs, err := buildTblDefsForSheets(ctx, log, xlFile.Sheets, hasHeader)
+	tblDefs, err := buildTblDefsForRanges(ctx, log, ranges)
 	if err != nil {
 		return err
 	}
 
 	for _, tblDef := range tblDefs {
 		if tblDef == nil {
-			// tblDef can be nil if its sheet is empty (has no data).
+			// tblDef can be nil if its range is empty (has no data).
 			continue
 		}
 		err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tblDef)
@@ -51,28 +51,28 @@ func xlsxToScratch(ctx context.Context, log lg.Log, src *source.Source, xlFile *
 
 	var imported, skipped int
 
-	for i := range xlFile.Sheets {
+	for i := range ranges {
 		if tblDefs[i] == nil {
-			// tblDef can be nil if its sheet is empty (has no data).
+			// tblDef can be nil if its range is empty (has no data).
 			skipped++
 			continue
 		}
-		err = importSheetToTable(ctx, log, xlFile.Sheets[i], hasHeader, scratchDB, tblDefs[i])
+		err = importRangeToTable(ctx, log, ranges[i], scratchDB, tblDefs[i])
 		if err != nil {
 			return err
 		}
 		imported++
 	}
 
-	log.Debugf("%d sheets imported (%d sheets skipped) from %s to %s in %s",
+	log.Debugf("%d ranges imported (%d ranges skipped) from %s to %s in %s",
 		imported, skipped, src.Handle, scratchDB.Source().Handle, time.Since(start))
 
 	return nil
 }
 
-// importSheetToTable imports sheet's data to its scratch table.
+// importRangeToTable imports the range's data to its scratch table.
 // The scratch table must already exist.
-func importSheetToTable(ctx context.Context, log lg.Log, sheet *xlsx.Sheet, hasHeader bool, scratchDB driver.Database, tblDef *sqlmodel.TableDef) error {
+func importRangeToTable(ctx context.Context, log lg.Log, tr *tableRange, scratchDB driver.Database, tblDef *sqlmodel.TableDef) error {
 	startTime := time.Now()
 
 	conn, err := scratchDB.DB().Conn(ctx)
@@ -91,8 +91,8 @@ func importSheetToTable(ctx context.Context, log lg.Log, sheet *xlsx.Sheet, hasH
 		return err
 	}
 
-	for i, row := range sheet.Rows {
-		if hasHeader && i == 0 {
+	for i, row := range tr.rows() {
+		if tr.hasHeader && i == 0 {
 			continue
 		}
 
@@ -100,7 +100,7 @@ func importSheetToTable(ctx context.Context, log lg.Log, sheet *xlsx.Sheet, hasH
 			continue
 		}
 
-		rec := rowToRecord(log, destColKinds, row, sheet.Name, i)
+		rec := rowToRecord(log, destColKinds, row, tr.date1904, tr.name, tr.minRow+i)
 		err = bi.Munge(rec)
 		if err != nil {
 			close(bi.RecordCh)
@@ -131,20 +131,16 @@ func importSheetToTable(ctx context.Context, log lg.Log, sheet *xlsx.Sheet, hasH
 	}
 
 	log.Debugf("Inserted %d rows from sheet %q into %s.%s in %s",
-		bi.Written(), sheet.Name, scratchDB.Source().Handle, tblDef.Name, time.Since(startTime))
+		bi.Written(), tr.sheet.Name, scratchDB.Source().Handle, tblDef.Name, time.Since(startTime))
 
 	return nil
 }
 
-// isEmptyRow returns true if row is nil or has zero cells, or if
+// isEmptyRow returns true if row has zero cells, or if
 // every cell value is empty string.
-func isEmptyRow(row *xlsx.Row) bool {
-	if row == nil || len(row.Cells) == 0 {
-		return true
-	}
-
-	for i := range row.Cells {
-		if row.Cells[i].Value != "" {
+func isEmptyRow(row []*xlsx.Cell) bool {
+	for i := range row {
+		if row[i] != nil && row[i].Value != "" {
 			return false
 		}
 	}
@@ -152,16 +148,16 @@ func isEmptyRow(row *xlsx.Row) bool {
 	return true
 }
 
-// buildTblDefsForSheets returns a TableDef for each sheet. If the
-// sheet is empty (has no data), the TableDef for that sheet will be nil.
-func buildTblDefsForSheets(ctx context.Context, log lg.Log, sheets []*xlsx.Sheet, hasHeader bool) ([]*sqlmodel.TableDef, error) {
-	tblDefs := make([]*sqlmodel.TableDef, len(sheets))
+// buildTblDefsForRanges returns a TableDef for each range. If the
+// range is empty (has no data), the TableDef for that range will be nil.
+func buildTblDefsForRanges(ctx context.Context, log lg.Log, ranges []*tableRange) ([]*sqlmodel.TableDef, error) {
+	tblDefs := make([]*sqlmodel.TableDef, len(ranges))
 
 	g, _ := errgroup.WithContext(ctx)
-	for i := range sheets {
+	for i := range ranges {
 		i := i
 		g.Go(func() error {
-			tblDef, err := buildTblDefForSheet(log, sheets[i], hasHeader)
+			tblDef, err := buildTblDefForRange(log, ranges[i])
 			if err != nil {
 				return err
 			}
@@ -178,71 +174,59 @@ func buildTblDefsForSheets(ctx context.Context, log lg.Log, sheets []*xlsx.Sheet
 	return tblDefs, nil
 }
 
-// buildTblDefForSheet creates a table for the given sheet, and returns
-// a model of the table, or an error. If the sheet is empty, (nil,nil)
-// is returned.
-func buildTblDefForSheet(log lg.Log, sheet *xlsx.Sheet, hasHeader bool) (*sqlmodel.TableDef, error) {
-	maxCols := getRowsMaxCellCount(sheet)
+// buildTblDefForRange returns a model of the table for the given
+// range, or an error. If the range is empty, (nil,nil) is returned.
+func buildTblDefForRange(log lg.Log, tr *tableRange) (*sqlmodel.TableDef, error) {
+	rows := tr.rows()
+	maxCols := getMaxCellCount(rows)
 	if maxCols == 0 {
-		log.Warnf("sheet %q is empty: skipping")
+		log.Warnf("XLSX range %q of sheet %q is empty: skipping", tr.name, tr.sheet.Name)
 		return nil, nil
 	}
 
 	colNames := make([]string, maxCols)
-	colKinds := make([]kind.Kind, maxCols)
-
 	firstDataRow := 0
-	if len(sheet.Rows) == 0 {
-		// TODO: is this even reachable? That is, if sheet.Rows is empty,
-		//  then sheet.cols (checked for above) will also be empty?
 
-		// sheet has no rows
-		for i := 0; i < maxCols; i++ {
-			colKinds[i] = kind.Text
-			colNames[i] = stringz.GenerateAlphaColName(i, false)
+	// Set up the column names
+	if tr.hasHeader {
+		firstDataRow = 1
+		for i, cell := range rows[0] {
+			if cell != nil {
+				colNames[i] = cell.Value
+			}
 		}
 	} else {
-		// sheet is non-empty
-
-		// Set up the column names
-		if hasHeader {
-			firstDataRow = 1
-			headerCells := sheet.Rows[0].Cells
-			for i := 0; i < len(headerCells); i++ {
-				colNames[i] = headerCells[i].Value
-			}
-		} else {
-			for i := 0; i < maxCols; i++ {
-				colNames[i] = stringz.GenerateAlphaColName(i, false)
-			}
+		for i := 0; i < maxCols; i++ {
+			colNames[i] = stringz.GenerateAlphaColName(i, false)
 		}
+	}
 
-		// Set up the column types
-		if firstDataRow >= len(sheet.Rows) {
-			// the sheet contains only one row (the header row). Let's
-			// explicitly set the column type nonetheless
-			for i := 0; i < maxCols; i++ {
-				colKinds[i] = kind.Text
-			}
-		} else {
-			// we have at least one data row, let's get the column types
-			var err error
-			colKinds, err = calcKindsForRows(firstDataRow, sheet.Rows)
-			if err != nil {
-				return nil, err
-			}
+	// Set up the column types
+	colKinds := make([]kind.Kind, maxCols)
+	if firstDataRow >= len(rows) {
+		// the range contains only one row (the header row). Let's
+		// explicitly set the column type nonetheless
+		for i := 0; i < maxCols; i++ {
+			colKinds[i] = kind.Text
+		}
+	} else {
+		// we have at least one data row, let's get the column types
+		var err error
+		colKinds, err = calcKindsForRows(firstDataRow, rows, tr.date1904)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	colNames, colKinds = syncColNamesKinds(colNames, colKinds)
 
-	tblDef := &sqlmodel.TableDef{Name: sheet.Name}
+	tblDef := &sqlmodel.TableDef{Name: tr.name}
 	cols := make([]*sqlmodel.ColDef, len(colNames))
 	for i, colName := range colNames {
 		cols[i] = &sqlmodel.ColDef{Table: tblDef, Name: colName, Kind: colKinds[i]}
 	}
 	tblDef.Cols = cols
-	log.Debugf("sheet %q: using col names [%q]", sheet.Name, strings.Join(colNames, ", "))
+	log.Debugf("XLSX range %q: using col names [%q]", tr.name, strings.Join(colNames, ", "))
 
 	return tblDef, nil
 }
@@ -297,29 +281,32 @@ func syncColNamesKinds(colNames []string, colKinds []kind.Kind) (names []string,
 	return colNames, colKinds
 }
 
-func rowToRecord(log lg.Log, destColKinds []kind.Kind, row *xlsx.Row, sheetName string, rowIndex int) []interface{} {
+func rowToRecord(log lg.Log, destColKinds []kind.Kind, row []*xlsx.Cell, date1904 bool, name string, rowIndex int) []interface{} {
 	vals := make([]interface{}, len(destColKinds))
-	for j, cell := range row.Cells {
+	for j, cell := range row {
 		if j >= len(vals) {
-			log.Warnf("sheet %s[%d:%d]: skipping additional cells because there's more cells than expected (%d)",
-				sheetName, rowIndex, j, len(destColKinds))
+			log.Warnf("%s[%d:%d]: skipping additional cells because there's more cells than expected (%d)",
+				name, rowIndex, j, len(destColKinds))
+			continue
+		}
+
+		if cell == nil {
 			continue
 		}
 
-		typ := cell.Type()
-		switch typ {
+		switch cell.Type() {
 		case xlsx.CellTypeBool:
 			vals[j] = cell.Bool()
 		case xlsx.CellTypeNumeric:
 			if cell.IsTime() {
-				t, err := cell.GetTime(false)
+				t, err := cell.GetTime(date1904)
 				if err != nil {
-					log.Warnf("sheet %s[%d:%d]: failed to get Excel time: %v", sheetName, rowIndex, j, err)
+					log.Warnf("%s[%d:%d]: failed to get Excel time: %v", name, rowIndex, j, err)
 					vals[j] = nil
 					continue
 				}
 
-				vals[j] = t
+				vals[j] = roundExcelTime(t)
 				continue
 			}
 
@@ -341,10 +328,10 @@ func rowToRecord(log lg.Log, destColKinds []kind.Kind, row *xlsx.Row, sheetName
 
 			// it's not an int, it's not a float, it's not empty string;
 			// just give up and make it a string.
-			log.Warnf("Failed to determine type of numeric cell [%s:%d:%d] from value: %q", sheetName, rowIndex, j, cell.Value)
+			log.Warnf("Failed to determine type of numeric cell [%s:%d:%d] from value: %q", name, rowIndex, j, cell.Value)
 			vals[j] = cell.Value
 			// FIXME: prob should return an error here?
-		case xlsx.CellTypeString:
+		case xlsx.CellTypeString, xlsx.CellTypeInline:
 			if cell.Value == "" {
 				if destColKinds[j] != kind.Text {
 					vals[j] = nil
@@ -354,9 +341,19 @@ func rowToRecord(log lg.Log, destColKinds []kind.Kind, row *xlsx.Row, sheetName
 
 			vals[j] = cell.String()
 		case xlsx.CellTypeDate:
-			// TODO: parse into a time value here
-			vals[j] = cell.Value
+			t, ok := parseDateCell(cell)
+			if !ok {
+				log.Warnf("%s[%d:%d]: failed to parse Excel date: %q", name, rowIndex, j, cell.Value)
+				vals[j] = nil
+				continue
+			}
+			vals[j] = t
+		case xlsx.CellTypeError:
+			// An error value such as "#DIV/0!" is not data.
+			vals[j] = nil
 		default:
+			// Includes xlsx.CellTypeStringFormula, for which
+			// cell.Value is the formula's cached value.
 			if cell.Value == "" {
 				vals[j] = nil
 			} else {
@@ -368,8 +365,10 @@ func rowToRecord(log lg.Log, destColKinds []kind.Kind, row *xlsx.Row, sheetName
 }
 
 // readCellValue reads the value of a cell, returning a value of
-// type that most matches the sq kind.
-func readCellValue(cell *xlsx.Cell) interface{} {
+// type that most matches the sq kind. The value of a formula cell
+// is the formula's cached value (i.e. the value as last calculated
+// by Excel).
+func readCellValue(cell *xlsx.Cell, date1904 bool) interface{} {
 	if cell == nil || cell.Value == "" {
 		return nil
 	}
@@ -382,14 +381,9 @@ func readCellValue(cell *xlsx.Cell) interface{} {
 		return val
 	case xlsx.CellTypeNumeric:
 		if cell.IsTime() {
-			t, err := cell.GetTime(false)
-			if err == nil {
-				return t
-			}
-
-			t, err = cell.GetTime(true)
+			t, err := cell.GetTime(date1904)
 			if err == nil {
-				return t
+				return roundExcelTime(t)
 			}
 
 			// Otherwise we have an error, just return the value
@@ -412,11 +406,16 @@ func readCellValue(cell *xlsx.Cell) interface{} {
 		val, _ = cell.FormattedValue()
 		return val
 
-	case xlsx.CellTypeString:
+	case xlsx.CellTypeString, xlsx.CellTypeInline:
 		val = cell.String()
 	case xlsx.CellTypeDate:
-		// TODO: parse into a time.Time value here?
+		t, ok := parseDateCell(cell)
+		if ok {
+			return t
+		}
 		val, _ = cell.FormattedValue()
+	case xlsx.CellTypeError:
+		return nil
 	default:
 		val, _ = cell.FormattedValue()
 	}
@@ -424,10 +423,31 @@ func readCellValue(cell *xlsx.Cell) interface{} {
 	return val
 }
 
+// roundExcelTime rounds t to the millisecond. An Excel date is
+// a floating point number of days, so a value converted to time
+// may be a fraction off, e.g. 05:06:06.999999876 instead of
+// 05:06:07. Excel itself doesn't display beyond milliseconds.
+func roundExcelTime(t time.Time) time.Time {
+	return t.Round(time.Millisecond)
+}
+
+// parseDateCell parses the value of a cell of type
+// xlsx.CellTypeDate, which is an ISO 8601 date or datetime.
+func parseDateCell(cell *xlsx.Cell) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		t, err := time.Parse(layout, cell.Value)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
 // calcKindsForRows calculates the lowest-common-denominator kind
 // for the cells of rows. The returned slice will have length
 // equal to the longest row.
-func calcKindsForRows(firstDataRow int, rows []*xlsx.Row) ([]kind.Kind, error) {
+func calcKindsForRows(firstDataRow int, rows [][]*xlsx.Cell, date1904 bool) ([]kind.Kind, error) {
 	if firstDataRow > len(rows) {
 		return nil, errz.Errorf("rows are empty")
 	}
@@ -439,12 +459,12 @@ func calcKindsForRows(firstDataRow int, rows []*xlsx.Row) ([]kind.Kind, error) {
 			continue
 		}
 
-		for j := len(detectors); j < len(rows[i].Cells); j++ {
+		for j := len(detectors); j < len(rows[i]); j++ {
 			detectors = append(detectors, kind.NewDetector())
 		}
 
-		for j := range rows[i].Cells {
-			val := readCellValue(rows[i].Cells[j])
+		for j := range rows[i] {
+			val := readCellValue(rows[i][j], date1904)
 			detectors[j].Sample(val)
 		}
 	}
@@ -463,17 +483,18 @@ func calcKindsForRows(firstDataRow int, rows []*xlsx.Row) ([]kind.Kind, error) {
 	return kinds, nil
 }
 
-// getColNames returns column names for the sheet. If hasHeader is true and there's
+// getColNames returns column names for rows. If hasHeader is true and there's
 // at least one row, the column names are the values of the first row. Otherwise
 // an alphabetical sequence (A, B... Z, AA, AB) is generated.
-func getColNames(sheet *xlsx.Sheet, hasHeader bool) []string {
-	numCells := getRowsMaxCellCount(sheet)
+func getColNames(rows [][]*xlsx.Cell, hasHeader bool) []string {
+	numCells := getMaxCellCount(rows)
 	colNames := make([]string, numCells)
 
-	if len(sheet.Rows) > 0 && hasHeader {
-		row := sheet.Rows[0]
-		for i := 0; i < len(row.Cells); i++ {
-			colNames[i] = row.Cells[i].String()
+	if len(rows) > 0 && hasHeader {
+		for i, cell := range rows[0] {
+			if cell != nil {
+				colNames[i] = cell.String()
+			}
 		}
 	}
 
@@ -486,17 +507,21 @@ func getColNames(sheet *xlsx.Sheet, hasHeader bool) []string {
 	return colNames
 }
 
-// getCellColumnTypes returns the xlsx cell types for the sheet, determined from
-// the values of the first data row (after any header row).
-func getCellColumnTypes(sheet *xlsx.Sheet, hasHeader bool) []xlsx.CellType {
-	types := make([]*xlsx.CellType, getRowsMaxCellCount(sheet))
+// getCellColumnTypes returns the xlsx cell types for rows, determined from
+// the values of the data rows (after any header row).
+func getCellColumnTypes(rows [][]*xlsx.Cell, hasHeader bool) []xlsx.CellType {
+	types := make([]*xlsx.CellType, getMaxCellCount(rows))
 	firstDataRow := 0
 	if hasHeader {
 		firstDataRow = 1
 	}
 
-	for x := firstDataRow; x < len(sheet.Rows); x++ {
-		for i, cell := range sheet.Rows[x].Cells {
+	for x := firstDataRow; x < len(rows); x++ {
+		for i, cell := range rows[x] {
+			if cell == nil {
+				continue
+			}
+
 			if types[i] == nil {
 				typ := cell.Type()
 				types[i] = &typ
@@ -518,6 +543,10 @@ func getCellColumnTypes(sheet *xlsx.Sheet, hasHeader bool) []xlsx.CellType {
 	// convert back to value types
 	ret := make([]xlsx.CellType, len(types))
 	for i, typ := range types {
+		if typ == nil {
+			ret[i] = xlsx.CellTypeString
+			continue
+		}
 		ret[i] = *typ
 	}
 
@@ -550,10 +579,23 @@ func getRowsMaxCellCount(sheet *xlsx.Sheet) int {
 	max := 0
 
 	for _, row := range sheet.Rows {
-		if len(row.Cells) > max {
+		if row != nil && len(row.Cells) > max {
 			max = len(row.Cells)
 		}
 	}
 
 	return max
 }
+
+// getMaxCellCount returns the largest count of cells in rows.
+func getMaxCellCount(rows [][]*xlsx.Cell) int {
+	max := 0
+
+	for _, row := range rows {
+		if len(row) > max {
+			max = len(row)
+		}
+	}
+
+	return max
+}
@@ -34,3 +34,38 @@ func Test_getRowsMaxCellCount(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseCellRef(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		in      string
+		want    cellRef
+		wantErr bool
+	}{
+		{in: "B4:H200", want: cellRef{minCol: 1, minRow: 3, maxCol: 7, maxRow: 199}},
+		{in: "Sheet1!$A$1:$C$10", want: cellRef{sheet: "Sheet1", maxCol: 2, maxRow: 9}},
+		{in: "'My ''Data'''!c2", want: cellRef{sheet: "My 'Data'", minCol: 2, minRow: 1, maxCol: 2, maxRow: 1}},
+		{in: "AA10:B2", want: cellRef{minCol: 1, minRow: 1, maxCol: 26, maxRow: 9}},
+		{in: "", wantErr: true},
+		{in: "A0", wantErr: true},
+		{in: "4B", wantErr: true},
+		{in: "!A1", wantErr: true},
+		{in: "A1:B2:C3", wantErr: true},
+		{in: "Sheet1!A1,Sheet1!B1", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseCellRef(tc.in)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, *got)
+		})
+	}
+}
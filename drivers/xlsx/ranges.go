@@ -0,0 +1,490 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/neilotoole/lg"
+	"github.com/tealeg/xlsx/v2"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/options"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+const (
+	// OptHeaderRow is the source option that specifies the (1-based)
+	// row number of each sheet's header row, e.g. "header_row=3".
+	// The rows above the header row (e.g. a title) are ignored.
+	// The option implies "header=true", which also applies to the
+	// Excel named ranges.
+	OptHeaderRow = "header_row"
+
+	// OptRange is the source option that restricts the import of a
+	// sheet to a range of cells, e.g. "range=Sheet1!B4:H200". If
+	// the sheet name is omitted, e.g. "range=B4:H200", the range
+	// applies to every sheet. Option OptHeaderRow is ignored for
+	// a sheet that has a range: instead, if "header=true", the
+	// first row of the range is the header row.
+	OptRange = "range"
+)
+
+// tableRange is a rectangular range of a sheet's cells that is
+// imported as a table. This may be the whole sheet, a range of
+// the sheet per OptRange, an Excel table, or an Excel named range.
+// Row and column indices are zero-based and inclusive.
+type tableRange struct {
+	name      string
+	sheet     *xlsx.Sheet
+	hasHeader bool
+	date1904  bool
+
+	minRow, minCol int
+	maxRow, maxCol int
+}
+
+// rows returns the cells of the range's rows. Each row is a
+// slice of the sheet row's cells, and thus rows may be ragged.
+func (tr *tableRange) rows() [][]*xlsx.Cell {
+	var rows [][]*xlsx.Cell
+	for i := tr.minRow; i <= tr.maxRow && i < len(tr.sheet.Rows); i++ {
+		row := tr.sheet.Rows[i]
+		if row == nil || tr.minCol >= len(row.Cells) {
+			rows = append(rows, nil)
+			continue
+		}
+
+		end := tr.maxCol + 1
+		if end > len(row.Cells) {
+			end = len(row.Cells)
+		}
+		rows = append(rows, row.Cells[tr.minCol:end])
+	}
+
+	return rows
+}
+
+// cellRef is a reference to a range of cells, such as "Sheet1!B4:H200".
+type cellRef struct {
+	// sheet is the sheet name, which may be empty.
+	sheet string
+
+	minRow, minCol int
+	maxRow, maxCol int
+}
+
+// parseCellRef parses a range reference such as "B4:H200",
+// "Sheet1!$B$4:$H$200" or "'My Sheet'!A1:C10". A single
+// cell, such as "B4", is also a valid range.
+func parseCellRef(s string) (*cellRef, error) {
+	ref := &cellRef{}
+	s = strings.TrimSpace(s)
+	orig := s
+
+	if i := strings.LastIndex(s, "!"); i >= 0 {
+		ref.sheet = s[:i]
+		s = s[i+1:]
+
+		switch {
+		case len(ref.sheet) > 1 && strings.HasPrefix(ref.sheet, "'") && strings.HasSuffix(ref.sheet, "'"):
+			ref.sheet = strings.ReplaceAll(ref.sheet[1:len(ref.sheet)-1], "''", "'")
+		case strings.ContainsAny(ref.sheet, "!,"):
+			// For example, a reference to multiple ranges,
+			// such as "Sheet1!A1:A3,Sheet1!C1:C3".
+			return nil, errz.Errorf("invalid cell range %q", orig)
+		}
+
+		if ref.sheet == "" {
+			return nil, errz.Errorf("invalid cell range %q: empty sheet name", orig)
+		}
+	}
+
+	parts := strings.Split(strings.ReplaceAll(s, "$", ""), ":")
+	if len(parts) > 2 {
+		return nil, errz.Errorf("invalid cell range %q", orig)
+	}
+
+	var err error
+	ref.minCol, ref.minRow, err = parseCellID(parts[0])
+	if err != nil {
+		return nil, errz.Wrapf(err, "invalid cell range %q", orig)
+	}
+
+	ref.maxCol, ref.maxRow = ref.minCol, ref.minRow
+	if len(parts) == 2 {
+		ref.maxCol, ref.maxRow, err = parseCellID(parts[1])
+		if err != nil {
+			return nil, errz.Wrapf(err, "invalid cell range %q", orig)
+		}
+	}
+
+	if ref.maxCol < ref.minCol {
+		ref.minCol, ref.maxCol = ref.maxCol, ref.minCol
+	}
+	if ref.maxRow < ref.minRow {
+		ref.minRow, ref.maxRow = ref.maxRow, ref.minRow
+	}
+
+	return ref, nil
+}
+
+// parseCellID parses a cell ID such as "B4", returning
+// zero-based indices.
+func parseCellID(id string) (col, row int, err error) {
+	i := strings.IndexAny(id, "0123456789")
+	if i <= 0 {
+		return 0, 0, errz.Errorf("invalid cell %q", id)
+	}
+
+	for _, r := range id[:i] {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return 0, 0, errz.Errorf("invalid cell %q", id)
+		}
+	}
+
+	row, err = strconv.Atoi(id[i:])
+	if err != nil || row < 1 {
+		return 0, 0, errz.Errorf("invalid cell %q", id)
+	}
+
+	return xlsx.ColLettersToIndex(strings.ToUpper(id[:i])), row - 1, nil
+}
+
+// getHeaderRow returns the value of OptHeaderRow, or zero if not set.
+func getHeaderRow(opts options.Options) (int, error) {
+	val := opts.Get(OptHeaderRow)
+	if val == "" {
+		return 0, nil
+	}
+
+	headerRow, err := strconv.Atoi(val)
+	if err != nil || headerRow < 1 {
+		return 0, errz.Errorf("option %q: must be a row number (1 or greater), but got %q", OptHeaderRow, val)
+	}
+
+	return headerRow, nil
+}
+
+// getRange returns the value of OptRange, or nil if not set.
+func getRange(opts options.Options) (*cellRef, error) {
+	val := opts.Get(OptRange)
+	if val == "" {
+		return nil, nil
+	}
+
+	ref, err := parseCellRef(val)
+	if err != nil {
+		return nil, errz.Wrapf(err, "option %q", OptRange)
+	}
+
+	return ref, nil
+}
+
+// getTableRanges returns the ranges of xlFile that are imported as
+// tables: each of the sheets (per options OptHeaderRow and OptRange),
+// followed by each Excel table, and each Excel named range. Arg data
+// is the raw XLSX data, from which the Excel tables are read.
+func getTableRanges(log lg.Log, src *source.Source, xlFile *xlsx.File, data []byte) ([]*tableRange, error) {
+	hasHeader, _, err := options.HasHeader(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	headerRow, err := getHeaderRow(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	rng, err := getRange(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	if headerRow > 0 {
+		hasHeader = true
+	}
+
+	if rng != nil && rng.sheet != "" && xlFile.Sheet[rng.sheet] == nil {
+		return nil, errz.Errorf("option %q: sheet %q not found in %s", OptRange, rng.sheet, src.Handle)
+	}
+
+	var ranges []*tableRange
+	names := map[string]bool{}
+	addRange := func(tr *tableRange) {
+		// Table names are case-insensitive in the scratch DB.
+		key := strings.ToLower(tr.name)
+		if names[key] {
+			log.Warnf("Skipping XLSX range %q of sheet %q: a table with that name already exists", tr.name, tr.sheet.Name)
+			return
+		}
+		names[key] = true
+		ranges = append(ranges, tr)
+	}
+
+	for _, sheet := range xlFile.Sheets {
+		fillMergedCells(sheet)
+
+		tr := &tableRange{
+			name:      sheet.Name,
+			sheet:     sheet,
+			hasHeader: hasHeader,
+			date1904:  xlFile.Date1904,
+			maxRow:    len(sheet.Rows) - 1,
+			maxCol:    getRowsMaxCellCount(sheet) - 1,
+		}
+
+		switch {
+		case rng != nil && (rng.sheet == "" || rng.sheet == sheet.Name):
+			tr.minRow, tr.minCol, tr.maxRow, tr.maxCol = rng.minRow, rng.minCol, rng.maxRow, rng.maxCol
+		case headerRow > 0:
+			tr.minRow = headerRow - 1
+		}
+
+		addRange(tr)
+	}
+
+	xlTables, err := readExcelTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, xlTbl := range xlTables {
+		sheet := xlFile.Sheet[xlTbl.sheet]
+		if sheet == nil {
+			continue
+		}
+
+		ref, err := parseCellRef(xlTbl.Ref)
+		if err != nil {
+			log.Warnf("Skipping Excel table %q: %v", xlTbl.DisplayName, err)
+			continue
+		}
+
+		// The header row count is 1 if not specified.
+		headerRowCount := 1
+		if xlTbl.HeaderRowCount != nil {
+			headerRowCount = *xlTbl.HeaderRowCount
+		}
+
+		addRange(&tableRange{
+			name:      xlTbl.DisplayName,
+			sheet:     sheet,
+			hasHeader: headerRowCount > 0,
+			date1904:  xlFile.Date1904,
+			minRow:    ref.minRow,
+			minCol:    ref.minCol,
+			maxRow:    ref.maxRow - xlTbl.TotalsRowCount,
+			maxCol:    ref.maxCol,
+		})
+	}
+
+	for _, dn := range xlFile.DefinedNames {
+		if dn.Hidden || strings.HasPrefix(dn.Name, "_xlnm.") {
+			// Built-in names such as _xlnm.Print_Area.
+			continue
+		}
+
+		// Only a name that refers to a single range of cells,
+		// e.g. "Sheet1!$A$1:$C$10", can be a table.
+		ref, err := parseCellRef(dn.Data)
+		if err != nil || ref.sheet == "" || xlFile.Sheet[ref.sheet] == nil {
+			log.Debugf("Skipping Excel defined name %q: not a range of cells: %s", dn.Name, dn.Data)
+			continue
+		}
+
+		addRange(&tableRange{
+			name:      dn.Name,
+			sheet:     xlFile.Sheet[ref.sheet],
+			hasHeader: hasHeader,
+			date1904:  xlFile.Date1904,
+			minRow:    ref.minRow,
+			minCol:    ref.minCol,
+			maxRow:    ref.maxRow,
+			maxCol:    ref.maxCol,
+		})
+	}
+
+	return ranges, nil
+}
+
+// fillMergedCells sets the value of each cell covered by a merged
+// cell to the value of the merged cell. For example, if A1:A3 are
+// merged with value "x", then the values of A2 and A3 become "x".
+// Thus each row of the merged range has the value, as is typically
+// intended by merging (e.g. a category spanning several rows).
+func fillMergedCells(sheet *xlsx.Sheet) {
+	for r, row := range sheet.Rows {
+		if row == nil {
+			continue
+		}
+
+		for c, cell := range row.Cells {
+			if cell == nil || (cell.HMerge == 0 && cell.VMerge == 0) {
+				continue
+			}
+
+			for i := r; i <= r+cell.VMerge && i < len(sheet.Rows); i++ {
+				coveredRow := sheet.Rows[i]
+				if coveredRow == nil {
+					continue
+				}
+
+				for j := c; j <= c+cell.HMerge; j++ {
+					if i == r && j == c {
+						continue
+					}
+
+					for len(coveredRow.Cells) <= j {
+						coveredRow.Cells = append(coveredRow.Cells, &xlsx.Cell{Row: coveredRow})
+					}
+
+					covered := *cell
+					covered.Row = coveredRow
+					covered.HMerge, covered.VMerge = 0, 0
+					coveredRow.Cells[j] = &covered
+				}
+			}
+		}
+	}
+}
+
+// excelTable is an Excel table (also known as a ListObject),
+// as defined in an xl/tables/tableN.xml file.
+type excelTable struct {
+	Name           string `xml:"name,attr"`
+	DisplayName    string `xml:"displayName,attr"`
+	Ref            string `xml:"ref,attr"`
+	HeaderRowCount *int   `xml:"headerRowCount,attr"`
+	TotalsRowCount int    `xml:"totalsRowCount,attr"`
+
+	// sheet is the name of the table's sheet.
+	sheet string
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// readExcelTables returns the Excel tables defined in the XLSX data.
+// The xlsx library doesn't read tables, so they are read from the
+// XLSX zip archive: the workbook's sheets reference the sheet parts,
+// and each sheet part's relationships reference its table parts.
+func readExcelTables(data []byte) ([]*excelTable, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	const workbookPath = "xl/workbook.xml"
+	wb := &xmlWorkbook{}
+	ok, err := readZipXML(files, workbookPath, wb)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	wbRels := &xmlRelationships{}
+	_, err = readZipXML(files, relsPath(workbookPath), wbRels)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*excelTable
+	for _, sheet := range wb.Sheets {
+		for _, wbRel := range wbRels.Rels {
+			if wbRel.ID != sheet.RID {
+				continue
+			}
+
+			sheetPath := resolveZipPath(workbookPath, wbRel.Target)
+			sheetRels := &xmlRelationships{}
+			_, err = readZipXML(files, relsPath(sheetPath), sheetRels)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rel := range sheetRels.Rels {
+				if !strings.HasSuffix(rel.Type, "/table") {
+					continue
+				}
+
+				tbl := &excelTable{sheet: sheet.Name}
+				ok, err = readZipXML(files, resolveZipPath(sheetPath, rel.Target), tbl)
+				if err != nil {
+					return nil, err
+				}
+
+				if ok {
+					if tbl.DisplayName == "" {
+						tbl.DisplayName = tbl.Name
+					}
+					tables = append(tables, tbl)
+				}
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+// readZipXML unmarshals the XML of the named file into v.
+// Return value ok is false if the file doesn't exist.
+func readZipXML(files map[string]*zip.File, name string, v interface{}) (ok bool, err error) {
+	f, ok := files[name]
+	if !ok {
+		return false, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return false, errz.Err(err)
+	}
+	defer rc.Close()
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return false, errz.Err(err)
+	}
+
+	err = xml.Unmarshal(b, v)
+	if err != nil {
+		return false, errz.Wrapf(err, "invalid XLSX part %s", name)
+	}
+
+	return true, nil
+}
+
+// relsPath returns the path of the relationships part for
+// the part at partPath, e.g. "xl/_rels/workbook.xml.rels".
+func relsPath(partPath string) string {
+	return path.Join(path.Dir(partPath), "_rels", path.Base(partPath)+".rels")
+}
+
+// resolveZipPath resolves a relationship target, which is
+// relative to the dir of the part at partPath, or absolute.
+func resolveZipPath(partPath, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(partPath), target)
+}
@@ -83,23 +83,25 @@ func (d *Driver) DriverMetadata() driver.Metadata {
 
 // Open implements driver.Driver.
 func (d *Driver) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
-	r, err := d.files.Open(src)
-	if err != nil {
-		return nil, err
-	}
-	defer d.log.WarnIfCloseError(r)
+	scratchDB, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		r, err := d.files.Open(src)
+		if err != nil {
+			return err
+		}
+		defer d.log.WarnIfCloseError(r)
 
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, errz.Err(err)
-	}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return errz.Err(err)
+		}
 
-	xlFile, err := xlsx.OpenBinary(b)
-	if err != nil {
-		return nil, err
-	}
+		xlFile, err := xlsx.OpenBinary(b)
+		if err != nil {
+			return err
+		}
 
-	scratchDB, err := d.scratcher.OpenScratch(ctx, src.Handle)
+		return xlsxToScratch(ctx, d.log, src, xlFile, b, destDB)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +109,6 @@ func (d *Driver) Open(ctx context.Context, src *source.Source) (driver.Database,
 	clnup := cleanup.New()
 	clnup.AddE(scratchDB.Close)
 
-	err = xlsxToScratch(ctx, d.log, src, xlFile, scratchDB)
-	if err != nil {
-		d.log.WarnIfError(clnup.Run())
-		return nil, err
-	}
-
 	return &database{log: d.log, src: src, impl: scratchDB, files: d.files, clnup: clnup}, nil
 }
 
@@ -131,6 +127,21 @@ func (d *Driver) ValidateSource(src *source.Source) (*source.Source, error) {
 		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
 	}
 
+	_, _, err := options.HasHeader(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = getHeaderRow(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = getRange(src.Options)
+	if err != nil {
+		return nil, err
+	}
+
 	return src, nil
 }
 
@@ -223,22 +234,23 @@ func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error)
 		return nil, errz.Errorf("unable to open XLSX file: ", d.src.Location, err)
 	}
 
-	hasHeader, _, err := options.HasHeader(d.src.Options)
+	ranges, err := getTableRanges(d.log, d.src, xlFile, b)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, sheet := range xlFile.Sheets {
-		tbl := &source.TableMetadata{Name: sheet.Name, RowCount: int64(len(sheet.Rows))}
+	for _, tr := range ranges {
+		rows := tr.rows()
+		tbl := &source.TableMetadata{Name: tr.name, RowCount: int64(len(rows))}
 
-		if hasHeader && tbl.RowCount > 0 {
+		if tr.hasHeader && tbl.RowCount > 0 {
 			tbl.RowCount--
 		}
 
-		colNames := getColNames(sheet, hasHeader)
+		colNames := getColNames(rows, tr.hasHeader)
 
 		// TODO: Should move over to using kind.Detector
-		colTypes := getCellColumnTypes(sheet, hasHeader)
+		colTypes := getCellColumnTypes(rows, tr.hasHeader)
 
 		for i, colType := range colTypes {
 			col := &source.ColMetadata{}
@@ -246,6 +258,7 @@ func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error)
 			col.ColumnType = col.BaseType
 			col.Position = int64(i)
 			col.Name = colNames[i]
+			col.Nullable = true // Any cell can be empty
 			tbl.Columns = append(tbl.Columns, col)
 		}
 
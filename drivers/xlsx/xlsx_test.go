@@ -1,11 +1,16 @@
 package xlsx_test
 
 import (
+	"archive/zip"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/neilotoole/sq/drivers/xlsx"
+	"github.com/neilotoole/sq/libsq/core/kind"
 	"github.com/neilotoole/sq/libsq/core/options"
 	"github.com/neilotoole/sq/libsq/source"
 	"github.com/neilotoole/sq/testh"
@@ -74,3 +79,163 @@ func TestHandleSomeEmptySheets(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 2, len(sink.Recs))
 }
+
+// writeAdvancedXLSX writes an XLSX file to a temp dir, returning the
+// file path. The file is built from raw XML parts (as opposed to via
+// the xlsx library), because it has features that the library can't
+// write: an Excel table, and formula cells with cached values.
+//
+// Sheet "Report" has a title row, a header row at row 3, a merged
+// cell (A4:A5), formula cells (col D), and date cells (col E). The
+// named range "Cities" refers to Report!$B$3:$C$6. Sheet "Data" has
+// an Excel table "Products" (B2:C5), which has a totals row.
+func writeAdvancedXLSX(t *testing.T) string {
+	const (
+		nsMain = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+		nsRel  = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+		nsPkg  = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+		relDoc = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+	)
+
+	parts := map[string]string{
+		"[Content_Types].xml": `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/tables/table1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"/>` +
+			`</Types>`,
+		"_rels/.rels": `<Relationships ` + nsPkg + `>` +
+			`<Relationship Id="rId1" Type="` + relDoc + `officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+		"xl/workbook.xml": `<workbook ` + nsMain + ` ` + nsRel + `><sheets>` +
+			`<sheet name="Report" sheetId="1" r:id="rId1"/><sheet name="Data" sheetId="2" r:id="rId2"/></sheets>` +
+			`<definedNames>` +
+			`<definedName name="_xlnm._FilterDatabase" localSheetId="0" hidden="1">Report!$A$3:$E$6</definedName>` +
+			`<definedName name="Cities">Report!$B$3:$C$6</definedName>` +
+			`</definedNames></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships ` + nsPkg + `>` +
+			`<Relationship Id="rId1" Type="` + relDoc + `worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="` + relDoc + `worksheet" Target="worksheets/sheet2.xml"/>` +
+			`<Relationship Id="rId3" Type="` + relDoc + `styles" Target="styles.xml"/></Relationships>`,
+		"xl/styles.xml": `<styleSheet ` + nsMain + `>` +
+			`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="1"><fill><patternFill patternType="none"/></fill></fills>` +
+			`<borders count="1"><border/></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+			`</styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet ` + nsMain + ` ` + nsRel + `><dimension ref="A1:E6"/><sheetData>` +
+			`<row r="1">` + strCell("A1", "Quarterly Report") + `</row>` +
+			`<row r="3">` + strCell("A3", "region") + strCell("B3", "city") + strCell("C3", "amount") +
+			strCell("D3", "double") + strCell("E3", "sold") + `</row>` +
+			`<row r="4">` + strCell("A4", "North") + strCell("B4", "Cork") + `<c r="C4"><v>10</v></c>` +
+			`<c r="D4"><f>C4*2</f><v>20</v></c><c r="E4" s="1"><v>44259</v></c></row>` +
+			`<row r="5">` + strCell("B5", "Galway") + `<c r="C5"><v>20</v></c>` +
+			`<c r="D5"><f>C5*2</f><v>40</v></c><c r="E5" t="d"><v>2021-03-05T10:30:00Z</v></c></row>` +
+			`<row r="6">` + strCell("A6", "South") + strCell("B6", "Kerry") + `<c r="C6"><v>30</v></c>` +
+			`<c r="D6"><f>C6*2</f><v>60</v></c><c r="E6" s="1"><v>44260.5</v></c></row>` +
+			`</sheetData><mergeCells count="1"><mergeCell ref="A4:A5"/></mergeCells></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet ` + nsMain + ` ` + nsRel + `><dimension ref="A1:C5"/><sheetData>` +
+			`<row r="1">` + strCell("A1", "Product list") + `</row>` +
+			`<row r="2">` + strCell("B2", "name") + strCell("C2", "price") + `</row>` +
+			`<row r="3">` + strCell("B3", "apple") + `<c r="C3"><v>1.5</v></c></row>` +
+			`<row r="4">` + strCell("B4", "pear") + `<c r="C4"><v>2.25</v></c></row>` +
+			`<row r="5">` + strCell("B5", "Total") + `<c r="C5"><f>SUBTOTAL(109,C3:C4)</f><v>3.75</v></c></row>` +
+			`</sheetData><tableParts count="1"><tablePart r:id="rId1"/></tableParts></worksheet>`,
+		"xl/worksheets/_rels/sheet2.xml.rels": `<Relationships ` + nsPkg + `>` +
+			`<Relationship Id="rId1" Type="` + relDoc + `table" Target="../tables/table1.xml"/></Relationships>`,
+		"xl/tables/table1.xml": `<table ` + nsMain + ` id="1" name="Table1" displayName="Products" ref="B2:C5" totalsRowCount="1">` +
+			`<tableColumns count="2"><tableColumn id="1" name="name"/><tableColumn id="2" name="price"/></tableColumns></table>`,
+	}
+
+	fpath := filepath.Join(t.TempDir(), "advanced.xlsx")
+	f, err := os.Create(fpath)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+
+	zw := zip.NewWriter(f)
+	for name, data := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	return fpath
+}
+
+func strCell(ref, val string) string {
+	return `<c r="` + ref + `" t="inlineStr"><is><t>` + val + `</t></is></c>`
+}
+
+func TestAdvancedImport(t *testing.T) {
+	t.Parallel()
+
+	fpath := writeAdvancedXLSX(t)
+	th := testh.New(t)
+
+	src := &source.Source{
+		Handle:   "@xlsx_header_row",
+		Type:     xlsx.Type,
+		Location: fpath,
+		Options:  options.Options{xlsx.OptHeaderRow: []string{"3"}},
+	}
+
+	srcMeta, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	var tblNames []string
+	for _, tblMeta := range srcMeta.Tables {
+		tblNames = append(tblNames, tblMeta.Name)
+	}
+	require.Equal(t, []string{"Report", "Data", "Products", "Cities"}, tblNames)
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM Report")
+	require.NoError(t, err)
+	require.Equal(t, []string{"region", "city", "amount", "double", "sold"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Text, kind.Text, kind.Int, kind.Int, kind.Datetime}, sink.RecMeta.Kinds())
+	require.Equal(t, 3, len(sink.Recs))
+
+	// The merged cell A4:A5 is filled down.
+	require.Equal(t, "North", testh.Val(sink.Recs[1][0]))
+	// Formula cells have their cached values.
+	require.Equal(t, int64(40), testh.Val(sink.Recs[1][3]))
+	require.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), testh.Val(sink.Recs[0][4]))
+	require.Equal(t, time.Date(2021, 3, 5, 10, 30, 0, 0, time.UTC), testh.Val(sink.Recs[1][4]))
+	require.Equal(t, time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC), testh.Val(sink.Recs[2][4]))
+
+	// The Excel table excludes the totals row.
+	sink, err = th.QuerySQL(src, "SELECT * FROM Products")
+	require.NoError(t, err)
+	require.Equal(t, []string{"name", "price"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Text, kind.Float}, sink.RecMeta.Kinds())
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, 2.25, testh.Val(sink.Recs[1][1]))
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM Cities")
+	require.NoError(t, err)
+	require.Equal(t, []string{"city", "amount"}, sink.RecMeta.Names())
+	require.Equal(t, 3, len(sink.Recs))
+
+	src = &source.Source{
+		Handle:   "@xlsx_range",
+		Type:     xlsx.Type,
+		Location: fpath,
+		Options:  options.Options{xlsx.OptRange: []string{"Report!B3:C5"}, "header": []string{"true"}},
+	}
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM Report")
+	require.NoError(t, err)
+	require.Equal(t, []string{"city", "amount"}, sink.RecMeta.Names())
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, "Galway", testh.Val(sink.Recs[1][0]))
+
+	src = &source.Source{
+		Handle:   "@xlsx_bad_range",
+		Type:     xlsx.Type,
+		Location: fpath,
+		Options:  options.Options{xlsx.OptRange: []string{"NotExist!B3:C5"}},
+	}
+	_, err = th.Databases().Open(th.Context, src)
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package xml
+
+import (
+	"context"
+	"io"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/userdriver/xmlud"
+)
+
+// GenerateDriverDef returns a user driver definition of genre "xml",
+// named name, for the XML document read from r. If name is empty,
+// the driver is named for the document element. The definition
+// maps the same tables and columns that the xml driver infers
+// from the document, and thus is a starting point for a
+// hand-tuned user driver.
+func GenerateDriverDef(ctx context.Context, r io.Reader, name string) (*userdriver.DriverDef, error) {
+	root, err := scanNodes(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	tbls, err := buildTables(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = root.name
+	}
+
+	def := &userdriver.DriverDef{
+		Name:     name,
+		Genre:    xmlud.Genre,
+		Title:    "Generated from XML document <" + root.name + ">",
+		Selector: root.path(),
+	}
+
+	for _, tbl := range tbls {
+		tblMapping := &userdriver.TableMapping{
+			Name:       tbl.name,
+			Selector:   tbl.node.path(),
+			PrimaryKey: []string{colRowID},
+		}
+
+		for _, col := range tbl.cols {
+			colMapping := &userdriver.ColMapping{Name: col.name, Kind: col.kind, Selector: col.sel}
+			switch {
+			case col.index == 0:
+				colMapping.Selector = "../sequence()"
+			case col.field == nil:
+				colMapping.Foreign = "../" + colRowID
+			}
+
+			tblMapping.Cols = append(tblMapping.Cols, colMapping)
+		}
+
+		def.Tables = append(def.Tables, tblMapping)
+	}
+
+	return def, nil
+}
@@ -0,0 +1,259 @@
+package xml
+
+import (
+	"context"
+	stdxml "encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// importXML imports the XML document returned by openFn into
+// the tables of scratchDB.
+func importXML(ctx context.Context, log lg.Log, openFn source.FileOpenFunc, scratchDB driver.Database) error {
+	r, err := openFn()
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r)
+
+	root, err := scanNodes(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	tbls, err := buildTables(root)
+	if err != nil {
+		return err
+	}
+
+	for _, tbl := range tbls {
+		err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tbl.tableDef())
+		if err != nil {
+			return err
+		}
+	}
+
+	r2, err := openFn()
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r2)
+
+	conn, err := scratchDB.DB().Conn(ctx)
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer log.WarnIfCloseError(conn)
+
+	ins := &inserter{
+		log:     log,
+		drvr:    scratchDB.SQLDriver(),
+		db:      conn,
+		rejects: driver.RejectsFrom(ctx),
+		execers: map[*table]*driver.StmtExecer{},
+		rowIDs:  map[*table]int64{},
+	}
+	defer ins.close()
+
+	err = ins.insertRows(ctx, root, r2)
+	if err != nil {
+		return err
+	}
+
+	for _, tbl := range tbls {
+		log.Debugf("Inserted %d rows into %s.%s", ins.rowIDs[tbl], scratchDB.Source().Handle, tbl.name)
+	}
+
+	return nil
+}
+
+// row is a row of a table, populated as the table's
+// element is read.
+type row struct {
+	tbl  *table
+	id   int64
+	vals []interface{}
+}
+
+// inserter inserts the rows of the document's tables.
+type inserter struct {
+	log     lg.Log
+	drvr    driver.SQLDriver
+	db      sqlz.DB
+	rejects *driver.Rejects
+
+	// execers holds the prepared insert statement of each table.
+	execers map[*table]*driver.StmtExecer
+
+	// rowIDs holds the ID of the most recent row of each table.
+	rowIDs map[*table]int64
+}
+
+// insertRows reads the XML document from r, whose document element
+// is root, and inserts the rows of the tables of root and its
+// descendants. The ID of a row is assigned at the element's start
+// tag, and the row is inserted at its end tag, thus child rows are
+// inserted before their parent row.
+func (ins *inserter) insertRows(ctx context.Context, root *node, r io.Reader) error {
+	type frame struct {
+		n    *node
+		text *strings.Builder
+
+		// row is the row of n's table, or of the
+		// table of n's nearest table ancestor.
+		row *row
+	}
+
+	var stack []*frame
+	dec := newDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errz.Wrap(err, "xml")
+		}
+
+		switch t := tok.(type) {
+		case stdxml.StartElement:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			f := &frame{n: root}
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				f.n, f.row = top.n.child(t.Name.Local), top.row
+				if f.n == nil {
+					// Can only happen if the document changed
+					// since it was scanned.
+					return errz.Errorf("xml: unexpected element <%s> in <%s>", t.Name.Local, top.n.path())
+				}
+			}
+
+			if tbl := f.n.tbl; tbl != nil {
+				ins.rowIDs[tbl]++
+				f.row = &row{tbl: tbl, id: ins.rowIDs[tbl], vals: make([]interface{}, len(tbl.cols))}
+				f.row.vals[0] = f.row.id
+				if tbl.parent != nil && stack[len(stack)-1].row != nil {
+					f.row.vals[1] = stack[len(stack)-1].row.id
+				}
+			}
+
+			if f.n.textCol != nil {
+				f.text = &strings.Builder{}
+			}
+
+			for _, attr := range t.Attr {
+				if col, ok := f.n.attrCols[attr.Name.Local]; ok && !isNamespaceDecl(attr) && f.row != nil {
+					f.row.vals[col.index] = attr.Value
+				}
+			}
+
+			stack = append(stack, f)
+
+		case stdxml.CharData:
+			if len(stack) > 0 && stack[len(stack)-1].text != nil {
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case stdxml.EndElement:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if f.text != nil && f.row != nil {
+				f.row.vals[f.n.textCol.index] = strings.TrimSpace(f.text.String())
+			}
+
+			if f.n.tbl != nil {
+				err = ins.insert(ctx, f.row)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// insert inserts rw into its table.
+func (ins *inserter) insert(ctx context.Context, rw *row) error {
+	rec, err := rowToRecord(rw)
+	if err != nil {
+		return err
+	}
+
+	execer, ok := ins.execers[rw.tbl]
+	if !ok {
+		colNames := make([]string, len(rw.tbl.cols))
+		for i, col := range rw.tbl.cols {
+			colNames[i] = col.name
+		}
+
+		execer, err = ins.drvr.PrepareInsertStmt(ctx, ins.db, rw.tbl.name, colNames, 1)
+		if err != nil {
+			return err
+		}
+		ins.execers[rw.tbl] = execer
+	}
+
+	err = execer.Munge(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = execer.Exec(ctx, rec...)
+	if err != nil && ins.rejects != nil {
+		err = ins.rejects.Reject(rw.tbl.name, rw.id, rec, err)
+	}
+
+	return err
+}
+
+// close closes the prepared insert statements.
+func (ins *inserter) close() {
+	for _, execer := range ins.execers {
+		ins.log.WarnIfCloseError(execer)
+	}
+}
+
+// rowToRecord returns the record to insert for rw. An empty
+// value in a non-text column is inserted as NULL.
+func rowToRecord(rw *row) ([]interface{}, error) {
+	rec := make([]interface{}, len(rw.vals))
+	for i, v := range rw.vals {
+		col := rw.tbl.cols[i]
+		val, ok := v.(string)
+		if !ok {
+			rec[i] = v
+			continue
+		}
+
+		if val == "" && col.kind != kind.Text {
+			continue
+		}
+
+		if col.mungeFn == nil {
+			rec[i] = val
+			continue
+		}
+
+		var err error
+		rec[i], err = col.mungeFn(val)
+		if err != nil {
+			return nil, errz.Wrapf(err, "xml: table %s: column %s", rw.tbl.name, col.name)
+		}
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,414 @@
+package xml
+
+import (
+	"context"
+	stdxml "encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+const (
+	// colRowID is the name of the generated key column of each table.
+	colRowID = "sq_id"
+
+	// colParentID is the name of the generated column of a child
+	// table, which references the colRowID column of the parent table.
+	colParentID = "sq_parent_id"
+
+	// colValue is the name of the column that holds the text
+	// of a table's element.
+	colValue = "value"
+
+	// colScopeSep is used when generating the column names of
+	// nested elements. Thus an element "address/city" becomes
+	// the column "address_city".
+	colScopeSep = "_"
+)
+
+// charsetReader implements xml.Decoder.CharsetReader, so that
+// documents with a non-UTF-8 encoding (such as ISO-8859-1) can
+// be decoded.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	r, err := charset.NewReaderLabel(label, input)
+	return r, errz.Err(err)
+}
+
+// node models an element of the document, identified by its
+// path from the document element, e.g. "/people/person/skill".
+type node struct {
+	name     string
+	parent   *node
+	children []*node
+
+	// attrs holds the names of the element's attributes, in the
+	// order in which they were first encountered.
+	attrs      []string
+	attrFields map[string]*field
+
+	// text is the field of the element's text content.
+	text *field
+
+	// repeats is true if the element occurs more than once
+	// within a single instance of its parent element.
+	repeats bool
+
+	// tbl is non-nil if each occurrence of the element
+	// is a row of tbl.
+	tbl *table
+
+	// attrCols and textCol are the columns (of tbl, or the table
+	// of the nearest ancestor) that the values of the element's
+	// attributes and text are imported to.
+	attrCols map[string]*column
+	textCol  *column
+}
+
+func newNode(name string, parent *node) *node {
+	return &node{name: name, parent: parent, attrFields: map[string]*field{}, text: newField()}
+}
+
+// path returns the path of n, e.g. "/people/person/skill".
+func (n *node) path() string {
+	if n.parent == nil {
+		return "/" + n.name
+	}
+	return n.parent.path() + "/" + n.name
+}
+
+// child returns the named child, or nil.
+func (n *node) child(name string) *node {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// attrField returns the field of the named attribute,
+// adding it if necessary.
+func (n *node) attrField(name string) *field {
+	f, ok := n.attrFields[name]
+	if !ok {
+		f = newField()
+		n.attrFields[name] = f
+		n.attrs = append(n.attrs, name)
+	}
+	return f
+}
+
+// field holds the sampled values of an attribute or of the text
+// of an element, from which the kind of its column is detected.
+type field struct {
+	detector *kind.Detector
+	samples  int
+
+	// found is true if a non-empty value was encountered.
+	found bool
+}
+
+func newField() *field {
+	return &field{detector: kind.NewDetector()}
+}
+
+// sample samples val, unless val is empty or enough values
+// have already been sampled.
+func (f *field) sample(val string) {
+	if val == "" {
+		return
+	}
+
+	f.found = true
+	if f.samples >= driver.Tuning.SampleSize {
+		return
+	}
+
+	f.samples++
+	f.detector.Sample(val)
+}
+
+// isNamespaceDecl returns true if attr is a namespace
+// declaration such as xmlns:dc="...".
+func isNamespaceDecl(attr stdxml.Attr) bool {
+	return attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns"
+}
+
+// scanNodes reads the XML document from r, returning the node
+// of the document element, with the nodes of its descendants.
+func scanNodes(ctx context.Context, r io.Reader) (*node, error) {
+	type frame struct {
+		n      *node
+		counts map[*node]int
+		text   strings.Builder
+	}
+
+	var (
+		root  *node
+		stack []*frame
+	)
+
+	dec := newDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errz.Wrap(err, "xml")
+		}
+
+		switch t := tok.(type) {
+		case stdxml.StartElement:
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			var n *node
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, errz.Errorf("xml: more than one document element: <%s> and <%s>",
+						root.name, t.Name.Local)
+				}
+				root = newNode(t.Name.Local, nil)
+				n = root
+			} else {
+				top := stack[len(stack)-1]
+				n = top.n.child(t.Name.Local)
+				if n == nil {
+					n = newNode(t.Name.Local, top.n)
+					top.n.children = append(top.n.children, n)
+				}
+
+				top.counts[n]++
+				if top.counts[n] > 1 {
+					n.repeats = true
+				}
+			}
+
+			for _, attr := range t.Attr {
+				if !isNamespaceDecl(attr) {
+					n.attrField(attr.Name.Local).sample(attr.Value)
+				}
+			}
+
+			stack = append(stack, &frame{n: n, counts: map[*node]int{}})
+
+		case stdxml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case stdxml.EndElement:
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			top.n.text.sample(strings.TrimSpace(top.text.String()))
+		}
+	}
+
+	if root == nil {
+		return nil, errz.New("xml: no document element")
+	}
+
+	return root, nil
+}
+
+// table models a table inferred from the document.
+type table struct {
+	name   string
+	node   *node
+	parent *table
+
+	// cols holds the table's columns, starting with the
+	// generated key columns.
+	cols []*column
+}
+
+// column models a column of a table.
+type column struct {
+	name string
+
+	// sel is the selector of the column's values, relative to the
+	// table's element, in the style of userdriver.ColMapping. For
+	// example "./address/city", "@id", or "./text()". The selector
+	// of a generated column is empty.
+	sel string
+
+	// field is nil for a generated column.
+	field *field
+
+	// index is the index of the column in its table.
+	index int
+
+	kind    kind.Kind
+	mungeFn kind.MungeFunc
+}
+
+// buildTables returns the tables for the document whose
+// element is root, in the order the tables are encountered
+// in the document, parent tables first.
+func buildTables(root *node) ([]*table, error) {
+	b := &tableBuilder{tblNames: map[string]bool{}}
+	b.walk(root, nil, nil)
+
+	tbls := b.tbls
+	if rootTbl := tbls[0]; len(rootTbl.cols) == 0 {
+		// The document element has no values of its own (it's
+		// just a container), so we don't import it.
+		root.tbl = nil
+		tbls = tbls[1:]
+		for _, tbl := range tbls {
+			if tbl.parent == rootTbl {
+				tbl.parent = nil
+			}
+		}
+	}
+
+	if len(tbls) == 0 {
+		return nil, errz.Errorf("xml: no data found in document element <%s>", root.name)
+	}
+
+	for _, tbl := range tbls {
+		err := tbl.finalize()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tbls, nil
+}
+
+// tableBuilder builds the tables of the document.
+type tableBuilder struct {
+	tbls     []*table
+	tblNames map[string]bool
+}
+
+// walk adds the columns for the attributes and text of n to tbl,
+// or to a new table if n is the document element or repeats,
+// and then recurses into n's children. Arg rel holds the names
+// of the elements from tbl's element (exclusive) to n.
+func (b *tableBuilder) walk(n *node, tbl *table, rel []string) {
+	if n.parent == nil || n.repeats {
+		tbl = b.newTable(n, tbl)
+		rel = nil
+	}
+
+	n.attrCols = map[string]*column{}
+	for _, attr := range n.attrs {
+		col := &column{field: n.attrFields[attr]}
+		if len(rel) == 0 {
+			col.name, col.sel = attr, "@"+attr
+		} else {
+			col.name = strings.Join(rel, colScopeSep) + colScopeSep + attr
+			col.sel = "./" + strings.Join(rel, "/") + "/@" + attr
+		}
+
+		tbl.cols = append(tbl.cols, col)
+		n.attrCols[attr] = col
+	}
+
+	// An element without attributes or children is a
+	// column even if it never has text.
+	isLeaf := len(n.children) == 0
+	switch {
+	case len(rel) == 0 && (n.text.found || (isLeaf && len(n.attrs) == 0)):
+		n.textCol = &column{name: colValue, sel: "./text()", field: n.text}
+	case len(rel) > 0 && (n.text.found || isLeaf):
+		n.textCol = &column{name: strings.Join(rel, colScopeSep), sel: "./" + strings.Join(rel, "/"), field: n.text}
+	}
+
+	if n.textCol != nil {
+		tbl.cols = append(tbl.cols, n.textCol)
+	}
+
+	for _, child := range n.children {
+		childRel := make([]string, len(rel), len(rel)+1)
+		copy(childRel, rel)
+		b.walk(child, tbl, append(childRel, child.name))
+	}
+}
+
+// newTable returns a new table for node n, whose parent table
+// is parent (which may be nil). The table is named for the element,
+// unless that name is already taken, in which case the name of the
+// parent table is prepended, e.g. "magazine_author".
+func (b *tableBuilder) newTable(n *node, parent *table) *table {
+	name := n.name
+	if b.tblNames[name] && parent != nil {
+		name = parent.name + colScopeSep + n.name
+	}
+
+	candidate := name
+	for i := 1; b.tblNames[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+
+	b.tblNames[candidate] = true
+	tbl := &table{name: candidate, node: n, parent: parent}
+	n.tbl = tbl
+	b.tbls = append(b.tbls, tbl)
+	return tbl
+}
+
+// finalize prepends the generated key columns to tbl.cols,
+// ensures that the column names are unique, and detects the
+// kind of each column.
+func (tbl *table) finalize() error {
+	genCols := []*column{{name: colRowID, kind: kind.Int}}
+	if tbl.parent != nil {
+		genCols = append(genCols, &column{name: colParentID, kind: kind.Int})
+	}
+	tbl.cols = append(genCols, tbl.cols...)
+
+	taken := map[string]bool{}
+	for i, col := range tbl.cols {
+		col.index = i
+
+		name := col.name
+		for j := 1; taken[name]; j++ {
+			name = fmt.Sprintf("%s_%d", col.name, j)
+		}
+		taken[name] = true
+		col.name = name
+
+		if col.field == nil {
+			continue
+		}
+
+		var err error
+		col.kind, col.mungeFn, err = col.field.detector.Detect()
+		if err != nil {
+			return errz.Wrapf(err, "xml: table %s: column %s", tbl.name, col.name)
+		}
+
+		if col.kind == kind.Null || col.kind == kind.Unknown {
+			col.kind = kind.Text
+		}
+	}
+
+	return nil
+}
+
+// tableDef returns the def of tbl.
+func (tbl *table) tableDef() *sqlmodel.TableDef {
+	tblDef := &sqlmodel.TableDef{Name: tbl.name, PKColName: colRowID}
+	for _, col := range tbl.cols {
+		colDef := &sqlmodel.ColDef{Table: tblDef, Name: col.name, Kind: col.kind}
+		if col.index == 1 && tbl.parent != nil {
+			colDef.ForeignKey = &sqlmodel.FKConstraint{RefTable: tbl.parent.name, RefCol: colRowID}
+		}
+		tblDef.Cols = append(tblDef.Cols, colDef)
+	}
+
+	return tblDef
+}
@@ -0,0 +1,239 @@
+// Package xml implements the sq driver for XML. Unlike the XML
+// user driver genre (see package xmlud), which requires a driver
+// definition mapping each table and column, this driver infers
+// the tables from the structure of the document:
+//
+//   - The document element, and each element that repeats within
+//     its parent element, is imported as a table.
+//   - The attributes and text of an element are columns of the table
+//     of the element (or of its nearest table ancestor), with a
+//     scoped name such as "address_city" for a nested element.
+//   - Each table has a generated key column "sq_id", and each child
+//     table has a column "sq_parent_id" that references the "sq_id"
+//     of the parent row.
+//
+// The document is read twice: first to infer the tables and the
+// kind of each column, and then to import the rows.
+package xml
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	stdxml "encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// Type is the XML driver type.
+const Type = source.Type("xml")
+
+// Provider implements driver.Provider.
+type Provider struct {
+	Log       lg.Log
+	Scratcher driver.ScratchDatabaseOpener
+	Files     *source.Files
+}
+
+// DriverFor implements driver.Provider.
+func (d *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
+	if typ != Type {
+		return nil, errz.Errorf("unsupported driver type %q", typ)
+	}
+
+	return &driveri{log: d.Log, scratcher: d.Scratcher, files: d.Files}, nil
+}
+
+// Driver implements driver.Driver.
+type driveri struct {
+	log       lg.Log
+	scratcher driver.ScratchDatabaseOpener
+	files     *source.Files
+}
+
+// DriverMetadata implements driver.Driver.
+func (d *driveri) DriverMetadata() driver.Metadata {
+	return driver.Metadata{
+		Type:        Type,
+		Description: "XML",
+		Doc:         "https://www.w3.org/XML/",
+	}
+}
+
+// Open implements driver.Driver.
+func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
+	impl, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return importXML(ctx, d.log, d.files.OpenFunc(src), destDB)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{log: d.log, src: src, impl: impl, files: d.files}, nil
+}
+
+// Truncate implements driver.Driver.
+func (d *driveri) Truncate(ctx context.Context, src *source.Source, tbl string, reset bool) (int64, error) {
+	return 0, errz.Errorf("truncate not supported for %s", Type)
+}
+
+// ValidateSource implements driver.Driver.
+func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
+	if src.Type != Type {
+		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
+	}
+
+	return src, nil
+}
+
+// Ping implements driver.Driver.
+func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
+	d.log.Debugf("driver %q attempting to ping %q", Type, src)
+
+	r, err := d.files.Open(src)
+	if err != nil {
+		return err
+	}
+	defer d.log.WarnIfCloseError(r)
+
+	return nil
+}
+
+// database implements driver.Database.
+type database struct {
+	log   lg.Log
+	src   *source.Source
+	impl  driver.Database
+	files *source.Files
+}
+
+// DB implements driver.Database.
+func (d *database) DB() *sql.DB {
+	return d.impl.DB()
+}
+
+// SQLDriver implements driver.Database.
+func (d *database) SQLDriver() driver.SQLDriver {
+	return d.impl.SQLDriver()
+}
+
+// Source implements driver.Database.
+func (d *database) Source() *source.Source {
+	return d.src
+}
+
+// TableMetadata implements driver.Database.
+func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
+	srcMeta, err := d.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return source.TableFromSourceMetadata(srcMeta, tblName)
+}
+
+// SourceMetadata implements driver.Database.
+func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error) {
+	md, err := d.impl.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Handle = d.src.Handle
+	md.Location = d.src.Location
+	md.SourceType = d.src.Type
+
+	md.Name, err = source.LocationFileName(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Size, err = d.files.Size(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.FQName = md.Name
+	return md, nil
+}
+
+// Close implements driver.Database.
+func (d *database) Close() error {
+	d.log.Debugf("Close database: %s", d.src)
+
+	return errz.Err(d.impl.Close())
+}
+
+var _ source.TypeDetectFunc = DetectXML
+
+// DetectXML implements source.TypeDetectFunc. The data is XML if
+// it consists of a well-formed element, optionally preceded by an
+// XML declaration. Note that HTML documents are left to the HTML
+// driver, which is also why the score is less than 1.0 if there
+// is no XML declaration.
+func DetectXML(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (detected source.Type, score float32, err error) {
+	var r io.ReadCloser
+	r, err = openFn()
+	if err != nil {
+		return source.TypeNone, 0, errz.Err(err)
+	}
+	defer log.WarnIfCloseError(r)
+
+	// We only examine the start of the data.
+	const maxDetectBytes = 1 << 20
+	buf := &bytes.Buffer{}
+	dec := newDecoder(io.TeeReader(io.LimitReader(r, maxDetectBytes), buf))
+
+	var depth, elemCount int
+	for depth > 0 || elemCount == 0 {
+		select {
+		case <-ctx.Done():
+			return source.TypeNone, 0, ctx.Err()
+		default:
+		}
+
+		var tok stdxml.Token
+		tok, err = dec.Token()
+		if err != nil {
+			if buf.Len() == maxDetectBytes && elemCount > 0 {
+				// The data is truncated, but it's XML thus far.
+				break
+			}
+			return source.TypeNone, 0, nil
+		}
+
+		switch t := tok.(type) {
+		case stdxml.StartElement:
+			if elemCount == 0 && strings.EqualFold(t.Name.Local, "html") {
+				return source.TypeNone, 0, nil
+			}
+			elemCount++
+			depth++
+		case stdxml.EndElement:
+			depth--
+		case stdxml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				// Text outside of the document element
+				return source.TypeNone, 0, nil
+			}
+		}
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(buf.Bytes()), []byte("<?xml")) {
+		return Type, 1.0, nil
+	}
+
+	return Type, 0.9, nil
+}
+
+// newDecoder returns a decoder that reads XML from r.
+func newDecoder(r io.Reader) *stdxml.Decoder {
+	dec := stdxml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+	return dec
+}
@@ -0,0 +1,164 @@
+package xml_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/xml"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+)
+
+const (
+	pathPeople  = "drivers/xml/testdata/people.xml"
+	pathCatalog = "drivers/xml/testdata/catalog.xml"
+
+	// pathReadings has no XML declaration.
+	pathReadings = "drivers/xml/testdata/readings.xml"
+)
+
+func newSource(fpath string) *source.Source {
+	return &source.Source{
+		Handle:   "@xml_" + stringz.Uniq8(),
+		Type:     xml.Type,
+		Location: proj.Abs(fpath),
+	}
+}
+
+func TestXML_RepeatingElements(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newSource(pathPeople)
+
+	// The container element <people> has no values of its own,
+	// so it's not a table.
+	srcMeta, err := th.Open(src).SourceMetadata(th.Context)
+	require.NoError(t, err)
+	require.Equal(t, []string{"person", "skill"}, srcMeta.TableNames())
+
+	sink, err := th.QuerySQL(src, "SELECT * FROM person")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sq_id", "id", "name", "born", "address_type", "address_city", "address_country"},
+		sink.RecMeta.Names())
+	require.Equal(t, kind.Date, sink.RecMeta.Kinds()[3])
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, "Marie Curie", testh.Val(sink.Recs[1][2]))
+	require.Equal(t, time.Date(1867, 11, 7, 0, 0, 0, 0, time.UTC), testh.Val(sink.Recs[1][3]))
+	require.Equal(t, "Warsaw", testh.Val(sink.Recs[1][5]))
+	require.Nil(t, sink.Recs[1][6])
+	require.Nil(t, sink.Recs[2][3])
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM skill")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sq_id", "sq_parent_id", "level", "value"}, sink.RecMeta.Names())
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, int64(1), testh.Val(sink.Recs[1][1]))
+	require.Equal(t, "Physics", testh.Val(sink.Recs[1][3]))
+	require.Equal(t, int64(2), testh.Val(sink.Recs[2][1]))
+
+	tblMeta, err := th.Open(src).TableMetadata(th.Context, "skill")
+	require.NoError(t, err)
+	require.Equal(t, &source.ForeignKey{RefTable: "person", RefCol: "sq_id"}, tblMeta.Columns[1].ForeignKey)
+}
+
+func TestXML_RootTable(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	src := newSource(pathCatalog)
+
+	// The document element has attributes, so it's a table, into
+	// which the non-repeating <magazine> element is flattened. The
+	// document is ISO-8859-1 encoded.
+	sink, err := th.QuerySQL(src, "SELECT * FROM catalog")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sq_id", "name", "year", "magazine_title", "magazine_author"}, sink.RecMeta.Names())
+	require.Equal(t, 1, len(sink.Recs))
+	require.Equal(t, "Café Culture", testh.Val(sink.Recs[0][3]))
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM book")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sq_id", "sq_parent_id", "isbn", "title", "price"}, sink.RecMeta.Names())
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, int64(1), testh.Val(sink.Recs[1][1]))
+
+	sink, err = th.QuerySQL(src, "SELECT * FROM author")
+	require.NoError(t, err)
+	require.Equal(t, []string{"sq_id", "sq_parent_id", "value"}, sink.RecMeta.Names())
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, "Helm", testh.Val(sink.Recs[1][2]))
+	require.Equal(t, int64(2), testh.Val(sink.Recs[2][1]))
+}
+
+func TestGenerateDriverDef(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open(proj.Abs(pathPeople))
+	require.NoError(t, err)
+	defer f.Close()
+
+	th := testh.New(t)
+	def, err := xml.GenerateDriverDef(th.Context, f, "")
+	require.NoError(t, err)
+	require.Empty(t, userdriver.ValidateDriverDef(def))
+	require.Equal(t, "people", def.Name)
+	require.Equal(t, "/people", def.Selector)
+	require.Equal(t, 2, len(def.Tables))
+
+	skill := def.Tables[1]
+	require.Equal(t, "/people/person/skill", skill.Selector)
+	require.Equal(t, []string{"sq_id"}, skill.PrimaryKey)
+	require.Equal(t, "../sequence()", skill.Cols[0].Selector)
+	require.Equal(t, "../sq_id", skill.Cols[1].Foreign)
+	require.Equal(t, "@level", skill.Cols[2].Selector)
+	require.Equal(t, "./text()", skill.Cols[3].Selector)
+
+	person := def.Tables[0]
+	col := person.ColBySelector("/people/person/address/@type")
+	require.NotNil(t, col)
+	require.Equal(t, "address_type", col.Name)
+}
+
+func TestDetectXML(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+
+	openFn := func(fpath string) source.FileOpenFunc {
+		return func() (io.ReadCloser, error) { return os.Open(proj.Abs(fpath)) }
+	}
+
+	testCases := []struct {
+		fpath     string
+		want      source.Type
+		wantScore float32
+	}{
+		{fpath: pathPeople, want: xml.Type, wantScore: 1.0},
+		{fpath: pathCatalog, want: xml.Type, wantScore: 1.0},
+		{fpath: pathReadings, want: xml.Type, wantScore: 0.9},
+		{fpath: "drivers/userdriver/xmlud/testdata/basic.rss.xml", want: xml.Type, wantScore: 1.0},
+		{fpath: "drivers/html/testdata/tables.html", want: source.TypeNone},
+		{fpath: "drivers/csv/testdata/sakila-csv/actor.csv", want: source.TypeNone},
+		{fpath: "drivers/json/testdata/actor.json", want: source.TypeNone},
+		{fpath: "drivers/yaml/testdata/actor.yaml", want: source.TypeNone},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.fpath, func(t *testing.T) {
+			typ, score, err := xml.DetectXML(th.Context, th.Log, openFn(tc.fpath))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, typ)
+			require.Equal(t, tc.wantScore, score)
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package yaml
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/neilotoole/lg"
+	"gopkg.in/yaml.v2"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// colScopeSep is used when generating flat column names. Thus
+// an entity "name.first" becomes "name_first".
+const colScopeSep = "_"
+
+// yamlObject is a YAML mapping, with the order of its keys
+// preserved. The values of nested mappings are also yamlObject.
+type yamlObject = yaml.MapSlice
+
+// document is a document of a YAML stream. A document is either
+// a mapping or a sequence of mappings; any other document is
+// an error. An empty document has no objects.
+type document struct {
+	objs []yamlObject
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *document) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	err := unmarshal(&v)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[interface{}]interface{}:
+		// Unmarshal again, preserving the order of the keys.
+		var obj yamlObject
+		err = unmarshal(&obj)
+		if err != nil {
+			return err
+		}
+		d.objs = []yamlObject{obj}
+		return nil
+	case []interface{}:
+		for i, elem := range v {
+			if _, ok := elem.(map[interface{}]interface{}); !ok {
+				return errz.Errorf("yaml: sequence element [%d] is %T, but expected a mapping", i, elem)
+			}
+		}
+		return unmarshal(&d.objs)
+	default:
+		return errz.Errorf("yaml: document is %T, but expected a mapping or a sequence of mappings", v)
+	}
+}
+
+// docDecoder decodes the documents of a YAML stream.
+type docDecoder struct {
+	dec *yaml.Decoder
+
+	// docCount is the number of documents decoded, including
+	// empty documents.
+	docCount int
+}
+
+func newDocDecoder(r io.Reader) *docDecoder {
+	return &docDecoder{dec: yaml.NewDecoder(r)}
+}
+
+// next returns the objects of the next non-empty document,
+// or nil at the end of the stream.
+func (dd *docDecoder) next() ([]yamlObject, error) {
+	for {
+		var doc document
+		err := dd.dec.Decode(&doc)
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, errz.Wrapf(err, "yaml: document %d", dd.docCount)
+		}
+
+		dd.docCount++
+		if len(doc.objs) > 0 {
+			return doc.objs, nil
+		}
+	}
+}
+
+// importYAML imports the YAML stream returned by openFn into
+// a table of scratchDB.
+func importYAML(ctx context.Context, log lg.Log, src *source.Source, openFn source.FileOpenFunc, scratchDB driver.Database) error {
+	r, err := openFn()
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(r)
+
+	flat := newFlattener()
+	dec := newDocDecoder(r)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		var objs []yamlObject
+		objs, err = dec.next()
+		if err != nil {
+			return err
+		}
+
+		if objs == nil {
+			break
+		}
+
+		for _, obj := range objs {
+			err = flat.addObject(obj)
+			if err != nil {
+				return errz.Wrapf(err, "yaml: document %d", dec.docCount-1)
+			}
+		}
+	}
+
+	if len(flat.rows) == 0 {
+		return errz.Errorf("yaml: no records found in %s", src.Handle)
+	}
+
+	tblDef, mungeFns, err := flat.buildTblDef(source.MonotableName)
+	if err != nil {
+		return err
+	}
+
+	err = scratchDB.SQLDriver().CreateTable(ctx, scratchDB.DB(), tblDef)
+	if err != nil {
+		return err
+	}
+
+	return insertRows(ctx, log, scratchDB, tblDef, mungeFns, flat.rows)
+}
+
+// insertRows inserts rows into the table tblDef of scratchDB.
+func insertRows(ctx context.Context, log lg.Log, scratchDB driver.Database, tblDef *sqlmodel.TableDef,
+	mungeFns []kind.MungeFunc, rows []map[string]interface{}) error {
+	conn, err := scratchDB.DB().Conn(ctx)
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer log.WarnIfCloseError(conn)
+
+	drvr := scratchDB.SQLDriver()
+	batchSize := driver.MaxBatchRows(drvr, len(tblDef.Cols))
+	bi, err := driver.NewBatchInsert(ctx, log, drvr, conn, tblDef.Name, tblDef.ColNames(), batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		rec, err := rowToRecord(tblDef, mungeFns, row)
+		if err == nil {
+			err = bi.Munge(rec)
+		}
+		if err != nil {
+			close(bi.RecordCh)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			close(bi.RecordCh)
+			return ctx.Err()
+		case err = <-bi.ErrCh:
+			if err != nil {
+				close(bi.RecordCh)
+				return err
+			}
+
+			// The batch inserter successfully completed
+			break
+		case bi.RecordCh <- rec:
+		}
+	}
+
+	close(bi.RecordCh) // Indicate that we're finished writing records
+
+	err = <-bi.ErrCh // Wait for bi to complete
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Inserted %d rows into %s.%s", bi.Written(), scratchDB.Source().Handle, tblDef.Name)
+	return nil
+}
+
+// rowToRecord returns the record to insert for row.
+func rowToRecord(tblDef *sqlmodel.TableDef, mungeFns []kind.MungeFunc, row map[string]interface{}) ([]interface{}, error) {
+	rec := make([]interface{}, len(tblDef.Cols))
+	for i, col := range tblDef.Cols {
+		val := row[col.Name]
+		if s, ok := val.(string); ok && mungeFns[i] != nil {
+			v, err := mungeFns[i](s)
+			if err != nil {
+				return nil, errz.Wrapf(err, "yaml: column %s", col.Name)
+			}
+			val = v
+		}
+		rec[i] = val
+	}
+
+	return rec, nil
+}
+
+// flattener flattens YAML objects into rows. The fields of nested
+// mappings are flattened into columns with a scoped name, e.g.
+// the field "name" of the mapping "metadata" becomes the column
+// "metadata_name". A sequence value is imported as its JSON text.
+type flattener struct {
+	// colNames holds the column names, in the order in
+	// which they were encountered.
+	colNames []string
+
+	detectors map[string]*kind.Detector
+
+	// rows holds a map of column name to value for each object.
+	rows []map[string]interface{}
+}
+
+func newFlattener() *flattener {
+	return &flattener{detectors: map[string]*kind.Detector{}}
+}
+
+// addObject adds a row for obj.
+func (f *flattener) addObject(obj yamlObject) error {
+	row := map[string]interface{}{}
+	err := f.flatten(row, "", obj)
+	if err != nil {
+		return err
+	}
+
+	f.rows = append(f.rows, row)
+	return nil
+}
+
+func (f *flattener) flatten(row map[string]interface{}, prefix string, obj yamlObject) error {
+	for _, item := range obj {
+		colName := prefix + fmt.Sprint(item.Key)
+
+		if nested, ok := item.Value.(yamlObject); ok {
+			err := f.flatten(row, colName+colScopeSep, nested)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := row[colName]; ok {
+			return errz.Errorf("duplicate column %q (the flattened name of a nested field)", colName)
+		}
+
+		val, err := scalarValue(item.Value)
+		if err != nil {
+			return errz.Wrapf(err, "field %q", colName)
+		}
+
+		detector, ok := f.detectors[colName]
+		if !ok {
+			detector = kind.NewDetector()
+			f.detectors[colName] = detector
+			f.colNames = append(f.colNames, colName)
+		}
+
+		detector.Sample(val)
+		row[colName] = val
+	}
+
+	return nil
+}
+
+// buildTblDef returns the def of table tblName, with a column for
+// each flattened field, the kind of the column detected from the
+// values of the rows. The returned munge funcs (which may be nil)
+// convert the values of each column to the column's kind.
+func (f *flattener) buildTblDef(tblName string) (*sqlmodel.TableDef, []kind.MungeFunc, error) {
+	tblDef := &sqlmodel.TableDef{Name: tblName}
+	mungeFns := make([]kind.MungeFunc, len(f.colNames))
+
+	for i, colName := range f.colNames {
+		var colKind kind.Kind
+		var err error
+		colKind, mungeFns[i], err = f.detectors[colName].Detect()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if colKind == kind.Null || colKind == kind.Unknown {
+			colKind = kind.Text
+		}
+
+		tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{Table: tblDef, Name: colName, Kind: colKind})
+	}
+
+	return tblDef, mungeFns, nil
+}
+
+// scalarValue returns the value to insert for YAML value v, which
+// is not a mapping. A sequence is returned as its JSON text.
+func scalarValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), nil
+	case []interface{}:
+		b, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			return nil, errz.Err(err)
+		}
+		return string(b), nil
+	default:
+		return v, nil
+	}
+}
+
+// jsonValue returns YAML value v converted to a value that can be
+// marshalled by encoding/json: the keys of a mapping must be strings.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yamlObject:
+		m := make(map[string]interface{}, len(v))
+		for _, item := range v {
+			m[fmt.Sprint(item.Key)] = jsonValue(item.Value)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = jsonValue(val)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(v))
+		for i := range v {
+			a[i] = jsonValue(v[i])
+		}
+		return a
+	default:
+		return v
+	}
+}
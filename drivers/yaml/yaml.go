@@ -0,0 +1,240 @@
+// Package yaml implements the sq driver for YAML. The records
+// of a YAML source are imported into a single table: each
+// document of a multi-document stream is a record, unless the
+// document is a sequence of mappings, in which case each
+// element of the sequence is a record. As with the JSON
+// driver, the fields of nested mappings are flattened into
+// columns with a scoped name, e.g. "metadata_name".
+package yaml
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"io/ioutil"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// Type is the YAML driver type.
+const Type = source.Type("yaml")
+
+// Provider implements driver.Provider.
+type Provider struct {
+	Log       lg.Log
+	Scratcher driver.ScratchDatabaseOpener
+	Files     *source.Files
+}
+
+// DriverFor implements driver.Provider.
+func (d *Provider) DriverFor(typ source.Type) (driver.Driver, error) {
+	if typ != Type {
+		return nil, errz.Errorf("unsupported driver type %q", typ)
+	}
+
+	return &driveri{log: d.Log, scratcher: d.Scratcher, files: d.Files}, nil
+}
+
+// Driver implements driver.Driver.
+type driveri struct {
+	log       lg.Log
+	scratcher driver.ScratchDatabaseOpener
+	files     *source.Files
+}
+
+// DriverMetadata implements driver.Driver.
+func (d *driveri) DriverMetadata() driver.Metadata {
+	return driver.Metadata{
+		Type:        Type,
+		Description: "YAML",
+		Doc:         "https://yaml.org",
+		Monotable:   true,
+	}
+}
+
+// Open implements driver.Driver.
+func (d *driveri) Open(ctx context.Context, src *source.Source) (driver.Database, error) {
+	impl, err := driver.OpenImport(ctx, d.scratcher, src, func(ctx context.Context, destDB driver.Database) error {
+		return importYAML(ctx, d.log, src, d.files.OpenFunc(src), destDB)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &database{log: d.log, src: src, impl: impl, files: d.files}, nil
+}
+
+// Truncate implements driver.Driver.
+func (d *driveri) Truncate(ctx context.Context, src *source.Source, tbl string, reset bool) (int64, error) {
+	return 0, errz.Errorf("truncate not supported for %s", Type)
+}
+
+// ValidateSource implements driver.Driver.
+func (d *driveri) ValidateSource(src *source.Source) (*source.Source, error) {
+	if src.Type != Type {
+		return nil, errz.Errorf("expected source type %q but got %q", Type, src.Type)
+	}
+
+	return src, nil
+}
+
+// Ping implements driver.Driver.
+func (d *driveri) Ping(ctx context.Context, src *source.Source) error {
+	d.log.Debugf("driver %q attempting to ping %q", Type, src)
+
+	r, err := d.files.Open(src)
+	if err != nil {
+		return err
+	}
+	defer d.log.WarnIfCloseError(r)
+
+	return nil
+}
+
+// database implements driver.Database.
+type database struct {
+	log   lg.Log
+	src   *source.Source
+	impl  driver.Database
+	files *source.Files
+}
+
+// DB implements driver.Database.
+func (d *database) DB() *sql.DB {
+	return d.impl.DB()
+}
+
+// SQLDriver implements driver.Database.
+func (d *database) SQLDriver() driver.SQLDriver {
+	return d.impl.SQLDriver()
+}
+
+// Source implements driver.Database.
+func (d *database) Source() *source.Source {
+	return d.src
+}
+
+// TableMetadata implements driver.Database.
+func (d *database) TableMetadata(ctx context.Context, tblName string) (*source.TableMetadata, error) {
+	if tblName != source.MonotableName {
+		return nil, errz.Errorf("table name should be %s for YAML, but got: %s",
+			source.MonotableName, tblName)
+	}
+
+	srcMeta, err := d.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// There will only ever be one table for YAML.
+	return srcMeta.Tables[0], nil
+}
+
+// SourceMetadata implements driver.Database.
+func (d *database) SourceMetadata(ctx context.Context) (*source.Metadata, error) {
+	md, err := d.impl.SourceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Handle = d.src.Handle
+	md.Location = d.src.Location
+	md.SourceType = d.src.Type
+
+	md.Name, err = source.LocationFileName(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Size, err = d.files.Size(d.src)
+	if err != nil {
+		return nil, err
+	}
+
+	md.FQName = md.Name
+	return md, nil
+}
+
+// Close implements driver.Database.
+func (d *database) Close() error {
+	d.log.Debugf("Close database: %s", d.src)
+
+	return errz.Err(d.impl.Close())
+}
+
+var _ source.TypeDetectFunc = DetectYAML
+
+// DetectYAML implements source.TypeDetectFunc. The data is YAML if
+// each of the first documents is a mapping or a sequence of mappings.
+// Note that JSON is also valid YAML: input that starts with '{' or
+// '[' is left to the JSON detectors.
+func DetectYAML(ctx context.Context, log lg.Log, openFn source.FileOpenFunc) (detected source.Type, score float32, err error) {
+	var r io.ReadCloser
+	r, err = openFn()
+	if err != nil {
+		return source.TypeNone, 0, errz.Err(err)
+	}
+	defer log.WarnIfCloseError(r)
+
+	// We only examine the start of the data.
+	const maxDetectBytes = 1 << 20
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxDetectBytes))
+	if err != nil {
+		return source.TypeNone, 0, errz.Err(err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return source.TypeNone, 0, nil
+	}
+
+	if len(data) == maxDetectBytes {
+		// The final document is likely truncated, so we only
+		// examine the documents preceding it.
+		i := bytes.LastIndex(data, []byte("\n---"))
+		if i <= 0 {
+			return source.TypeNone, 0, nil
+		}
+		data = data[:i]
+	}
+
+	dec := newDocDecoder(bytes.NewReader(data))
+	var validDocs int
+	for validDocs < driver.Tuning.SampleSize {
+		select {
+		case <-ctx.Done():
+			return source.TypeNone, 0, ctx.Err()
+		default:
+		}
+
+		var objs []yamlObject
+		objs, err = dec.next()
+		if err != nil {
+			// Not YAML, or not YAML that we can import.
+			return source.TypeNone, 0, nil
+		}
+
+		if objs == nil {
+			break
+		}
+
+		validDocs++
+	}
+
+	if validDocs == 0 {
+		return source.TypeNone, 0, nil
+	}
+
+	if bytes.HasPrefix(trimmed, []byte("---")) || bytes.HasPrefix(trimmed, []byte("%YAML")) {
+		return Type, 1.0, nil
+	}
+
+	// Otherwise, the data could be something else that happens to
+	// be valid YAML, so we're not entirely sure.
+	return Type, 0.9, nil
+}
@@ -0,0 +1,100 @@
+package yaml_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/yaml"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/proj"
+)
+
+const (
+	pathActor       = "drivers/yaml/testdata/actor.yaml"
+	pathDeployments = "drivers/yaml/testdata/deployments.yaml"
+)
+
+func newSource(fpath string) *source.Source {
+	return &source.Source{
+		Handle:   "@yaml_" + stringz.Uniq8(),
+		Type:     yaml.Type,
+		Location: proj.Abs(fpath),
+	}
+}
+
+func TestYAML_Sequence(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	sink, err := th.QuerySQL(newSource(pathActor), "SELECT * FROM data")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"actor_id", "first_name", "last_name", "last_update"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Int, kind.Text, kind.Text, kind.Datetime}, sink.RecMeta.Kinds())
+	require.Equal(t, 3, len(sink.Recs))
+	require.Equal(t, int64(2), testh.Val(sink.Recs[1][0]))
+	require.Equal(t, "NICK", testh.Val(sink.Recs[1][1]))
+	require.Equal(t, time.Date(2020, 2, 15, 6, 59, 28, 0, time.UTC), testh.Val(sink.Recs[1][3]))
+}
+
+func TestYAML_MultiDocument(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+	sink, err := th.QuerySQL(newSource(pathDeployments), "SELECT * FROM data")
+	require.NoError(t, err)
+
+	// The fields of nested mappings are flattened.
+	require.Equal(t, []string{"apiVersion", "kind", "metadata_name", "metadata_labels_app",
+		"spec_replicas", "spec_paused", "spec_ports"}, sink.RecMeta.Names())
+	require.Equal(t, []kind.Kind{kind.Text, kind.Text, kind.Text, kind.Text,
+		kind.Int, kind.Bool, kind.Text}, sink.RecMeta.Kinds())
+
+	// The empty document is skipped.
+	require.Equal(t, 2, len(sink.Recs))
+	require.Equal(t, "web", testh.Val(sink.Recs[0][2]))
+	require.Equal(t, "[80,443]", testh.Val(sink.Recs[0][6]))
+	require.Equal(t, "worker", testh.Val(sink.Recs[1][2]))
+	require.Nil(t, sink.Recs[1][3])
+	require.Equal(t, true, testh.Val(sink.Recs[1][5]))
+}
+
+func TestDetectYAML(t *testing.T) {
+	t.Parallel()
+
+	th := testh.New(t)
+
+	openFn := func(fpath string) source.FileOpenFunc {
+		return func() (io.ReadCloser, error) { return os.Open(proj.Abs(fpath)) }
+	}
+
+	testCases := []struct {
+		fpath     string
+		want      source.Type
+		wantScore float32
+	}{
+		{fpath: pathActor, want: yaml.Type, wantScore: 0.9},
+		{fpath: pathDeployments, want: yaml.Type, wantScore: 0.9},
+		{fpath: "drivers/csv/testdata/sakila-csv/actor.csv", want: source.TypeNone},
+		{fpath: "drivers/json/testdata/actor.json", want: source.TypeNone},
+		{fpath: "drivers/json/testdata/actor.jsonl", want: source.TypeNone},
+		{fpath: "drivers/html/testdata/tables.html", want: source.TypeNone},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.fpath, func(t *testing.T) {
+			typ, score, err := yaml.DetectYAML(th.Context, th.Log, openFn(tc.fpath))
+			require.NoError(t, err)
+			require.Equal(t, tc.want, typ)
+			require.Equal(t, tc.wantScore, score)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package libsq
+
+import (
+	"context"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// NewTableDefFromMetadata returns a definition for a table named
+// destTbl, with the columns of tblMeta. The definition is suitable
+// for creating the table in a database of a different type: the
+// column kinds are translated to native types by the destination
+// driver's CreateTable method. A single-column primary key and
+// column nullability are preserved, but other constraints (such as
+// foreign keys and column defaults) are not.
+func NewTableDefFromMetadata(tblMeta *source.TableMetadata, destTbl string) *sqlmodel.TableDef {
+	tblDef := &sqlmodel.TableDef{Name: destTbl}
+
+	if pkCols := tblMeta.PKCols(); len(pkCols) == 1 {
+		tblDef.PKColName = pkCols[0].Name
+	}
+
+	for _, col := range tblMeta.Columns {
+		colKind := col.Kind
+		if colKind == kind.Unknown || colKind == kind.Null {
+			colKind = kind.Text
+		}
+
+		tblDef.Cols = append(tblDef.Cols, &sqlmodel.ColDef{
+			Table:   tblDef,
+			Name:    col.Name,
+			Kind:    colKind,
+			NotNull: !col.Nullable,
+		})
+	}
+
+	return tblDef
+}
+
+// CopyTableData copies the records returned by query against fromDB
+// to the existing table destDB.destTbl, returning the number of rows
+// copied. The records are streamed, so the data need not fit in memory.
+// The names of the columns returned by query must match columns of
+// destTbl.
+func CopyTableData(ctx context.Context, log lg.Log, fromDB driver.Database, query string, destDB driver.Database, destTbl string) (copied int64, err error) {
+	inserter := NewDBWriter(log, destDB, destTbl, driver.Tuning.RecordChSize)
+
+	err = QuerySQL(ctx, log, fromDB, inserter, query)
+	if err != nil {
+		return 0, errz.Wrapf(err, "insert %s.%s failed", destDB.Source().Handle, destTbl)
+	}
+
+	copied, err = inserter.Wait() // Wait for the writer to finish processing
+	if err != nil {
+		return 0, errz.Wrapf(err, "insert %s.%s failed", destDB.Source().Handle, destTbl)
+	}
+
+	log.Debugf("Copied %d rows to %s.%s", copied, destDB.Source().Handle, destTbl)
+	return copied, nil
+}
+
+// SortTablesByFK returns tblMetas ordered such that each table comes
+// after the tables that its foreign keys reference, for those tables
+// that are also in tblMetas. Otherwise, the order of tblMetas is
+// retained. A table's foreign key reference to itself is ignored.
+// It is an error if the foreign key references form a cycle.
+func SortTablesByFK(tblMetas []*source.TableMetadata) ([]*source.TableMetadata, error) {
+	byName := make(map[string]*source.TableMetadata, len(tblMetas))
+	for _, tblMeta := range tblMetas {
+		byName[tblMeta.Name] = tblMeta
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(tblMetas))
+	sorted := make([]*source.TableMetadata, 0, len(tblMetas))
+
+	var visit func(tblMeta *source.TableMetadata) error
+	visit = func(tblMeta *source.TableMetadata) error {
+		switch state[tblMeta.Name] {
+		case visited:
+			return nil
+		case visiting:
+			return errz.Errorf("foreign keys of table %s form a cycle", tblMeta.Name)
+		}
+
+		state[tblMeta.Name] = visiting
+		for _, col := range tblMeta.Columns {
+			if col.ForeignKey == nil || col.ForeignKey.RefTable == tblMeta.Name {
+				continue
+			}
+
+			if refMeta, ok := byName[col.ForeignKey.RefTable]; ok {
+				if err := visit(refMeta); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[tblMeta.Name] = visited
+		sorted = append(sorted, tblMeta)
+		return nil
+	}
+
+	for _, tblMeta := range tblMetas {
+		if err := visit(tblMeta); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
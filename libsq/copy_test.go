@@ -0,0 +1,72 @@
+package libsq_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+func TestSortTablesByFK(t *testing.T) {
+	t.Parallel()
+
+	// newTbl returns a table whose foreign keys reference refTbls.
+	newTbl := func(name string, refTbls ...string) *source.TableMetadata {
+		tblMeta := &source.TableMetadata{Name: name}
+		for _, ref := range refTbls {
+			tblMeta.Columns = append(tblMeta.Columns, &source.ColMetadata{
+				Name:       ref + "_id",
+				ForeignKey: &source.ForeignKey{RefTable: ref, RefCol: "id"},
+			})
+		}
+		return tblMeta
+	}
+
+	testCases := []struct {
+		name    string
+		tbls    []*source.TableMetadata
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no_fks",
+			tbls: []*source.TableMetadata{newTbl("a"), newTbl("b")},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "parents_first",
+			tbls: []*source.TableMetadata{newTbl("payment", "rental", "customer"), newTbl("rental", "customer"), newTbl("customer")},
+			want: []string{"customer", "rental", "payment"},
+		},
+		{
+			name: "self_and_absent_refs",
+			tbls: []*source.TableMetadata{newTbl("staff", "staff", "store"), newTbl("a")},
+			want: []string{"staff", "a"},
+		},
+		{
+			name:    "cycle",
+			tbls:    []*source.TableMetadata{newTbl("a", "b"), newTbl("b", "a")},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			sorted, err := libsq.SortTablesByFK(tc.tbls)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			names := make([]string, len(sorted))
+			for i, tblMeta := range sorted {
+				names[i] = tblMeta.Name
+			}
+			require.Equal(t, tc.want, names)
+		})
+	}
+}
@@ -135,6 +135,102 @@ func SplitSQL(input io.Reader, delim string, moreDelims ...string) (stmts []stri
 	return stmts, types, nil
 }
 
+// SplitStmts splits sql into statements on each semicolon that
+// is not within a quoted string or identifier ('...', "..." or
+// `...`), a comment (-- or /* */), or a Postgres dollar-quoted
+// string ($$...$$ or $tag$...$tag$). Unlike SplitSQL, the semicolon
+// needn't be at the end of a line, e.g. "SELECT * FROM actor; SELECT
+// * FROM film". The returned statements are trimmed of whitespace,
+// and statements that are empty (or consist only of comments) are
+// not returned.
+func SplitStmts(sql string) []string {
+	var stmts []string
+	var start int
+
+	// hasCode is true if the current statement has
+	// content other than whitespace and comments.
+	var hasCode bool
+
+	// skipTo returns the index of the last byte of the first
+	// occurrence of s in sql[from:], or the last index of sql
+	// if s doesn't occur (e.g. an unterminated comment).
+	skipTo := func(from int, s string) int {
+		n := strings.Index(sql[from:], s)
+		if n < 0 {
+			return len(sql) - 1
+		}
+		return from + n + len(s) - 1
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'', c == '"', c == '`':
+			// A doubled quote (e.g. 'it''s') is handled naturally:
+			// the quote closes and then immediately reopens.
+			i = skipTo(i+1, string(c))
+			hasCode = true
+		case strings.HasPrefix(sql[i:], "--"):
+			i = skipTo(i+2, "\n")
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipTo(i+2, "*/")
+		case c == '$':
+			if tag := dollarQuoteTag(sql, i); tag != "" {
+				i = skipTo(i+len(tag), tag)
+			}
+			hasCode = true
+		case c == ';':
+			if hasCode {
+				stmts = append(stmts, strings.TrimSpace(sql[start:i]))
+			}
+			start = i + 1
+			hasCode = false
+		case !unicode.IsSpace(rune(c)):
+			hasCode = true
+		}
+	}
+
+	if hasCode {
+		stmts = append(stmts, strings.TrimSpace(sql[start:]))
+	}
+
+	return stmts
+}
+
+// dollarQuoteTag returns the Postgres dollar-quote tag (e.g. "$$"
+// or "$body$") that starts at sql[i], or empty string if sql[i]
+// doesn't start a dollar quote, e.g. a parameter such as "$1".
+func dollarQuoteTag(sql string, i int) string {
+	if i > 0 && isIdentByte(sql[i-1]) {
+		// The $ is part of an identifier, e.g. "my$tbl".
+		return ""
+	}
+
+	for j := i + 1; j < len(sql); j++ {
+		c := sql[j]
+		switch {
+		case c == '$':
+			return sql[i : j+1]
+		case c >= '0' && c <= '9':
+			if j == i+1 {
+				// A tag can't start with a digit, e.g. "$1".
+				return ""
+			}
+		case !isIdentByte(c):
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// isIdentByte returns true if c can be part of
+// an unquoted identifier.
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= '0' && c <= '9') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= utf8.RuneSelf
+}
+
 // trimTrailingDelims iteratively trims trailing whitespace
 // and delims from line. If delim starts with a letter, care
 // is taken that the delim is only stripped on a word boundary.
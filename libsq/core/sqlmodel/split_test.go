@@ -220,3 +220,36 @@ func TestSplitInput(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitStmts(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		want []string
+	}{
+		{sql: "", want: nil},
+		{sql: " ; ;", want: nil},
+		{sql: "SELECT * FROM actor", want: []string{"SELECT * FROM actor"}},
+		{sql: "SELECT * FROM actor;", want: []string{"SELECT * FROM actor"}},
+		{sql: "SELECT * FROM actor; SELECT * FROM film", want: []string{"SELECT * FROM actor", "SELECT * FROM film"}},
+		{sql: "SELECT * FROM actor;\nSELECT * FROM film;\n", want: []string{"SELECT * FROM actor", "SELECT * FROM film"}},
+		{sql: "SELECT 'a;b'; SELECT 'it''s;'", want: []string{"SELECT 'a;b'", "SELECT 'it''s;'"}},
+		{sql: `SELECT "a;b" FROM t; SELECT ` + "`c;d`" + ` FROM t`, want: []string{`SELECT "a;b" FROM t`, "SELECT `c;d` FROM t"}},
+		{sql: "SELECT 1; -- one; two\nSELECT 2", want: []string{"SELECT 1", "-- one; two\nSELECT 2"}},
+		{sql: "SELECT 1; /* one; two */ SELECT 2; /* trailing; */", want: []string{"SELECT 1", "/* one; two */ SELECT 2"}},
+		{sql: "SELECT 1; -- trailing", want: []string{"SELECT 1"}},
+		{
+			sql:  "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql; SELECT f()",
+			want: []string{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT f()"},
+		},
+		{sql: "SELECT $body$a;b$body$; SELECT 2", want: []string{"SELECT $body$a;b$body$", "SELECT 2"}},
+		{sql: "SELECT * FROM t WHERE a = $1; SELECT 2", want: []string{"SELECT * FROM t WHERE a = $1", "SELECT 2"}},
+		{sql: "SELECT my$col; SELECT 2", want: []string{"SELECT my$col", "SELECT 2"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.sql, func(t *testing.T) {
+			require.Equal(t, tc.want, sqlmodel.SplitStmts(tc.sql))
+		})
+	}
+}
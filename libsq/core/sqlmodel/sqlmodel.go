@@ -26,6 +26,10 @@ type TableDef struct {
 
 	// Cols is the table's column definitions.
 	Cols []*ColDef `json:"cols"`
+
+	// UniqueCols, if non-empty, are the columns of a (possibly
+	// composite) unique constraint on the table.
+	UniqueCols []string `json:"unique_cols,omitempty"`
 }
 
 // NewTableDef is a convenience constructor for creating
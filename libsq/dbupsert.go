@@ -0,0 +1,294 @@
+package libsq
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+)
+
+// DBUpsertWriter implements RecordWriter, upserting records to a
+// database table: if a row with the same values of the key columns
+// already exists in the table, that row is updated; otherwise
+// the record is inserted as a new row.
+//
+// If the destination driver implements driver.Upserter, the native
+// upsert statement is used. Otherwise, an UPDATE statement is
+// executed for each record, followed by an INSERT if no row
+// was updated.
+type DBUpsertWriter struct {
+	log      lg.Log
+	wg       *sync.WaitGroup
+	cancelFn context.CancelFunc
+	destDB   driver.Database
+	destTbl  string
+	keyCols  []string
+	recordCh chan sqlz.Record
+	errCh    chan error
+	errs     []error
+
+	// upsertExecer is non-nil if the driver implements driver.Upserter.
+	upsertExecer *driver.StmtExecer
+
+	// updateExecer and insertExecer are used if the driver
+	// does not implement driver.Upserter.
+	updateExecer *driver.StmtExecer
+	insertExecer *driver.StmtExecer
+
+	// keyIndices holds the index of each of keyCols in the record.
+	keyIndices []int
+
+	inserted  int64
+	updated   int64
+	unchanged int64
+
+	preWriteHooks []DBWriterPreWriteHook
+}
+
+// NewDBUpsertWriter returns a new writer than implements RecordWriter.
+// The writer upserts records from recordCh to destTbl in destDB,
+// matching existing rows by keyCols. The recChSize param controls
+// the size of recordCh returned by the writer's Open method.
+func NewDBUpsertWriter(log lg.Log, destDB driver.Database, destTbl string, keyCols []string, recChSize int, preWriteHooks ...DBWriterPreWriteHook) *DBUpsertWriter {
+	return &DBUpsertWriter{
+		log:           log,
+		destDB:        destDB,
+		destTbl:       destTbl,
+		keyCols:       keyCols,
+		recordCh:      make(chan sqlz.Record, recChSize),
+		errCh:         make(chan error, 3),
+		wg:            &sync.WaitGroup{},
+		preWriteHooks: preWriteHooks,
+	}
+}
+
+// Open implements RecordWriter.
+func (w *DBUpsertWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMeta sqlz.RecordMeta) (chan<- sqlz.Record, <-chan error, error) {
+	w.cancelFn = cancelFn
+
+	if len(w.keyCols) == 0 {
+		return nil, nil, errz.Errorf("upsert %s.%s: no key columns specified", w.destDB.Source().Handle, w.destTbl)
+	}
+
+	colNames := recMeta.Names()
+	for _, keyCol := range w.keyCols {
+		i := stringz.SliceIndex(colNames, keyCol)
+		if i < 0 {
+			return nil, nil, errz.Errorf("upsert %s.%s: key column %q not found in records (%s)",
+				w.destDB.Source().Handle, w.destTbl, keyCol, strings.Join(colNames, driver.Comma))
+		}
+		w.keyIndices = append(w.keyIndices, i)
+	}
+
+	tx, err := w.destDB.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, errz.Wrapf(err, "failed to open tx for %s.%s", w.destDB.Source().Handle, w.destTbl)
+	}
+
+	for _, hook := range w.preWriteHooks {
+		err = hook(ctx, recMeta, w.destDB, tx)
+		if err != nil {
+			w.rollback(tx, err)
+			return nil, nil, err
+		}
+	}
+
+	err = w.prepare(ctx, tx, colNames)
+	if err != nil {
+		w.rollback(tx, err)
+		return nil, nil, err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		var numRows int64
+		defer func() {
+			close(w.errCh)
+			w.wg.Done()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				w.rollback(tx, ctx.Err())
+				return
+
+			case rec := <-w.recordCh:
+				if rec == nil {
+					// recordCh has been closed, so it's time to commit.
+					commitErr := errz.Err(tx.Commit())
+					if commitErr != nil {
+						w.log.Error(commitErr)
+						w.addErrs(commitErr)
+					} else {
+						w.log.Debugf("Tx commit success for %s.%s", w.destDB.Source().Handle, w.destTbl)
+					}
+
+					return
+				}
+
+				numRows++
+				err = w.upsertOrReject(ctx, tx, numRows, rec)
+				if err != nil {
+					w.rollback(tx, err)
+					return
+				}
+			}
+		}
+	}()
+
+	return w.recordCh, w.errCh, nil
+}
+
+// prepare prepares the statements used to upsert records.
+func (w *DBUpsertWriter) prepare(ctx context.Context, tx sqlz.DB, colNames []string) error {
+	drvr := w.destDB.SQLDriver()
+
+	var err error
+	if upserter, ok := drvr.(driver.Upserter); ok {
+		w.upsertExecer, err = upserter.PrepareUpsertStmt(ctx, tx, w.destTbl, colNames, w.keyCols)
+		return err
+	}
+
+	whereBuilder := strings.Builder{}
+	for i, keyCol := range w.keyCols {
+		if i > 0 {
+			whereBuilder.WriteString(" AND ")
+		}
+		whereBuilder.WriteString(drvr.Dialect().Enquote(keyCol))
+		whereBuilder.WriteString(" = ?")
+	}
+
+	w.updateExecer, err = drvr.PrepareUpdateStmt(ctx, tx, w.destTbl, colNames, whereBuilder.String())
+	if err != nil {
+		return err
+	}
+
+	w.insertExecer, err = drvr.PrepareInsertStmt(ctx, tx, w.destTbl, colNames, 1)
+	return err
+}
+
+// upsertOrReject upserts rec, which is the row'th record. If ctx
+// has a driver.Rejects, a failing rec is rejected instead of
+// returning an error, until the rejects error budget is exhausted.
+func (w *DBUpsertWriter) upsertOrReject(ctx context.Context, tx *sql.Tx, row int64, rec sqlz.Record) error {
+	rejects := driver.RejectsFrom(ctx)
+	if rejects == nil {
+		return w.doUpsert(ctx, rec)
+	}
+
+	upsertErr, err := driver.ExecSavepoint(ctx, w.destDB.SQLDriver(), tx, func() error {
+		return w.doUpsert(ctx, rec)
+	})
+	if err != nil || upsertErr == nil {
+		return err
+	}
+
+	return rejects.Reject(w.destTbl, row, rec, upsertErr)
+}
+
+func (w *DBUpsertWriter) doUpsert(ctx context.Context, rec sqlz.Record) error {
+	if w.upsertExecer != nil {
+		err := w.upsertExecer.Munge(rec)
+		if err != nil {
+			return err
+		}
+
+		affected, err := w.upsertExecer.Exec(ctx, rec...)
+		if err != nil {
+			return err
+		}
+
+		switch affected {
+		case driver.UpsertInserted:
+			w.inserted++
+		case driver.UpsertUnchanged:
+			w.unchanged++
+		default:
+			w.updated++
+		}
+		return nil
+	}
+
+	err := w.updateExecer.Munge(rec)
+	if err != nil {
+		return err
+	}
+
+	args := make([]interface{}, len(rec), len(rec)+len(w.keyIndices))
+	copy(args, rec)
+	for _, i := range w.keyIndices {
+		args = append(args, rec[i])
+	}
+
+	affected, err := w.updateExecer.Exec(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if affected > 0 {
+		w.updated++
+		return nil
+	}
+
+	// No existing row was updated, so we insert the record.
+	_, err = w.insertExecer.Exec(ctx, rec...)
+	if err != nil {
+		return err
+	}
+
+	w.inserted++
+	return nil
+}
+
+// Wait implements RecordWriter. The returned written value is the
+// total of inserted, updated and unchanged rows: use the Counts
+// method to get the individual values.
+func (w *DBUpsertWriter) Wait() (written int64, err error) {
+	w.wg.Wait()
+	if w.cancelFn != nil {
+		w.cancelFn()
+	}
+
+	return w.inserted + w.updated + w.unchanged, errz.Combine(w.errs...)
+}
+
+// Counts returns the number of rows inserted and updated, and the
+// number of existing rows that were unchanged by their record. Only
+// some drivers report unchanged rows: for others, such a row counts
+// as updated. Counts should only be invoked after Wait returns.
+func (w *DBUpsertWriter) Counts() (inserted, updated, unchanged int64) {
+	return w.inserted, w.updated, w.unchanged
+}
+
+// addErrs handles any non-nil err in errs by appending it to w.errs
+// and sending it on w.errCh.
+func (w *DBUpsertWriter) addErrs(errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			w.errs = append(w.errs, err)
+			w.errCh <- err
+		}
+	}
+}
+
+// rollback rolls back tx. Note that rollback or commit of the tx
+// will close all of the tx's prepared statements, so we don't
+// need to close those manually.
+func (w *DBUpsertWriter) rollback(tx *sql.Tx, causeErrs ...error) {
+	w.log.Errorf("failed to upsert to %s.%s: tx rollback due to: %s",
+		w.destDB.Source().Handle, w.destTbl, causeErrs[0])
+
+	rollbackErr := errz.Err(tx.Rollback())
+	w.log.WarnIfError(rollbackErr)
+
+	w.addErrs(causeErrs...)
+	w.addErrs(rollbackErr)
+}
@@ -15,6 +15,15 @@ import (
 
 // DBWriter implements RecordWriter, writing
 // records to a database table.
+//
+// Typically the records are written in a single tx, along with the
+// changes made by the pre-write hooks (such as creating the table),
+// and thus the write is all-or-nothing. However, if the driver
+// implements driver.BulkLoader (and bulk load is available, and not
+// disabled via DisableBulkLoad), the hooks' tx is committed, and the
+// records are then loaded in a separate tx. If the load fails, any
+// table created by the hooks is dropped, but other changes made by
+// the hooks are not undone.
 type DBWriter struct {
 	log      lg.Log
 	wg       *sync.WaitGroup
@@ -31,6 +40,15 @@ type DBWriter struct {
 	// needed to perform actions before insertion, such as creating
 	// the dest table on the fly.
 	preWriteHooks []DBWriterPreWriteHook
+
+	// noBulkLoad, if true, prevents the records being bulk
+	// loaded. See DisableBulkLoad.
+	noBulkLoad bool
+
+	// dropOnFail is true if the records are bulk loaded to a table
+	// that was created by the pre-write hooks, in which case the
+	// table is dropped if the load fails.
+	dropOnFail bool
 }
 
 // DBWriterPreWriteHook is a function that is invoked before DBWriter
@@ -40,8 +58,17 @@ type DBWriterPreWriteHook func(ctx context.Context, recMeta sqlz.RecordMeta, des
 // DBWriterCreateTableIfNotExistsHook returns a hook that
 // creates destTblName if it does not exist.
 func DBWriterCreateTableIfNotExistsHook(destTblName string) DBWriterPreWriteHook {
+	return DBWriterCreateUniqueTableIfNotExistsHook(destTblName, nil)
+}
+
+// DBWriterCreateUniqueTableIfNotExistsHook returns a hook that
+// creates destTblName, with a unique constraint on uniqueCols
+// (if non-empty), if it does not exist.
+func DBWriterCreateUniqueTableIfNotExistsHook(destTblName string, uniqueCols []string) DBWriterPreWriteHook {
 	return func(ctx context.Context, recMeta sqlz.RecordMeta, destDB driver.Database, tx sqlz.DB) error {
-		tblExists, err := destDB.SQLDriver().TableExists(ctx, destDB.DB(), destTblName)
+		// Note that we check tx, as an earlier hook
+		// may have dropped the table in tx.
+		tblExists, err := destDB.SQLDriver().TableExists(ctx, tx, destTblName)
 		if err != nil {
 			return errz.Err(err)
 		}
@@ -53,6 +80,7 @@ func DBWriterCreateTableIfNotExistsHook(destTblName string) DBWriterPreWriteHook
 		destColNames := recMeta.Names()
 		destColKinds := recMeta.Kinds()
 		destTblDef := sqlmodel.NewTableDef(destTblName, destColNames, destColKinds)
+		destTblDef.UniqueCols = uniqueCols
 
 		err = destDB.SQLDriver().CreateTable(ctx, tx, destTblDef)
 		if err != nil {
@@ -63,6 +91,44 @@ func DBWriterCreateTableIfNotExistsHook(destTblName string) DBWriterPreWriteHook
 	}
 }
 
+// DBWriterDeleteRowsHook returns a hook that deletes all rows
+// of destTblName, if it exists. Unlike SQLDriver.Truncate, the
+// rows are deleted in the writer's tx, and thus are restored if
+// the write fails. Note that DBWriter.DisableBulkLoad should be
+// invoked, as a bulk load commits the tx before loading.
+func DBWriterDeleteRowsHook(destTblName string) DBWriterPreWriteHook {
+	return func(ctx context.Context, recMeta sqlz.RecordMeta, destDB driver.Database, tx sqlz.DB) error {
+		tblExists, err := destDB.SQLDriver().TableExists(ctx, tx, destTblName)
+		if err != nil || !tblExists {
+			return errz.Err(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+destDB.SQLDriver().Dialect().Enquote(destTblName))
+		if err != nil {
+			return errz.Wrapf(err, "failed to delete rows of %s.%s", destDB.Source().Handle, destTblName)
+		}
+
+		return nil
+	}
+}
+
+// DBWriterDropTableHook returns a hook that drops destTblName, if
+// it exists, in the writer's tx. Typically this hook is followed
+// by a hook that creates the table. As with DBWriterDeleteRowsHook,
+// DBWriter.DisableBulkLoad should be invoked. Note that some
+// databases (e.g. MySQL) implicitly commit DDL statements, in
+// which case the drop can't be rolled back.
+func DBWriterDropTableHook(destTblName string) DBWriterPreWriteHook {
+	return func(ctx context.Context, recMeta sqlz.RecordMeta, destDB driver.Database, tx sqlz.DB) error {
+		err := destDB.SQLDriver().DropTable(ctx, tx, destTblName, true)
+		if err != nil {
+			return errz.Wrapf(err, "failed to drop table %s.%s", destDB.Source().Handle, destTblName)
+		}
+
+		return nil
+	}
+}
+
 // NewDBWriter returns a new writer than implements RecordWriter.
 // The writer writes records from recordCh to destTbl
 // in destDB. The recChSize param controls the size of recordCh
@@ -84,6 +150,16 @@ func NewDBWriter(log lg.Log, destDB driver.Database, destTbl string, recChSize i
 	// ctx is done, we send ctx.Err, followed by any rollback err.
 }
 
+// DisableBulkLoad prevents w from bulk loading the records, even if
+// the driver implements driver.BulkLoader. A bulk load commits the
+// changes made by the pre-write hooks before loading the records, so
+// bulk loading must be disabled if those changes (e.g. deleting the
+// existing rows) must be rolled back when the write fails. It must
+// be invoked before Open.
+func (w *DBWriter) DisableBulkLoad() {
+	w.noBulkLoad = true
+}
+
 // Open implements RecordWriter.
 func (w *DBWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMeta sqlz.RecordMeta) (chan<- sqlz.Record, <-chan error, error) {
 	w.cancelFn = cancelFn
@@ -94,6 +170,12 @@ func (w *DBWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMet
 		return nil, nil, errz.Wrapf(err, "failed to open tx for %s.%s", w.destDB.Source().Handle, w.destTbl)
 	}
 
+	tblExisted, err := w.destDB.SQLDriver().TableExists(ctx, tx, w.destTbl)
+	if err != nil {
+		w.rollback(tx, err)
+		return nil, nil, err
+	}
+
 	for _, hook := range w.preWriteHooks {
 		err = hook(ctx, recMeta, w.destDB, tx)
 		if err != nil {
@@ -102,8 +184,7 @@ func (w *DBWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMet
 		}
 	}
 
-	batchSize := driver.MaxBatchRows(w.destDB.SQLDriver(), len(recMeta.Names()))
-	w.bi, err = driver.NewBatchInsert(ctx, w.log, w.destDB.SQLDriver(), tx, w.destTbl, recMeta.Names(), batchSize)
+	tx, err = w.newBatchInsert(ctx, tx, recMeta, !tblExisted)
 	if err != nil {
 		w.rollback(tx, err)
 		return nil, nil, err
@@ -144,6 +225,12 @@ func (w *DBWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMet
 						return
 					}
 
+					if tx == nil {
+						// The records were bulk loaded, which is
+						// performed in its own tx.
+						return
+					}
+
 					commitErr := errz.Err(tx.Commit())
 					if commitErr != nil {
 						w.log.Error(commitErr)
@@ -173,6 +260,45 @@ func (w *DBWriter) Open(ctx context.Context, cancelFn context.CancelFunc, recMet
 	return w.recordCh, w.errCh, nil
 }
 
+// newBatchInsert sets w.bi. If the driver implements
+// driver.BulkLoader, and bulk load is available, tx is committed
+// (the bulk load is performed in its own tx), and the returned tx
+// is nil. Otherwise, w.bi uses tx, and tx is returned. On error,
+// the returned tx is non-nil if tx should be rolled back. Arg
+// tblCreated is true if the pre-write hooks created the table.
+func (w *DBWriter) newBatchInsert(ctx context.Context, tx *sql.Tx, recMeta sqlz.RecordMeta, tblCreated bool) (*sql.Tx, error) {
+	drvr := w.destDB.SQLDriver()
+	colNames := recMeta.Names()
+
+	// Bulk load is all-or-nothing, so it's not used if bad rows
+	// are to be rejected.
+	if bulkLoader, ok := drvr.(driver.BulkLoader); ok && !w.noBulkLoad && driver.RejectsFrom(ctx) == nil {
+		canBulkLoad, err := bulkLoader.CanBulkLoad(ctx, tx, w.destTbl, colNames)
+		if err != nil {
+			return tx, err
+		}
+
+		if canBulkLoad {
+			// The pre-write hooks (e.g. creating the dest table) must
+			// be committed before bulk loading on another connection.
+			err = errz.Err(tx.Commit())
+			if err != nil {
+				return nil, err
+			}
+			w.dropOnFail = tblCreated
+
+			w.log.Debugf("Bulk loading %s.%s", w.destDB.Source().Handle, w.destTbl)
+			w.bi, err = bulkLoader.NewBulkLoad(ctx, w.log, w.destDB.DB(), w.destTbl, colNames)
+			return nil, err
+		}
+	}
+
+	var err error
+	batchSize := driver.MaxBatchRows(drvr, len(colNames))
+	w.bi, err = driver.NewBatchInsert(ctx, w.log, drvr, tx, w.destTbl, colNames, batchSize)
+	return tx, err
+}
+
 // Wait implements RecordWriter.
 func (w *DBWriter) Wait() (written int64, err error) {
 	w.wg.Wait()
@@ -200,9 +326,24 @@ func (w *DBWriter) addErrs(errs ...error) {
 
 // rollback rolls back tx. Note that rollback or commit of the tx
 // will close all of the tx's prepared statements, so we don't
-// need to close those manually.
+// need to close those manually. If tx is nil (because the records
+// are bulk loaded in their own tx), there's nothing to roll back,
+// but the table is dropped if it was created by this write.
 func (w *DBWriter) rollback(tx *sql.Tx, causeErrs ...error) {
 	// Guaranteed to be at least one causeErr
+	if tx == nil {
+		w.log.Errorf("failed to insert to %s.%s: %s", w.destDB.Source().Handle, w.destTbl, causeErrs[0])
+		w.addErrs(causeErrs...)
+
+		if w.dropOnFail {
+			// ctx may be done, so we use a new ctx.
+			dropErr := w.destDB.SQLDriver().DropTable(context.Background(), w.destDB.DB(), w.destTbl, true)
+			w.log.WarnIfError(dropErr)
+			w.addErrs(dropErr)
+		}
+		return
+	}
+
 	w.log.Errorf("failed to insert to %s.%s: tx rollback due to: %s",
 		w.destDB.Source().Handle, w.destTbl, causeErrs[0])
 
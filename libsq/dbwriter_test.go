@@ -0,0 +1,145 @@
+package libsq_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/neilotoole/lg"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/drivers/sqlite3"
+	"github.com/neilotoole/sq/libsq"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+	"github.com/neilotoole/sq/testh"
+	"github.com/neilotoole/sq/testh/sakila"
+)
+
+// bulkLoaderDB wraps a driver.Database, such that its SQLDriver
+// is a bulkLoaderDriver.
+type bulkLoaderDB struct {
+	driver.Database
+	drvr *bulkLoaderDriver
+}
+
+func (d *bulkLoaderDB) SQLDriver() driver.SQLDriver {
+	return d.drvr
+}
+
+// bulkLoaderDriver wraps a driver.SQLDriver, implementing
+// driver.BulkLoader with a bulk load that always fails.
+type bulkLoaderDriver struct {
+	driver.SQLDriver
+	canBulkLoad  bool
+	canInvoked   bool
+	loadInvoked  bool
+	errBulkLoad  error
+	loadRecCount int
+}
+
+func (d *bulkLoaderDriver) CanBulkLoad(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string) (bool, error) {
+	d.canInvoked = true
+	return d.canBulkLoad, nil
+}
+
+func (d *bulkLoaderDriver) NewBulkLoad(ctx context.Context, log lg.Log, db *sql.DB, destTbl string, destColNames []string) (*driver.BatchInsert, error) {
+	d.loadInvoked = true
+	mungeFn := func(vals sqlz.Record) error { return nil }
+	loadFn := func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		for range recCh {
+			d.loadRecCount++
+		}
+		return 0, d.errBulkLoad
+	}
+
+	return driver.NewBulkLoadBatchInsert(ctx, mungeFn, loadFn), nil
+}
+
+func TestDBWriter_BulkLoad(t *testing.T) {
+	t.Parallel()
+
+	const destTbl = "actor_copy"
+
+	testCases := []struct {
+		name        string
+		canBulkLoad bool
+		disable     bool
+		tblExists   bool
+
+		wantCanInvoked  bool
+		wantLoadInvoked bool
+		wantErr         bool
+		wantTblExists   bool
+	}{
+		{name: "fallback", canBulkLoad: false, wantCanInvoked: true, wantTblExists: true},
+		{name: "disabled", canBulkLoad: true, disable: true, wantTblExists: true},
+		{name: "fail_drops_created_tbl", canBulkLoad: true, wantCanInvoked: true, wantLoadInvoked: true, wantErr: true},
+		{
+			name: "fail_keeps_existing_tbl", canBulkLoad: true, tblExists: true,
+			wantCanInvoked: true, wantLoadInvoked: true, wantErr: true, wantTblExists: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			th := testh.New(t)
+			fromDB := th.Open(th.Source(sakila.CSVActor))
+			destSrc := &source.Source{
+				Handle:   "@dest",
+				Type:     sqlite3.Type,
+				Location: "sqlite3://" + filepath.Join(t.TempDir(), "dest.db"),
+			}
+			destDB := th.Open(destSrc)
+
+			if tc.tblExists {
+				tblMeta, err := fromDB.TableMetadata(th.Context, "data")
+				require.NoError(t, err)
+				tblDef := libsq.NewTableDefFromMetadata(tblMeta, destTbl)
+				require.NoError(t, destDB.SQLDriver().CreateTable(th.Context, destDB.DB(), tblDef))
+			}
+
+			drvr := &bulkLoaderDriver{
+				SQLDriver:   destDB.SQLDriver(),
+				canBulkLoad: tc.canBulkLoad,
+				errBulkLoad: errors.New("bulk load failed"),
+			}
+			bulkDB := &bulkLoaderDB{Database: destDB, drvr: drvr}
+
+			w := libsq.NewDBWriter(th.Log, bulkDB, destTbl, driver.Tuning.RecordChSize,
+				libsq.DBWriterCreateTableIfNotExistsHook(destTbl))
+			if tc.disable {
+				w.DisableBulkLoad()
+			}
+
+			err := libsq.QuerySQL(th.Context, th.Log, fromDB, w, "SELECT * FROM data")
+			require.NoError(t, err)
+			written, err := w.Wait()
+
+			require.Equal(t, tc.wantCanInvoked, drvr.canInvoked)
+			require.Equal(t, tc.wantLoadInvoked, drvr.loadInvoked)
+			if tc.wantLoadInvoked {
+				require.Equal(t, sakila.TblActorCount, drvr.loadRecCount)
+			}
+
+			exists, existsErr := destDB.SQLDriver().TableExists(th.Context, destDB.DB(), destTbl)
+			require.NoError(t, existsErr)
+			require.Equal(t, tc.wantTblExists, exists)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, int64(sakila.TblActorCount), written)
+			require.Equal(t, int64(sakila.TblActorCount), th.RowCount(destSrc, destTbl))
+		})
+	}
+}
@@ -0,0 +1,449 @@
+// Package diff implements comparison of table data (and
+// schema) between sources.
+package diff
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/driver"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// Table identifies a table in a database.
+type Table struct {
+	DB   driver.Database
+	Name string
+}
+
+// String returns @HANDLE.TABLE.
+func (t Table) String() string {
+	return t.DB.Source().Handle + "." + t.Name
+}
+
+// Status is the diff status of a row, table or column.
+type Status string
+
+const (
+	// StatusAdded indicates an element that is present on the
+	// right side but not the left.
+	StatusAdded Status = "added"
+
+	// StatusRemoved indicates an element that is present on the
+	// left side but not the right.
+	StatusRemoved Status = "removed"
+
+	// StatusChanged indicates an element that is present on both
+	// sides, but which differs.
+	StatusChanged Status = "changed"
+)
+
+// RowDiff describes a row that differs between the left
+// and right tables.
+type RowDiff struct {
+	// Status is the diff status of the row.
+	Status Status `json:"status"`
+
+	// Key holds the values of the key columns, in the
+	// same order as DataSummary.Key.
+	Key []interface{} `json:"key"`
+
+	// Cols holds the columns that differ. For an added or
+	// removed row, Cols holds all of the compared columns, with
+	// ColDiff.Left or ColDiff.Right (respectively) being nil.
+	Cols []ColDiff `json:"cols"`
+}
+
+// ColDiff describes a column value that differs between the
+// left and right tables.
+type ColDiff struct {
+	Name  string      `json:"name"`
+	Left  interface{} `json:"left"`
+	Right interface{} `json:"right"`
+}
+
+// DataSummary summarizes a data diff.
+type DataSummary struct {
+	// Left is the left table, e.g. "@sakila_sl3.actor".
+	Left string `json:"left"`
+
+	// Right is the right table, e.g. "@sakila_pg.actor".
+	Right string `json:"right"`
+
+	// Key is the names of the columns that identify a row.
+	Key []string `json:"key"`
+
+	// Cols is the names of the compared columns, that is, the
+	// columns present in both tables.
+	Cols []string `json:"cols"`
+
+	// LeftOnlyCols is the names of the columns that are only
+	// present in the left table. These columns are not compared.
+	LeftOnlyCols []string `json:"left_only_cols,omitempty"`
+
+	// RightOnlyCols is the names of the columns that are only
+	// present in the right table. These columns are not compared.
+	RightOnlyCols []string `json:"right_only_cols,omitempty"`
+
+	LeftRows  int64 `json:"left_rows"`
+	RightRows int64 `json:"right_rows"`
+	Same      int64 `json:"same"`
+	Changed   int64 `json:"changed"`
+	Added     int64 `json:"added"`
+	Removed   int64 `json:"removed"`
+}
+
+// Differs returns true if any rows were added, removed or changed.
+func (s *DataSummary) Differs() bool {
+	return s.Changed+s.Added+s.Removed > 0
+}
+
+// DataHandler receives the results of a data diff.
+type DataHandler interface {
+	// Open is invoked before the rows are compared. The
+	// summary counts are not yet populated.
+	Open(summary *DataSummary) error
+
+	// RowDiff is invoked for each row that differs, in key order.
+	RowDiff(rd *RowDiff) error
+
+	// Close is invoked after all rows have been compared.
+	Close(summary *DataSummary) error
+}
+
+// Data compares the data of table left with table right, invoking
+// h for each row that differs. Rows are matched on the values
+// of keyCols; if keyCols is empty, the primary key of the left table
+// is used. Columns present in only one of the tables are not compared.
+//
+// Each table is read via a query ordered by the key columns, and the
+// two result sets are merged as they are read. Thus tables larger
+// than memory can be compared. Text key columns are ordered by code
+// point (see driver.Dialect.BinaryOrder) rather than by the column's
+// collation, such that both databases order the keys consistently.
+// If the rows are nonetheless out of order, an error is returned.
+//
+// Values are compared in a normalized form determined by the kind
+// of each column, such that for example int 1 in one database is
+// equal to decimal 1.0 in another.
+func Data(ctx context.Context, log lg.Log, left, right Table, keyCols []string, h DataHandler) error {
+	leftMeta, err := left.DB.TableMetadata(ctx, left.Name)
+	if err != nil {
+		return err
+	}
+
+	rightMeta, err := right.DB.TableMetadata(ctx, right.Name)
+	if err != nil {
+		return err
+	}
+
+	summary := &DataSummary{Left: left.String(), Right: right.String()}
+
+	if len(keyCols) == 0 {
+		for _, col := range leftMeta.PKCols() {
+			keyCols = append(keyCols, col.Name)
+		}
+
+		if len(keyCols) == 0 {
+			return errz.Errorf("diff: table %s has no primary key: specify the key columns explicitly", left)
+		}
+	}
+	summary.Key = keyCols
+
+	// The compared cols are the cols in both tables, in the order
+	// of the left table. The key cols come first.
+	var cols []string
+	var kinds []kind.Kind
+	cols = append(cols, keyCols...)
+	for _, col := range leftMeta.Columns {
+		if rightMeta.Column(col.Name) == nil {
+			summary.LeftOnlyCols = append(summary.LeftOnlyCols, col.Name)
+			continue
+		}
+
+		if !stringz.InSlice(keyCols, col.Name) {
+			cols = append(cols, col.Name)
+		}
+	}
+
+	for _, col := range rightMeta.Columns {
+		if leftMeta.Column(col.Name) == nil {
+			summary.RightOnlyCols = append(summary.RightOnlyCols, col.Name)
+		}
+	}
+
+	for _, name := range cols {
+		leftCol, rightCol := leftMeta.Column(name), rightMeta.Column(name)
+		switch {
+		case leftCol == nil:
+			return errz.Errorf("diff: key column %q not found in %s", name, left)
+		case rightCol == nil:
+			return errz.Errorf("diff: key column %q not found in %s", name, right)
+		}
+
+		kinds = append(kinds, commonKind(leftCol.Kind, rightCol.Kind))
+	}
+	summary.Cols = cols[len(keyCols):]
+
+	leftIt, err := newRowIter(ctx, log, left, leftMeta, cols, len(keyCols), kinds)
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(leftIt)
+
+	rightIt, err := newRowIter(ctx, log, right, rightMeta, cols, len(keyCols), kinds)
+	if err != nil {
+		return err
+	}
+	defer log.WarnIfCloseError(rightIt)
+
+	err = h.Open(summary)
+	if err != nil {
+		return err
+	}
+
+	err = merge(ctx, summary, leftIt, rightIt, h)
+	if err != nil {
+		return err
+	}
+
+	return h.Close(summary)
+}
+
+// merge walks leftIt and rightIt (which are both in key order)
+// in step, invoking h for each row that differs.
+func merge(ctx context.Context, summary *DataSummary, leftIt, rightIt *rowIter, h DataHandler) error {
+	numKeys := len(summary.Key)
+
+	leftRow, err := leftIt.next()
+	if err != nil {
+		return err
+	}
+
+	rightRow, err := rightIt.next()
+	if err != nil {
+		return err
+	}
+
+	for leftRow != nil || rightRow != nil {
+		select {
+		case <-ctx.Done():
+			return errz.Err(ctx.Err())
+		default:
+		}
+
+		var rd *RowDiff
+		cmp := 0
+		switch {
+		case leftRow == nil:
+			cmp = 1
+		case rightRow == nil:
+			cmp = -1
+		default:
+			cmp = compareKeys(leftRow.norm[:numKeys], rightRow.norm[:numKeys])
+		}
+
+		switch {
+		case cmp < 0:
+			summary.Removed++
+			rd = &RowDiff{Status: StatusRemoved, Key: leftRow.vals[:numKeys]}
+			for i, name := range summary.Cols {
+				rd.Cols = append(rd.Cols, ColDiff{Name: name, Left: leftRow.vals[numKeys+i]})
+			}
+		case cmp > 0:
+			summary.Added++
+			rd = &RowDiff{Status: StatusAdded, Key: rightRow.vals[:numKeys]}
+			for i, name := range summary.Cols {
+				rd.Cols = append(rd.Cols, ColDiff{Name: name, Right: rightRow.vals[numKeys+i]})
+			}
+		default:
+			for i, name := range summary.Cols {
+				if equal(leftRow.norm[numKeys+i], rightRow.norm[numKeys+i]) {
+					continue
+				}
+
+				if rd == nil {
+					rd = &RowDiff{Status: StatusChanged, Key: leftRow.vals[:numKeys]}
+				}
+				rd.Cols = append(rd.Cols, ColDiff{
+					Name:  name,
+					Left:  leftRow.vals[numKeys+i],
+					Right: rightRow.vals[numKeys+i],
+				})
+			}
+
+			if rd == nil {
+				summary.Same++
+			} else {
+				summary.Changed++
+			}
+		}
+
+		if rd != nil {
+			err = h.RowDiff(rd)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cmp <= 0 {
+			leftRow, err = leftIt.next()
+			if err != nil {
+				return err
+			}
+		}
+
+		if cmp >= 0 {
+			rightRow, err = rightIt.next()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	summary.LeftRows = leftIt.count
+	summary.RightRows = rightIt.count
+	return nil
+}
+
+// row is a row read by rowIter.
+type row struct {
+	// vals holds the dereferenced record values, suitable for output.
+	vals []interface{}
+
+	// norm holds the normalized values, suitable for comparison.
+	norm []interface{}
+}
+
+// rowIter iterates over the rows of a table in key order.
+type rowIter struct {
+	tbl      Table
+	rows     *sql.Rows
+	newRecFn driver.NewRecordFunc
+	scanRow  []interface{}
+	numKeys  int
+	kinds    []kind.Kind
+	hasNext  bool
+	prevKey  []interface{}
+	count    int64
+}
+
+func newRowIter(ctx context.Context, log lg.Log, tbl Table, tblMeta *source.TableMetadata, cols []string,
+	numKeys int, kinds []kind.Kind) (*rowIter, error) {
+	dialect := tbl.DB.SQLDriver().Dialect()
+
+	quotedCols := make([]string, len(cols))
+	for i := range cols {
+		quotedCols[i] = dialect.Enquote(cols[i])
+	}
+
+	// The rows must be ordered as per compareKeys, which puts nil
+	// first. Databases differ in where they put NULL (e.g. Postgres
+	// puts NULL last), and not all support NULLS FIRST, so we sort
+	// on a CASE expression before each key col. Text key cols are
+	// ordered by code point, as per compare, regardless of the col's
+	// collation (e.g. Postgres "en_US" or MySQL "utf8mb4_general_ci").
+	orderBy := make([]string, numKeys)
+	for i, col := range quotedCols[:numKeys] {
+		term := col
+		if colMeta := tblMeta.Column(cols[i]); colMeta != nil && colMeta.Kind == kind.Text && dialect.BinaryOrder != nil {
+			term = dialect.BinaryOrder(col)
+		}
+		orderBy[i] = "CASE WHEN " + col + " IS NULL THEN 0 ELSE 1 END, " + term
+	}
+
+	query := "SELECT " + strings.Join(quotedCols, ", ") +
+		" FROM " + dialect.Enquote(tbl.Name) +
+		" ORDER BY " + strings.Join(orderBy, ", ")
+
+	log.Debugf("diff: %s: %s", tbl.DB.Source().Handle, query)
+	rows, err := tbl.DB.DB().QueryContext(ctx, query)
+	if err != nil {
+		return nil, errz.Wrapf(err, "diff: query against %s failed: %s", tbl.DB.Source().Handle, query)
+	}
+
+	// As per libsq.QuerySQL, for some drivers the column types
+	// are only complete after rows.Next is first invoked.
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		log.WarnIfCloseError(rows)
+		return nil, errz.Err(err)
+	}
+
+	it := &rowIter{tbl: tbl, rows: rows, numKeys: numKeys, kinds: kinds}
+	it.hasNext = rows.Next()
+	if rows.Err() != nil {
+		log.WarnIfCloseError(rows)
+		return nil, errz.Err(rows.Err())
+	}
+
+	if it.hasNext {
+		colTypes, err = rows.ColumnTypes()
+		if err != nil {
+			log.WarnIfCloseError(rows)
+			return nil, errz.Err(err)
+		}
+	}
+
+	var recMeta sqlz.RecordMeta
+	recMeta, it.newRecFn, err = tbl.DB.SQLDriver().RecordMeta(colTypes)
+	if err != nil {
+		log.WarnIfCloseError(rows)
+		return nil, err
+	}
+
+	it.scanRow = recMeta.NewScanRow()
+	return it, nil
+}
+
+// next returns the next row, or nil if there are no more rows.
+// An error is returned if the rows are not strictly in key order.
+func (it *rowIter) next() (*row, error) {
+	if !it.hasNext {
+		return nil, errz.Err(it.rows.Err())
+	}
+
+	err := it.rows.Scan(it.scanRow...)
+	if err != nil {
+		return nil, errz.Wrapf(err, "diff: %s", it.tbl)
+	}
+
+	rec, err := it.newRecFn(it.scanRow)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &row{vals: make([]interface{}, len(rec)), norm: make([]interface{}, len(rec))}
+	for i := range rec {
+		r.vals[i] = deref(rec[i])
+		r.norm[i] = normalize(it.kinds[i], r.vals[i])
+	}
+
+	key := r.norm[:it.numKeys]
+	if it.prevKey != nil {
+		switch cmp := compareKeys(it.prevKey, key); {
+		case cmp == 0:
+			return nil, errz.Errorf("diff: %s: key %v is not unique", it.tbl, r.vals[:it.numKeys])
+		case cmp > 0:
+			return nil, errz.Errorf("diff: %s: key %v is out of order: the database may use a different collation",
+				it.tbl, r.vals[:it.numKeys])
+		}
+	}
+	it.prevKey = key
+
+	it.count++
+	it.hasNext = it.rows.Next()
+	return r, nil
+}
+
+// Close closes the underlying rows.
+func (it *rowIter) Close() error {
+	return errz.Err(it.rows.Close())
+}
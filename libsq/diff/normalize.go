@@ -0,0 +1,249 @@
+package diff
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neilotoole/sq/libsq/core/kind"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+)
+
+// deref returns the value pointed to by sqlz.Record element val.
+func deref(val interface{}) interface{} {
+	switch val := val.(type) {
+	case *int64:
+		return *val
+	case *float64:
+		return *val
+	case *bool:
+		return *val
+	case *string:
+		return *val
+	case *[]byte:
+		return *val
+	case *time.Time:
+		return *val
+	default:
+		return val
+	}
+}
+
+// commonKind returns the kind to which values of a column should be
+// normalized, when the column has kind a in one table and kind b in
+// the other.
+func commonKind(a, b kind.Kind) kind.Kind {
+	if a == b {
+		return a
+	}
+
+	switch {
+	case isLooseKind(a):
+		return b
+	case isLooseKind(b):
+		return a
+	case isNumericKind(a) && isNumericKind(b):
+		return kind.Decimal
+	case a == kind.Bool && b == kind.Int, a == kind.Int && b == kind.Bool:
+		// Some databases store BOOLEAN as INT.
+		return kind.Bool
+	case isTimeKind(a) && isTimeKind(b):
+		return kind.Datetime
+	default:
+		return kind.Text
+	}
+}
+
+func isLooseKind(k kind.Kind) bool {
+	return k == kind.Unknown || k == kind.Null || k == kind.Text
+}
+
+func isNumericKind(k kind.Kind) bool {
+	return k == kind.Int || k == kind.Float || k == kind.Decimal
+}
+
+func isTimeKind(k kind.Kind) bool {
+	return k == kind.Datetime || k == kind.Date
+}
+
+// timeLayouts are the layouts used to parse a text value
+// as a time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// normalize returns the (dereferenced) value val in a canonical form
+// for kind k, such that values from different databases can be
+// compared. The canonical forms are:
+//
+//   nil, int64, float64, *big.Rat, bool, time.Time, string
+//
+// If val cannot be converted to k's canonical form (e.g. text "abc"
+// for kind.Int), the string representation of val is returned.
+func normalize(k kind.Kind, val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+
+	if b, ok := val.([]byte); ok {
+		val = string(b)
+	}
+
+	switch k {
+	case kind.Int:
+		switch v := val.(type) {
+		case int64:
+			return v
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v)
+			}
+		case bool:
+			if v {
+				return int64(1)
+			}
+			return int64(0)
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i
+			}
+		}
+	case kind.Float:
+		switch v := val.(type) {
+		case float64:
+			return v
+		case int64:
+			return float64(v)
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+		}
+	case kind.Decimal:
+		switch v := val.(type) {
+		case int64:
+			return new(big.Rat).SetInt64(v)
+		case float64:
+			// Use the shortest decimal representation of the
+			// float, so that float 0.1 equals decimal "0.1".
+			if r, ok := new(big.Rat).SetString(stringz.FormatFloat(v)); ok {
+				return r
+			}
+		case string:
+			if r, ok := new(big.Rat).SetString(strings.TrimSpace(v)); ok {
+				return r
+			}
+		}
+	case kind.Bool:
+		switch v := val.(type) {
+		case bool:
+			return v
+		case int64:
+			return v != 0
+		case string:
+			if b, err := stringz.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b
+			}
+		}
+	case kind.Datetime, kind.Date:
+		switch v := val.(type) {
+		case time.Time:
+			return v.UTC()
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+					return t.UTC()
+				}
+			}
+		}
+	}
+
+	switch v := val.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		return stringz.FormatFloat(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compare returns -1, 0 or 1 if normalized value a is less than,
+// equal to, or greater than normalized value b. Nil is less than
+// any other value. Values of differing types are compared via
+// their string representation.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return compareOrdered(a < b, a > b)
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return compareOrdered(a < b, a > b)
+		}
+	case *big.Rat:
+		if b, ok := b.(*big.Rat); ok {
+			return a.Cmp(b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return compareOrdered(!a && b, a && !b)
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return compareOrdered(a.Before(b), a.After(b))
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareKeys compares the normalized key values a and b
+// element by element, as per compare.
+func compareKeys(a, b []interface{}) int {
+	for i := range a {
+		if cmp := compare(a[i], b[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+// equal returns true if normalized values a and b are equal.
+func equal(a, b interface{}) bool {
+	return compare(a, b) == 0
+}
@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/neilotoole/sq/libsq/core/kind"
+)
+
+func TestNormalizeEqual(t *testing.T) {
+	tm := time.Date(2020, 2, 15, 6, 59, 28, 0, time.UTC)
+
+	testCases := []struct {
+		leftKind, rightKind kind.Kind
+		left, right         interface{}
+		wantEqual           bool
+	}{
+		{kind.Int, kind.Int, int64(1), int64(1), true},
+		{kind.Int, kind.Int, int64(1), int64(2), false},
+		{kind.Int, kind.Decimal, int64(1), "1.00", true},
+		{kind.Float, kind.Decimal, float64(0.1), []byte("0.1"), true},
+		{kind.Int, kind.Float, int64(7), float64(7.5), false},
+		{kind.Bool, kind.Int, true, int64(1), true},
+		{kind.Bool, kind.Int, false, int64(1), false},
+		{kind.Datetime, kind.Text, tm, "2020-02-15T06:59:28Z", true},
+		{kind.Datetime, kind.Datetime, tm, tm.In(time.FixedZone("X", 3600)), true},
+		{kind.Date, kind.Text, tm.Truncate(24 * time.Hour), "2020-02-15", true},
+		{kind.Text, kind.Text, "abc", []byte("abc"), true},
+		{kind.Text, kind.Text, "abc", nil, false},
+		{kind.Text, kind.Text, nil, nil, true},
+		{kind.Int, kind.Text, int64(1), "abc", false},
+	}
+
+	for _, tc := range testCases {
+		k := commonKind(tc.leftKind, tc.rightKind)
+		gotEqual := equal(normalize(k, tc.left), normalize(k, tc.right))
+		require.Equal(t, tc.wantEqual, gotEqual, "%s(%v) vs %s(%v)", tc.leftKind, tc.left, tc.rightKind, tc.right)
+	}
+}
+
+func TestCompareKeys(t *testing.T) {
+	require.Equal(t, -1, compareKeys([]interface{}{int64(1), "b"}, []interface{}{int64(2), "a"}))
+	require.Equal(t, 1, compareKeys([]interface{}{int64(1), "b"}, []interface{}{int64(1), "a"}))
+	require.Equal(t, 0, compareKeys([]interface{}{int64(1), "a"}, []interface{}{int64(1), "a"}))
+	require.Equal(t, -1, compareKeys([]interface{}{nil}, []interface{}{int64(1)}))
+}
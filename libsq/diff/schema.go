@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// SchemaDiff describes the schema differences between two sources.
+type SchemaDiff struct {
+	// Left identifies the left source, e.g. "@prod", or the
+	// path of a snapshot file.
+	Left string `json:"left"`
+
+	// Right identifies the right source.
+	Right string `json:"right"`
+
+	// Tables holds the tables that differ. Tables that are
+	// the same in both sources are not included.
+	Tables []*TableDiff `json:"tables"`
+}
+
+// Differs returns true if there are any schema differences.
+func (sd *SchemaDiff) Differs() bool {
+	return len(sd.Tables) > 0
+}
+
+// TableDiff describes a table that differs between two sources.
+type TableDiff struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+
+	// Cols holds the columns that differ. It is empty for
+	// an added or removed table.
+	Cols []*ColSchemaDiff `json:"cols,omitempty"`
+}
+
+// ColSchemaDiff describes a column that differs between two
+// versions of a table.
+type ColSchemaDiff struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+
+	// Changes holds the column metadata fields that differ. It
+	// is empty for an added or removed column.
+	Changes []FieldDiff `json:"changes,omitempty"`
+}
+
+// FieldDiff describes a metadata field value that differs.
+type FieldDiff struct {
+	// Field is the metadata field name, e.g. "column_type".
+	Field string      `json:"field"`
+	Left  interface{} `json:"left"`
+	Right interface{} `json:"right"`
+}
+
+// Schema compares the schema of source metadata left with right.
+// Added and removed tables and columns are reported, as are
+// changes to each column's type, kind, nullability, primary
+// key membership and default value. Other metadata, such as
+// row counts, is ignored.
+func Schema(left, right *source.Metadata) *SchemaDiff {
+	sd := &SchemaDiff{Left: left.Handle, Right: right.Handle, Tables: []*TableDiff{}}
+
+	for _, leftTbl := range left.Tables {
+		rightTbl := findTable(right, leftTbl.Name)
+		if rightTbl == nil {
+			sd.Tables = append(sd.Tables, &TableDiff{Name: leftTbl.Name, Status: StatusRemoved})
+			continue
+		}
+
+		if td := diffTable(leftTbl, rightTbl); td != nil {
+			sd.Tables = append(sd.Tables, td)
+		}
+	}
+
+	for _, rightTbl := range right.Tables {
+		if findTable(left, rightTbl.Name) == nil {
+			sd.Tables = append(sd.Tables, &TableDiff{Name: rightTbl.Name, Status: StatusAdded})
+		}
+	}
+
+	return sd
+}
+
+// diffTable returns the differences between the columns of
+// left and right, or nil if there are none.
+func diffTable(left, right *source.TableMetadata) *TableDiff {
+	td := &TableDiff{Name: left.Name, Status: StatusChanged}
+
+	for _, leftCol := range left.Columns {
+		rightCol := right.Column(leftCol.Name)
+		if rightCol == nil {
+			td.Cols = append(td.Cols, &ColSchemaDiff{Name: leftCol.Name, Status: StatusRemoved})
+			continue
+		}
+
+		changes := diffCol(leftCol, rightCol)
+		if len(changes) > 0 {
+			td.Cols = append(td.Cols, &ColSchemaDiff{Name: leftCol.Name, Status: StatusChanged, Changes: changes})
+		}
+	}
+
+	for _, rightCol := range right.Columns {
+		if left.Column(rightCol.Name) == nil {
+			td.Cols = append(td.Cols, &ColSchemaDiff{Name: rightCol.Name, Status: StatusAdded})
+		}
+	}
+
+	if len(td.Cols) == 0 {
+		return nil
+	}
+
+	return td
+}
+
+// diffCol returns the metadata fields that differ between
+// left and right.
+func diffCol(left, right *source.ColMetadata) []FieldDiff {
+	var changes []FieldDiff
+	add := func(field string, l, r interface{}) {
+		if l != r {
+			changes = append(changes, FieldDiff{Field: field, Left: l, Right: r})
+		}
+	}
+
+	add("column_type", left.ColumnType, right.ColumnType)
+	add("kind", left.Kind, right.Kind)
+	add("nullable", left.Nullable, right.Nullable)
+	add("primary_key", left.PrimaryKey, right.PrimaryKey)
+	add("default_value", left.DefaultValue, right.DefaultValue)
+
+	return changes
+}
+
+func findTable(md *source.Metadata, name string) *source.TableMetadata {
+	for _, tbl := range md.Tables {
+		if tbl.Name == name {
+			return tbl
+		}
+	}
+
+	return nil
+}
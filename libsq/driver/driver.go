@@ -148,12 +148,71 @@ type SQLDriver interface {
 	// or equivalent clause is added, if supported.
 	DropTable(ctx context.Context, db sqlz.DB, tbl string, ifExists bool) error
 
+	// RenameTable renames tbl to newName, which must not exist.
+	RenameTable(ctx context.Context, db sqlz.DB, tbl, newName string) error
+
 	// AlterTableAddColumn adds column col to tbl. The column is appended
 	// to the list of columns (that is, the column position cannot be
 	// specified).
 	AlterTableAddColumn(ctx context.Context, db *sql.DB, tbl string, col string, kind kind.Kind) error
 }
 
+// Upserter is implemented by a SQLDriver that supports a native
+// upsert statement, such as "INSERT ... ON CONFLICT" or "MERGE".
+// If a SQLDriver does not implement Upserter, the caller can
+// fall back to SQLDriver.PrepareUpdateStmt followed by
+// SQLDriver.PrepareInsertStmt.
+type Upserter interface {
+	// PrepareUpsertStmt prepares a statement for inserting a row of
+	// values to destColNames in destTbl or, if a row with the same
+	// values of keyColNames already exists, for updating that row.
+	// Each execution of the statement upserts a single row. The
+	// affected value returned by the StmtExecer's Exec method is
+	// UpsertInserted, UpsertUpdated or UpsertUnchanged. Note that
+	// some implementations require a unique constraint (or index)
+	// on exactly keyColNames, and return an error if there's none.
+	//
+	// Use the returned StmtExecer per its documentation. It is the caller's
+	// responsibility to close the execer.
+	//
+	// Note that db must guarantee a single connection: that is, db
+	// must be a sql.Conn or sql.Tx.
+	PrepareUpsertStmt(ctx context.Context, db sqlz.DB, destTbl string, destColNames, keyColNames []string) (*StmtExecer, error)
+}
+
+// BulkLoader is implemented by a SQLDriver that supports a native
+// bulk load mechanism, such as Postgres "COPY". Bulk loading is
+// typically much faster than executing batched INSERT statements.
+// If a SQLDriver does not implement BulkLoader (or if CanBulkLoad
+// returns false), the caller should fall back to NewBatchInsert.
+type BulkLoader interface {
+	// CanBulkLoad returns true if records can be bulk loaded to
+	// destColNames of destTbl. For example, bulk loading may be
+	// disabled by the database's configuration. Note that db may
+	// be a sql.Tx in which destTbl was created.
+	CanBulkLoad(ctx context.Context, db sqlz.DB, destTbl string, destColNames []string) (bool, error)
+
+	// NewBulkLoad returns a BatchInsert that loads records to
+	// destColNames of destTbl using the native bulk load mechanism.
+	// Use the returned BatchInsert per its documentation.
+	//
+	// Unlike NewBatchInsert, the load is performed in its own
+	// transaction, on a connection obtained from db. Thus destTbl
+	// must already exist outside of any uncommitted transaction.
+	NewBulkLoad(ctx context.Context, log lg.Log, db *sql.DB, destTbl string, destColNames []string) (*BatchInsert, error)
+}
+
+// Values returned by the Exec method of a StmtExecer returned
+// by Upserter.PrepareUpsertStmt. These follow the MySQL
+// convention for the affected value of an upsert. Not all
+// implementations can report UpsertUnchanged, which indicates
+// an existing row whose values were the same as the record's.
+const (
+	UpsertUnchanged int64 = 0
+	UpsertInserted  int64 = 1
+	UpsertUpdated   int64 = 2
+)
+
 // Database models a database handle. It is conceptually equivalent to
 // stdlib sql.DB, and in fact encapsulates a sql.DB instance. The
 // realized sql.DB instance can be accessed via the DB method.
@@ -195,6 +254,10 @@ type Metadata struct {
 	// user driver definition, and false if built-in.
 	UserDefined bool `json:"user_defined"`
 
+	// File is the path of the file that a user driver's definition
+	// was loaded from. It is empty for built-in drivers.
+	File string `json:"file,omitempty"`
+
 	// IsSQL is true if this driver is a SQL driver.
 	IsSQL bool `json:"is_sql"`
 
@@ -220,6 +283,18 @@ type Dialect struct {
 
 	// MaxBatchValues is the maximum number of values in a batch insert.
 	MaxBatchValues int
+
+	// Savepoint returns the statements to create, roll back to, and
+	// release the savepoint named name. The release statement may
+	// be empty. If Savepoint is nil, the SQL standard statements
+	// (e.g. "SAVEPOINT name") are used.
+	Savepoint func(name string) (create, rollback, release string)
+
+	// BinaryOrder returns an ORDER BY term that orders the text
+	// expression expr by code point, regardless of the collation
+	// of expr (e.g. a case-insensitive collation). If BinaryOrder
+	// is nil, expr is used as is.
+	BinaryOrder func(expr string) string
 }
 
 // Enquote returns s surrounded by d.Quote.
@@ -241,6 +316,7 @@ type Databases struct {
 	scratchSrcFn ScratchSrcFunc
 	dbases       map[string]Database
 	clnup        *cleanup.Cleanup
+	importCache  *ImportCache
 }
 
 // NewDatabases returns a Databases instances.
@@ -322,6 +398,33 @@ func (d *Databases) OpenScratch(ctx context.Context, name string) (Database, err
 	return backingDB, nil
 }
 
+// SetImportCache sets the cache used by OpenImport. If c is nil
+// (the default), OpenImport does not cache imported data. It must
+// be invoked before d is used.
+func (d *Databases) SetImportCache(c *ImportCache) {
+	d.importCache = c
+}
+
+// OpenImport returns a database holding the data of src, which
+// is imported via importFn into a scratch database. If d has an
+// import cache (see SetImportCache), the cached database for src
+// is returned if available; otherwise the imported database is
+// added to the cache. The cache is not used for @stdin, if ctx was
+// returned by WithoutImportCache, or if ctx has a Rejects (see
+// WithRejects), as rows could be rejected during the import.
+//
+// OpenImport implements ImportOpener.
+func (d *Databases) OpenImport(ctx context.Context, src *source.Source, importFn ImportFunc) (Database, error) {
+	// Note that d.mu is not acquired here: OpenImport is typically
+	// invoked by a driver's Open method, via d.Open, which holds d.mu.
+	importCache := d.importCache
+	if importCache == nil || src.Handle == source.StdinHandle || importCacheDisabled(ctx) || RejectsFrom(ctx) != nil {
+		return openScratchImport(ctx, d, src, importFn)
+	}
+
+	return importCache.open(ctx, d.drvrs, src, importFn)
+}
+
 // OpenJoin opens an appropriate database for use as
 // as a work DB for joining across sources.
 //
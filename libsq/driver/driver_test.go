@@ -1,6 +1,9 @@
 package driver_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,6 +18,7 @@ import (
 	"github.com/neilotoole/sq/libsq/core/kind"
 
 	"github.com/neilotoole/sq/libsq/core/sqlmodel"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
 	"github.com/neilotoole/sq/libsq/core/stringz"
 	"github.com/neilotoole/sq/libsq/driver"
 	"github.com/neilotoole/sq/libsq/source"
@@ -320,6 +324,92 @@ func TestNewBatchInsert(t *testing.T) {
 	}
 }
 
+func TestNewBulkLoad(t *testing.T) {
+	// SQLite doesn't implement driver.BulkLoader.
+	for _, handle := range sakila.SQLAllExternal() {
+		handle := handle
+
+		t.Run(handle, func(t *testing.T) {
+			th, src, dbase, drvr := testh.NewWith(t, handle)
+			bulkLoader, ok := drvr.(driver.BulkLoader)
+			if !ok {
+				t.Skipf("driver %s does not implement driver.BulkLoader", drvr.DriverMetadata().Type)
+			}
+
+			tblName := th.CopyTable(true, src, sakila.TblActor, "", false)
+
+			recMeta, recs := testh.RecordsFromTbl(t, handle, sakila.TblActor)
+			canBulkLoad, err := bulkLoader.CanBulkLoad(th.Context, dbase.DB(), tblName, recMeta.Names())
+			require.NoError(t, err)
+			if !canBulkLoad {
+				t.Skipf("bulk load not available for %s.%s", src.Handle, tblName)
+			}
+
+			bi, err := bulkLoader.NewBulkLoad(th.Context, th.Log, dbase.DB(), tblName, recMeta.Names())
+			require.NoError(t, err)
+
+			for _, rec := range recs {
+				require.NoError(t, bi.Munge(rec))
+
+				select {
+				case err = <-bi.ErrCh:
+					close(bi.RecordCh)
+					t.Fatal(err)
+				case bi.RecordCh <- rec:
+				}
+			}
+			close(bi.RecordCh)
+
+			require.Nil(t, <-bi.ErrCh)
+			require.Equal(t, int64(sakila.TblActorCount), bi.Written())
+			require.Equal(t, int64(sakila.TblActorCount), th.RowCount(src, tblName))
+		})
+	}
+}
+
+func TestNewBulkLoadBatchInsert(t *testing.T) {
+	var got [][]interface{}
+	loadFn := func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		for rec := range recCh {
+			got = append(got, rec)
+		}
+		return int64(len(got)), nil
+	}
+
+	mungeFn := func(rec sqlz.Record) error {
+		rec[0] = "munged"
+		return nil
+	}
+
+	bi := driver.NewBulkLoadBatchInsert(context.Background(), mungeFn, loadFn)
+	for i := 0; i < 3; i++ {
+		rec := []interface{}{i}
+		require.NoError(t, bi.Munge(rec))
+		bi.RecordCh <- rec
+	}
+	close(bi.RecordCh)
+
+	require.Nil(t, <-bi.ErrCh)
+	require.Equal(t, int64(3), bi.Written())
+	require.Equal(t, 3, len(got))
+	require.Equal(t, "munged", got[0][0])
+
+	wantErr := errors.New("load failed")
+	bi = driver.NewBulkLoadBatchInsert(context.Background(), mungeFn, func(ctx context.Context, recCh <-chan []interface{}) (int64, error) {
+		return 0, wantErr
+	})
+	require.Equal(t, wantErr, <-bi.ErrCh)
+}
+
+func TestDerefRecord(t *testing.T) {
+	i, s := int64(7), "hello"
+	var nilStr *string
+	rec := []interface{}{nil, &i, &s, nilStr, true}
+
+	driver.DerefRecord(rec)
+	require.Equal(t, []interface{}{nil, int64(7), "hello", nil, true}, rec)
+}
+
 // coreDrivers is a slice of the core driver types.
 var coreDrivers = []source.Type{
 	postgres.Type,
@@ -489,3 +579,27 @@ func TestSQLDriver_AlterTableAddColumn(t *testing.T) {
 		})
 	}
 }
+
+func TestRejects(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rejects := driver.NewRejects(2, buf)
+	require.Nil(t, driver.RejectsFrom(context.Background()))
+	require.True(t, rejects == driver.RejectsFrom(driver.WithRejects(context.Background(), rejects)))
+
+	require.NoError(t, rejects.Reject("actor", 7, []interface{}{int64(7), "Alice"}, errors.New("constraint failed")))
+	require.NoError(t, rejects.Reject("actor", 9, []interface{}{int64(9), nil}, errors.New("constraint failed")))
+	require.Error(t, rejects.Reject("actor", 11, []interface{}{int64(11), "Bob"}, errors.New("constraint failed")))
+	require.Equal(t, 2, rejects.Count())
+
+	want := `{"table":"actor","row":7,"error":"constraint failed","record":[7,"Alice"]}
+{"table":"actor","row":9,"error":"constraint failed","record":[9,null]}
+`
+	require.Equal(t, want, buf.String())
+
+	// Negative maxErrors is unlimited
+	rejects = driver.NewRejects(-1, nil)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, rejects.Reject("actor", int64(i), nil, errors.New("constraint failed")))
+	}
+	require.Equal(t, 100, rejects.Count())
+}
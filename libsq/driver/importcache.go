@@ -0,0 +1,318 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neilotoole/lg"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/stringz"
+	"github.com/neilotoole/sq/libsq/source"
+)
+
+// ImportFunc imports the data of a source into destDB, which
+// is typically a scratch database.
+type ImportFunc func(ctx context.Context, destDB Database) error
+
+// ImportOpener opens a database holding the data imported from
+// a source that is not itself a SQL database, such as CSV.
+type ImportOpener interface {
+	// OpenImport returns a database holding the data of src. If
+	// the data must be imported, importFn is invoked to do so.
+	OpenImport(ctx context.Context, src *source.Source, importFn ImportFunc) (Database, error)
+}
+
+// OpenImport is a convenience function that invokes OpenImport if
+// scratcher implements ImportOpener. Otherwise a scratch database
+// is opened, and importFn is invoked with that database.
+func OpenImport(ctx context.Context, scratcher ScratchDatabaseOpener, src *source.Source, importFn ImportFunc) (Database, error) {
+	if opener, ok := scratcher.(ImportOpener); ok {
+		return opener.OpenImport(ctx, src, importFn)
+	}
+
+	return openScratchImport(ctx, scratcher, src, importFn)
+}
+
+// openScratchImport opens a scratch database via scratcher,
+// and imports src's data into it via importFn.
+func openScratchImport(ctx context.Context, scratcher ScratchDatabaseOpener, src *source.Source, importFn ImportFunc) (Database, error) {
+	scratchDB, err := scratcher.OpenScratch(ctx, src.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	err = importFn(ctx, scratchDB)
+	if err != nil {
+		_ = scratchDB.Close()
+		return nil, err
+	}
+
+	return scratchDB, nil
+}
+
+type noImportCacheKey struct{}
+
+// WithoutImportCache returns a new context that disables the import
+// cache for OpenImport. This is used when a source's data is imported
+// as part of another source's import, such as for the files of a
+// directory source.
+func WithoutImportCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noImportCacheKey{}, true)
+}
+
+// importCacheDisabled returns true if ctx was returned
+// by WithoutImportCache.
+func importCacheDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noImportCacheKey{}).(bool)
+	return disabled
+}
+
+type importCacheKeyKey struct{}
+
+// WithImportCacheKey returns a new context that adds key to the
+// import cache key of a source opened via OpenImport. A driver uses
+// this when a change to something other than the source, such as a
+// user driver definition, must invalidate the source's cached import.
+func WithImportCacheKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, importCacheKeyKey{}, key)
+}
+
+// importCacheKeyFrom returns the value passed to
+// WithImportCacheKey, or empty string.
+func importCacheKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(importCacheKeyKey{}).(string)
+	return key
+}
+
+// importCacheVersion is part of each import cache key: it should
+// be incremented when a change to sq invalidates cached imports.
+const importCacheVersion = "1"
+
+// ImportCache is an on-disk cache of the databases holding data
+// imported from sources such as CSV, XLSX or JSON. A cache entry
+// is keyed by the source's handle, type, location and options, by a
+// fingerprint of the source data (see source.Files.Fingerprint), and
+// by any key passed to WithImportCacheKey.
+// Thus a query against an unchanged source reuses the cached
+// database instead of importing the source data again.
+type ImportCache struct {
+	log lg.Log
+	dir string
+
+	// dbSrcFn returns the source for the cache database
+	// file at path, which is read-only if readOnly is true.
+	dbSrcFn func(path string, readOnly bool) *source.Source
+
+	// fingerprintFn returns the fingerprint of src's data.
+	fingerprintFn func(ctx context.Context, src *source.Source) (string, error)
+}
+
+// NewImportCache returns a new ImportCache that stores cached
+// databases in dir. Arg dbSrcFn returns the source for a cache
+// database file (e.g. a SQLite source), and fingerprintFn
+// returns a fingerprint of a source's data. A cached database
+// is opened via the read-only source returned by dbSrcFn, such
+// that a query (e.g. "DELETE FROM data") can't modify it.
+func NewImportCache(log lg.Log, dir string, dbSrcFn func(path string, readOnly bool) *source.Source,
+	fingerprintFn func(ctx context.Context, src *source.Source) (string, error)) *ImportCache {
+	return &ImportCache{log: log, dir: dir, dbSrcFn: dbSrcFn, fingerprintFn: fingerprintFn}
+}
+
+// ImportCacheEntry describes a cached import.
+type ImportCacheEntry struct {
+	// Handle is the handle of the imported source.
+	Handle string `json:"handle"`
+
+	// Type is the type of the imported source.
+	Type source.Type `json:"driver"`
+
+	// Location is the location of the imported source.
+	Location string `json:"location"`
+
+	// Path is the path of the cached database file.
+	Path string `json:"path"`
+
+	// Size is the size in bytes of the cached database file.
+	Size int64 `json:"size"`
+
+	// Created is when the cached database was created.
+	Created time.Time `json:"created"`
+}
+
+// Dir returns the cache directory.
+func (c *ImportCache) Dir() string {
+	return c.dir
+}
+
+// key returns the cache key for src.
+func (c *ImportCache) key(ctx context.Context, src *source.Source) (string, error) {
+	fingerprint, err := c.fingerprintFn(ctx, src)
+	if err != nil {
+		return "", err
+	}
+
+	var opts string
+	if src.Options != nil {
+		opts = src.Options.Encode()
+	}
+
+	h := sha256.New()
+	for _, s := range []string{importCacheVersion, src.Handle, string(src.Type), src.Location, opts, fingerprint, importCacheKeyFrom(ctx)} {
+		_, _ = fmt.Fprintf(h, "%d:%s\n", len(s), s)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// Entries returns the entries of the cache, sorted by handle.
+func (c *ImportCache) Entries() ([]*ImportCacheEntry, error) {
+	fpaths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	entries := make([]*ImportCacheEntry, 0, len(fpaths))
+	for _, fpath := range fpaths {
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return nil, errz.Err(err)
+		}
+
+		entry := &ImportCacheEntry{}
+		err = json.Unmarshal(data, entry)
+		if err != nil {
+			return nil, errz.Wrapf(err, "invalid import cache entry: %s", fpath)
+		}
+
+		entry.Path = strings.TrimSuffix(fpath, ".json") + ".db"
+		fi, err := os.Stat(entry.Path)
+		if err != nil {
+			// The db file is gone: the entry is stale.
+			c.log.Warnf("Ignoring stale import cache entry: %s", fpath)
+			continue
+		}
+		entry.Size = fi.Size()
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Handle < entries[j].Handle
+	})
+
+	return entries, nil
+}
+
+// Clear removes the cache entries for the sources with the
+// given handles, or all entries if no handles are specified.
+// The removed entries are returned.
+func (c *ImportCache) Clear(handles ...string) ([]*ImportCacheEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*ImportCacheEntry
+	for _, entry := range entries {
+		if len(handles) > 0 && !stringz.InSlice(handles, entry.Handle) {
+			continue
+		}
+
+		err = errz.Combine(removeIfExists(entry.Path), removeIfExists(strings.TrimSuffix(entry.Path, ".db")+".json"))
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+
+	return removed, nil
+}
+
+// open returns the cached database for src, invoking importFn
+// to populate the cache database if there's no entry for src.
+func (c *ImportCache) open(ctx context.Context, drvrs Provider, src *source.Source, importFn ImportFunc) (Database, error) {
+	key, err := c.key(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPath := filepath.Join(c.dir, key+".db")
+	dbSrc := c.dbSrcFn(dbPath, true)
+	drvr, err := drvrs.DriverFor(dbSrc.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = os.Stat(dbPath); err == nil {
+		c.log.Debugf("Import cache hit for %s: %s", src.Handle, dbPath)
+		return drvr.Open(ctx, dbSrc)
+	}
+
+	c.log.Debugf("Import cache miss for %s: %s", src.Handle, dbPath)
+	err = os.MkdirAll(c.dir, 0750)
+	if err != nil {
+		return nil, errz.Wrap(err, "failed to create import cache dir")
+	}
+
+	// We import into a temp file, which is renamed only after
+	// the import succeeds, so that an incomplete import is
+	// never visible to a concurrent sq process.
+	tmpPath := filepath.Join(c.dir, fmt.Sprintf("%s.%d.tmp", key, os.Getpid()))
+	tmpDB, err := drvr.Open(ctx, c.dbSrcFn(tmpPath, false))
+	if err != nil {
+		return nil, err
+	}
+
+	err = importFn(ctx, tmpDB)
+	err = errz.Combine(err, tmpDB.Close())
+	if err != nil {
+		c.log.WarnIfError(removeIfExists(tmpPath))
+		return nil, err
+	}
+
+	entry := &ImportCacheEntry{Handle: src.Handle, Type: src.Type, Location: src.RedactedLocation(), Created: time.Now()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		c.log.WarnIfError(removeIfExists(tmpPath))
+		return nil, errz.Err(err)
+	}
+
+	// Any older entries for src are now stale, so we remove them.
+	_, err = c.Clear(src.Handle)
+	if err != nil {
+		c.log.WarnIfError(removeIfExists(tmpPath))
+		return nil, err
+	}
+
+	err = os.Rename(tmpPath, dbPath)
+	if err != nil {
+		c.log.WarnIfError(removeIfExists(tmpPath))
+		return nil, errz.Err(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(c.dir, key+".json"), data, 0600)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	return drvr.Open(ctx, dbSrc)
+}
+
+// removeIfExists removes the file at fpath, if it exists.
+func removeIfExists(fpath string) error {
+	err := os.Remove(fpath)
+	if err != nil && !os.IsNotExist(err) {
+		return errz.Err(err)
+	}
+	return nil
+}
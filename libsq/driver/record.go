@@ -383,6 +383,10 @@ func (bi BatchInsert) Munge(rec []interface{}) error {
 // NewBatchInsert returns a new BatchInsert instance. The internal
 // goroutine is started.
 //
+// If ctx has a Rejects (see WithRejects), a failing batch is retried
+// row by row, and each row that fails is rejected, until the Rejects
+// error budget is exhausted.
+//
 // Note that the db arg must guarantee a single connection: that is,
 // it must be a sql.Conn or sql.Tx.
 func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB, destTbl string, destColNames []string, batchSize int) (*BatchInsert, error) {
@@ -401,6 +405,7 @@ func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB,
 	}
 
 	bi := &BatchInsert{RecordCh: recCh, ErrCh: errCh, written: atomic.NewInt64(0), mungeFn: inserter.mungeFn}
+	rr := &rowRetrier{drvr: drvr, db: db, destTbl: destTbl, destColNames: destColNames, rejects: RejectsFrom(ctx)}
 
 	go func() {
 		// vals holds rows of values as a single slice. That is, vals is
@@ -412,6 +417,8 @@ func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB,
 		var affected int64
 
 		defer func() {
+			log.WarnIfError(rr.close())
+
 			if inserter != nil {
 				if err == nil {
 					// If no pre-existing error, any inserter.Close error
@@ -457,7 +464,7 @@ func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB,
 			}
 
 			if len(vals)/rowLen == batchSize { // We've got a full batch to send
-				affected, err = inserter.Exec(ctx, vals...)
+				affected, err = rr.exec(ctx, inserter, vals)
 				if err != nil {
 					return
 				}
@@ -499,7 +506,7 @@ func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB,
 				return
 			}
 
-			affected, err = inserter.Exec(ctx, vals...)
+			affected, err = rr.exec(ctx, inserter, vals)
 			if err != nil {
 				return
 			}
@@ -514,6 +521,140 @@ func NewBatchInsert(ctx context.Context, log lg.Log, drvr SQLDriver, db sqlz.DB,
 	return bi, nil
 }
 
+// rowRetrier executes batch inserts for BatchInsert. If rejects is
+// non-nil, a failing batch is retried row by row, rejecting each
+// row that fails.
+type rowRetrier struct {
+	drvr         SQLDriver
+	db           sqlz.DB
+	destTbl      string
+	destColNames []string
+	rejects      *Rejects
+
+	// rowInserter inserts a single row. It is lazily prepared.
+	rowInserter *StmtExecer
+
+	// numRows is the number of rows executed before the current batch.
+	numRows int64
+}
+
+// exec executes inserter with vals, returning the number of
+// rows inserted.
+func (rr *rowRetrier) exec(ctx context.Context, inserter *StmtExecer, vals []interface{}) (affected int64, err error) {
+	rowLen := len(rr.destColNames)
+	firstRow := rr.numRows + 1
+	rr.numRows += int64(len(vals) / rowLen)
+
+	if rr.rejects == nil {
+		return inserter.Exec(ctx, vals...)
+	}
+
+	execErr, err := ExecSavepoint(ctx, rr.drvr, rr.db, func() error {
+		var execErr error
+		affected, execErr = inserter.Exec(ctx, vals...)
+		return execErr
+	})
+	if err != nil || execErr == nil {
+		return affected, err
+	}
+
+	// The batch failed, so we retry row by row.
+	if rr.rowInserter == nil {
+		rr.rowInserter, err = rr.drvr.PrepareInsertStmt(ctx, rr.db, rr.destTbl, rr.destColNames, 1)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	affected = 0
+	for i := 0; i < len(vals)/rowLen; i++ {
+		row := vals[i*rowLen : (i+1)*rowLen]
+
+		var rowAffected int64
+		execErr, err = ExecSavepoint(ctx, rr.drvr, rr.db, func() error {
+			var execErr error
+			rowAffected, execErr = rr.rowInserter.Exec(ctx, row...)
+			return execErr
+		})
+		if err != nil {
+			return affected, err
+		}
+
+		if execErr != nil {
+			err = rr.rejects.Reject(rr.destTbl, firstRow+int64(i), row, execErr)
+			if err != nil {
+				return affected, err
+			}
+			continue
+		}
+
+		affected += rowAffected
+	}
+
+	return affected, nil
+}
+
+// close closes the row inserter, if it was prepared.
+func (rr *rowRetrier) close() error {
+	if rr.rowInserter == nil {
+		return nil
+	}
+
+	return rr.rowInserter.Close()
+}
+
+// BulkLoadFunc is provided by BulkLoader implementations to load
+// the (munged) records received on recCh, until recCh is closed
+// or ctx is done. The number of records loaded is returned.
+type BulkLoadFunc func(ctx context.Context, recCh <-chan []interface{}) (loaded int64, err error)
+
+// NewBulkLoadBatchInsert returns a BatchInsert that passes the records
+// sent on its RecordCh to loadFn, which is invoked in a new goroutine.
+// This allows a BulkLoader to be used interchangeably with the
+// BatchInsert returned by NewBatchInsert. The mungeFn arg is
+// returned by the BatchInsert's Munge method.
+func NewBulkLoadBatchInsert(ctx context.Context, mungeFn InsertMungeFunc, loadFn BulkLoadFunc) *BatchInsert {
+	recCh := make(chan []interface{}, Tuning.RecordChSize)
+	errCh := make(chan error, 1)
+
+	bi := &BatchInsert{RecordCh: recCh, ErrCh: errCh, written: atomic.NewInt64(0), mungeFn: mungeFn}
+
+	go func() {
+		loaded, err := loadFn(ctx, recCh)
+		bi.written.Store(loaded)
+		if err != nil {
+			errCh <- err
+		}
+
+		close(errCh)
+	}()
+
+	return bi
+}
+
+// DerefRecord replaces each pointer value of rec, such as *int64
+// or *string, with the value it points to (or nil). This is useful
+// for bulk load implementations that don't accept pointer values.
+func DerefRecord(rec []interface{}) {
+	for i := range rec {
+		if rec[i] == nil {
+			continue
+		}
+
+		rv := reflect.ValueOf(rec[i])
+		if rv.Kind() != reflect.Ptr {
+			continue
+		}
+
+		if rv.IsNil() {
+			rec[i] = nil
+			continue
+		}
+
+		rec[i] = rv.Elem().Interface()
+	}
+}
+
 // MaxBatchRows returns the maximum number of rows allowed for a
 // batch insert for drvr. Note that the returned value may differ
 // for each database driver.
@@ -0,0 +1,136 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+	"github.com/neilotoole/sq/libsq/core/sqlz"
+)
+
+// Rejects tracks rows that are rejected (skipped) because they could
+// not be inserted, allowing an insert to continue despite bad rows.
+// Rejects is safe for concurrent use: a single Rejects instance is
+// typically shared by all of the inserts of an operation, and thus
+// the error budget (MaxErrors) applies to the operation as a whole.
+//
+// Rejects is added to a context via WithRejects. BatchInsert (and
+// thus DBWriter) and the scratch importers honor the Rejects on ctx.
+// If ctx has no Rejects, the first insert error is fatal.
+type Rejects struct {
+	mu        sync.Mutex
+	maxErrors int
+	w         io.Writer
+	count     int
+}
+
+// NewRejects returns a new Rejects that permits up to maxErrors
+// rows to be rejected: if maxErrors is negative, there is no limit.
+// If w is non-nil, each rejected row is written to w as a JSON
+// object on its own line (JSONL), with fields "table", "row",
+// "error" and "record".
+func NewRejects(maxErrors int, w io.Writer) *Rejects {
+	return &Rejects{maxErrors: maxErrors, w: w}
+}
+
+// Reject records that rec could not be inserted into tbl due to
+// cause. Arg row is the 1-based position of rec in the insert. If
+// the error budget is exhausted, Reject returns a non-nil error
+// (wrapping cause), and the insert should be abandoned.
+func (r *Rejects) Reject(tbl string, row int64, rec []interface{}, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxErrors >= 0 && r.count >= r.maxErrors {
+		return errz.Wrapf(cause, "insert %s: max errors (%d) exceeded at row %d", tbl, r.maxErrors, row)
+	}
+	r.count++
+
+	if r.w == nil {
+		return nil
+	}
+
+	reject := struct {
+		Table  string        `json:"table"`
+		Row    int64         `json:"row"`
+		Error  string        `json:"error"`
+		Record []interface{} `json:"record"`
+	}{Table: tbl, Row: row, Error: cause.Error(), Record: rec}
+
+	b, err := json.Marshal(reject)
+	if err != nil {
+		return errz.Wrapf(err, "insert %s: write reject for row %d", tbl, row)
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s\n", b)
+	return errz.Wrapf(err, "insert %s: write reject for row %d", tbl, row)
+}
+
+// Count returns the number of rows rejected.
+func (r *Rejects) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+type rejectsKey struct{}
+
+// WithRejects returns a new context with r added.
+func WithRejects(ctx context.Context, r *Rejects) context.Context {
+	return context.WithValue(ctx, rejectsKey{}, r)
+}
+
+// RejectsFrom returns the Rejects added to ctx via WithRejects,
+// or nil if ctx has no Rejects.
+func RejectsFrom(ctx context.Context) *Rejects {
+	r, _ := ctx.Value(rejectsKey{}).(*Rejects)
+	return r
+}
+
+// savepointName is the name of the savepoint used by ExecSavepoint.
+const savepointName = "sq_savepoint"
+
+// ExecSavepoint invokes fn. If db is a *sql.Tx, fn is executed
+// within a savepoint, which is rolled back if fn returns an error.
+// This keeps the tx usable after a failed statement: some databases
+// (e.g. Postgres) otherwise abort the entire tx. The error returned
+// by fn is returned in fnErr, while any error from the savepoint
+// statements themselves is returned in err.
+func ExecSavepoint(ctx context.Context, drvr SQLDriver, db sqlz.DB, fn func() error) (fnErr, err error) {
+	tx, ok := db.(*sql.Tx)
+	if !ok {
+		return fn(), nil
+	}
+
+	createStmt, rollbackStmt, releaseStmt := "SAVEPOINT "+savepointName,
+		"ROLLBACK TO SAVEPOINT "+savepointName, "RELEASE SAVEPOINT "+savepointName
+	if savepoint := drvr.Dialect().Savepoint; savepoint != nil {
+		createStmt, rollbackStmt, releaseStmt = savepoint(savepointName)
+	}
+
+	_, err = tx.ExecContext(ctx, createStmt)
+	if err != nil {
+		return nil, errz.Err(err)
+	}
+
+	fnErr = fn()
+	if fnErr != nil {
+		_, err = tx.ExecContext(ctx, rollbackStmt)
+		if err != nil {
+			return fnErr, errz.Err(err)
+		}
+	}
+
+	if releaseStmt != "" {
+		_, err = tx.ExecContext(ctx, releaseStmt)
+		if err != nil {
+			return fnErr, errz.Err(err)
+		}
+	}
+
+	return fnErr, nil
+}
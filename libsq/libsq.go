@@ -11,6 +11,7 @@ package libsq
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/neilotoole/sq/libsq/ast"
 
@@ -103,6 +104,15 @@ func QuerySQL(ctx context.Context, log lg.Log, dbase driver.Database, recw Recor
 	if err != nil {
 		return errz.Wrapf(err, `SQL query against %s failed: %s`, dbase.Source().Handle, query)
 	}
+
+	return QuerySQLRows(ctx, log, dbase, recw, rows)
+}
+
+// QuerySQLRows is like QuerySQL, but writes the results of rows,
+// which were returned by a query against dbase. This is useful if
+// the caller must inspect rows (e.g. via rows.Columns) before the
+// results are written. QuerySQLRows closes rows.
+func QuerySQLRows(ctx context.Context, log lg.Log, dbase driver.Database, recw RecordWriter, rows *sql.Rows) error {
 	defer log.WarnIfCloseError(rows)
 
 	// This next part is a bit ugly.
@@ -0,0 +1,231 @@
+package source
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/h2non/filetype/matchers"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+// archiveExts are the file extensions of archive locations. Note
+// that for a compressed archive such as "data.tar.gz", the location
+// extension is ".tar" (see parseLoc).
+var archiveExts = []string{".zip", ".tar", ".tgz", ".tbz2"}
+
+// IsArchiveLocation returns true if loc is the location of a
+// zip or tar archive (possibly compressed, e.g. "data.tar.gz").
+// The member files of the archive are returned by Files.ArchiveFiles.
+func IsArchiveLocation(loc string) bool {
+	if IsSQLLocation(loc) {
+		return false
+	}
+
+	ploc, err := parseLoc(loc)
+	if err != nil || ploc.typ != TypeNone {
+		return false
+	}
+
+	ext := strings.ToLower(ploc.ext)
+	for _, archiveExt := range archiveExts {
+		if ext == archiveExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ArchiveFiles extracts the archive at loc (see IsArchiveLocation)
+// to a temp dir, returning the dir and the sorted paths of the
+// extracted member files. Hidden files, and files in hidden dirs,
+// are omitted. The archive is extracted only once per fs instance;
+// the temp dir is removed by fs.Close.
+func (fs *Files) ArchiveFiles(loc string) (dir string, fpaths []string, err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	dir, ok := fs.archiveDirs[loc]
+	if !ok {
+		dir, err = fs.extractArchive(loc)
+		if err != nil {
+			return "", nil, err
+		}
+		fs.archiveDirs[loc] = dir
+	}
+
+	err = filepath.Walk(dir, func(fpath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fpath != dir && (strings.HasPrefix(fi.Name(), ".") || fi.Name() == "__MACOSX") {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fi.Mode().IsRegular() {
+			fpaths = append(fpaths, fpath)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, errz.Err(err)
+	}
+
+	if len(fpaths) == 0 {
+		return "", nil, errz.Errorf("no files in archive: %s", loc)
+	}
+
+	sort.Strings(fpaths)
+	return dir, fpaths, nil
+}
+
+// extractArchive extracts the archive at loc to a new temp dir,
+// which is returned. If the archive is compressed (e.g. "data.tar.gz"),
+// the reader returned by fs.newReader takes care of decompression.
+func (fs *Files) extractArchive(loc string) (dir string, err error) {
+	r, err := fs.newReader(loc)
+	if err != nil {
+		return "", err
+	}
+	defer fs.log.WarnIfCloseError(r)
+
+	dir, err = ioutil.TempDir("", "sq_archive_*")
+	if err != nil {
+		return "", errz.Err(err)
+	}
+
+	fs.clnup.AddE(func() error {
+		return errz.Err(os.RemoveAll(dir))
+	})
+
+	// The magic number of a tar file is at offset 257.
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(262)
+	if err != nil && err != io.EOF {
+		return "", errz.Err(err)
+	}
+
+	fs.log.Debugf("Extracting archive %s to: %s", loc, dir)
+	switch {
+	case matchers.Zip(head):
+		err = extractZip(br, dir)
+	case matchers.Tar(head):
+		err = extractTar(br, dir)
+	default:
+		err = errz.Errorf("not a zip or tar archive: %s", loc)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// extractZip extracts the zip archive data of r into dir. The
+// zip format requires random access, so r's data is first
+// copied to a temp file.
+func extractZip(r io.Reader, dir string) error {
+	f, err := ioutil.TempFile("", "sq_archive_*.zip")
+	if err != nil {
+		return errz.Err(err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return errz.Wrap(err, "zip")
+	}
+
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+
+		err = extractZipFile(zf, memberPath(dir, zf.Name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractZipFile writes the content of zip file zf to fpath.
+func extractZipFile(zf *zip.File, fpath string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return errz.Wrapf(err, "zip: %s", zf.Name)
+	}
+
+	err = writeMemberFile(fpath, rc)
+	return errz.Combine(err, rc.Close())
+}
+
+// extractTar extracts the tar archive data of r into dir.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errz.Wrap(err, "tar")
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			// We're only interested in regular files.
+			continue
+		}
+
+		err = writeMemberFile(memberPath(dir, hdr.Name), tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// memberPath returns the path in dir for archive member name.
+// A name such as "../../etc/passwd" can't escape dir.
+func memberPath(dir, name string) string {
+	return filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+// writeMemberFile writes the data of r to a new file at fpath,
+// creating the parent dirs of fpath if necessary.
+func writeMemberFile(fpath string, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(fpath), 0750)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	f, err := os.OpenFile(fpath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errz.Err(err)
+	}
+
+	_, err = io.Copy(f, r)
+	return errz.Combine(errz.Err(err), f.Close())
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/h2non/filetype/matchers"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/neilotoole/sq/libsq/core/errz"
+)
+
+// Compression formats that are transparently decompressed
+// by Files.
+const (
+	compressionNone  = ""
+	compressionGzip  = "gzip"
+	compressionBzip2 = "bzip2"
+	compressionXz    = "xz"
+	compressionZstd  = "zstd"
+)
+
+// compressionExts are the file extensions of the compression
+// formats. For a location such as "actor.csv.gz", the extension
+// ".gz" is disregarded when determining the source type.
+var compressionExts = []string{".gz", ".bz2", ".xz", ".zst"}
+
+// zstdMagic is the magic number of the zstd format. The
+// h2non/filetype pkg doesn't (yet) match zstd.
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// isCompressionExt returns true if ext (e.g. ".gz") is the
+// extension of a compression format.
+func isCompressionExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, compressionExt := range compressionExts {
+		if ext == compressionExt {
+			return true
+		}
+	}
+	return false
+}
+
+// detectCompression returns the compression format of the data
+// with header head, or compressionNone.
+func detectCompression(head []byte) string {
+	switch {
+	case matchers.Gz(head):
+		return compressionGzip
+	case matchers.Bz2(head):
+		return compressionBzip2
+	case matchers.Xz(head):
+		return compressionXz
+	case bytes.HasPrefix(head, zstdMagic):
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// newDecompressReader returns a reader of the decompressed data of
+// r if r's data is compressed (as determined by the magic number
+// of the data), or a reader of r's data unmodified. The returned
+// string is the compression format, or compressionNone. The caller
+// is responsible for closing the returned reader; r is not closed.
+func newDecompressReader(r io.Reader) (io.ReadCloser, string, error) {
+	// We only need the header: the first 262 bytes.
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(262)
+	if err != nil && err != io.EOF {
+		return nil, compressionNone, errz.Err(err)
+	}
+
+	compression := detectCompression(head)
+	switch compression {
+	case compressionGzip:
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, compression, errz.Wrap(err, "gzip")
+		}
+		return gzr, compression, nil
+	case compressionBzip2:
+		return ioutil.NopCloser(bzip2.NewReader(br)), compression, nil
+	case compressionXz:
+		xzr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, compression, errz.Wrap(err, "xz")
+		}
+		return ioutil.NopCloser(xzr), compression, nil
+	case compressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, compression, errz.Wrap(err, "zstd")
+		}
+		return zr.IOReadCloser(), compression, nil
+	default:
+		return ioutil.NopCloser(br), compressionNone, nil
+	}
+}
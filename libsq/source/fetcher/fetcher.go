@@ -38,6 +38,39 @@ func (f *Fetcher) Fetch(ctx context.Context, url string, w io.Writer) error {
 	return fetchHTTP(ctx, f.Config, url, w)
 }
 
+// Validator returns a value that changes when the document at url
+// changes, without fetching the document body. This is the document's
+// ETag header, or if there's no ETag, its Last-Modified header,
+// per a HEAD request. If the response has neither header, the
+// empty string is returned.
+func (f *Fetcher) Validator(ctx context.Context, url string) (string, error) {
+	c := httpClient(f.Config)
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return "", errz.Err(err)
+	}
+
+	resp, err := ctxhttp.Do(ctx, c, req)
+	if err != nil {
+		return "", errz.Err(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errz.Errorf("http: returned non-200 status code (%s) from: %s", resp.Status, url)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return "etag:" + etag, nil
+	}
+
+	if lastMod := resp.Header.Get("Last-Modified"); lastMod != "" {
+		return "last-modified:" + lastMod, nil
+	}
+
+	return "", nil
+}
+
 func httpClient(cfg *Config) *http.Client {
 	var client = *http.DefaultClient
 
@@ -65,3 +65,31 @@ func TestFetcherConfig(t *testing.T) {
 	err = fetchr.Fetch(ctx, server.URL, ioutil.Discard)
 	require.NoError(t, err)
 }
+
+func TestFetcherValidator(t *testing.T) {
+	ctx := context.Background()
+	header := http.Header{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "HEAD", r.Method)
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+	}))
+	defer server.Close()
+
+	fetchr := &fetcher.Fetcher{}
+	got, err := fetchr.Validator(ctx, server.URL)
+	require.NoError(t, err)
+	require.Empty(t, got, "no ETag or Last-Modified")
+
+	header.Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+	got, err = fetchr.Validator(ctx, server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "last-modified:Wed, 21 Oct 2015 07:28:00 GMT", got)
+
+	header.Set("ETag", `"33a64df5"`)
+	got, err = fetchr.Validator(ctx, server.URL)
+	require.NoError(t, err)
+	require.Equal(t, `etag:"33a64df5"`, got, "ETag is preferred")
+}
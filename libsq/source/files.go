@@ -2,12 +2,16 @@ package source
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"mime"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -42,11 +46,15 @@ type Files struct {
 	clnup     *cleanup.Cleanup
 	fcache    *fscache.FSCache
 	detectFns []TypeDetectFunc
+
+	// archiveDirs maps an archive location to the
+	// temp dir it was extracted to.
+	archiveDirs map[string]string
 }
 
 // NewFiles returns a new Files instance.
 func NewFiles(log lg.Log) (*Files, error) {
-	fs := &Files{log: log, clnup: cleanup.New()}
+	fs := &Files{log: log, clnup: cleanup.New(), archiveDirs: map[string]string{}}
 
 	tmpdir, err := ioutil.TempDir("", "sq_files_fscache_*")
 	if err != nil {
@@ -94,6 +102,75 @@ func (fs *Files) Size(src *Source) (size int64, err error) {
 	return size, nil
 }
 
+// Fingerprint returns a value that changes when the data of
+// src.Location changes. For a local file, the fingerprint is
+// derived from the file's size and modification time; for a
+// remote file, it is the file's HTTP ETag or Last-Modified header,
+// or if neither is available, a checksum of the file's contents. For a dir
+// location (see IsDirLocation), the fingerprint covers each of the
+// matched files. It is an error to invoke Fingerprint for StdinHandle.
+func (fs *Files) Fingerprint(ctx context.Context, src *Source) (string, error) {
+	if src.Location == StdinHandle {
+		return "", errz.Errorf("cannot fingerprint %s", StdinHandle)
+	}
+
+	if IsDirLocation(src.Location) {
+		// The fingerprint covers each of the matched files.
+		fpaths, err := DirLocationFiles(src.Location)
+		if err != nil {
+			return "", err
+		}
+
+		h := sha256.New()
+		for _, fpath := range fpaths {
+			fingerprint, err := fs.Fingerprint(ctx, &Source{Location: fpath})
+			if err != nil {
+				return "", err
+			}
+			_, _ = fmt.Fprintf(h, "%s:%s\n", fpath, fingerprint)
+		}
+
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	if fpath, ok := isFpath(src.Location); ok {
+		fi, err := os.Stat(fpath)
+		if err != nil {
+			return "", errz.Err(err)
+		}
+
+		return fmt.Sprintf("%d:%d", fi.Size(), fi.ModTime().UnixNano()), nil
+	}
+
+	if u, ok := httpURL(src.Location); ok {
+		// Prefer the document's ETag or Last-Modified header, so
+		// that the document needn't be downloaded to fingerprint it.
+		fetchr := &fetcher.Fetcher{}
+		validator, err := fetchr.Validator(ctx, u.String())
+		switch {
+		case err != nil:
+			// Not all servers support HEAD: fall back to the checksum.
+			fs.log.Warnf("Unable to get HTTP validator for %s: %v", src.RedactedLocation(), err)
+		case validator != "":
+			return validator, nil
+		}
+	}
+
+	r, err := fs.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer fs.log.WarnIfCloseError(r)
+
+	h := sha256.New()
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return "", errz.Err(err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // AddStdin copies f to fs's cache: the stdin data in f
 // is later accessible via fs.Open(src) where src.Handle
 // is StdinHandle; f's type can be detected via TypeStdin.
@@ -149,12 +226,24 @@ func (fs *Files) addFile(f *os.File, key string) (fscache.ReadAtCloser, error) {
 		return nil, errz.Errorf("failed to add to fscache (possibly previously added): %s", key)
 	}
 
+	// If f's data is compressed (e.g. gzip), it's the decompressed
+	// data that is copied to fscache.
+	dr, compression, err := newDecompressReader(f)
+	if err != nil {
+		fs.log.WarnIfCloseError(r)
+		return nil, errz.Wrapf(err, "failed to decompress: %s", f.Name())
+	}
+
+	if compression != compressionNone {
+		fs.log.Debugf("Decompressing %s data from: %s", compression, f.Name())
+	}
+
 	// TODO: Problematically, we copy the entire contents of f into fscache.
 	// If f is a large file (e.g. piped over stdin), this means that
 	// everything is held up until f is fully copied. Hopefully we can
 	// do something with fscache so that the readers returned from
 	// fscache can lazily read from f.
-	copied, err := io.Copy(w, f)
+	copied, err := io.Copy(w, dr)
 	if err != nil {
 		fs.log.WarnIfCloseError(r)
 		return nil, errz.Err(err)
@@ -162,7 +251,7 @@ func (fs *Files) addFile(f *os.File, key string) (fscache.ReadAtCloser, error) {
 
 	fs.log.Debugf("Copied %d bytes to fscache from: %s", copied, key)
 
-	err = errz.Combine(w.Close(), f.Close())
+	err = errz.Combine(dr.Close(), w.Close(), f.Close())
 	if err != nil {
 		fs.log.WarnIfCloseError(r)
 		return nil, err
@@ -173,7 +262,9 @@ func (fs *Files) addFile(f *os.File, key string) (fscache.ReadAtCloser, error) {
 
 // Open returns a new io.ReadCloser for src.Location.
 // If src.Handle is StdinHandle, AddStdin must first have
-// been invoked. The caller must close the reader.
+// been invoked. The caller must close the reader. If the
+// data is compressed (gzip, bzip2, xz or zstd), the reader
+// returns the decompressed data.
 func (fs *Files) Open(src *Source) (io.ReadCloser, error) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
@@ -497,6 +588,89 @@ func DetectMagicNumber(ctx context.Context, log lg.Log, openFn FileOpenFunc) (de
 	}
 }
 
+// IsDirLocation returns true if loc is the path of a local
+// directory, or a file path glob pattern such as "/path/to/*.csv".
+// The files matched by loc are returned by DirLocationFiles.
+func IsDirLocation(loc string) bool {
+	fpath, ok := isFpath(loc)
+	if !ok {
+		return false
+	}
+
+	if isGlob(fpath) {
+		return true
+	}
+
+	fi, err := os.Stat(fpath)
+	return err == nil && fi.IsDir()
+}
+
+// DirLocationFiles returns the sorted paths of the regular files
+// matched by loc, which is a directory path (in which case the
+// non-hidden files of the directory are matched), or a file path
+// glob pattern. It is an error if no file is matched.
+func DirLocationFiles(loc string) ([]string, error) {
+	fpath, ok := isFpath(loc)
+	if !ok {
+		return nil, errz.Errorf("not a directory or glob location: %s", loc)
+	}
+
+	pattern := fpath
+	if !isGlob(fpath) {
+		pattern = filepath.Join(escapeGlob(fpath), "*")
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, errz.Wrapf(err, "invalid glob location: %s", loc)
+	}
+
+	var fpaths []string
+	for _, match := range matches {
+		if strings.HasPrefix(filepath.Base(match), ".") {
+			continue
+		}
+
+		fi, err := os.Stat(match)
+		if err != nil {
+			return nil, errz.Err(err)
+		}
+
+		if fi.Mode().IsRegular() {
+			fpaths = append(fpaths, match)
+		}
+	}
+
+	if len(fpaths) == 0 {
+		return nil, errz.Errorf("no files match location: %s", loc)
+	}
+
+	sort.Strings(fpaths)
+	return fpaths, nil
+}
+
+// globChars are the glob pattern metacharacters, per filepath.Match.
+const globChars = "*?["
+
+// isGlob returns true if fpath contains glob pattern
+// metacharacters, per filepath.Match, and there's no existing
+// file at fpath. Thus a file such as "report[2020].csv" is not
+// mistaken for a glob.
+func isGlob(fpath string) bool {
+	if !strings.ContainsAny(fpath, globChars) {
+		return false
+	}
+
+	_, err := os.Stat(fpath)
+	return err != nil
+}
+
+// escapeGlob returns fpath with its glob pattern metacharacters
+// escaped, such that fpath can be used as part of a glob pattern.
+func escapeGlob(fpath string) string {
+	return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(fpath)
+}
+
 // AbsLocation returns the absolute path of loc. That is, relative
 // paths etc in loc are resolved. If loc is not a file path or
 // it cannot be processed, loc is returned unmodified.
@@ -1,6 +1,10 @@
 package source_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"io"
 	"io/ioutil"
@@ -8,10 +12,12 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/neilotoole/errgroup"
 	"github.com/neilotoole/lg/testlg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
 
 	"github.com/neilotoole/sq/drivers/csv"
 	"github.com/neilotoole/sq/drivers/mysql"
@@ -256,3 +262,141 @@ func TestFiles_Size(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, wantSize, gotSize2)
 }
+
+func TestFiles_Compressed(t *testing.T) {
+	wantBytes := proj.ReadFile(sakila.PathCSVActor)
+
+	testCases := []struct {
+		ext      string
+		newBufWr func(w io.Writer) (io.WriteCloser, error)
+	}{
+		{ext: ".gz", newBufWr: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }},
+		{ext: ".xz", newBufWr: func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }},
+		{ext: ".zst", newBufWr: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.ext, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := tc.newBufWr(buf)
+			require.NoError(t, err)
+			_, err = w.Write(wantBytes)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			fpath := filepath.Join(t.TempDir(), "actor.csv"+tc.ext)
+			require.NoError(t, ioutil.WriteFile(fpath, buf.Bytes(), 0600))
+
+			th := testh.New(t)
+			fs := th.Files()
+
+			typ, err := fs.Type(th.Context, fpath)
+			require.NoError(t, err)
+			require.Equal(t, csv.TypeCSV, typ)
+
+			gotBytes, err := fs.ReadAll(&source.Source{Handle: "@actor_csv", Location: fpath})
+			require.NoError(t, err)
+			require.Equal(t, wantBytes, gotBytes)
+		})
+	}
+}
+
+func TestFiles_ArchiveFiles(t *testing.T) {
+	members := map[string][]byte{
+		"actor.csv":           proj.ReadFile(sakila.PathCSVActor),
+		"data/actor.tsv":      proj.ReadFile(sakila.PathTSVActor),
+		".hidden/actor.csv":   proj.ReadFile(sakila.PathCSVActor),
+		"../../escape/x.csv":  []byte("x"),
+		"data/__MACOSX/x.csv": []byte("x"),
+	}
+	wantNames := []string{"actor.csv", "data/actor.tsv", "escape/x.csv"}
+
+	zipBuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zipBuf)
+	for name, data := range members {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	tgzBuf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(tgzBuf)
+	tw := tar.NewWriter(gzw)
+	for name, data := range members {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	testCases := map[string][]byte{
+		"data.zip":    zipBuf.Bytes(),
+		"data.tar.gz": tgzBuf.Bytes(),
+	}
+
+	for fname, data := range testCases {
+		fname, data := fname, data
+
+		t.Run(fname, func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), fname)
+			require.NoError(t, ioutil.WriteFile(fpath, data, 0600))
+			require.True(t, source.IsArchiveLocation(fpath))
+
+			th := testh.New(t)
+			fs := th.Files()
+
+			dir, fpaths, err := fs.ArchiveFiles(fpath)
+			require.NoError(t, err)
+
+			gotNames := make([]string, len(fpaths))
+			for i := range fpaths {
+				rel, err := filepath.Rel(dir, fpaths[i])
+				require.NoError(t, err)
+				gotNames[i] = filepath.ToSlash(rel)
+			}
+			require.Equal(t, wantNames, gotNames)
+
+			gotBytes, err := ioutil.ReadFile(fpaths[1])
+			require.NoError(t, err)
+			require.Equal(t, members["data/actor.tsv"], gotBytes)
+		})
+	}
+
+	require.False(t, source.IsArchiveLocation(proj.Abs(sakila.PathXLSX)))
+	require.False(t, source.IsArchiveLocation("sqlite3:///path/to/data.zip"))
+}
+
+func TestIsDirLocation_GlobChars(t *testing.T) {
+	data, err := ioutil.ReadFile(proj.Abs(sakila.PathCSVActor))
+	require.NoError(t, err)
+
+	// Both the dir and the file names contain glob metacharacters.
+	fdir := filepath.Join(t.TempDir(), "exports[1]")
+	require.NoError(t, os.Mkdir(fdir, 0750))
+	fpath := filepath.Join(fdir, "report[2020].csv")
+	require.NoError(t, ioutil.WriteFile(fpath, data, 0600))
+
+	// An existing file is not mistaken for a glob.
+	require.False(t, source.IsDirLocation(fpath))
+
+	fs, err := source.NewFiles(testlg.New(t))
+	require.NoError(t, err)
+	fs.AddTypeDetectors(testh.TypeDetectors()...)
+	gotType, err := fs.Type(context.Background(), fpath)
+	require.NoError(t, err)
+	require.Equal(t, csv.TypeCSV, gotType)
+
+	require.True(t, source.IsDirLocation(fdir))
+	gotFiles, err := source.DirLocationFiles(fdir)
+	require.NoError(t, err)
+	require.Equal(t, []string{fpath}, gotFiles)
+
+	// A pattern that doesn't exist as a file is a glob.
+	require.True(t, source.IsDirLocation(filepath.Join(fdir, "*.csv")))
+	require.True(t, source.IsDirLocation(filepath.Join(t.TempDir(), "report[0-9].csv")))
+}
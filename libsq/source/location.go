@@ -29,7 +29,7 @@ func LocationFileName(src *Source) (string, error) {
 		return "", err
 	}
 
-	return ploc.name + ploc.ext, nil
+	return ploc.name + ploc.ext + ploc.compressExt, nil
 }
 
 // IsSQLLocation returns true if source location loc seems to be
@@ -136,9 +136,14 @@ type parsedLoc struct {
 	// as "/path/to/things.xlsx" it would be "things".
 	name string
 
-	// ext is the file extension, if applicable.
+	// ext is the file extension, if applicable. For a compressed
+	// file such as "actor.csv.gz", ext is ".csv".
 	ext string
 
+	// compressExt is the compression file extension, if any,
+	// e.g. ".gz" for "actor.csv.gz".
+	compressExt string
+
 	// dsn is the connection "data source name" that can be used in a
 	// call to sql/Open. Empty for non-SQL locations.
 	dsn string
@@ -158,12 +163,14 @@ func parseLoc(loc string) (*parsedLoc, error) {
 
 		// no scheme: it's just a regular file path for a document such as an Excel file
 		name := filepath.Base(loc)
-		ploc.ext = filepath.Ext(name)
-		if ploc.ext != "" {
-			name = name[:len(name)-len(ploc.ext)]
+		if strings.ContainsAny(name, globChars) && isGlob(loc) {
+			// For a glob such as "/path/to/*.csv", we use
+			// the name of the parent dir, i.e. "to".
+			ploc.name = filepath.Base(filepath.Dir(loc))
+			return ploc, nil
 		}
 
-		ploc.name = name
+		ploc.name, ploc.ext, ploc.compressExt = splitExt(name)
 		return ploc, nil
 	}
 
@@ -179,13 +186,7 @@ func parseLoc(loc string) (*parsedLoc, error) {
 			}
 		}
 
-		name := path.Base(u.Path)
-		ploc.ext = path.Ext(name)
-		if ploc.ext != "" {
-			name = name[:len(name)-len(ploc.ext)]
-		}
-
-		ploc.name = name
+		ploc.name, ploc.ext, ploc.compressExt = splitExt(path.Base(u.Path))
 		return ploc, nil
 	}
 
@@ -252,3 +253,23 @@ func parseLoc(loc string) (*parsedLoc, error) {
 
 	return ploc, nil
 }
+
+// splitExt splits file name into its base name, extension, and
+// compression extension. For example, "actor.csv.gz" is split
+// into "actor", ".csv" and ".gz"; "actor.csv" is split into
+// "actor", ".csv" and "".
+func splitExt(fname string) (name, ext, compressExt string) {
+	name = fname
+	ext = filepath.Ext(name)
+	if isCompressionExt(ext) {
+		compressExt = ext
+		name = name[:len(name)-len(ext)]
+		ext = filepath.Ext(name)
+	}
+
+	if ext != "" {
+		name = name[:len(name)-len(ext)]
+	}
+
+	return name, ext, compressExt
+}
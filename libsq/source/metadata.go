@@ -142,7 +142,24 @@ type ColMetadata struct {
 	Nullable     bool      `json:"nullable"`
 	DefaultValue string    `json:"default_value,omitempty"`
 	Comment      string    `json:"comment,omitempty"`
-	// TODO: Add foreign key field
+
+	// ForeignKey is the foreign key reference of the column,
+	// or nil. Not all drivers populate this field.
+	ForeignKey *ForeignKey `json:"foreign_key,omitempty"`
+}
+
+// ForeignKey models a column's foreign key reference
+// to a column of a parent table.
+type ForeignKey struct {
+	// RefTable is the name of the referenced parent table.
+	RefTable string `json:"ref_table"`
+
+	// RefCol is the name of the referenced column of the parent table.
+	RefCol string `json:"ref_col"`
+}
+
+func (fk *ForeignKey) String() string {
+	return fk.RefTable + "." + fk.RefCol
 }
 
 func (c *ColMetadata) String() string {
@@ -116,6 +116,9 @@ const (
 	typeXLSX = Type("xlsx")
 	typeCSV  = Type("csv")
 	typeTSV  = Type("tsv")
+	typeHTML = Type("html")
+	typeYAML = Type("yaml")
+	typeXML  = Type("xml")
 )
 
 // typeFromMediaType returns the driver type corresponding to mediatype.
@@ -135,6 +138,14 @@ func typeFromMediaType(mediatype string) (typ Type, ok bool) {
 		return typeCSV, true
 	case strings.Contains(mediatype, `text/tab-separated-values`):
 		return typeTSV, true
+	case strings.Contains(mediatype, `text/html`):
+		return typeHTML, true
+	case strings.Contains(mediatype, `yaml`):
+		// application/yaml, application/x-yaml, text/yaml, etc.
+		return typeYAML, true
+	case strings.Contains(mediatype, `/xml`):
+		// application/xml, text/xml
+		return typeXML, true
 	}
 
 	return TypeNone, false
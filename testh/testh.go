@@ -23,14 +23,23 @@ import (
 	"github.com/neilotoole/sq/cli/config"
 	"github.com/neilotoole/sq/cli/output"
 	"github.com/neilotoole/sq/drivers/csv"
+	"github.com/neilotoole/sq/drivers/dir"
+	"github.com/neilotoole/sq/drivers/fwf"
+	"github.com/neilotoole/sq/drivers/html"
 	"github.com/neilotoole/sq/drivers/json"
 	"github.com/neilotoole/sq/drivers/mysql"
+	"github.com/neilotoole/sq/drivers/parquet"
 	"github.com/neilotoole/sq/drivers/postgres"
 	"github.com/neilotoole/sq/drivers/sqlite3"
 	"github.com/neilotoole/sq/drivers/sqlserver"
 	"github.com/neilotoole/sq/drivers/userdriver"
+	"github.com/neilotoole/sq/drivers/userdriver/csvud"
+	"github.com/neilotoole/sq/drivers/userdriver/jsonud"
+	"github.com/neilotoole/sq/drivers/userdriver/regexud"
 	"github.com/neilotoole/sq/drivers/userdriver/xmlud"
 	"github.com/neilotoole/sq/drivers/xlsx"
+	"github.com/neilotoole/sq/drivers/xml"
+	yamld "github.com/neilotoole/sq/drivers/yaml"
 	"github.com/neilotoole/sq/libsq"
 	"github.com/neilotoole/sq/libsq/core/cleanup"
 	"github.com/neilotoole/sq/libsq/core/errz"
@@ -134,6 +143,16 @@ func (h *Helper) init() {
 
 		h.registry.AddProvider(xlsx.Type, &xlsx.Provider{Log: log, Scratcher: h.databases, Files: h.files})
 		h.files.AddTypeDetectors(xlsx.DetectXLSX)
+		h.registry.AddProvider(html.Type, &html.Provider{Log: log, Scratcher: h.databases, Files: h.files})
+		h.files.AddTypeDetectors(html.DetectHTML)
+		h.registry.AddProvider(parquet.Type, &parquet.Provider{Log: log, Scratcher: h.databases, Files: h.files})
+		h.files.AddTypeDetectors(parquet.DetectParquet)
+		h.registry.AddProvider(fwf.Type, &fwf.Provider{Log: log, Scratcher: h.databases, Files: h.files})
+		h.registry.AddProvider(yamld.Type, &yamld.Provider{Log: log, Scratcher: h.databases, Files: h.files})
+		h.files.AddTypeDetectors(yamld.DetectYAML)
+		h.registry.AddProvider(xml.Type, &xml.Provider{Log: log, Scratcher: h.databases, Files: h.files})
+		h.files.AddTypeDetectors(xml.DetectXML)
+		h.registry.AddProvider(dir.Type, &dir.Provider{Log: log, Scratcher: h.databases, Files: h.files, Drivers: h.registry})
 
 		h.addUserDrivers()
 	})
@@ -495,11 +514,14 @@ func (h *Helper) Registry() *driver.Registry {
 
 // addUserDrivers adds some user drivers to the registry.
 func (h *Helper) addUserDrivers() {
-	userDriverDefs := DriverDefsFrom(h.T, testsrc.PathDriverDefPpl, testsrc.PathDriverDefRSS)
+	userDriverDefs := DriverDefsFrom(h.T, testsrc.PathDriverDefPpl, testsrc.PathDriverDefRSS,
+		testsrc.PathDriverDefOrders, testsrc.PathDriverDefContacts, testsrc.PathDriverDefNginx)
 
-	// One day we may have more supported user driver genres.
 	userDriverImporters := map[string]userdriver.ImportFunc{
-		xmlud.Genre: xmlud.Import,
+		xmlud.Genre:   xmlud.Import,
+		jsonud.Genre:  jsonud.Import,
+		csvud.Genre:   csvud.Import,
+		regexud.Genre: regexud.Import,
 	}
 
 	for _, userDriverDef := range userDriverDefs {
@@ -674,12 +696,12 @@ var (
 // Name is a convenience function for building a test name to
 // pass to t.Run.
 //
-//  t.Run(testh.Name("my_test", 1), func(t *testing.T) {
+//	t.Run(testh.Name("my_test", 1), func(t *testing.T) {
 //
 // The most common usage is with test names that are file
 // paths.
 //
-//   testh.Name("path/to/file") --> "path_to_file"
+//	testh.Name("path/to/file") --> "path_to_file"
 //
 // Any element of arg that prints to empty string is skipped.
 func Name(args ...interface{}) string {
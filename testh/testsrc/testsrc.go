@@ -14,6 +14,15 @@ const (
 	// RSSNYTLocalUD is the handle of a user-defined RSS source.
 	RSSNYTLocalUD = "@ud_rss_nytimes_local"
 
+	// OrdersUD is the handle of a user-defined JSON "orders" source.
+	OrdersUD = "@ud_orders"
+
+	// ContactsUD is the handle of a user-defined CSV "contacts" source.
+	ContactsUD = "@ud_contacts"
+
+	// NginxUD is the handle of a user-defined regex "nginx" source.
+	NginxUD = "@ud_nginx"
+
 	// MiscDB is the handle of a SQLite DB with misc testing data.
 	MiscDB = "@miscdb"
 
@@ -35,5 +44,10 @@ const (
 	PathDriverDefPpl = "drivers/userdriver/xmlud/testdata/ppl.sq.yml"
 	PathDriverDefRSS = "drivers/userdriver/xmlud/testdata/rss.sq.yml"
 
+	PathDriverDefOrders   = "drivers/userdriver/jsonud/testdata/orders.sq.yml"
+	PathDriverDefContacts = "drivers/userdriver/csvud/testdata/contacts.sq.yml"
+	PathDriverDefNginx    = "drivers/userdriver/regexud/testdata/nginx.sq.yml"
+	PathDriverDefAppLog   = "drivers/userdriver/regexud/testdata/app.sq.yml"
+
 	PathXLSXTestHeader = "drivers/xlsx/testdata/test_header.xlsx"
 )